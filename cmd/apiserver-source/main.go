@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+
+	"go.uber.org/zap"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
+	"knative.dev/pkg/injection/sharedmain"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+	"knative.dev/pkg/signals"
+	"knative.dev/pkg/system"
+
+	"github.com/mattmoor/mink/pkg/apiserversource"
+)
+
+const component = "apiserver-source"
+
+func main() {
+	ctx := signals.NewContext()
+	cfg := sharedmain.ParseAndGetConfigOrDie()
+	ctx, informers := injection.Default.SetupInformers(ctx, cfg)
+
+	logger, _ := sharedmain.SetupLoggerOrDie(ctx, component)
+	defer logger.Sync()
+	ctx = logging.WithLogger(ctx, logger)
+
+	identity := os.Getenv("POD_NAME")
+	if identity == "" {
+		logger.Fatal("POD_NAME must be set to lease the ApiServerSource adapter")
+	}
+
+	// Export the connection, lag and drop metrics of each source.
+	watcher := configmap.NewInformedWatcher(kubeclient.Get(ctx), system.Namespace())
+	watcher.Watch(metrics.ConfigMapName(), metrics.UpdateExporterFromConfigMap(component, logger))
+	if err := watcher.Start(ctx.Done()); err != nil {
+		logger.Fatalw("Failed to start ConfigMap watcher", zap.Error(err))
+	}
+
+	impl := apiserversource.NewController(ctx, identity, cfg)
+
+	logger.Info("Starting informers.")
+	if err := controller.StartInformers(ctx.Done(), informers...); err != nil {
+		logger.Fatalw("Failed to start informers", zap.Error(err))
+	}
+	controller.StartAll(ctx.Done(), impl)
+}
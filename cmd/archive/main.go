@@ -0,0 +1,59 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"go.uber.org/zap"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/injection"
+	"knative.dev/pkg/injection/sharedmain"
+	"knative.dev/pkg/signals"
+
+	"github.com/mattmoor/mink/pkg/archive"
+)
+
+const component = "archive"
+
+func main() {
+	ctx := signals.NewContext()
+	cfg := sharedmain.ParseAndGetConfigOrDie()
+	ctx, _ = injection.Default.SetupInformers(ctx, cfg)
+
+	logger, _ := sharedmain.SetupLoggerOrDie(ctx, component)
+	defer logger.Sync()
+
+	root := os.Getenv("ARCHIVE_PATH")
+	if root == "" {
+		root = archive.DefaultPath
+	}
+
+	srv := &http.Server{
+		Addr:    ":8080",
+		Handler: archive.NewServer(logger, kubeclient.Get(ctx), root),
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	logger.Infof("Serving the archive at %s on %s", root, srv.Addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Fatalw("Error serving the archive", zap.Error(err))
+	}
+}
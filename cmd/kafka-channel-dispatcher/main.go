@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/eventing-contrib/kafka/channel/pkg/utils"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
+	"knative.dev/pkg/injection/sharedmain"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/signals"
+	"knative.dev/pkg/system"
+
+	"github.com/mattmoor/mink/pkg/kafkachannel"
+)
+
+const (
+	component = "kafka-channel-dispatcher"
+
+	// configMapName holds the bootstrap servers of the Kafka cluster.
+	configMapName = "config-kafka"
+)
+
+func main() {
+	ctx := signals.NewContext()
+	cfg := sharedmain.ParseAndGetConfigOrDie()
+	ctx, informers := injection.Default.SetupInformers(ctx, cfg)
+
+	logger, _ := sharedmain.SetupLoggerOrDie(ctx, component)
+	defer logger.Sync()
+	ctx = logging.WithLogger(ctx, logger)
+
+	var env kafkachannel.EnvConfig
+	if err := envconfig.Process("", &env); err != nil {
+		logger.Fatalw("Failed to process env var", zap.Error(err))
+	}
+
+	// We restart to pick up changes to the Kafka configuration.  We run
+	// in the dataplane, so rather than crash while Kafka isn't configured
+	// (or reachable) we idle.
+	cm, err := kubeclient.Get(ctx).CoreV1().ConfigMaps(system.Namespace()).Get(configMapName, metav1.GetOptions{})
+	if err != nil {
+		logger.Fatalw("Failed to get ConfigMap "+configMapName, zap.Error(err))
+	}
+	watcher := configmap.NewInformedWatcher(kubeclient.Get(ctx), system.Namespace())
+	watcher.Watch(configMapName, utils.KafkaConfigMapObserver(logger))
+	if err := watcher.Start(ctx.Done()); err != nil {
+		logger.Fatalw("Failed to start ConfigMap watcher", zap.Error(err))
+	}
+	kafkaConfig, err := utils.GetKafkaConfig(cm.Data)
+	if err != nil {
+		logger.Infow("KafkaChannels are disabled until "+configMapName+" is configured", zap.Error(err))
+		<-ctx.Done()
+		return
+	}
+
+	var (
+		impl       *controller.Impl
+		dispatcher *kafkachannel.Dispatcher
+	)
+	for {
+		impl, dispatcher, err = kafkachannel.NewController(ctx, kafkaConfig.Brokers)
+		if err == nil {
+			break
+		}
+		logger.Errorw("Failed to connect to Kafka, retrying", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+
+	logger.Info("Starting informers.")
+	if err := controller.StartInformers(ctx.Done(), informers...); err != nil {
+		logger.Fatalw("Failed to start informers", zap.Error(err))
+	}
+	go controller.StartAll(ctx.Done(), impl)
+
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", env.Port), Handler: dispatcher}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	logger.Infof("Receiving events on %s", srv.Addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Fatalw("Error serving events", zap.Error(err))
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
+	"knative.dev/pkg/injection/sharedmain"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+	"knative.dev/pkg/signals"
+	"knative.dev/pkg/system"
+	sksinformer "knative.dev/serving/pkg/client/injection/informers/networking/v1alpha1/serverlessservice"
+
+	"github.com/mattmoor/mink/pkg/reconciler/activatorlocality"
+)
+
+const (
+	component = "locality-stats"
+
+	scrapeInterval = 10 * time.Second
+)
+
+type envConfig struct {
+	// NodeName is the node of the dataplane pod whose Envoys we scrape.
+	NodeName string `envconfig:"NODE_NAME" required:"true"`
+
+	// EnvoyAdminURLs are the admin servers of the pod's Envoys.
+	EnvoyAdminURLs []string `envconfig:"ENVOY_ADMIN_URLS" required:"true"`
+}
+
+func main() {
+	ctx := signals.NewContext()
+	cfg := sharedmain.ParseAndGetConfigOrDie()
+	ctx, informers := injection.Default.SetupInformers(ctx, cfg)
+
+	logger, _ := sharedmain.SetupLoggerOrDie(ctx, component)
+	defer logger.Sync()
+	ctx = logging.WithLogger(ctx, logger)
+
+	var env envConfig
+	if err := envconfig.Process("", &env); err != nil {
+		logger.Fatalw("Failed to process env var", zap.Error(err))
+	}
+
+	// Export the requests of each node to the local and remote activators.
+	watcher := configmap.NewInformedWatcher(kubeclient.Get(ctx), system.Namespace())
+	watcher.Watch(metrics.ConfigMapName(), metrics.UpdateExporterFromConfigMap(component, logger))
+	if err := watcher.Start(ctx.Done()); err != nil {
+		logger.Fatalw("Failed to start ConfigMap watcher", zap.Error(err))
+	}
+
+	scraper := activatorlocality.NewScraper(env.NodeName, env.EnvoyAdminURLs, sksinformer.Get(ctx).Lister())
+
+	logger.Info("Starting informers.")
+	if err := controller.StartInformers(ctx.Done(), informers...); err != nil {
+		logger.Fatalw("Failed to start informers", zap.Error(err))
+	}
+
+	ticker := time.NewTicker(scrapeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := scraper.Scrape(ctx); err != nil {
+				logger.Errorw("Failed to scrape Envoy's stats", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/signals"
+
+	"github.com/mattmoor/mink/pkg/outgoingwebhook"
+)
+
+const component = "webhook-sink"
+
+func main() {
+	ctx := signals.NewContext()
+
+	// We run as a sidecar in the namespace of our subject, so we can't
+	// read mink's logging configuration.
+	logger, _ := logging.NewLogger("", "info")
+	logger = logger.Named(component)
+	defer logger.Sync()
+
+	var env outgoingwebhook.EnvConfig
+	if err := envconfig.Process("", &env); err != nil {
+		logger.Fatalw("Failed to process env var", zap.Error(err))
+	}
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", env.Port),
+		Handler: outgoingwebhook.NewHandler(logger, env),
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	logger.Infof("Delivering events received on %s to %s", srv.Addr, env.URL)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Fatalw("Error serving events", zap.Error(err))
+	}
+}
@@ -27,13 +27,16 @@ import (
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/injection"
 	"knative.dev/pkg/webhook/psbinding"
+
+	"github.com/mattmoor/mink/pkg/stepbinding"
+	"github.com/mattmoor/mink/pkg/webhook/audit"
 )
 
-func NewSinkBindingWebhook(opts ...psbinding.ReconcilerOption) injection.ControllerConstructor {
+func NewSinkBindingWebhook(auditor *audit.Auditor, opts ...psbinding.ReconcilerOption) injection.ControllerConstructor {
 	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
 		sbresolver := sinkbinding.WithContextFactory(ctx, func(types.NamespacedName) {})
 
-		return psbinding.NewAdmissionController(ctx,
+		return audit.Wrap(ctx, cmw, auditor, stepbinding.Wrap(psbinding.NewAdmissionController(ctx,
 			// Name of the resource webhook.
 			"sinkbindings.webhook.mink.knative.dev",
 
@@ -48,13 +51,13 @@ func NewSinkBindingWebhook(opts ...psbinding.ReconcilerOption) injection.Control
 
 			// Pass through options from our caller.
 			opts...,
-		)
+		)))
 	}
 }
 
-func NewVSphereBindingWebhook(opts ...psbinding.ReconcilerOption) injection.ControllerConstructor {
+func NewVSphereBindingWebhook(auditor *audit.Auditor, opts ...psbinding.ReconcilerOption) injection.ControllerConstructor {
 	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
-		return psbinding.NewAdmissionController(ctx,
+		return audit.Wrap(ctx, cmw, auditor, stepbinding.Wrap(psbinding.NewAdmissionController(ctx,
 			// Name of the resource webhook.
 			"vspherebindings.webhook.mink.knative.dev",
 
@@ -71,13 +74,13 @@ func NewVSphereBindingWebhook(opts ...psbinding.ReconcilerOption) injection.Cont
 				return ctx, nil
 			},
 			opts...,
-		)
+		)))
 	}
 }
 
-func NewBindingWebhook(resource string, gla psbinding.GetListAll, wc psbinding.BindableContext) injection.ControllerConstructor {
+func NewBindingWebhook(auditor *audit.Auditor, resource string, gla psbinding.GetListAll, wc psbinding.BindableContext) injection.ControllerConstructor {
 	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
-		return psbinding.NewAdmissionController(ctx,
+		return audit.Wrap(ctx, cmw, auditor, stepbinding.Wrap(psbinding.NewAdmissionController(ctx,
 			// Name of the resource webhook.
 			fmt.Sprintf("%s.webhook.mink.knative.dev", resource),
 
@@ -89,6 +92,6 @@ func NewBindingWebhook(resource string, gla psbinding.GetListAll, wc psbinding.B
 
 			// How to setup the context prior to invoking Do/Undo.
 			wc,
-		)
+		)))
 	}
 }
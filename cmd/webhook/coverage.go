@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+
+	apixclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
+	"knative.dev/pkg/logging"
+)
+
+// installedGroups are the API groups of the CRDs that mink installs, whose
+// resources our webhooks should admit.  We also install Contour's CRDs,
+// which Contour validates itself, and Serving's caching Images, which
+// (as upstream) nothing admits.
+var installedGroups = sets.NewString(
+	"serving.knative.dev",
+	"autoscaling.internal.knative.dev",
+	"networking.internal.knative.dev",
+	"eventing.knative.dev",
+	"messaging.knative.dev",
+	"flows.knative.dev",
+	"sources.knative.dev",
+	"tekton.dev",
+	"sources.tanzu.vmware.com",
+	"bindings.mattmoor.dev",
+	"sources.vaikas.dev",
+	"sinks.mink.knative.dev",
+	"networking.mink.knative.dev",
+	"bindings.mink.knative.dev",
+)
+
+// warnUncoveredTypes warns about the versions served by the CRDs we
+// install that have no entry in ourTypes, whose resources our webhooks
+// therefore neither default nor validate.
+func warnUncoveredTypes(ctx context.Context, cfg *rest.Config) {
+	logger := logging.FromContext(ctx)
+
+	client, err := apixclient.NewForConfig(cfg)
+	if err != nil {
+		logger.Warnw("Unable to check the webhooks' coverage of our CRDs", "error", err)
+		return
+	}
+	crds, err := client.ApiextensionsV1beta1().CustomResourceDefinitions().List(metav1.ListOptions{})
+	if err != nil {
+		logger.Warnw("Unable to check the webhooks' coverage of our CRDs", "error", err)
+		return
+	}
+	for _, crd := range crds.Items {
+		if !installedGroups.Has(crd.Spec.Group) {
+			continue
+		}
+		versions := sets.NewString()
+		for _, v := range crd.Spec.Versions {
+			if v.Served {
+				versions.Insert(v.Name)
+			}
+		}
+		if len(crd.Spec.Versions) == 0 {
+			versions.Insert(crd.Spec.Version)
+		}
+		for _, version := range versions.List() {
+			gvk := schema.GroupVersionKind{
+				Group:   crd.Spec.Group,
+				Version: version,
+				Kind:    crd.Spec.Names.Kind,
+			}
+			if _, ok := ourTypes[gvk]; !ok {
+				logger.Warnf("%s is served by CRD %s but missing from ourTypes, so it is not admitted by our webhooks", gvk, crd.Name)
+			}
+		}
+	}
+}
@@ -19,49 +19,151 @@ package main
 import (
 	"context"
 
+	contourv1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	serviceinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/service"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
 	"knative.dev/pkg/logging"
+	"knative.dev/pkg/webhook/resourcesemantics"
 	"knative.dev/pkg/webhook/resourcesemantics/defaulting"
+	ingressinformer "knative.dev/serving/pkg/client/injection/informers/networking/v1alpha1/ingress"
+	sksinformer "knative.dev/serving/pkg/client/injection/informers/networking/v1alpha1/serverlessservice"
 
 	tkndefaultconfig "github.com/tektoncd/pipeline/pkg/apis/config"
 	"github.com/tektoncd/pipeline/pkg/contexts"
 	knedefaultconfig "knative.dev/eventing/pkg/apis/config"
 	channeldefaultconfig "knative.dev/eventing/pkg/apis/messaging/config"
 	knsdefaultconfig "knative.dev/serving/pkg/apis/config"
+
+	"github.com/mattmoor/mink/pkg/reconciler/activatorlocality"
+	"github.com/mattmoor/mink/pkg/reconciler/guard"
+	"github.com/mattmoor/mink/pkg/reconciler/stepdigests"
+	"github.com/mattmoor/mink/pkg/webhook/adapters"
+	"github.com/mattmoor/mink/pkg/webhook/audit"
 )
 
-func NewDefaultingAdmissionController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
-	// Decorate contexts with the current state of the config.
-	knsstore := knsdefaultconfig.NewStore(logging.FromContext(ctx).Named("config-store"))
-	knsstore.WatchConfigs(cmw)
+func NewDefaultingAdmissionController(auditor *audit.Auditor) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		// Decorate contexts with the current state of the config.
+		knsstore := knsdefaultconfig.NewStore(logging.FromContext(ctx).Named("config-store"))
+		knsstore.WatchConfigs(cmw)
+
+		knestore := knedefaultconfig.NewStore(logging.FromContext(ctx).Named("config-store"))
+		knestore.WatchConfigs(cmw)
+
+		tknstore := tkndefaultconfig.NewStore(logging.FromContext(ctx).Named("config-store"))
+		tknstore.WatchConfigs(cmw)
+
+		channelStore := channeldefaultconfig.NewStore(logging.FromContext(ctx).Named("channel-config-store"))
+		channelStore.WatchConfigs(cmw)
+
+		return audit.Wrap(ctx, cmw, auditor, defaulting.NewAdmissionController(ctx,
+
+			// Name of the resource webhook.
+			"webhook.mink.knative.dev",
+
+			// The path on which to serve the webhook.
+			"/defaulting",
+
+			// The resources to validate and default.
+			ourTypes,
+
+			// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
+			func(ctx context.Context) context.Context {
+				return contexts.WithDefaultConfigurationName(channelStore.ToContext(tknstore.ToContext(knestore.ToContext(knsstore.ToContext(ctx)))))
+			},
 
-	knestore := knedefaultconfig.NewStore(logging.FromContext(ctx).Named("config-store"))
-	knestore.WatchConfigs(cmw)
+			// Whether to disallow unknown fields.
+			true,
+		))
+	}
+}
+
+func NewHTTPProxyDefaultingController(auditor *audit.Auditor) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		sksLister := sksinformer.Get(ctx).Lister()
+		ingressLister := ingressinformer.Get(ctx).Lister()
+		serviceLister := serviceinformer.Get(ctx).Lister()
+
+		return audit.Wrap(ctx, cmw, auditor, defaulting.NewAdmissionController(ctx,
+
+			// Name of the resource webhook.
+			"httpproxies.webhook.mink.knative.dev",
+
+			// The path on which to serve the webhook.
+			"/httpproxies",
+
+			// The resources to default, which route guarded KIngresses through
+			// the guard, and ServerlessServices in Proxy mode through the
+			// node-local activator.
+			map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
+				contourv1.SchemeGroupVersion.WithKind("HTTPProxy"): &adapters.HTTPProxy{},
+			},
+
+			// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
+			func(ctx context.Context) context.Context {
+				ctx = guard.WithListers(ctx, ingressLister, serviceLister)
+				return activatorlocality.WithListers(ctx, sksLister, serviceLister)
+			},
+
+			// Whether to disallow unknown fields.
+			false,
+		))
+	}
+}
+
+func NewRoutingDefaultingController(auditor *audit.Auditor) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		return audit.Wrap(ctx, cmw, auditor, defaulting.NewAdmissionController(ctx,
+
+			// Name of the resource webhook.
+			"routing.webhook.mink.knative.dev",
 
-	tknstore := tkndefaultconfig.NewStore(logging.FromContext(ctx).Named("config-store"))
-	tknstore.WatchConfigs(cmw)
+			// The path on which to serve the webhook.
+			"/routing",
+
+			// The resources to default, which carry the ingress class of the
+			// visibility class they select to their KIngresses.
+			routingTypes,
+
+			// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
+			nil,
+
+			// Whether to disallow unknown fields.
+			false,
+		))
+	}
+}
 
-	channelStore := channeldefaultconfig.NewStore(logging.FromContext(ctx).Named("channel-config-store"))
-	channelStore.WatchConfigs(cmw)
+func NewTaskRunPodDefaultingController(auditor *audit.Auditor) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		resolver := stepdigests.NewResolver(kubeclient.Get(ctx))
 
-	return defaulting.NewAdmissionController(ctx,
+		return audit.Wrap(ctx, cmw, auditor, defaulting.NewAdmissionController(ctx,
 
-		// Name of the resource webhook.
-		"webhook.mink.knative.dev",
+			// Name of the resource webhook.
+			"pods.webhook.mink.knative.dev",
 
-		// The path on which to serve the webhook.
-		"/defaulting",
+			// The path on which to serve the webhook.
+			"/pods",
 
-		// The resources to validate and default.
-		ourTypes,
+			// The resources to default, which pins the images of the steps
+			// and sidecars of TaskRun pods to digests.
+			map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
+				corev1.SchemeGroupVersion.WithKind("Pod"): &adapters.Pod{},
+			},
 
-		// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
-		func(ctx context.Context) context.Context {
-			return contexts.WithDefaultConfigurationName(channelStore.ToContext(tknstore.ToContext(knestore.ToContext(knsstore.ToContext(ctx)))))
-		},
+			// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
+			func(ctx context.Context) context.Context {
+				return stepdigests.WithResolver(ctx, resolver)
+			},
 
-		// Whether to disallow unknown fields.
-		true,
-	)
+			// Whether to disallow unknown fields.
+			false,
+		))
+	}
 }
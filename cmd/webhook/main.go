@@ -28,6 +28,29 @@ import (
 	"github.com/mattmoor/bindings/pkg/reconciler/slackbinding"
 	"github.com/mattmoor/bindings/pkg/reconciler/sqlbinding"
 	"github.com/mattmoor/bindings/pkg/reconciler/twitterbinding"
+	"github.com/mattmoor/mink/pkg/autoscaler"
+	"github.com/mattmoor/mink/pkg/reconciler/activatorlocality"
+	"github.com/mattmoor/mink/pkg/reconciler/apiserversource"
+	"github.com/mattmoor/mink/pkg/reconciler/domainmapping"
+	"github.com/mattmoor/mink/pkg/reconciler/githubstatus"
+	"github.com/mattmoor/mink/pkg/reconciler/guard"
+	"github.com/mattmoor/mink/pkg/reconciler/kafkabroker"
+	"github.com/mattmoor/mink/pkg/reconciler/kafkachannel"
+	"github.com/mattmoor/mink/pkg/reconciler/kafkasource"
+	"github.com/mattmoor/mink/pkg/reconciler/magicdns"
+	"github.com/mattmoor/mink/pkg/reconciler/messagesink"
+	"github.com/mattmoor/mink/pkg/reconciler/pipelinerunsink"
+	"github.com/mattmoor/mink/pkg/reconciler/postgrescdc"
+	"github.com/mattmoor/mink/pkg/reconciler/rollout"
+	"github.com/mattmoor/mink/pkg/reconciler/runevents"
+	"github.com/mattmoor/mink/pkg/reconciler/rungc"
+	"github.com/mattmoor/mink/pkg/reconciler/sqlsink"
+	"github.com/mattmoor/mink/pkg/reconciler/stepdigests"
+	"github.com/mattmoor/mink/pkg/reconciler/taskrunlogs"
+	"github.com/mattmoor/mink/pkg/reconciler/visibilityclass"
+	"github.com/mattmoor/mink/pkg/reconciler/webhookbinding"
+	"github.com/mattmoor/mink/pkg/stepbinding"
+	"github.com/mattmoor/mink/pkg/webhook/audit"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
 	"github.com/tektoncd/pipeline/pkg/reconciler/pipelinerun"
 	"github.com/tektoncd/pipeline/pkg/reconciler/taskrun"
@@ -35,8 +58,6 @@ import (
 	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspherebinding"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource"
 	github "knative.dev/eventing-contrib/github/pkg/reconciler"
-	kafkasource "knative.dev/eventing-contrib/kafka/source/pkg/reconciler"
-	"knative.dev/eventing/pkg/reconciler/apiserversource"
 	"knative.dev/eventing/pkg/reconciler/channel"
 	"knative.dev/eventing/pkg/reconciler/containersource"
 	"knative.dev/eventing/pkg/reconciler/mtbroker"
@@ -44,7 +65,6 @@ import (
 	pingsource "knative.dev/eventing/pkg/reconciler/pingsource/controller"
 	"knative.dev/eventing/pkg/reconciler/sinkbinding"
 	"knative.dev/eventing/pkg/reconciler/subscription"
-	"knative.dev/net-contour/pkg/reconciler/contour"
 	"knative.dev/net-http01/pkg/challenger"
 	"knative.dev/net-http01/pkg/reconciler/certificate"
 	"knative.dev/pkg/configmap"
@@ -83,6 +103,8 @@ var (
 		"The container image containing our PR binary.")
 	imageDigestExporterImage = flag.String("imagedigest-exporter-image", "override-with-imagedigest-exporter-image:latest",
 		"The container image containing our image digest exporter binary.")
+	webhookSinkImage = flag.String("webhook-sink-image", "override-with-webhook-sink:latest",
+		"The container image of the sidecar that WebhookBindings add to their subjects.")
 )
 
 func main() {
@@ -123,16 +145,35 @@ func main() {
 	// TODO(mattmoor): Support running this on a different (random?) port.
 	go http.ListenAndServe(":8080", chlr)
 
+	// KPA-class autoscaling runs in-process, with the state shared by its
+	// controllers fed by the activators' stats.  The replica holding the
+	// autoscaler's Lease scales.
+	identity, podIP := os.Getenv("POD_NAME"), os.Getenv("POD_IP")
+	if identity == "" || podIP == "" {
+		log.Fatal("POD_NAME and POD_IP must be set to lease the autoscaler")
+	}
+	as := autoscaler.New(identity, podIP)
+
+	// The webhooks share a single Auditor, which records their decisions
+	// to the sink in config-admission-audit.
+	auditor := audit.NewAuditor()
+
 	nop := func(ctx context.Context, b psbinding.Bindable) (context.Context, error) {
 		return ctx, nil
 	}
 
-	sharedmain.WebhookMainWithConfig(ctx, "controller", sharedmain.ParseAndGetConfigOrDie(),
+	cfg := sharedmain.ParseAndGetConfigOrDie()
+
+	// Warn about any CRDs we install that our webhooks don't admit.
+	go warnUncoveredTypes(ctx, cfg)
+
+	sharedmain.WebhookMainWithConfig(ctx, "controller", cfg,
 		certificates.NewController,
-		NewDefaultingAdmissionController,
-		NewValidationAdmissionController,
-		NewConfigValidationController,
-		NewConversionController,
+		NewDefaultingAdmissionController(auditor),
+		NewValidationAdmissionController(auditor),
+		NewImageValidationController(auditor), NewPodImageValidationController(auditor),
+		NewConfigValidationController(auditor),
+		NewConversionController(auditor),
 
 		// Serving resource controllers.
 		configuration.NewController,
@@ -143,11 +184,26 @@ func main() {
 		service.NewController,
 		gc.NewController,
 		hpa.NewController,
+		as.NewKPAController,
+		as.NewMetricController,
 
-		// Contour KIngress controller.
-		contour.NewController,
+		// Contour KIngress controller, for those without a visibility class.
+		visibilityclass.NewContourController,
+		// Route Envoy to the activator on its own node.
+		activatorlocality.NewController, NewHTTPProxyDefaultingController(auditor),
+		// Expose Services through their named visibility classes, and
+		// protect those that ask for it with the guard.
+		visibilityclass.NewController, guard.NewController,
+		NewRoutingDefaultingController(auditor), NewRoutingValidationController(auditor),
+		// Map custom domains onto Services and Routes.
+		domainmapping.NewController,
+		// Keep the default domain pointed at the external Envoy.
+		magicdns.NewController,
+		// Progressively roll out the latest Revisions of Services.
+		rollout.NewController,
 
-		// Eventing source resource controllers.
+		// Eventing source resource controllers.  ApiServerSources are
+		// watched by the shared adapter in the dataplane.
 		apiserversource.NewController,
 		pingsource.NewController,
 		containersource.NewController,
@@ -155,17 +211,32 @@ func main() {
 		// Messaging controllers.
 		channel.NewController,
 		subscription.NewController,
+		kafkachannel.NewController,
 
 		// Eventing
 		mtnamespace.NewController,
 		mtbroker.NewController,
+		kafkabroker.NewController,
 
-		// For each binding we have a controller and a binding webhook.
-		sinkbinding.NewController, NewSinkBindingWebhook(sbSelector),
+		// For each binding we have a controller and a binding webhook.  The
+		// bindings also reach TaskRuns and PipelineRuns through their steps.
+		stepbinding.WithRuns(sinkbinding.NewController), NewSinkBindingWebhook(auditor, sbSelector),
 
 		// Tekton stuff
 		taskrun.NewController(images),
 		pipelinerun.NewController(images),
+		taskrunlogs.NewController,
+		// Pin step images to digests, and record them in TaskRun status.
+		stepdigests.NewController, NewTaskRunPodDefaultingController(auditor),
+		rungc.NewTaskRunController,
+		rungc.NewPipelineRunController,
+		runevents.NewTaskRunController,
+		runevents.NewPipelineRunController,
+		pipelinerunsink.NewController,
+		sqlsink.NewController,
+		messagesink.NewSlackController,
+		messagesink.NewTwitterController,
+		githubstatus.NewController,
 
 		// GitHubSource
 		github.NewController,
@@ -176,10 +247,11 @@ func main() {
 		// VMware stuff
 		vspheresource.NewController,
 		// For each binding we have a controller and a binding webhook.
-		vspherebinding.NewController, NewVSphereBindingWebhook(vsbSelector),
+		stepbinding.WithRuns(vspherebinding.NewController), NewVSphereBindingWebhook(auditor, vsbSelector),
 
 		// PostgresSource
-		postgressource.NewController,
+		postgrescdc.ExcludeLogicalReplication(postgressource.NewController),
+		postgrescdc.NewController,
 
 		// HTTP01 Solver
 		func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
@@ -187,10 +259,14 @@ func main() {
 		},
 
 		// Collection of mattmoor bindings that I need to upstream somewhere...
-		githubbinding.NewController, NewBindingWebhook("githubbindings", githubbinding.ListAll, nop),
-		slackbinding.NewController, NewBindingWebhook("slackbindings", slackbinding.ListAll, nop),
-		twitterbinding.NewController, NewBindingWebhook("twitterbindings", twitterbinding.ListAll, nop),
-		cloudsqlbinding.NewController, NewBindingWebhook("googlecloudsqlbindings", cloudsqlbinding.ListAll, nop),
-		sqlbinding.NewController, NewBindingWebhook("sqlbindings", sqlbinding.ListAll, nop),
+		stepbinding.WithRuns(githubbinding.NewController), NewBindingWebhook(auditor, "githubbindings", githubbinding.ListAll, nop),
+		stepbinding.WithRuns(slackbinding.NewController), NewBindingWebhook(auditor, "slackbindings", slackbinding.ListAll, nop),
+		stepbinding.WithRuns(twitterbinding.NewController), NewBindingWebhook(auditor, "twitterbindings", twitterbinding.ListAll, nop),
+		stepbinding.WithRuns(cloudsqlbinding.NewController), NewBindingWebhook(auditor, "googlecloudsqlbindings", cloudsqlbinding.ListAll, nop),
+		stepbinding.WithRuns(sqlbinding.NewController), NewBindingWebhook(auditor, "sqlbindings", sqlbinding.ListAll, nop),
+
+		// Sign and deliver requests from bound workloads to external webhooks.
+		stepbinding.WithRuns(webhookbinding.NewController(*webhookSinkImage)),
+		NewBindingWebhook(auditor, "webhookbindings", webhookbinding.ListAll, webhookbinding.WithSinkImage(*webhookSinkImage)),
 	)
 }
@@ -22,8 +22,10 @@ import (
 
 	tknv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	tknv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	postgresv1alpha1 "github.com/vaikas/postgressource/pkg/apis/sources/v1alpha1"
 	vsourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
 	githubv1alpha1 "knative.dev/eventing-contrib/github/pkg/apis/sources/v1alpha1"
+	kafkamessagingv1alpha1 "knative.dev/eventing-contrib/kafka/channel/pkg/apis/messaging/v1alpha1"
 	kafkasourcesv1alpha1 "knative.dev/eventing-contrib/kafka/source/pkg/apis/sources/v1alpha1"
 	configsv1alpha1 "knative.dev/eventing/pkg/apis/configs/v1alpha1"
 	eventingv1alpha1 "knative.dev/eventing/pkg/apis/eventing/v1alpha1"
@@ -41,6 +43,10 @@ import (
 	"knative.dev/serving/pkg/apis/serving/v1beta1"
 
 	mattmoorv1alpha1 "github.com/mattmoor/bindings/pkg/apis/bindings/v1alpha1"
+	bindingsv1alpha1 "github.com/mattmoor/mink/pkg/apis/bindings/v1alpha1"
+	networkingv1alpha1 "github.com/mattmoor/mink/pkg/apis/networking/v1alpha1"
+	sinksv1alpha1 "github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+	"github.com/mattmoor/mink/pkg/webhook/adapters"
 )
 
 var ourTypes = map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
@@ -84,6 +90,10 @@ var ourTypes = map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
 	messagingv1beta1.SchemeGroupVersion.WithKind("Channel"):         &messagingv1beta1.Channel{},
 	messagingv1beta1.SchemeGroupVersion.WithKind("Subscription"):    &messagingv1beta1.Subscription{},
 
+	// For group messaging.knative.dev (contrib)
+	// v1alpha1
+	kafkamessagingv1alpha1.SchemeGroupVersion.WithKind("KafkaChannel"): &kafkamessagingv1alpha1.KafkaChannel{},
+
 	// For group sources.knative.dev (contrib)
 	// v1alpha1
 	githubv1alpha1.SchemeGroupVersion.WithKind("GitHubSource"):      &githubv1alpha1.GitHubSource{},
@@ -131,10 +141,61 @@ var ourTypes = map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
 	vsourcesv1alpha1.SchemeGroupVersion.WithKind("VSphereSource"):  &vsourcesv1alpha1.VSphereSource{},
 	vsourcesv1alpha1.SchemeGroupVersion.WithKind("VSphereBinding"): &vsourcesv1alpha1.VSphereBinding{},
 
+	// For group sources.vaikas.dev
+	postgresv1alpha1.SchemeGroupVersion.WithKind("PostgresSource"): &adapters.PostgresSource{},
+
 	// mattmoor bindings
 	mattmoorv1alpha1.SchemeGroupVersion.WithKind("GithubBinding"):         &mattmoorv1alpha1.GithubBinding{},
 	mattmoorv1alpha1.SchemeGroupVersion.WithKind("GoogleCloudSQLBinding"): &mattmoorv1alpha1.GoogleCloudSQLBinding{},
 	mattmoorv1alpha1.SchemeGroupVersion.WithKind("SQLBinding"):            &mattmoorv1alpha1.SQLBinding{},
 	mattmoorv1alpha1.SchemeGroupVersion.WithKind("SlackBinding"):          &mattmoorv1alpha1.SlackBinding{},
 	mattmoorv1alpha1.SchemeGroupVersion.WithKind("TwitterBinding"):        &mattmoorv1alpha1.TwitterBinding{},
+
+	// For group sinks.mink.knative.dev
+	sinksv1alpha1.SchemeGroupVersion.WithKind("PipelineRunSink"): &sinksv1alpha1.PipelineRunSink{},
+	sinksv1alpha1.SchemeGroupVersion.WithKind("SQLSink"):         &sinksv1alpha1.SQLSink{},
+	sinksv1alpha1.SchemeGroupVersion.WithKind("SlackSink"):       &sinksv1alpha1.SlackSink{},
+	sinksv1alpha1.SchemeGroupVersion.WithKind("TwitterSink"):     &sinksv1alpha1.TwitterSink{},
+
+	// For group networking.mink.knative.dev
+	networkingv1alpha1.SchemeGroupVersion.WithKind("DomainMapping"): &networkingv1alpha1.DomainMapping{},
+
+	// For group bindings.mink.knative.dev
+	bindingsv1alpha1.SchemeGroupVersion.WithKind("WebhookBinding"): &bindingsv1alpha1.WebhookBinding{},
+}
+
+// routingTypes are the resources whose metadata customizes how they are
+// exposed, which our routing webhooks default and validate.
+var routingTypes = map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
+	v1alpha1.SchemeGroupVersion.WithKind("Route"):   &adapters.Route{},
+	v1alpha1.SchemeGroupVersion.WithKind("Service"): &adapters.Route{},
+	v1beta1.SchemeGroupVersion.WithKind("Route"):    &adapters.Route{},
+	v1beta1.SchemeGroupVersion.WithKind("Service"):  &adapters.Route{},
+	v1.SchemeGroupVersion.WithKind("Route"):         &adapters.Route{},
+	v1.SchemeGroupVersion.WithKind("Service"):       &adapters.Route{},
+}
+
+// imageTypes are the resources whose specs reference images, which our
+// image policy webhook validates.
+var imageTypes = map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
+	v1alpha1.SchemeGroupVersion.WithKind("Revision"):      &adapters.Workload{},
+	v1alpha1.SchemeGroupVersion.WithKind("Configuration"): &adapters.Workload{},
+	v1alpha1.SchemeGroupVersion.WithKind("Service"):       &adapters.Workload{},
+	v1beta1.SchemeGroupVersion.WithKind("Revision"):       &adapters.Workload{},
+	v1beta1.SchemeGroupVersion.WithKind("Configuration"):  &adapters.Workload{},
+	v1beta1.SchemeGroupVersion.WithKind("Service"):        &adapters.Workload{},
+	v1.SchemeGroupVersion.WithKind("Revision"):            &adapters.Workload{},
+	v1.SchemeGroupVersion.WithKind("Configuration"):       &adapters.Workload{},
+	v1.SchemeGroupVersion.WithKind("Service"):             &adapters.Workload{},
+
+	tknv1alpha1.SchemeGroupVersion.WithKind("Pipeline"):    &adapters.Workload{},
+	tknv1alpha1.SchemeGroupVersion.WithKind("Task"):        &adapters.Workload{},
+	tknv1alpha1.SchemeGroupVersion.WithKind("ClusterTask"): &adapters.Workload{},
+	tknv1alpha1.SchemeGroupVersion.WithKind("TaskRun"):     &adapters.Workload{},
+	tknv1alpha1.SchemeGroupVersion.WithKind("PipelineRun"): &adapters.Workload{},
+	tknv1beta1.SchemeGroupVersion.WithKind("Pipeline"):     &adapters.Workload{},
+	tknv1beta1.SchemeGroupVersion.WithKind("Task"):         &adapters.Workload{},
+	tknv1beta1.SchemeGroupVersion.WithKind("ClusterTask"):  &adapters.Workload{},
+	tknv1beta1.SchemeGroupVersion.WithKind("TaskRun"):      &adapters.Workload{},
+	tknv1beta1.SchemeGroupVersion.WithKind("PipelineRun"):  &adapters.Workload{},
 }
@@ -20,15 +20,19 @@ import (
 	"context"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	secretinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/secret"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/metrics"
 	"knative.dev/pkg/webhook/configmaps"
+	"knative.dev/pkg/webhook/resourcesemantics"
 	"knative.dev/pkg/webhook/resourcesemantics/validation"
 
 	// config validation constructors
-	contourconfig "knative.dev/net-contour/pkg/reconciler/contour/config"
 	metricsconfig "knative.dev/pkg/metrics"
 	tracingconfig "knative.dev/pkg/tracing/config"
 	defaultconfig "knative.dev/serving/pkg/apis/config"
@@ -44,71 +48,174 @@ import (
 	knedefaultconfig "knative.dev/eventing/pkg/apis/config"
 	channeldefaultconfig "knative.dev/eventing/pkg/apis/messaging/config"
 	knsdefaultconfig "knative.dev/serving/pkg/apis/config"
+
+	minkgcconfig "github.com/mattmoor/mink/pkg/gc"
+	githubconfig "github.com/mattmoor/mink/pkg/reconciler/githubstatus/config"
+	magicdnsconfig "github.com/mattmoor/mink/pkg/reconciler/magicdns/config"
+	runeventsconfig "github.com/mattmoor/mink/pkg/reconciler/runevents/config"
+	visibilityconfig "github.com/mattmoor/mink/pkg/reconciler/visibilityclass/config"
+	"github.com/mattmoor/mink/pkg/webhook/adapters"
+	"github.com/mattmoor/mink/pkg/webhook/audit"
+	auditconfig "github.com/mattmoor/mink/pkg/webhook/audit/config"
+	"github.com/mattmoor/mink/pkg/webhook/imagepolicy"
+	imagepolicyconfig "github.com/mattmoor/mink/pkg/webhook/imagepolicy/config"
 )
 
-func NewValidationAdmissionController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
-	// Decorate contexts with the current state of the config.
-	knsstore := knsdefaultconfig.NewStore(logging.FromContext(ctx).Named("config-store"))
-	knsstore.WatchConfigs(cmw)
+func NewValidationAdmissionController(auditor *audit.Auditor) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		// Decorate contexts with the current state of the config.
+		knsstore := knsdefaultconfig.NewStore(logging.FromContext(ctx).Named("config-store"))
+		knsstore.WatchConfigs(cmw)
+
+		knestore := knedefaultconfig.NewStore(logging.FromContext(ctx).Named("config-store"))
+		knestore.WatchConfigs(cmw)
+
+		tknstore := tkndefaultconfig.NewStore(logging.FromContext(ctx).Named("config-store"))
+		tknstore.WatchConfigs(cmw)
+
+		channelStore := channeldefaultconfig.NewStore(logging.FromContext(ctx).Named("channel-config-store"))
+		channelStore.WatchConfigs(cmw)
+
+		return audit.Wrap(ctx, cmw, auditor, validation.NewAdmissionController(ctx,
+
+			// Name of the resource webhook.
+			"validation.webhook.mink.knative.dev",
+
+			// The path on which to serve the webhook.
+			"/resource-validation",
+
+			// The resources to validate and default.
+			ourTypes,
+
+			// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
+			func(ctx context.Context) context.Context {
+				return contexts.WithDefaultConfigurationName(channelStore.ToContext(tknstore.ToContext(knestore.ToContext(knsstore.ToContext(ctx)))))
+			},
+
+			// Whether to disallow unknown fields.
+			true,
+		))
+	}
+}
+
+func NewRoutingValidationController(auditor *audit.Auditor) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		// Decorate contexts with the current state of the config.
+		store := visibilityconfig.NewStore(logging.FromContext(ctx).Named("visibility-config-store"))
+		store.WatchConfigs(cmw)
 
-	knestore := knedefaultconfig.NewStore(logging.FromContext(ctx).Named("config-store"))
-	knestore.WatchConfigs(cmw)
+		return audit.Wrap(ctx, cmw, auditor, validation.NewAdmissionController(ctx,
 
-	tknstore := tkndefaultconfig.NewStore(logging.FromContext(ctx).Named("config-store"))
-	tknstore.WatchConfigs(cmw)
+			// Name of the resource webhook.
+			"routing.validation.webhook.mink.knative.dev",
 
-	channelStore := channeldefaultconfig.NewStore(logging.FromContext(ctx).Named("channel-config-store"))
-	channelStore.WatchConfigs(cmw)
+			// The path on which to serve the webhook.
+			"/routing-validation",
 
-	return validation.NewAdmissionController(ctx,
+			// The resources to validate, which must select visibility classes
+			// that are defined in config-contour, and ask for valid protection.
+			routingTypes,
 
+			// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
+			store.ToContext,
+
+			// Whether to disallow unknown fields.
+			false,
+		))
+	}
+}
+
+func NewImageValidationController(auditor *audit.Auditor) injection.ControllerConstructor {
+	return imageValidationController(auditor,
 		// Name of the resource webhook.
-		"validation.webhook.mink.knative.dev",
+		"images.validation.webhook.mink.knative.dev",
 
 		// The path on which to serve the webhook.
-		"/resource-validation",
+		"/image-validation",
 
-		// The resources to validate and default.
-		ourTypes,
+		// The resources whose images must satisfy config-image-policy.
+		imageTypes)
+}
 
-		// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
-		func(ctx context.Context) context.Context {
-			return contexts.WithDefaultConfigurationName(channelStore.ToContext(tknstore.ToContext(knestore.ToContext(knsstore.ToContext(ctx)))))
-		},
+func NewPodImageValidationController(auditor *audit.Auditor) injection.ControllerConstructor {
+	return imageValidationController(auditor,
+		// Name of the resource webhook.
+		"pod-images.validation.webhook.mink.knative.dev",
 
-		// Whether to disallow unknown fields.
-		true,
-	)
+		// The path on which to serve the webhook.
+		"/pod-image-validation",
+
+		// The pods of TaskRuns, whose steps and sidecars must satisfy
+		// config-image-policy once their images are pinned and their
+		// parameters substituted, and whose step templates must decode.
+		map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
+			corev1.SchemeGroupVersion.WithKind("Pod"): &adapters.Pod{},
+		})
 }
 
-func NewConfigValidationController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
-	return configmaps.NewAdmissionController(ctx,
+func imageValidationController(auditor *audit.Auditor, name, path string, types map[schema.GroupVersionKind]resourcesemantics.GenericCRD) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		// Decorate contexts with the current image policy.
+		store := imagepolicyconfig.NewStore(logging.FromContext(ctx).Named("image-policy-config-store"))
+		store.WatchConfigs(cmw)
 
-		// Name of the configmap webhook.
-		"config.webhook.mink.knative.dev",
+		verifier := imagepolicy.NewVerifier(kubeclient.Get(ctx), secretinformer.Get(ctx).Lister())
 
-		// The path on which to serve the webhook.
-		"/config-validation",
-
-		// The configmaps to validate.
-		configmap.Constructors{
-			tracingconfig.ConfigName:         tracingconfig.NewTracingConfigFromConfigMap,
-			autoscalerconfig.ConfigName:      autoscalerconfig.NewConfigFromConfigMap,
-			certconfig.CertManagerConfigName: certconfig.NewCertManagerConfigFromConfigMap,
-			gcconfig.ConfigName:              gcconfig.NewConfigFromConfigMapFunc(ctx),
-			network.ConfigName:               network.NewConfigFromConfigMap,
-			deployment.ConfigName:            deployment.NewConfigFromConfigMap,
-			metrics.ConfigMapName():          metricsconfig.NewObservabilityConfigFromConfigMap,
-			logging.ConfigMapName():          logging.NewConfigFromConfigMap,
-			domainconfig.DomainConfigName:    domainconfig.NewDomainFromConfigMap,
-			defaultconfig.DefaultsConfigName: func(cm *corev1.ConfigMap) (interface{}, error) {
-				// Validate config-defaults for both serving and tekton.
-				if _, err := tkndefaultconfig.NewDefaultsFromConfigMap(cm); err != nil {
-					return nil, err
-				}
-				return knsdefaultconfig.NewDefaultsConfigFromConfigMap(cm)
+		return audit.Wrap(ctx, cmw, auditor, validation.NewAdmissionController(ctx, name, path, types,
+
+			// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
+			func(ctx context.Context) context.Context {
+				return imagepolicy.WithVerifier(store.ToContext(ctx), verifier)
+			},
+
+			// Whether to disallow unknown fields.
+			false,
+		))
+	}
+}
+
+func NewConfigValidationController(auditor *audit.Auditor) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		return audit.Wrap(ctx, cmw, auditor, configmaps.NewAdmissionController(ctx,
+
+			// Name of the configmap webhook.
+			"config.webhook.mink.knative.dev",
+
+			// The path on which to serve the webhook.
+			"/config-validation",
+
+			// The configmaps to validate.
+			configmap.Constructors{
+				tracingconfig.ConfigName:         tracingconfig.NewTracingConfigFromConfigMap,
+				autoscalerconfig.ConfigName:      autoscalerconfig.NewConfigFromConfigMap,
+				certconfig.CertManagerConfigName: certconfig.NewCertManagerConfigFromConfigMap,
+				network.ConfigName:               network.NewConfigFromConfigMap,
+				deployment.ConfigName:            deployment.NewConfigFromConfigMap,
+				metrics.ConfigMapName():          metricsconfig.NewObservabilityConfigFromConfigMap,
+				logging.ConfigMapName():          logging.NewConfigFromConfigMap,
+				domainconfig.DomainConfigName:    domainconfig.NewDomainFromConfigMap,
+				defaultconfig.DefaultsConfigName: func(cm *corev1.ConfigMap) (interface{}, error) {
+					// Validate config-defaults for both serving and tekton.
+					if _, err := tkndefaultconfig.NewDefaultsFromConfigMap(cm); err != nil {
+						return nil, err
+					}
+					return knsdefaultconfig.NewDefaultsConfigFromConfigMap(cm)
+				},
+				gcconfig.ConfigName: func(cm *corev1.ConfigMap) (interface{}, error) {
+					// Validate config-gc for both serving and mink.
+					if _, err := minkgcconfig.NewConfigFromConfigMap(cm); err != nil {
+						return nil, err
+					}
+					return gcconfig.NewConfigFromConfigMapFunc(ctx)(cm)
+				},
+				// config-contour holds both net-contour's visibilities and our classes.
+				visibilityconfig.ConfigName:  visibilityconfig.NewVisibilityFromConfigMap,
+				runeventsconfig.ConfigName:   runeventsconfig.NewConfigFromConfigMap,
+				githubconfig.ConfigName:      githubconfig.NewConfigFromConfigMap,
+				magicdnsconfig.ConfigName:    magicdnsconfig.NewMagicDNSFromConfigMap,
+				imagepolicyconfig.ConfigName: imagepolicyconfig.NewPolicyFromConfigMap,
+				auditconfig.ConfigName:       auditconfig.NewConfigFromConfigMap,
 			},
-			contourconfig.ContourConfigName: contourconfig.NewContourFromConfigMap,
-		},
-	)
+		))
+	}
 }
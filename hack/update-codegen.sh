@@ -18,9 +18,30 @@ set -o errexit
 set -o nounset
 set -o pipefail
 
+if [ -z "${GOPATH:-}" ]; then
+  export GOPATH=$(go env GOPATH)
+fi
+
 REPO_ROOT=$(dirname ${BASH_SOURCE})/..
+CODEGEN_PKG=${CODEGEN_PKG:-$(ls -d -1 ${REPO_ROOT}/vendor/k8s.io/code-generator 2>/dev/null || echo ../code-generator)}
+KNATIVE_CODEGEN_PKG=${KNATIVE_CODEGEN_PKG:-$(ls -d -1 ${REPO_ROOT}/vendor/knative.dev/pkg 2>/dev/null || echo ../pkg)}
 
 # TODO(mattmoor): Do fun things with config/
 
 # Make sure our dependencies are up-to-date
 ${REPO_ROOT}/hack/update-deps.sh
+
+${CODEGEN_PKG}/generate-groups.sh "deepcopy,client,informer,lister" \
+  github.com/mattmoor/mink/pkg/client github.com/mattmoor/mink/pkg/apis \
+  "bindings:v1alpha1 networking:v1alpha1 sinks:v1alpha1" \
+  --go-header-file ${REPO_ROOT}/hack/boilerplate/boilerplate.go.txt
+
+# Knative Injection
+${KNATIVE_CODEGEN_PKG}/hack/generate-knative.sh "injection" \
+  github.com/mattmoor/mink/pkg/client github.com/mattmoor/mink/pkg/apis \
+  "bindings:v1alpha1 networking:v1alpha1 sinks:v1alpha1" \
+  --go-header-file ${REPO_ROOT}/hack/boilerplate/boilerplate.go.txt
+
+# We have no unit tests to consume the fakes, and keeping them would have
+# dep vendor client-go's testing packages just for them.
+rm -rf $(find ${REPO_ROOT}/pkg/client -type d -name fake)
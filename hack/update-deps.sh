@@ -127,14 +127,9 @@ for dir in webhooks ; do
   done
 done
 
-rewrite_common "./vendor/knative.dev/serving/config/post-install/default-domain.yaml" "./config/core/200-imported/200-serving/deployments"
-
 # We need the Image resource from caching, but used by serving.
 rewrite_common "./vendor/knative.dev/caching/config/image.yaml" "./config/core/200-imported/200-serving/100-resources"
 
-# Copy the autoscaler as-is.
-rewrite_common "./vendor/knative.dev/serving/config/core/deployments/autoscaler.yaml" "./config/core/200-imported/200-serving/deployments"
-
 
 #################################################
 #
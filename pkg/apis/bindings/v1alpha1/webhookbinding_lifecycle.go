@@ -0,0 +1,157 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/apis/duck"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/tracker"
+
+	"github.com/mattmoor/mink/pkg/outgoingwebhook"
+)
+
+const (
+	// WebhookBindingConditionReady is set when the binding has been applied to the subjects.
+	WebhookBindingConditionReady = apis.ConditionReady
+)
+
+var webhookCondSet = apis.NewLivingConditionSet()
+
+// GetGroupVersionKind implements kmeta.OwnerRefable
+func (*WebhookBinding) GetGroupVersionKind() schema.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("WebhookBinding")
+}
+
+// GetConditionSet retrieves the condition set for this resource.
+func (*WebhookBinding) GetConditionSet() apis.ConditionSet {
+	return webhookCondSet
+}
+
+// GetSubject implements Bindable
+func (wb *WebhookBinding) GetSubject() tracker.Reference {
+	return wb.Spec.Subject
+}
+
+// GetBindingStatus implements Bindable
+func (wb *WebhookBinding) GetBindingStatus() duck.BindableStatus {
+	return &wb.Status
+}
+
+// SetObservedGeneration implements BindableStatus
+func (wbs *WebhookBindingStatus) SetObservedGeneration(gen int64) {
+	wbs.ObservedGeneration = gen
+}
+
+// InitializeConditions implements BindableStatus
+func (wbs *WebhookBindingStatus) InitializeConditions() {
+	webhookCondSet.Manage(wbs).InitializeConditions()
+}
+
+// MarkBindingUnavailable implements BindableStatus
+func (wbs *WebhookBindingStatus) MarkBindingUnavailable(reason, message string) {
+	webhookCondSet.Manage(wbs).MarkFalse(
+		WebhookBindingConditionReady, reason, "%s", message)
+}
+
+// MarkBindingAvailable implements BindableStatus
+func (wbs *WebhookBindingStatus) MarkBindingAvailable() {
+	webhookCondSet.Manage(wbs).MarkTrue(WebhookBindingConditionReady)
+}
+
+// Do implements psbinding.Bindable, giving each [init]container of the
+// subject the webhook's endpoint and signing secret.  When the context
+// carries the image of the sink, we also add a sidecar to which the
+// containers may send CloudEvents to be signed and delivered.
+func (wb *WebhookBinding) Do(ctx context.Context, ps *duckv1.WithPod) {
+
+	// First undo so that we can just unconditionally append below.
+	wb.Undo(ctx, ps)
+
+	secret := wb.Spec.Secret
+	env := []corev1.EnvVar{{
+		Name:  outgoingwebhook.URLEnv,
+		Value: wb.Spec.URL.String(),
+	}, {
+		Name: outgoingwebhook.SecretEnv,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &secret,
+		},
+	}}
+	image := outgoingwebhook.SinkImageFrom(ctx)
+
+	containerEnv := append([]corev1.EnvVar(nil), env...)
+	if image != "" {
+		containerEnv = append(containerEnv, corev1.EnvVar{
+			Name:  outgoingwebhook.SinkEnv,
+			Value: outgoingwebhook.SinkURL,
+		})
+	}
+	spec := &ps.Spec.Template.Spec
+	for i := range spec.InitContainers {
+		spec.InitContainers[i].Env = append(spec.InitContainers[i].Env, containerEnv...)
+	}
+	for i := range spec.Containers {
+		spec.Containers[i].Env = append(spec.Containers[i].Env, containerEnv...)
+	}
+
+	if image != "" {
+		spec.Containers = append(spec.Containers, corev1.Container{
+			Name:  outgoingwebhook.SinkContainerName,
+			Image: image,
+			Env:   env,
+		})
+	}
+}
+
+// Undo implements psbinding.Bindable
+func (wb *WebhookBinding) Undo(ctx context.Context, ps *duckv1.WithPod) {
+	spec := &ps.Spec.Template.Spec
+
+	// Make sure the PodSpec does NOT have the sink sidecar.
+	for i, c := range spec.Containers {
+		if c.Name == outgoingwebhook.SinkContainerName {
+			spec.Containers = append(spec.Containers[:i], spec.Containers[i+1:]...)
+			break
+		}
+	}
+
+	// Make sure that none of the [init]containers have our environment.
+	for i := range spec.InitContainers {
+		spec.InitContainers[i].Env = dropWebhookEnv(spec.InitContainers[i].Env)
+	}
+	for i := range spec.Containers {
+		spec.Containers[i].Env = dropWebhookEnv(spec.Containers[i].Env)
+	}
+}
+
+// dropWebhookEnv returns the environment without the variables we inject.
+func dropWebhookEnv(env []corev1.EnvVar) []corev1.EnvVar {
+	kept := env[:0]
+	for _, ev := range env {
+		switch ev.Name {
+		case outgoingwebhook.URLEnv, outgoingwebhook.SecretEnv, outgoingwebhook.SinkEnv:
+		default:
+			kept = append(kept, ev)
+		}
+	}
+	return kept
+}
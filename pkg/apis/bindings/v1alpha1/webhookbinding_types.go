@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/apis/duck"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/tracker"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WebhookBinding is a Knative-style Binding for injecting the endpoint of
+// an external webhook and the secret with which to sign requests to it
+// into any Kubernetes resource with a Pod Spec, alongside a sidecar that
+// signs and delivers the CloudEvents sent to it.
+type WebhookBinding struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the desired state of the WebhookBinding (from the client).
+	// +optional
+	Spec WebhookBindingSpec `json:"spec,omitempty"`
+
+	// Status communicates the observed state of the WebhookBinding (from the controller).
+	// +optional
+	Status WebhookBindingStatus `json:"status,omitempty"`
+}
+
+var (
+	// Check that WebhookBinding can be validated, defaulted and bound.
+	_ apis.Validatable   = (*WebhookBinding)(nil)
+	_ apis.Defaultable   = (*WebhookBinding)(nil)
+	_ kmeta.OwnerRefable = (*WebhookBinding)(nil)
+	_ apis.Listable      = (*WebhookBinding)(nil)
+	_ duck.Bindable      = (*WebhookBinding)(nil)
+)
+
+// WebhookBindingSpec holds the desired state of the WebhookBinding (from the client).
+type WebhookBindingSpec struct {
+	// Subject holds a reference to the "pod speccable" Kubernetes resource which will
+	// be bound with the webhook's endpoint and signing secret.
+	Subject tracker.Reference `json:"subject"`
+
+	// URL is the external endpoint of the webhook.
+	URL apis.URL `json:"url"`
+
+	// Secret selects the key of a secret in the WebhookBinding's namespace
+	// holding the secret with which requests to the webhook are signed.
+	// The key defaults to "secret".
+	Secret corev1.SecretKeySelector `json:"secret"`
+}
+
+// WebhookBindingStatus communicates the observed state of the WebhookBinding (from the controller).
+type WebhookBindingStatus struct {
+	duckv1.Status `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WebhookBindingList is a list of WebhookBinding resources
+type WebhookBindingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []WebhookBinding `json:"items"`
+}
+
+// GetListType implements apis.Listable
+func (*WebhookBinding) GetListType() runtime.Object {
+	return &WebhookBindingList{}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	"knative.dev/pkg/apis"
+)
+
+// Validate implements apis.Validatable
+func (wb *WebhookBinding) Validate(ctx context.Context) *apis.FieldError {
+	return wb.Spec.Validate(ctx).ViaField("spec")
+}
+
+// Validate implements apis.Validatable
+func (wbs *WebhookBindingSpec) Validate(ctx context.Context) *apis.FieldError {
+	errs := wbs.Subject.Validate(ctx).ViaField("subject")
+
+	switch {
+	case wbs.URL.IsEmpty():
+		errs = errs.Also(apis.ErrMissingField("url"))
+	case wbs.URL.Scheme != "http" && wbs.URL.Scheme != "https":
+		errs = errs.Also(apis.ErrInvalidValue(wbs.URL.String(), "url"))
+	case wbs.URL.Host == "":
+		errs = errs.Also(apis.ErrInvalidValue(wbs.URL.String(), "url"))
+	}
+
+	if wbs.Secret.Name == "" {
+		errs = errs.Also(apis.ErrMissingField("name").ViaField("secret"))
+	}
+	if wbs.Secret.Key == "" {
+		errs = errs.Also(apis.ErrMissingField("key").ViaField("secret"))
+	}
+	return errs
+}
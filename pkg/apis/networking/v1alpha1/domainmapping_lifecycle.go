@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	netv1alpha1 "knative.dev/serving/pkg/apis/networking/v1alpha1"
+)
+
+const (
+	// DomainMappingConditionReady is set when the domain is mapped onto
+	// its target.
+	DomainMappingConditionReady = apis.ConditionReady
+
+	// DomainMappingConditionDomainClaimed is set when no older
+	// DomainMapping claims the same domain.
+	DomainMappingConditionDomainClaimed apis.ConditionType = "DomainClaimed"
+
+	// DomainMappingConditionReferenceResolved is set when the referenced
+	// Service or Route is routable.
+	DomainMappingConditionReferenceResolved apis.ConditionType = "ReferenceResolved"
+
+	// DomainMappingConditionCertificateProvisioned is set when the
+	// certificate for the domain is ready, or not required.
+	DomainMappingConditionCertificateProvisioned apis.ConditionType = "CertificateProvisioned"
+
+	// DomainMappingConditionIngressReady is set when the KIngress for the
+	// domain is ready.
+	DomainMappingConditionIngressReady apis.ConditionType = "IngressReady"
+)
+
+var dmCondSet = apis.NewLivingConditionSet(
+	DomainMappingConditionDomainClaimed,
+	DomainMappingConditionReferenceResolved,
+	DomainMappingConditionCertificateProvisioned,
+	DomainMappingConditionIngressReady,
+)
+
+// GetGroupVersionKind implements kmeta.OwnerRefable
+func (*DomainMapping) GetGroupVersionKind() schema.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("DomainMapping")
+}
+
+// GetConditionSet retrieves the condition set for this resource.
+func (*DomainMapping) GetConditionSet() apis.ConditionSet {
+	return dmCondSet
+}
+
+// InitializeConditions sets the initial values to the conditions.
+func (dms *DomainMappingStatus) InitializeConditions() {
+	dmCondSet.Manage(dms).InitializeConditions()
+}
+
+// IsReady returns true if the domain is mapped onto its target.
+func (dms *DomainMappingStatus) IsReady() bool {
+	return dmCondSet.Manage(dms).IsHappy()
+}
+
+// MarkDomainClaimed marks that the DomainMapping owns its domain.
+func (dms *DomainMappingStatus) MarkDomainClaimed() {
+	dmCondSet.Manage(dms).MarkTrue(DomainMappingConditionDomainClaimed)
+}
+
+// MarkDomainAlreadyClaimed marks that an older DomainMapping in the
+// provided namespace claims the same domain.
+func (dms *DomainMappingStatus) MarkDomainAlreadyClaimed(namespace string) {
+	dmCondSet.Manage(dms).MarkFalse(DomainMappingConditionDomainClaimed, "DomainAlreadyClaimed",
+		"The domain is already mapped by the DomainMapping in namespace %q.", namespace)
+}
+
+// MarkReferenceResolved marks that the referenced Service or Route is routable.
+func (dms *DomainMappingStatus) MarkReferenceResolved() {
+	dmCondSet.Manage(dms).MarkTrue(DomainMappingConditionReferenceResolved)
+}
+
+// MarkReferenceNotResolved marks that the referenced Service or Route is not
+// (yet) routable.
+func (dms *DomainMappingStatus) MarkReferenceNotResolved(reason, messageFormat string, messageA ...interface{}) {
+	dmCondSet.Manage(dms).MarkFalse(DomainMappingConditionReferenceResolved, reason, messageFormat, messageA...)
+}
+
+// MarkCertificateReady marks that the certificate for the domain is ready.
+func (dms *DomainMappingStatus) MarkCertificateReady(name string) {
+	dmCondSet.Manage(dms).MarkTrueWithReason(DomainMappingConditionCertificateProvisioned,
+		"CertificateReady", "Certificate %s is ready.", name)
+}
+
+// MarkCertificateNotReady marks that the certificate for the domain is
+// being provisioned.
+func (dms *DomainMappingStatus) MarkCertificateNotReady(name string) {
+	dmCondSet.Manage(dms).MarkUnknown(DomainMappingConditionCertificateProvisioned,
+		"CertificateNotReady", "Certificate %s is not ready.", name)
+}
+
+// MarkCertificateNotRequired marks that no certificate is provisioned, since
+// auto-TLS is disabled.
+func (dms *DomainMappingStatus) MarkCertificateNotRequired() {
+	dmCondSet.Manage(dms).MarkTrueWithReason(DomainMappingConditionCertificateProvisioned,
+		"TLSNotEnabled", "Auto-TLS is not enabled, so no certificate is provisioned.")
+}
+
+// PropagateIngressStatus updates our IngressReady condition from the
+// status of the provided KIngress.
+func (dms *DomainMappingStatus) PropagateIngressStatus(is netv1alpha1.IngressStatus) {
+	cc := is.GetCondition(netv1alpha1.IngressConditionReady)
+	switch {
+	case cc == nil:
+		dmCondSet.Manage(dms).MarkUnknown(DomainMappingConditionIngressReady,
+			"IngressNotConfigured", "Ingress has not yet been reconciled.")
+	case cc.IsTrue():
+		dmCondSet.Manage(dms).MarkTrue(DomainMappingConditionIngressReady)
+	case cc.IsFalse():
+		dmCondSet.Manage(dms).MarkFalse(DomainMappingConditionIngressReady, cc.Reason, "%s", cc.Message)
+	default:
+		dmCondSet.Manage(dms).MarkUnknown(DomainMappingConditionIngressReady, cc.Reason, "%s", cc.Message)
+	}
+}
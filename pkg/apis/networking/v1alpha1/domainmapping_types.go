@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/kmeta"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DomainMapping maps a custom domain, which is its name, onto a Knative
+// Service or Route in its namespace.
+type DomainMapping struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the desired state of the DomainMapping (from the client).
+	// +optional
+	Spec DomainMappingSpec `json:"spec,omitempty"`
+
+	// Status communicates the observed state of the DomainMapping (from the controller).
+	// +optional
+	Status DomainMappingStatus `json:"status,omitempty"`
+}
+
+var (
+	// Check that DomainMapping can be validated and defaulted.
+	_ apis.Validatable   = (*DomainMapping)(nil)
+	_ apis.Defaultable   = (*DomainMapping)(nil)
+	_ kmeta.OwnerRefable = (*DomainMapping)(nil)
+	_ apis.Listable      = (*DomainMapping)(nil)
+)
+
+// DomainMappingSpec holds the desired state of the DomainMapping (from the client).
+type DomainMappingSpec struct {
+	// Ref references the Knative Service or Route onto which the domain
+	// is mapped, which must be in the DomainMapping's namespace.
+	Ref duckv1.KReference `json:"ref"`
+}
+
+// DomainMappingStatus communicates the observed state of the DomainMapping (from the controller).
+type DomainMappingStatus struct {
+	duckv1.Status `json:",inline"`
+
+	// URL is the URL at which the target is reachable through the
+	// mapped domain.
+	// +optional
+	URL *apis.URL `json:"url,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DomainMappingList is a list of DomainMapping resources
+type DomainMappingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []DomainMapping `json:"items"`
+}
+
+// GetListType implements apis.Listable
+func (*DomainMapping) GetListType() runtime.Object {
+	return &DomainMappingList{}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/network"
+	"knative.dev/serving/pkg/apis/serving"
+)
+
+// Validate implements apis.Validatable
+func (dm *DomainMapping) Validate(ctx context.Context) *apis.FieldError {
+	return validateDomain(dm.Name).ViaField("metadata").Also(dm.Spec.Validate(apis.WithinParent(ctx, dm.ObjectMeta)).ViaField("spec"))
+}
+
+// Validate implements apis.Validatable
+func (dms *DomainMappingSpec) Validate(ctx context.Context) *apis.FieldError {
+	errs := dms.Ref.Validate(ctx)
+	if errs != nil {
+		return errs.ViaField("ref")
+	}
+	gv, err := schema.ParseGroupVersion(dms.Ref.APIVersion)
+	if err != nil {
+		errs = errs.Also(apis.ErrInvalidValue(dms.Ref.APIVersion, "apiVersion"))
+	} else if gv.Group != serving.GroupName {
+		errs = errs.Also(apis.ErrGeneric("must reference a "+serving.GroupName+" resource", "apiVersion"))
+	}
+	switch dms.Ref.Kind {
+	case "Service", "Route":
+	default:
+		errs = errs.Also(apis.ErrGeneric("must reference a Service or Route", "kind"))
+	}
+	return errs.ViaField("ref")
+}
+
+// validateDomain checks that the DomainMapping's name is a domain we may
+// route from outside of the cluster.
+func validateDomain(name string) *apis.FieldError {
+	if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("invalid value: %s", name),
+			Paths:   []string{"name"},
+			Details: strings.Join(msgs, ", "),
+		}
+	}
+	if !strings.Contains(name, ".") {
+		return apis.ErrGeneric("must be a fully qualified domain name", "name")
+	}
+	if strings.HasSuffix(name, "."+network.GetClusterDomainName()) {
+		return apis.ErrGeneric("must not be a cluster-local domain", "name")
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+const (
+	// PipelineRunSinkConditionReady is set when the sink is ready to accept events.
+	PipelineRunSinkConditionReady = apis.ConditionReady
+)
+
+var prsCondSet = apis.NewLivingConditionSet()
+
+// GetGroupVersionKind implements kmeta.OwnerRefable
+func (*PipelineRunSink) GetGroupVersionKind() schema.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("PipelineRunSink")
+}
+
+// GetConditionSet retrieves the condition set for this resource.
+func (*PipelineRunSink) GetConditionSet() apis.ConditionSet {
+	return prsCondSet
+}
+
+// InitializeConditions sets the initial values to the conditions.
+func (prss *PipelineRunSinkStatus) InitializeConditions() {
+	prsCondSet.Manage(prss).InitializeConditions()
+}
+
+// IsReady returns true if the sink is ready to accept events.
+func (prss *PipelineRunSinkStatus) IsReady() bool {
+	return prsCondSet.Manage(prss).IsHappy()
+}
+
+// MarkAddress sets the address of the sink, and marks it ready.
+func (prss *PipelineRunSinkStatus) MarkAddress(url *apis.URL) {
+	prss.Address = &duckv1.Addressable{URL: url}
+	prsCondSet.Manage(prss).MarkTrue(PipelineRunSinkConditionReady)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	tknv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/kmeta"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PipelineRunSink is an addressable sink that creates a PipelineRun for
+// each CloudEvent it accepts, with parameters extracted from the event.
+// Events are deduplicated by their (source, id) for a day, which is
+// recorded in a ConfigMap owned by the sink.
+type PipelineRunSink struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the desired state of the PipelineRunSink (from the client).
+	// +optional
+	Spec PipelineRunSinkSpec `json:"spec,omitempty"`
+
+	// Status communicates the observed state of the PipelineRunSink (from the controller).
+	// +optional
+	Status PipelineRunSinkStatus `json:"status,omitempty"`
+}
+
+var (
+	// Check that PipelineRunSink can be validated and defaulted.
+	_ apis.Validatable   = (*PipelineRunSink)(nil)
+	_ apis.Defaultable   = (*PipelineRunSink)(nil)
+	_ kmeta.OwnerRefable = (*PipelineRunSink)(nil)
+	_ apis.Listable      = (*PipelineRunSink)(nil)
+)
+
+// PipelineRunSinkSpec holds the desired state of the PipelineRunSink (from the client).
+type PipelineRunSinkSpec struct {
+	// Params describes how the parameters of each PipelineRun are
+	// extracted from the CloudEvent that triggered it.
+	// +optional
+	Params []PipelineRunSinkParam `json:"params,omitempty"`
+
+	// Template is the template for the PipelineRuns this sink creates.
+	Template PipelineRunTemplate `json:"template"`
+
+	// Concurrency is the maximum number of PipelineRuns created by this
+	// sink that may execute at once.  Events received while at the limit
+	// are rejected, so that the sender may retry them later.  Zero means
+	// that there is no limit.
+	// +optional
+	Concurrency int32 `json:"concurrency,omitempty"`
+}
+
+// PipelineRunSinkParam describes how a parameter is extracted from a
+// CloudEvent.  Exactly one of Attribute or Path must be specified.
+type PipelineRunSinkParam struct {
+	// Name is the name of the PipelineRun parameter.
+	Name string `json:"name"`
+
+	// Attribute is the name of the CloudEvent attribute (including
+	// extensions) holding the parameter's value, e.g. subject.
+	// +optional
+	Attribute string `json:"attribute,omitempty"`
+
+	// Path is a dot-separated path into the CloudEvent's JSON data
+	// holding the parameter's value, e.g. head_commit.id.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Default is the value to use when the event does not carry the
+	// parameter.  When omitted, such events are rejected.
+	// +optional
+	Default *string `json:"default,omitempty"`
+}
+
+// PipelineRunTemplate is the template from which we create PipelineRuns.
+type PipelineRunTemplate struct {
+	// Metadata holds the labels and annotations for the PipelineRuns.
+	// +optional
+	Metadata PipelineRunTemplateMeta `json:"metadata,omitempty"`
+
+	// Spec is the spec of the PipelineRuns, to which the extracted
+	// parameters are added.
+	Spec tknv1alpha1.PipelineRunSpec `json:"spec"`
+}
+
+// PipelineRunTemplateMeta holds the subset of ObjectMeta we allow
+// PipelineRunTemplates to specify.
+type PipelineRunTemplateMeta struct {
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// PipelineRunSinkStatus communicates the observed state of the PipelineRunSink (from the controller).
+type PipelineRunSinkStatus struct {
+	duckv1.Status `json:",inline"`
+
+	// PipelineRunSink is Addressable.
+	duckv1.AddressStatus `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PipelineRunSinkList is a list of PipelineRunSink resources
+type PipelineRunSinkList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []PipelineRunSink `json:"items"`
+}
+
+// GetListType implements apis.Listable
+func (*PipelineRunSink) GetListType() runtime.Object {
+	return &PipelineRunSinkList{}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/apis"
+)
+
+// Validate implements apis.Validatable
+func (prs *PipelineRunSink) Validate(ctx context.Context) *apis.FieldError {
+	return prs.Spec.Validate(ctx).ViaField("spec")
+}
+
+// Validate implements apis.Validatable
+func (prss *PipelineRunSinkSpec) Validate(ctx context.Context) (errs *apis.FieldError) {
+	names := sets.NewString()
+	for i, p := range prss.Params {
+		if p.Name == "" {
+			errs = errs.Also(apis.ErrMissingField("name").ViaFieldIndex("params", i))
+		} else if names.Has(p.Name) {
+			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("duplicate parameter %q", p.Name), "name").ViaFieldIndex("params", i))
+		}
+		names.Insert(p.Name)
+
+		switch {
+		case p.Attribute == "" && p.Path == "":
+			errs = errs.Also(apis.ErrMissingOneOf("attribute", "path").ViaFieldIndex("params", i))
+		case p.Attribute != "" && p.Path != "":
+			errs = errs.Also(apis.ErrMultipleOneOf("attribute", "path").ViaFieldIndex("params", i))
+		}
+	}
+
+	if prss.Concurrency < 0 {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(prss.Concurrency, 0, "*", "concurrency"))
+	}
+
+	return errs.Also(prss.Template.Spec.Validate(ctx).ViaField("template", "spec"))
+}
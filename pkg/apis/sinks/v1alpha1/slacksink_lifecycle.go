@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+const (
+	// SlackSinkConditionReady is set when the sink is ready to accept events.
+	SlackSinkConditionReady = apis.ConditionReady
+
+	// SlackSinkConditionSecretReady is set when the secret holding the
+	// credentials is usable.
+	SlackSinkConditionSecretReady apis.ConditionType = "SecretReady"
+
+	// SlackSinkConditionAddressable is set when the sink has an address.
+	SlackSinkConditionAddressable apis.ConditionType = "Addressable"
+)
+
+var slacksCondSet = apis.NewLivingConditionSet(
+	SlackSinkConditionSecretReady,
+	SlackSinkConditionAddressable,
+)
+
+// GetGroupVersionKind implements kmeta.OwnerRefable
+func (*SlackSink) GetGroupVersionKind() schema.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("SlackSink")
+}
+
+// GetConditionSet retrieves the condition set for this resource.
+func (*SlackSink) GetConditionSet() apis.ConditionSet {
+	return slacksCondSet
+}
+
+// InitializeConditions sets the initial values to the conditions.
+func (sss *SlackSinkStatus) InitializeConditions() {
+	slacksCondSet.Manage(sss).InitializeConditions()
+}
+
+// IsReady returns true if the sink is ready to accept events.
+func (sss *SlackSinkStatus) IsReady() bool {
+	return slacksCondSet.Manage(sss).IsHappy()
+}
+
+// MarkAddress sets the address of the sink.
+func (sss *SlackSinkStatus) MarkAddress(url *apis.URL) {
+	sss.Address = &duckv1.Addressable{URL: url}
+	slacksCondSet.Manage(sss).MarkTrue(SlackSinkConditionAddressable)
+}
+
+// MarkSecretReady marks the sink's secret usable.
+func (sss *SlackSinkStatus) MarkSecretReady() {
+	slacksCondSet.Manage(sss).MarkTrue(SlackSinkConditionSecretReady)
+}
+
+// MarkSecretNotReady marks the sink's secret unusable.
+func (sss *SlackSinkStatus) MarkSecretNotReady(reason, messageFormat string, messageA ...interface{}) {
+	slacksCondSet.Manage(sss).MarkFalse(SlackSinkConditionSecretReady, reason, messageFormat, messageA...)
+}
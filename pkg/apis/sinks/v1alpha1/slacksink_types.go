@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/kmeta"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SlackSink is an addressable sink that posts a message to a Slack channel for
+// each CloudEvent it accepts.
+type SlackSink struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the desired state of the SlackSink (from the client).
+	// +optional
+	Spec SlackSinkSpec `json:"spec,omitempty"`
+
+	// Status communicates the observed state of the SlackSink (from the controller).
+	// +optional
+	Status SlackSinkStatus `json:"status,omitempty"`
+}
+
+var (
+	// Check that SlackSink can be validated and defaulted.
+	_ apis.Validatable   = (*SlackSink)(nil)
+	_ apis.Defaultable   = (*SlackSink)(nil)
+	_ kmeta.OwnerRefable = (*SlackSink)(nil)
+	_ apis.Listable      = (*SlackSink)(nil)
+)
+
+// SlackSinkSpec holds the desired state of the SlackSink (from the client).
+type SlackSinkSpec struct {
+	// Secret holds a reference to a secret containing the Slack auth
+	// data, with the same keys as for SlackBinding.
+	Secret corev1.LocalObjectReference `json:"secret"`
+
+	// Channel is the ID or name of the channel the messages are posted to.
+	Channel string `json:"channel"`
+
+	// Template is the Go text/template rendering each CloudEvent into
+	// the text of a message.  It is executed against the event's attributes
+	// (e.g. {{ .Subject }}) and its data, which is decoded when it holds
+	// JSON (e.g. {{ .Data.sender.login }}).  By default it names the
+	// event's type and source.
+	// +optional
+	Template string `json:"template,omitempty"`
+}
+
+// SlackSinkStatus communicates the observed state of the SlackSink (from the controller).
+type SlackSinkStatus struct {
+	duckv1.Status `json:",inline"`
+
+	// SlackSink is Addressable.
+	duckv1.AddressStatus `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SlackSinkList is a list of SlackSink resources
+type SlackSinkList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SlackSink `json:"items"`
+}
+
+// GetListType implements apis.Listable
+func (*SlackSink) GetListType() runtime.Object {
+	return &SlackSinkList{}
+}
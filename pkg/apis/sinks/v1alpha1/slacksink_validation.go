@@ -0,0 +1,48 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"text/template"
+
+	"knative.dev/pkg/apis"
+)
+
+// Validate implements apis.Validatable
+func (ss *SlackSink) Validate(ctx context.Context) *apis.FieldError {
+	return ss.Spec.Validate(ctx).ViaField("spec")
+}
+
+// Validate implements apis.Validatable
+func (sss *SlackSinkSpec) Validate(ctx context.Context) (errs *apis.FieldError) {
+	if sss.Secret.Name == "" {
+		errs = errs.Also(apis.ErrMissingField("secret.name"))
+	}
+	if sss.Channel == "" {
+		errs = errs.Also(apis.ErrMissingField("channel"))
+	}
+	return errs.Also(validateTemplate(sss.Template))
+}
+
+// validateTemplate checks that the template of a message parses.
+func validateTemplate(tmpl string) *apis.FieldError {
+	if _, err := template.New("template").Parse(tmpl); err != nil {
+		return apis.ErrInvalidValue(err.Error(), "template")
+	}
+	return nil
+}
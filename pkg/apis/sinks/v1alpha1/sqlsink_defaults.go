@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultSQLSinkBatchSize is the default maximum number of events
+	// written by a statement.
+	DefaultSQLSinkBatchSize = 100
+
+	// DefaultSQLSinkBatchDelay is how long an event waits for others to
+	// join its batch by default.
+	DefaultSQLSinkBatchDelay = 100 * time.Millisecond
+)
+
+// SetDefaults implements apis.Defaultable
+func (s *SQLSink) SetDefaults(ctx context.Context) {
+	s.Spec.SetDefaults(ctx)
+}
+
+// SetDefaults implements apis.Defaultable
+func (ss *SQLSinkSpec) SetDefaults(ctx context.Context) {
+	if ss.Dialect == "" {
+		ss.Dialect = SQLDialectPostgres
+	}
+	if ss.Mode == "" {
+		ss.Mode = SQLSinkModeInsert
+	}
+	if len(ss.Columns) == 0 {
+		for _, attr := range []string{"id", "source", "type", "time", "data"} {
+			ss.Columns = append(ss.Columns, SQLSinkColumn{Name: attr, Attribute: attr})
+		}
+	}
+	if len(ss.Key) == 0 {
+		for _, c := range ss.Columns {
+			if c.Attribute == "id" {
+				ss.Key = append(ss.Key, c.Name)
+			}
+		}
+	}
+	if ss.Batch.Size == 0 {
+		ss.Batch.Size = DefaultSQLSinkBatchSize
+	}
+	if ss.Batch.Delay == nil {
+		ss.Batch.Delay = &metav1.Duration{Duration: DefaultSQLSinkBatchDelay}
+	}
+}
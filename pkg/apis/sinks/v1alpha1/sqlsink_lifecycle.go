@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+const (
+	// SQLSinkConditionReady is set when the sink is ready to accept events.
+	SQLSinkConditionReady = apis.ConditionReady
+
+	// SQLSinkConditionSecretReady is set when the secret holding the
+	// database's connection string is usable.
+	SQLSinkConditionSecretReady apis.ConditionType = "SecretReady"
+
+	// SQLSinkConditionAddressable is set when the sink has an address.
+	SQLSinkConditionAddressable apis.ConditionType = "Addressable"
+)
+
+var sqlsCondSet = apis.NewLivingConditionSet(
+	SQLSinkConditionSecretReady,
+	SQLSinkConditionAddressable,
+)
+
+// GetGroupVersionKind implements kmeta.OwnerRefable
+func (*SQLSink) GetGroupVersionKind() schema.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("SQLSink")
+}
+
+// GetConditionSet retrieves the condition set for this resource.
+func (*SQLSink) GetConditionSet() apis.ConditionSet {
+	return sqlsCondSet
+}
+
+// InitializeConditions sets the initial values to the conditions.
+func (sss *SQLSinkStatus) InitializeConditions() {
+	sqlsCondSet.Manage(sss).InitializeConditions()
+}
+
+// IsReady returns true if the sink is ready to accept events.
+func (sss *SQLSinkStatus) IsReady() bool {
+	return sqlsCondSet.Manage(sss).IsHappy()
+}
+
+// MarkAddress sets the address of the sink.
+func (sss *SQLSinkStatus) MarkAddress(url *apis.URL) {
+	sss.Address = &duckv1.Addressable{URL: url}
+	sqlsCondSet.Manage(sss).MarkTrue(SQLSinkConditionAddressable)
+}
+
+// MarkSecretReady marks the sink's secret usable.
+func (sss *SQLSinkStatus) MarkSecretReady() {
+	sqlsCondSet.Manage(sss).MarkTrue(SQLSinkConditionSecretReady)
+}
+
+// MarkSecretNotReady marks the sink's secret unusable.
+func (sss *SQLSinkStatus) MarkSecretNotReady(reason, messageFormat string, messageA ...interface{}) {
+	sqlsCondSet.Manage(sss).MarkFalse(SQLSinkConditionSecretReady, reason, messageFormat, messageA...)
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/kmeta"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SQLSink is an addressable sink that writes a row into a SQL table for
+// each CloudEvent it accepts, with columns extracted from the event.
+type SQLSink struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the desired state of the SQLSink (from the client).
+	// +optional
+	Spec SQLSinkSpec `json:"spec,omitempty"`
+
+	// Status communicates the observed state of the SQLSink (from the controller).
+	// +optional
+	Status SQLSinkStatus `json:"status,omitempty"`
+}
+
+var (
+	// Check that SQLSink can be validated and defaulted.
+	_ apis.Validatable   = (*SQLSink)(nil)
+	_ apis.Defaultable   = (*SQLSink)(nil)
+	_ kmeta.OwnerRefable = (*SQLSink)(nil)
+	_ apis.Listable      = (*SQLSink)(nil)
+)
+
+// SQLDialect is the flavor of SQL spoken by the database.
+type SQLDialect string
+
+const (
+	// SQLDialectPostgres is the dialect of PostgreSQL, the default.
+	SQLDialectPostgres SQLDialect = "postgres"
+
+	// SQLDialectMySQL is the dialect of MySQL.
+	SQLDialectMySQL SQLDialect = "mysql"
+)
+
+// SQLSinkMode is how the sink writes rows whose key already exists.
+type SQLSinkMode string
+
+const (
+	// SQLSinkModeInsert leaves existing rows alone, so that redelivered
+	// events are ignored.  It is the default.
+	SQLSinkModeInsert SQLSinkMode = "Insert"
+
+	// SQLSinkModeUpsert overwrites the columns of existing rows.
+	SQLSinkModeUpsert SQLSinkMode = "Upsert"
+)
+
+// SQLSinkSpec holds the desired state of the SQLSink (from the client).
+type SQLSinkSpec struct {
+	// Secret holds a reference to a secret containing the database's
+	// connection string under the key connectionstr, as for SQLBinding.
+	Secret corev1.LocalObjectReference `json:"secret"`
+
+	// Dialect is the flavor of SQL spoken by the database, which is
+	// postgres by default.
+	// +optional
+	Dialect SQLDialect `json:"dialect,omitempty"`
+
+	// Table is the (optionally schema qualified) table into which rows
+	// are written.
+	Table string `json:"table"`
+
+	// Columns describes how the values of each row are extracted from
+	// the CloudEvent.  By default the id, source, type, time and data of
+	// the event are written to columns of the same names.
+	// +optional
+	Columns []SQLSinkColumn `json:"columns,omitempty"`
+
+	// Key names the columns of a unique constraint of the table, on
+	// which rows conflict.  It defaults to the column holding the event's
+	// id, which makes redelivered events idempotent.
+	// +optional
+	Key []string `json:"key,omitempty"`
+
+	// Mode is how rows whose key already exists are written, either
+	// Insert (the default) or Upsert.
+	// +optional
+	Mode SQLSinkMode `json:"mode,omitempty"`
+
+	// Batch controls how events are grouped into a single statement.
+	// +optional
+	Batch SQLSinkBatch `json:"batch,omitempty"`
+}
+
+// SQLSinkColumn describes how a column's value is extracted from a
+// CloudEvent.  Exactly one of Attribute or Path must be specified.
+type SQLSinkColumn struct {
+	// Name is the name of the column.
+	Name string `json:"name"`
+
+	// Attribute is the name of the CloudEvent attribute (including
+	// extensions) holding the column's value, e.g. subject.  The
+	// attribute data holds the event's payload verbatim.
+	// +optional
+	Attribute string `json:"attribute,omitempty"`
+
+	// Path is a dot-separated path into the CloudEvent's JSON data
+	// holding the column's value, e.g. head_commit.id.  Objects and
+	// arrays are written as JSON.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// SQLSinkBatch controls how events are grouped into a single statement.
+// Senders are only acknowledged once the statement commits.
+type SQLSinkBatch struct {
+	// Size is the maximum number of events written by a statement, which
+	// is 100 by default.
+	// +optional
+	Size int32 `json:"size,omitempty"`
+
+	// Delay is how long an event may wait for others to join its batch,
+	// which is 100ms by default.
+	// +optional
+	Delay *metav1.Duration `json:"delay,omitempty"`
+}
+
+// SQLSinkStatus communicates the observed state of the SQLSink (from the controller).
+type SQLSinkStatus struct {
+	duckv1.Status `json:",inline"`
+
+	// SQLSink is Addressable.
+	duckv1.AddressStatus `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SQLSinkList is a list of SQLSink resources
+type SQLSinkList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SQLSink `json:"items"`
+}
+
+// GetListType implements apis.Listable
+func (*SQLSink) GetListType() runtime.Object {
+	return &SQLSinkList{}
+}
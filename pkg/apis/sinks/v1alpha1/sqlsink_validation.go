@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/apis"
+)
+
+// identifier matches the (optionally schema qualified) unquoted table
+// names we write to.
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+// MaxSQLSinkBatchSize bounds the size of batches, so that their
+// statements stay within the databases' limits on parameters.
+const MaxSQLSinkBatchSize = 1000
+
+// Validate implements apis.Validatable
+func (s *SQLSink) Validate(ctx context.Context) *apis.FieldError {
+	return s.Spec.Validate(ctx).ViaField("spec")
+}
+
+// Validate implements apis.Validatable
+func (ss *SQLSinkSpec) Validate(ctx context.Context) (errs *apis.FieldError) {
+	if ss.Secret.Name == "" {
+		errs = errs.Also(apis.ErrMissingField("secret.name"))
+	}
+
+	switch ss.Dialect {
+	case SQLDialectPostgres, SQLDialectMySQL:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(ss.Dialect, "dialect"))
+	}
+
+	switch {
+	case ss.Table == "":
+		errs = errs.Also(apis.ErrMissingField("table"))
+	case !identifier.MatchString(ss.Table):
+		errs = errs.Also(apis.ErrInvalidValue(ss.Table, "table"))
+	}
+
+	columns := sets.NewString()
+	for i, c := range ss.Columns {
+		switch {
+		case c.Name == "":
+			errs = errs.Also(apis.ErrMissingField("name").ViaFieldIndex("columns", i))
+		case !identifier.MatchString(c.Name):
+			errs = errs.Also(apis.ErrInvalidValue(c.Name, "name").ViaFieldIndex("columns", i))
+		case columns.Has(c.Name):
+			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("duplicate column %q", c.Name), "name").ViaFieldIndex("columns", i))
+		}
+		columns.Insert(c.Name)
+
+		switch {
+		case c.Attribute == "" && c.Path == "":
+			errs = errs.Also(apis.ErrMissingOneOf("attribute", "path").ViaFieldIndex("columns", i))
+		case c.Attribute != "" && c.Path != "":
+			errs = errs.Also(apis.ErrMultipleOneOf("attribute", "path").ViaFieldIndex("columns", i))
+		}
+	}
+	if len(ss.Columns) == 0 {
+		errs = errs.Also(apis.ErrMissingField("columns"))
+	}
+
+	if len(ss.Key) == 0 {
+		errs = errs.Also(apis.ErrMissingField("key"))
+	}
+	for i, k := range ss.Key {
+		if !columns.Has(k) {
+			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("%q is not a column", k)).ViaFieldIndex("key", i))
+		}
+	}
+
+	switch ss.Mode {
+	case SQLSinkModeInsert, SQLSinkModeUpsert:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(ss.Mode, "mode"))
+	}
+
+	if ss.Batch.Size < 1 || ss.Batch.Size > MaxSQLSinkBatchSize {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(ss.Batch.Size, 1, MaxSQLSinkBatchSize, "batch.size"))
+	}
+	if ss.Batch.Delay != nil && ss.Batch.Delay.Duration < 0 {
+		errs = errs.Also(apis.ErrInvalidValue(ss.Batch.Delay.Duration.String(), "batch.delay"))
+	}
+	return errs
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+const (
+	// TwitterSinkConditionReady is set when the sink is ready to accept events.
+	TwitterSinkConditionReady = apis.ConditionReady
+
+	// TwitterSinkConditionSecretReady is set when the secret holding the
+	// credentials is usable.
+	TwitterSinkConditionSecretReady apis.ConditionType = "SecretReady"
+
+	// TwitterSinkConditionAddressable is set when the sink has an address.
+	TwitterSinkConditionAddressable apis.ConditionType = "Addressable"
+)
+
+var twitsCondSet = apis.NewLivingConditionSet(
+	TwitterSinkConditionSecretReady,
+	TwitterSinkConditionAddressable,
+)
+
+// GetGroupVersionKind implements kmeta.OwnerRefable
+func (*TwitterSink) GetGroupVersionKind() schema.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("TwitterSink")
+}
+
+// GetConditionSet retrieves the condition set for this resource.
+func (*TwitterSink) GetConditionSet() apis.ConditionSet {
+	return twitsCondSet
+}
+
+// InitializeConditions sets the initial values to the conditions.
+func (tws *TwitterSinkStatus) InitializeConditions() {
+	twitsCondSet.Manage(tws).InitializeConditions()
+}
+
+// IsReady returns true if the sink is ready to accept events.
+func (tws *TwitterSinkStatus) IsReady() bool {
+	return twitsCondSet.Manage(tws).IsHappy()
+}
+
+// MarkAddress sets the address of the sink.
+func (tws *TwitterSinkStatus) MarkAddress(url *apis.URL) {
+	tws.Address = &duckv1.Addressable{URL: url}
+	twitsCondSet.Manage(tws).MarkTrue(TwitterSinkConditionAddressable)
+}
+
+// MarkSecretReady marks the sink's secret usable.
+func (tws *TwitterSinkStatus) MarkSecretReady() {
+	twitsCondSet.Manage(tws).MarkTrue(TwitterSinkConditionSecretReady)
+}
+
+// MarkSecretNotReady marks the sink's secret unusable.
+func (tws *TwitterSinkStatus) MarkSecretNotReady(reason, messageFormat string, messageA ...interface{}) {
+	twitsCondSet.Manage(tws).MarkFalse(TwitterSinkConditionSecretReady, reason, messageFormat, messageA...)
+}
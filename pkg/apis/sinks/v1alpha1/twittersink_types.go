@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/kmeta"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TwitterSink is an addressable sink that tweets for each CloudEvent it accepts.
+type TwitterSink struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the desired state of the TwitterSink (from the client).
+	// +optional
+	Spec TwitterSinkSpec `json:"spec,omitempty"`
+
+	// Status communicates the observed state of the TwitterSink (from the controller).
+	// +optional
+	Status TwitterSinkStatus `json:"status,omitempty"`
+}
+
+var (
+	// Check that TwitterSink can be validated and defaulted.
+	_ apis.Validatable   = (*TwitterSink)(nil)
+	_ apis.Defaultable   = (*TwitterSink)(nil)
+	_ kmeta.OwnerRefable = (*TwitterSink)(nil)
+	_ apis.Listable      = (*TwitterSink)(nil)
+)
+
+// TwitterSinkSpec holds the desired state of the TwitterSink (from the client).
+type TwitterSinkSpec struct {
+	// Secret holds a reference to a secret containing the Twitter auth
+	// data, with the same keys as for TwitterBinding.
+	Secret corev1.LocalObjectReference `json:"secret"`
+
+	// Template is the Go text/template rendering each CloudEvent into
+	// the text of a tweet.  It is executed against the event's attributes
+	// (e.g. {{ .Subject }}) and its data, which is decoded when it holds
+	// JSON (e.g. {{ .Data.sender.login }}).  By default it names the
+	// event's type and source.
+	// +optional
+	Template string `json:"template,omitempty"`
+}
+
+// TwitterSinkStatus communicates the observed state of the TwitterSink (from the controller).
+type TwitterSinkStatus struct {
+	duckv1.Status `json:",inline"`
+
+	// TwitterSink is Addressable.
+	duckv1.AddressStatus `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TwitterSinkList is a list of TwitterSink resources
+type TwitterSinkList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []TwitterSink `json:"items"`
+}
+
+// GetListType implements apis.Listable
+func (*TwitterSink) GetListType() runtime.Object {
+	return &TwitterSinkList{}
+}
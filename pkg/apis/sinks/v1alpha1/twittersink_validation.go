@@ -0,0 +1,36 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	"knative.dev/pkg/apis"
+)
+
+// Validate implements apis.Validatable
+func (ts *TwitterSink) Validate(ctx context.Context) *apis.FieldError {
+	return ts.Spec.Validate(ctx).ViaField("spec")
+}
+
+// Validate implements apis.Validatable
+func (tss *TwitterSinkSpec) Validate(ctx context.Context) (errs *apis.FieldError) {
+	if tss.Secret.Name == "" {
+		errs = errs.Also(apis.ErrMissingField("secret.name"))
+	}
+	return errs.Also(validateTemplate(tss.Template))
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserversource
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"knative.dev/eventing/pkg/apis/sources/v1alpha2"
+	apiserversourceinformer "knative.dev/eventing/pkg/client/injection/informers/sources/v1alpha2/apiserversource"
+	listers "knative.dev/eventing/pkg/client/listers/sources/v1alpha2"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+)
+
+const (
+	controllerAgentName = "apiserver-source-adapter"
+
+	// The timings with which we lease the adapter.
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// Adapter implements controller.Reconciler, watching the resources of
+// every ApiServerSource while we hold the adapter's Lease.
+type Adapter struct {
+	// ctx bounds the lifetime of our sources.
+	ctx      context.Context
+	identity string
+	// cfg is what we impersonate each source's ServiceAccount with.
+	cfg *rest.Config
+
+	kubeclient kubernetes.Interface
+	ceclient   cloudevents.Client
+	lister     listers.ApiServerSourceLister
+
+	// resync queues every ApiServerSource when the Lease changes hands.
+	resync func()
+
+	m       sync.Mutex
+	leading bool
+	clients map[string]dynamic.Interface
+	watches map[watchKey]*sharedWatch
+	sources map[string]*source
+}
+
+// Check that our Adapter implements controller.Reconciler
+var _ controller.Reconciler = (*Adapter)(nil)
+
+// NewController creates the Adapter of this replica, identified by the
+// given name, and starts contending for its Lease.
+func NewController(ctx context.Context, identity string, cfg *rest.Config) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	sourceInformer := apiserversourceinformer.Get(ctx)
+
+	ceclient, err := cloudevents.NewDefaultClient()
+	if err != nil {
+		logger.Fatalw("Failed to create CloudEvents client", zap.Error(err))
+	}
+
+	a := &Adapter{
+		ctx:        ctx,
+		identity:   identity,
+		cfg:        cfg,
+		kubeclient: kubeclient.Get(ctx),
+		ceclient:   ceclient,
+		lister:     sourceInformer.Lister(),
+		clients:    make(map[string]dynamic.Interface),
+		watches:    make(map[watchKey]*sharedWatch),
+		sources:    make(map[string]*source),
+	}
+	impl := controller.NewImpl(a, logger, controllerAgentName)
+	a.resync = func() {
+		impl.GlobalResync(sourceInformer.Informer())
+	}
+
+	logger.Info("Setting up event handlers")
+	sourceInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	go a.lease(ctx)
+	return impl
+}
+
+// lease contends for the adapter's Lease until the context is cancelled,
+// watching the resources of every ApiServerSource while we hold it.
+func (a *Adapter) lease(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Namespace: system.Namespace(),
+			Name:      LeaseName,
+		},
+		Client:     a.kubeclient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: a.identity},
+	}
+	for ctx.Err() == nil {
+		le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   leaseDuration,
+			RenewDeadline:   renewDeadline,
+			RetryPeriod:     retryPeriod,
+			ReleaseOnCancel: true,
+			Name:            LeaseName,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(context.Context) {
+					logger.Info("Leading the ApiServerSource adapter")
+					a.setLeading(true)
+				},
+				OnStoppedLeading: func() {
+					logger.Info("Stopped leading the ApiServerSource adapter")
+					a.setLeading(false)
+				},
+			},
+		})
+		if err != nil {
+			logger.Fatalw("Failed to create leader elector", zap.Error(err))
+		}
+		// Run returns when we lose the Lease, after which we contend again.
+		le.Run(ctx)
+	}
+}
+
+// setLeading records whether we hold the Lease, and queues every
+// ApiServerSource to start or stop its watches.
+func (a *Adapter) setLeading(leading bool) {
+	a.m.Lock()
+	a.leading = leading
+	a.m.Unlock()
+	a.resync()
+}
+
+// Reconcile implements controller.Reconciler
+func (a *Adapter) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	src, err := a.lister.ApiServerSources(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		a.stop(key)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	a.m.Lock()
+	leading := a.leading
+	a.m.Unlock()
+	// The controlplane only marks a source with sufficient permissions
+	// once its ServiceAccount may watch all of its resources.
+	if !leading || src.DeletionTimestamp != nil || src.Status.SinkURI == nil ||
+		!src.Status.GetCondition(v1alpha2.ApiServerConditionSufficientPermissions).IsTrue() {
+		a.stop(key)
+		return nil
+	}
+
+	keys, err := watchKeys(src)
+	if err != nil {
+		a.stop(key)
+		return err
+	}
+	want := settings{
+		Spec: src.Spec,
+		Sink: src.Status.SinkURI.String(),
+	}
+
+	a.m.Lock()
+	s, ok := a.sources[key]
+	a.m.Unlock()
+	if ok && equality.Semantic.DeepEqual(s.settings, want) {
+		return nil
+	}
+	a.stop(key)
+
+	client, err := a.clientFor(UserName(src))
+	if err != nil {
+		return err
+	}
+	sctx, cancel := context.WithCancel(a.ctx)
+	s = &source{
+		key:       key,
+		settings:  want,
+		keys:      keys,
+		host:      a.cfg.Host,
+		logger:    logger.With(zap.String("source", key)),
+		ceclient:  a.ceclient,
+		reporter:  newReporter(namespace, name),
+		queue:     make(chan queued, queueSize),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		connected: make(map[watchKey]bool, len(keys)),
+	}
+	go s.run(sctx)
+
+	logger.Infof("Starting %d watches of %s", len(keys), key)
+	a.m.Lock()
+	defer a.m.Unlock()
+	a.sources[key] = s
+	for _, k := range keys {
+		w, ok := a.watches[k]
+		if !ok {
+			logger.Infof("Starting watch of %s", k)
+			w = newSharedWatch(k, client)
+			a.watches[k] = w
+		}
+		w.subscribe(s)
+	}
+	return nil
+}
+
+// clientFor returns a client impersonating the given user.
+func (a *Adapter) clientFor(user string) (dynamic.Interface, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	if client, ok := a.clients[user]; ok {
+		return client, nil
+	}
+	cfg := rest.CopyConfig(a.cfg)
+	cfg.Impersonate = rest.ImpersonationConfig{UserName: user}
+	client, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	a.clients[user] = client
+	return client, nil
+}
+
+// stop stops the delivery of the given ApiServerSource, if any, along with
+// any watches that no other source shares.
+func (a *Adapter) stop(key string) {
+	a.m.Lock()
+	s, ok := a.sources[key]
+	if ok {
+		delete(a.sources, key)
+		for _, k := range s.keys {
+			if w, ok := a.watches[k]; ok && w.unsubscribe(s) == 0 {
+				close(w.stop)
+				delete(a.watches, k)
+			}
+		}
+	}
+	a.m.Unlock()
+	if ok {
+		s.cancel()
+		<-s.done
+		s.reporter.reportConnected(false)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apiserversource watches the resources of every ApiServerSource in
+// the cluster from a shared adapter.  Sources that watch the same resources
+// in the same namespace as the same ServiceAccount share a single watch,
+// which the adapter opens by impersonating that ServiceAccount.
+package apiserversource
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/eventing/pkg/apis/sources/v1alpha2"
+)
+
+// LeaseName is the name of the Lease held by the adapter replica watching
+// the resources of every ApiServerSource.
+const LeaseName = "apiserversource-adapter"
+
+// UserName returns the user as which the resources of the ApiServerSource
+// are watched.
+func UserName(src *v1alpha2.ApiServerSource) string {
+	sa := src.Spec.ServiceAccountName
+	if sa == "" {
+		sa = "default"
+	}
+	return fmt.Sprintf("system:serviceaccount:%s:%s", src.Namespace, sa)
+}
+
+// watchKey identifies a watch, which sources with the same key share.
+type watchKey struct {
+	User      string
+	GVR       schema.GroupVersionResource
+	Namespace string
+	Selector  string
+}
+
+func (k watchKey) String() string {
+	return fmt.Sprintf("%s in %s (selector %q) as %s", k.GVR, k.Namespace, k.Selector, k.User)
+}
+
+// watchKeys returns the distinct watches of the ApiServerSource.
+func watchKeys(src *v1alpha2.ApiServerSource) ([]watchKey, error) {
+	keys := make([]watchKey, 0, len(src.Spec.Resources))
+	seen := make(map[watchKey]bool, len(src.Spec.Resources))
+	for _, res := range src.Spec.Resources {
+		gv, err := schema.ParseGroupVersion(res.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+		gvr, _ := meta.UnsafeGuessKindToResource(gv.WithKind(res.Kind))
+		key := watchKey{
+			User:      UserName(src),
+			GVR:       gvr,
+			Namespace: src.Namespace,
+		}
+		if res.LabelSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(res.LabelSelector)
+			if err != nil {
+				return nil, err
+			}
+			key.Selector = selector.String()
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
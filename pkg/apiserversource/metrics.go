@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserversource
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	pkgmetrics "knative.dev/pkg/metrics"
+)
+
+var (
+	connectedM = stats.Int64(
+		"apiserversource_connected",
+		"Whether every watch of the ApiServerSource is connected to the API server",
+		stats.UnitDimensionless)
+	lagM = stats.Float64(
+		"apiserversource_delivery_lag",
+		"Time from observing a change to the sink accepting its event",
+		stats.UnitMilliseconds)
+	droppedM = stats.Int64(
+		"apiserversource_events_dropped",
+		"Number of events the ApiServerSource failed to deliver",
+		stats.UnitDimensionless)
+
+	namespaceKey = tag.MustNewKey("namespace_name")
+	nameKey      = tag.MustNewKey("name")
+	reasonKey    = tag.MustNewKey("reason")
+)
+
+const (
+	// The reasons for which we drop events.
+	reasonQueueFull  = "QueueFull"
+	reasonSendFailed = "SendFailed"
+)
+
+func init() {
+	if err := view.Register(
+		&view.View{
+			Description: connectedM.Description(),
+			Measure:     connectedM,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceKey, nameKey},
+		},
+		&view.View{
+			Description: lagM.Description(),
+			Measure:     lagM,
+			Aggregation: view.Distribution(10, 50, 100, 500, 1000, 5000, 10000, 30000, 60000),
+			TagKeys:     []tag.Key{namespaceKey, nameKey},
+		},
+		&view.View{
+			Description: droppedM.Description(),
+			Measure:     droppedM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceKey, nameKey, reasonKey},
+		},
+	); err != nil {
+		panic(err)
+	}
+}
+
+// reporter records the metrics of a single ApiServerSource.
+type reporter struct {
+	ctx context.Context
+}
+
+func newReporter(namespace, name string) *reporter {
+	// Kubernetes names are always valid tag values.
+	ctx, _ := tag.New(context.Background(),
+		tag.Upsert(namespaceKey, namespace),
+		tag.Upsert(nameKey, name))
+	return &reporter{ctx: ctx}
+}
+
+func (r *reporter) reportConnected(connected bool) {
+	var v int64
+	if connected {
+		v = 1
+	}
+	pkgmetrics.Record(r.ctx, connectedM.M(v))
+}
+
+func (r *reporter) reportLag(lag time.Duration) {
+	pkgmetrics.Record(r.ctx, lagM.M(float64(lag)/float64(time.Millisecond)))
+}
+
+func (r *reporter) reportDropped(reason string) {
+	ctx, err := tag.New(r.ctx, tag.Upsert(reasonKey, reason))
+	if err != nil {
+		return
+	}
+	pkgmetrics.Record(ctx, droppedM.M(1))
+}
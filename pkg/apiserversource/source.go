@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserversource
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"knative.dev/eventing/pkg/apis/sources/v1alpha2"
+)
+
+const (
+	// queueSize bounds the events of a source awaiting delivery, beyond
+	// which we drop them rather than hold up the sources sharing a watch.
+	queueSize = 1000
+
+	// The bounds of the backoff with which we retry delivery to a sink.
+	minBackoff  = 100 * time.Millisecond
+	maxAttempts = 5
+)
+
+// source delivers the changes observed by its watches to the sink of a
+// single ApiServerSource.
+type source struct {
+	key string
+	// settings is what the source was started with, restarting it when
+	// they change.
+	settings settings
+	keys     []watchKey
+	// host is the CloudEvents source of our events.
+	host     string
+	logger   *zap.SugaredLogger
+	ceclient cloudevents.Client
+	reporter *reporter
+
+	queue  chan queued
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	m         sync.Mutex
+	connected map[watchKey]bool
+}
+
+type settings struct {
+	Spec v1alpha2.ApiServerSourceSpec
+	Sink string
+}
+
+// queued is an event awaiting delivery.
+type queued struct {
+	event    cloudevents.Event
+	observed time.Time
+}
+
+// setConnected records whether the watch of the given key is connected,
+// reporting whether all of our watches are.
+func (s *source) setConnected(key watchKey, connected bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.connected[key] = connected
+	all := len(s.connected) == len(s.keys)
+	for _, c := range s.connected {
+		all = all && c
+	}
+	s.reporter.reportConnected(all)
+}
+
+// offer queues the event of a change to the given object, unless the
+// source filters it out.
+func (s *source) offer(makeEvent eventMaker, obj interface{}) {
+	if owner := s.settings.Spec.ResourceOwner; owner != nil && !controlledBy(obj, owner) {
+		return
+	}
+	event, err := makeEvent(s.host, obj, s.settings.Spec.EventMode == v1alpha2.ReferenceMode)
+	if err != nil {
+		s.logger.Warnw("Failed to make event", zap.Error(err))
+		return
+	}
+	if ceo := s.settings.Spec.CloudEventOverrides; ceo != nil {
+		for k, v := range ceo.Extensions {
+			event.SetExtension(k, v)
+		}
+	}
+	select {
+	case s.queue <- queued{event: event, observed: time.Now()}:
+	default:
+		s.reporter.reportDropped(reasonQueueFull)
+	}
+}
+
+// run delivers queued events until the context is cancelled.
+func (s *source) run(ctx context.Context) {
+	defer close(s.done)
+	ctx = cloudevents.ContextWithTarget(ctx, s.settings.Sink)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case q := <-s.queue:
+			s.send(ctx, q)
+		}
+	}
+}
+
+// send delivers the event to the sink, backing off for a bounded number of
+// attempts before dropping it, so that a failing sink doesn't stall the
+// events that follow.
+func (s *source) send(ctx context.Context, q queued) {
+	backoff := minBackoff
+	for attempt := 1; ; attempt++ {
+		result := s.ceclient.Send(ctx, q.event)
+		if cloudevents.IsACK(result) {
+			s.reporter.reportLag(time.Since(q.observed))
+			return
+		}
+		if attempt == maxAttempts {
+			s.logger.Errorw("Dropping event", zap.String("id", q.event.ID()), zap.Error(result))
+			s.reporter.reportDropped(reasonSendFailed)
+			return
+		}
+		s.logger.Warnw("Failed to deliver event", zap.String("id", q.event.ID()), zap.Error(result))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// controlledBy returns whether the object's controller has the apiVersion
+// and kind of the given owner, either of which may be left empty.
+func controlledBy(obj interface{}, owner *v1alpha2.APIVersionKind) bool {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return false
+	}
+	controller := metav1.GetControllerOf(u)
+	return controller != nil &&
+		(owner.APIVersion == "" || owner.APIVersion == controller.APIVersion) &&
+		(owner.Kind == "" || owner.Kind == controller.Kind)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserversource
+
+import (
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/eventing/pkg/adapter/apiserver/events"
+)
+
+// sharedWatch is a watch shared by the sources with the same watchKey, to
+// each of which it dispatches the changes that it observes.
+type sharedWatch struct {
+	key  watchKey
+	stop chan struct{}
+
+	m           sync.Mutex
+	connected   bool
+	subscribers map[string]*source
+}
+
+// sharedWatch implements cache.Store, so that it may be fed by a Reflector
+// without keeping a copy of the resources it watches.
+var _ cache.Store = (*sharedWatch)(nil)
+
+// newSharedWatch starts a watch of the given key with the client, which
+// impersonates the key's user.
+func newSharedWatch(key watchKey, client dynamic.Interface) *sharedWatch {
+	w := &sharedWatch{
+		key:         key,
+		stop:        make(chan struct{}),
+		subscribers: make(map[string]*source),
+	}
+	resource := client.Resource(key.GVR).Namespace(key.Namespace)
+	lw := &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			opts.LabelSelector = key.Selector
+			l, err := resource.List(opts)
+			w.setConnected(err == nil)
+			if err != nil {
+				return nil, err
+			}
+			return l, nil
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			opts.LabelSelector = key.Selector
+			wi, err := resource.Watch(opts)
+			w.setConnected(err == nil)
+			return wi, err
+		},
+	}
+	// Like the upstream adapter, we never resync, as we have nothing to
+	// resync with.
+	go cache.NewReflector(lw, &unstructured.Unstructured{}, w, 0).Run(w.stop)
+	return w
+}
+
+// subscribe starts dispatching changes to the source.
+func (w *sharedWatch) subscribe(s *source) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.subscribers[s.key] = s
+	s.setConnected(w.key, w.connected)
+}
+
+// unsubscribe stops dispatching changes to the source, returning how many
+// sources remain subscribed.
+func (w *sharedWatch) unsubscribe(s *source) int {
+	w.m.Lock()
+	defer w.m.Unlock()
+	delete(w.subscribers, s.key)
+	return len(w.subscribers)
+}
+
+// setConnected records whether we are connected to the API server, which
+// fails when the user lacks permission to watch our resources.
+func (w *sharedWatch) setConnected(connected bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.connected == connected {
+		return
+	}
+	w.connected = connected
+	for _, s := range w.subscribers {
+		s.setConnected(w.key, connected)
+	}
+}
+
+func (w *sharedWatch) dispatch(makeEvent eventMaker, obj interface{}) {
+	w.m.Lock()
+	subscribers := make([]*source, 0, len(w.subscribers))
+	for _, s := range w.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	w.m.Unlock()
+	for _, s := range subscribers {
+		s.offer(makeEvent, obj)
+	}
+}
+
+// Add implements cache.Store
+func (w *sharedWatch) Add(obj interface{}) error {
+	w.dispatch(events.MakeAddEvent, obj)
+	return nil
+}
+
+// Update implements cache.Store
+func (w *sharedWatch) Update(obj interface{}) error {
+	w.dispatch(events.MakeUpdateEvent, obj)
+	return nil
+}
+
+// Delete implements cache.Store
+func (w *sharedWatch) Delete(obj interface{}) error {
+	w.dispatch(events.MakeDeleteEvent, obj)
+	return nil
+}
+
+// List implements cache.Store
+func (w *sharedWatch) List() []interface{} {
+	return nil
+}
+
+// ListKeys implements cache.Store
+func (w *sharedWatch) ListKeys() []string {
+	return nil
+}
+
+// Get implements cache.Store
+func (w *sharedWatch) Get(obj interface{}) (item interface{}, exists bool, err error) {
+	return nil, false, nil
+}
+
+// GetByKey implements cache.Store
+func (w *sharedWatch) GetByKey(key string) (item interface{}, exists bool, err error) {
+	return nil, false, nil
+}
+
+// Replace implements cache.Store.  Like the upstream adapter, we don't send
+// events for the resources that exist when we (re)connect.
+func (w *sharedWatch) Replace([]interface{}, string) error {
+	return nil
+}
+
+// Resync implements cache.Store
+func (w *sharedWatch) Resync() error {
+	return nil
+}
+
+// eventMaker makes the event of a change, as the functions of the
+// upstream adapter's events package do.
+type eventMaker func(source string, obj interface{}, ref bool) (cloudevents.Event, error)
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package archive persists build output (e.g. TaskRun step logs) to the
+// artifact storage configured via config-artifact-bucket, falling back on
+// a volume (provisioned per config-artifact-pvc) behind the archive server.
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tektoncd/pipeline/pkg/artifacts"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/system"
+)
+
+const (
+	// DefaultPath is where the archive volume is mounted in the archive
+	// server.
+	DefaultPath = "/var/lib/mink/archive"
+
+	// VolumeName is the name of the PersistentVolumeClaim backing the
+	// archive server.
+	VolumeName = "archive"
+)
+
+// ErrNotConfigured is returned when no storage is available for archival.
+var ErrNotConfigured = errors.New("no archive storage is configured")
+
+// Store is the interface for persisting archived objects.
+type Store interface {
+	// Write persists the contents of body under the provided key.
+	Write(ctx context.Context, key string, body io.Reader) error
+
+	// URL returns the stable URL at which the object with the provided
+	// key may be retrieved.
+	URL(key string) string
+
+	// Prune removes all of the objects written before the provided time.
+	Prune(ctx context.Context, before time.Time) error
+
+	// Close releases the resources held by the Store.
+	Close() error
+}
+
+// NewStoreFromConfigMap creates a Store from the supplied
+// config-artifact-bucket ConfigMap.  When the ConfigMap does not specify
+// a bucket location, archives are written through the archive server at
+// ARCHIVE_URL (if set), whose volume is provisioned per config-artifact-pvc.
+func NewStoreFromConfigMap(ctx context.Context, kc kubernetes.Interface, cm *corev1.ConfigMap) (Store, error) {
+	if location, ok := cm.Data[artifacts.BucketLocationKey]; ok && location != "" {
+		var creds []byte
+		if name := cm.Data[artifacts.BucketServiceAccountSecretName]; name != "" {
+			secret, err := kc.CoreV1().Secrets(system.Namespace()).Get(name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch bucket credentials: %w", err)
+			}
+			key := cm.Data[artifacts.BucketServiceAccountSecretKey]
+			if creds = secret.Data[key]; len(creds) == 0 {
+				return nil, fmt.Errorf("secret %q has no key %q", name, key)
+			}
+		}
+		return newBucketStore(ctx, location, creds)
+	}
+
+	base := os.Getenv("ARCHIVE_URL")
+	if base == "" {
+		return nil, ErrNotConfigured
+	}
+	if err := ensureVolume(kc); err != nil {
+		return nil, err
+	}
+	return newServerStore(base)
+}
+
+// ensureVolume creates the archive server's PersistentVolumeClaim, sized
+// and classed the way Tekton's config-artifact-pvc sizes and classes those
+// of PipelineRuns, unless it already exists.
+func ensureVolume(kc kubernetes.Interface) error {
+	pvcs := kc.CoreV1().PersistentVolumeClaims(system.Namespace())
+	if _, err := pvcs.Get(VolumeName, metav1.GetOptions{}); err == nil {
+		return nil
+	} else if !apierrs.IsNotFound(err) {
+		return err
+	}
+
+	var data map[string]string
+	cm, err := kc.CoreV1().ConfigMaps(system.Namespace()).Get(artifacts.GetPVCConfigName(), metav1.GetOptions{})
+	if err == nil {
+		data = cm.Data
+	} else if !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to fetch %s: %w", artifacts.GetPVCConfigName(), err)
+	}
+	size := data[artifacts.PVCSizeKey]
+	if size == "" {
+		size = artifacts.DefaultPVCSize
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", artifacts.PVCSizeKey, size, err)
+	}
+	var storageClassName *string
+	if name := data[artifacts.PVCStorageClassNameKey]; name != "" {
+		storageClassName = &name
+	}
+
+	_, err = pvcs.Create(&corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      VolumeName,
+			Namespace: system.Namespace(),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: quantity,
+				},
+			},
+			StorageClassName: storageClassName,
+		},
+	})
+	if apierrs.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// bucketStore archives objects into a GCS bucket.
+type bucketStore struct {
+	client *storage.Client
+	bucket string
+	prefix string
+}
+
+var _ Store = (*bucketStore)(nil)
+
+func newBucketStore(ctx context.Context, location string, creds []byte) (*bucketStore, error) {
+	if !strings.HasPrefix(location, "gs://") {
+		return nil, fmt.Errorf("unsupported bucket location %q, expected gs://", location)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(location, "gs://"), "/", 2)
+
+	var opts []option.ClientOption
+	if len(creds) > 0 {
+		opts = append(opts, option.WithCredentialsJSON(creds))
+	}
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	bs := &bucketStore{
+		client: client,
+		bucket: parts[0],
+	}
+	if len(parts) > 1 {
+		bs.prefix = strings.Trim(parts[1], "/")
+	}
+	return bs, nil
+}
+
+func (bs *bucketStore) object(key string) string {
+	return path.Join(bs.prefix, "archive", key)
+}
+
+// Write implements Store
+func (bs *bucketStore) Write(ctx context.Context, key string, body io.Reader) error {
+	w := bs.client.Bucket(bs.bucket).Object(bs.object(key)).NewWriter(ctx)
+	if _, err := io.Copy(w, body); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// URL implements Store
+func (bs *bucketStore) URL(key string) string {
+	return fmt.Sprintf("gs://%s/%s", bs.bucket, bs.object(key))
+}
+
+// Prune implements Store
+func (bs *bucketStore) Prune(ctx context.Context, before time.Time) error {
+	b := bs.client.Bucket(bs.bucket)
+	it := b.Objects(ctx, &storage.Query{Prefix: bs.object("") + "/"})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if attrs.Created.Before(before) {
+			if err := b.Object(attrs.Name).Delete(ctx); err != nil && err != storage.ErrObjectNotExist {
+				return err
+			}
+		}
+	}
+}
+
+// Close implements Store
+func (bs *bucketStore) Close() error {
+	return bs.client.Close()
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// dirStore archives objects onto the local (PVC-backed) volume of the
+// archive server.
+type dirStore struct {
+	root string
+}
+
+// Write persists the contents of body under the provided key.
+func (ds *dirStore) Write(ctx context.Context, key string, body io.Reader) error {
+	p := filepath.Join(ds.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Prune removes all of the objects written before the provided time.
+func (ds *dirStore) Prune(ctx context.Context, before time.Time) error {
+	return filepath.Walk(ds.root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !info.ModTime().Before(before) {
+			return nil
+		}
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		// Clean up any directories this leaves empty, stopping at
+		// the first one that still has content.
+		for dir := filepath.Dir(p); dir != ds.root; dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// Open opens the object with the provided key, which must not be a
+// directory.
+func (ds *dirStore) Open(key string) (*os.File, os.FileInfo, error) {
+	f, err := os.Open(filepath.Join(ds.root, filepath.FromSlash(key)))
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, nil, os.ErrNotExist
+	}
+	return f, info, nil
+}
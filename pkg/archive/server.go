@@ -0,0 +1,182 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"go.uber.org/zap"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Server serves the archive volume.  The controlplane writes and prunes
+// archives through it, and users read the archives of the TaskRuns they
+// may get.  Both authenticate with Kubernetes bearer tokens, and nothing
+// (in particular no directory) is listed.
+type Server struct {
+	store  *dirStore
+	client kubernetes.Interface
+	logger *zap.SugaredLogger
+}
+
+var _ http.Handler = (*Server)(nil)
+
+// NewServer creates a Server for the archive volume mounted at root.
+func NewServer(logger *zap.SugaredLogger, client kubernetes.Interface, root string) *Server {
+	return &Server{
+		store:  &dirStore{root: filepath.Clean(root)},
+		client: client,
+		logger: logger,
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, err := s.authenticate(r)
+	if err != nil {
+		s.logger.Errorw("Error authenticating request", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	} else if user == nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	// Archives are keyed by the namespace, name and UID of their TaskRun.
+	key := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	parts := strings.Split(key, "/")
+
+	var attrs *authorizationv1.ResourceAttributes
+	switch {
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && len(parts) == 4:
+		attrs = taskRunAttributes("get", "", parts[0], parts[1])
+	case r.Method == http.MethodPut && len(parts) == 4:
+		attrs = taskRunAttributes("update", "status", parts[0], parts[1])
+	case r.Method == http.MethodDelete && key == "":
+		// Pruning spans every namespace.
+		attrs = taskRunAttributes("update", "status", "", "")
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if allowed, err := s.authorize(user, attrs); err != nil {
+		s.logger.Errorw("Error authorizing request", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	} else if !allowed {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		f, info, err := s.store.Open(key)
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+			return
+		} else if err != nil {
+			s.logger.Errorw("Error opening archive", zap.String("key", key), zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+
+	case http.MethodPut:
+		if err := s.store.Write(r.Context(), key, r.Body); err != nil {
+			s.logger.Errorw("Error writing archive", zap.String("key", key), zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+
+	case http.MethodDelete:
+		before, err := time.Parse(time.RFC3339, r.URL.Query().Get("before"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.store.Prune(r.Context(), before); err != nil {
+			s.logger.Errorw("Error pruning archive", zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// authenticate returns the user whose bearer token authorizes the request,
+// or nil when it carries no valid token.
+func (s *Server) authenticate(r *http.Request) (*authenticationv1.UserInfo, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		return nil, nil
+	}
+	tr, err := s.client.AuthenticationV1().TokenReviews().Create(&authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token: token,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !tr.Status.Authenticated {
+		return nil, nil
+	}
+	return &tr.Status.User, nil
+}
+
+// authorize returns whether the user may act on the TaskRun(s) described by
+// attrs.
+func (s *Server) authorize(user *authenticationv1.UserInfo, attrs *authorizationv1.ResourceAttributes) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	sar, err := s.client.AuthorizationV1().SubjectAccessReviews().Create(&authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: attrs,
+			User:               user.Username,
+			Groups:             user.Groups,
+			UID:                user.UID,
+			Extra:              extra,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return sar.Status.Allowed, nil
+}
+
+func taskRunAttributes(verb, subresource, namespace, name string) *authorizationv1.ResourceAttributes {
+	return &authorizationv1.ResourceAttributes{
+		Namespace:   namespace,
+		Verb:        verb,
+		Group:       pipeline.GroupName,
+		Resource:    "taskruns",
+		Subresource: subresource,
+		Name:        name,
+	}
+}
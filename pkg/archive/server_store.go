@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/transport"
+)
+
+// tokenFile holds the token of the controlplane's ServiceAccount, with
+// which it authenticates to the archive server.
+const tokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// serverStore archives objects through the archive server.
+type serverStore struct {
+	baseURL string
+	client  *http.Client
+}
+
+var _ Store = (*serverStore)(nil)
+
+func newServerStore(baseURL string) (*serverStore, error) {
+	rt, err := transport.NewBearerAuthWithRefreshRoundTripper("", tokenFile, http.DefaultTransport)
+	if err != nil {
+		return nil, err
+	}
+	return &serverStore{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Transport: rt},
+	}, nil
+}
+
+// Write implements Store
+func (ss *serverStore) Write(ctx context.Context, key string, body io.Reader) error {
+	req, err := http.NewRequest(http.MethodPut, ss.URL(key), body)
+	if err != nil {
+		return err
+	}
+	return ss.do(req.WithContext(ctx))
+}
+
+// URL implements Store
+func (ss *serverStore) URL(key string) string {
+	return ss.baseURL + "/" + key
+}
+
+// Prune implements Store
+func (ss *serverStore) Prune(ctx context.Context, before time.Time) error {
+	u := ss.baseURL + "/?" + url.Values{"before": {before.Format(time.RFC3339)}}.Encode()
+	req, err := http.NewRequest(http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+	return ss.do(req.WithContext(ctx))
+}
+
+// Close implements Store
+func (ss *serverStore) Close() error {
+	ss.client.CloseIdleConnections()
+	return nil
+}
+
+func (ss *serverStore) do(req *http.Request) error {
+	resp, err := ss.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package autoscaler runs KPA-class autoscaling in-process: the metric
+// collection and scaling deciders that upstream runs in the standalone
+// autoscaler Deployment are shared by the KPA and Metric controllers, and
+// fed by a stats server to which the activators report.  Only the replica
+// holding the autoscaler's Lease scales, and the activators' stats are
+// routed to it.
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	endpointsinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/endpoints"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	pkgreconciler "knative.dev/pkg/reconciler"
+	"knative.dev/pkg/system"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	av1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	"knative.dev/serving/pkg/apis/serving"
+	asmetrics "knative.dev/serving/pkg/autoscaler/metrics"
+	"knative.dev/serving/pkg/autoscaler/scaling"
+	"knative.dev/serving/pkg/autoscaler/statserver"
+	metricinformer "knative.dev/serving/pkg/client/injection/informers/autoscaling/v1alpha1/metric"
+	painformer "knative.dev/serving/pkg/client/injection/informers/autoscaling/v1alpha1/podautoscaler"
+	smetrics "knative.dev/serving/pkg/metrics"
+	"knative.dev/serving/pkg/reconciler/autoscaling/kpa"
+	"knative.dev/serving/pkg/reconciler/metric"
+	"knative.dev/serving/pkg/resources"
+)
+
+const (
+	// statsServerAddr is where we accept the websocket connections over
+	// which the activators report stats.  The autoscaler Service maps the
+	// port the activators expect onto this one.
+	statsServerAddr = ":8083"
+	statsServerPort = 8083
+	statsBufferLen  = 1000
+
+	// LeaseName is the name of the Lease held by the controlplane replica
+	// that scales, and ServiceName that of the selectorless Service whose
+	// Endpoints it points at itself for the activators' stats.
+	LeaseName   = "autoscaler"
+	ServiceName = "autoscaler"
+
+	// The timings with which we lease the autoscaler.
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// Autoscaler holds the state shared by the KPA and Metric controllers.
+// Every controlplane replica keeps this state, but only the one holding
+// the Lease reconciles into it; the others drop what they had.
+type Autoscaler struct {
+	// identity and podIP identify this replica.
+	identity string
+	podIP    string
+
+	once        sync.Once
+	kubeclient  kubernetes.Interface
+	collector   *asmetrics.MetricCollector
+	multiScaler *scaling.MultiScaler
+
+	m       sync.Mutex
+	leading bool
+	// resyncs queue every resource of our controllers when the Lease
+	// changes hands.
+	resyncs []func()
+}
+
+// New creates a new Autoscaler for the replica with the given name and IP,
+// whose state is set up by the first of its controllers to be constructed.
+func New(identity, podIP string) *Autoscaler {
+	return &Autoscaler{
+		identity: identity,
+		podIP:    podIP,
+	}
+}
+
+// NewKPAController returns the controller for KPA-class PodAutoscalers.
+func (a *Autoscaler) NewKPAController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	a.setup(ctx)
+	impl := kpa.NewController(ctx, cmw, a.multiScaler)
+	onlyKpaClass := pkgreconciler.AnnotationFilterFunc(
+		autoscaling.ClassAnnotationKey, autoscaling.KPA, false /*allowUnset*/)
+	a.gate(impl, func() {
+		impl.FilteredGlobalResync(onlyKpaClass, painformer.Get(ctx).Informer())
+	}, func(ctx context.Context, namespace, name string) error {
+		return a.multiScaler.Delete(ctx, namespace, name)
+	})
+	return impl
+}
+
+// NewMetricController returns the controller for Metrics, which drives
+// what the collector scrapes.
+func (a *Autoscaler) NewMetricController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	a.setup(ctx)
+	impl := metric.NewController(ctx, cmw, a.collector)
+	a.gate(impl, func() {
+		impl.GlobalResync(metricinformer.Get(ctx).Informer())
+	}, func(_ context.Context, namespace, name string) error {
+		return a.collector.Delete(namespace, name)
+	})
+	return impl
+}
+
+// gate has the controller only reconcile while we hold the Lease, and
+// otherwise drop the state of its resources with the provided function.
+// The resync function queues all of its resources.
+func (a *Autoscaler) gate(impl *controller.Impl, resync func(),
+	drop func(ctx context.Context, namespace, name string) error) {
+	impl.Reconciler = &gatedReconciler{
+		isLeading: a.isLeading,
+		leading:   impl.Reconciler,
+		drop:      drop,
+	}
+	a.m.Lock()
+	defer a.m.Unlock()
+	a.resyncs = append(a.resyncs, resync)
+}
+
+// gatedReconciler implements controller.Reconciler, delegating to the
+// wrapped Reconciler while we hold the Lease.
+type gatedReconciler struct {
+	isLeading func() bool
+	leading   controller.Reconciler
+	drop      func(ctx context.Context, namespace, name string) error
+}
+
+// Check that our gatedReconciler implements controller.Reconciler
+var _ controller.Reconciler = (*gatedReconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *gatedReconciler) Reconcile(ctx context.Context, key string) error {
+	if r.isLeading() {
+		return r.leading.Reconcile(ctx, key)
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	return r.drop(ctx, namespace, name)
+}
+
+func (a *Autoscaler) isLeading() bool {
+	a.m.Lock()
+	defer a.m.Unlock()
+	return a.leading
+}
+
+// setLeading records whether we hold the Lease, and queues everything our
+// controllers reconcile to take up or drop its state.
+func (a *Autoscaler) setLeading(leading bool) {
+	a.m.Lock()
+	a.leading = leading
+	resyncs := a.resyncs
+	a.m.Unlock()
+	for _, resync := range resyncs {
+		resync()
+	}
+}
+
+func (a *Autoscaler) setup(ctx context.Context) {
+	a.once.Do(func() {
+		logger := logging.FromContext(ctx).Named("autoscaler")
+		endpointsInformer := endpointsinformer.Get(ctx)
+
+		a.kubeclient = kubeclient.Get(ctx)
+		a.collector = asmetrics.NewMetricCollector(
+			statsScraperFactoryFunc(endpointsInformer.Lister()), logger)
+		a.multiScaler = scaling.NewMultiScaler(ctx.Done(),
+			uniScalerFactoryFunc(endpointsInformer.Lister(), a.collector), logger)
+
+		// statsCh is the main communication channel between the stats
+		// server and the multiscaler.  Every replica serves stats, but
+		// the activators only report to the one holding the Lease.
+		statsCh := make(chan asmetrics.StatMessage, statsBufferLen)
+		statsServer := statserver.New(statsServerAddr, statsCh, logger)
+		go func() {
+			if err := statsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatalw("Error serving autoscaler stats", zap.Error(err))
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			statsServer.Shutdown(5 * time.Second)
+		}()
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case sm := <-statsCh:
+					a.collector.Record(sm.Key, sm.Stat)
+					a.multiScaler.Poke(sm.Key, sm.Stat)
+				}
+			}
+		}()
+
+		go a.lease(logging.WithLogger(ctx, logger))
+	})
+}
+
+// lease contends for the autoscaler's Lease until the context is
+// cancelled, scaling while we hold it.
+func (a *Autoscaler) lease(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Namespace: system.Namespace(),
+			Name:      LeaseName,
+		},
+		Client:     a.kubeclient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: a.identity},
+	}
+	for ctx.Err() == nil {
+		le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   leaseDuration,
+			RenewDeadline:   renewDeadline,
+			RetryPeriod:     retryPeriod,
+			ReleaseOnCancel: true,
+			Name:            LeaseName,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(context.Context) {
+					logger.Info("Leading the autoscaler")
+					if err := a.claimEndpoints(); err != nil {
+						logger.Errorw("Error routing the activators' stats to us", zap.Error(err))
+					}
+					a.setLeading(true)
+				},
+				OnStoppedLeading: func() {
+					logger.Info("Stopped leading the autoscaler")
+					a.setLeading(false)
+				},
+			},
+		})
+		if err != nil {
+			logger.Fatalw("Failed to create leader elector", zap.Error(err))
+		}
+		// Run returns when we lose the Lease, after which we contend again.
+		le.Run(ctx)
+	}
+}
+
+// claimEndpoints points the Endpoints of the autoscaler Service at our
+// stats server, so that the activators report to us.
+func (a *Autoscaler) claimEndpoints() error {
+	want := []corev1.EndpointSubset{{
+		Addresses: []corev1.EndpointAddress{{IP: a.podIP}},
+		Ports: []corev1.EndpointPort{{
+			Name: "http",
+			Port: statsServerPort,
+		}},
+	}}
+	eps := a.kubeclient.CoreV1().Endpoints(system.Namespace())
+	ep, err := eps.Get(ServiceName, metav1.GetOptions{})
+	if apierrs.IsNotFound(err) {
+		_, err = eps.Create(&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ServiceName,
+				Namespace: system.Namespace(),
+			},
+			Subsets: want,
+		})
+		return err
+	} else if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(ep.Subsets, want) {
+		return nil
+	}
+	ep = ep.DeepCopy()
+	ep.Subsets = want
+	_, err = eps.Update(ep)
+	return err
+}
+
+func uniScalerFactoryFunc(endpointsLister corev1listers.EndpointsLister,
+	metricClient asmetrics.MetricClient) scaling.UniScalerFactory {
+	return func(decider *scaling.Decider) (scaling.UniScaler, error) {
+		if v, ok := decider.Labels[serving.ConfigurationLabelKey]; !ok || v == "" {
+			return nil, fmt.Errorf("label %q not found or empty in Decider %s", serving.ConfigurationLabelKey, decider.Name)
+		}
+		if decider.Spec.ServiceName == "" {
+			return nil, fmt.Errorf("%s decider has empty ServiceName", decider.Name)
+		}
+
+		serviceName := decider.Labels[serving.ServiceLabelKey] // This can be empty.
+		configName := decider.Labels[serving.ConfigurationLabelKey]
+
+		// Create a stats reporter which tags statistics by PA namespace, configuration name, and PA name.
+		ctx, err := smetrics.RevisionContext(decider.Namespace, serviceName, configName, decider.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		return scaling.New(decider.Namespace, decider.Name, metricClient, endpointsLister, &decider.Spec, ctx)
+	}
+}
+
+func statsScraperFactoryFunc(endpointsLister corev1listers.EndpointsLister) asmetrics.StatsScraperFactory {
+	return func(metric *av1alpha1.Metric) (asmetrics.StatsScraper, error) {
+		podCounter := resources.NewScopedEndpointsCounter(
+			endpointsLister, metric.Namespace, metric.Spec.ScrapeTarget)
+		return asmetrics.NewServiceScraper(metric, podCounter)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package extract pulls values out of CloudEvents, for the sinks that map
+// events onto other resources.
+package extract
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/types"
+)
+
+// Attribute returns the value of the named CloudEvent attribute or
+// extension, and whether the event carries it.
+func Attribute(ev *event.Event, name string) (string, bool, error) {
+	var value string
+	switch name {
+	case "id":
+		value = ev.ID()
+	case "source":
+		value = ev.Source()
+	case "type":
+		value = ev.Type()
+	case "subject":
+		value = ev.Subject()
+	case "specversion":
+		value = ev.SpecVersion()
+	case "datacontenttype":
+		value = ev.DataContentType()
+	case "dataschema":
+		value = ev.DataSchema()
+	case "time":
+		if t := ev.Time(); !t.IsZero() {
+			value = types.FormatTime(t)
+		}
+	default:
+		ext, ok := ev.Extensions()[name]
+		if !ok {
+			return "", false, nil
+		}
+		s, err := types.Format(ext)
+		if err != nil {
+			return "", false, err
+		}
+		value = s
+	}
+	return value, value != "", nil
+}
+
+// Path returns the value at the dot-separated path within the decoded
+// JSON data, and whether it is present (and not null).
+func Path(data interface{}, path string) (interface{}, bool) {
+	cursor := data
+	for _, segment := range strings.Split(path, ".") {
+		switch v := cursor.(type) {
+		case map[string]interface{}:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			cursor = next
+		case []interface{}:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			cursor = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return cursor, cursor != nil
+}
+
+// String renders a value returned by Path, returning strings verbatim
+// and other values as JSON.
+func String(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gc
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ConfigName is the name of the ConfigMap holding garbage collection
+	// settings.  We share it with Knative Serving, which ignores our keys.
+	ConfigName = "config-gc"
+
+	taskRunLogRetentionKey = "taskrun-log-retention"
+	succeededRunLimitKey   = "succeeded-run-limit"
+	failedRunLimitKey      = "failed-run-limit"
+	succeededRunTTLKey     = "succeeded-run-ttl"
+	failedRunTTLKey        = "failed-run-ttl"
+
+	// Unlimited is the run limit that disables count-based collection.
+	Unlimited = -1
+)
+
+// Config holds the mink-specific garbage collection settings.
+type Config struct {
+	// TaskRunLogRetention is how long archived TaskRun logs are kept
+	// before they are pruned from the archive.  Zero keeps them forever.
+	TaskRunLogRetention time.Duration
+
+	// SucceededRunLimit and FailedRunLimit are the number of completed
+	// TaskRuns (per Task) and PipelineRuns (per Pipeline) with each outcome
+	// to keep.  Unlimited disables count-based collection.
+	SucceededRunLimit int64
+	FailedRunLimit    int64
+
+	// SucceededRunTTL and FailedRunTTL are how long after completion a
+	// TaskRun or PipelineRun with each outcome is kept.  Zero disables
+	// time-based collection.
+	SucceededRunTTL time.Duration
+	FailedRunTTL    time.Duration
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		TaskRunLogRetention: 30 * 24 * time.Hour,
+		SucceededRunLimit:   Unlimited,
+		FailedRunLimit:      Unlimited,
+	}
+}
+
+// NewConfigFromConfigMap creates a Config from the supplied ConfigMap.
+func NewConfigFromConfigMap(configMap *corev1.ConfigMap) (*Config, error) {
+	c := defaultConfig()
+
+	for _, dur := range []struct {
+		key   string
+		field *time.Duration
+	}{{
+		key:   taskRunLogRetentionKey,
+		field: &c.TaskRunLogRetention,
+	}, {
+		key:   succeededRunTTLKey,
+		field: &c.SucceededRunTTL,
+	}, {
+		key:   failedRunTTLKey,
+		field: &c.FailedRunTTL,
+	}} {
+		if raw, ok := configMap.Data[dur.key]; ok {
+			val, err := time.ParseDuration(raw)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %q: %w", dur.key, err)
+			} else if val < 0 {
+				return nil, fmt.Errorf("%s must be non-negative, was: %v", dur.key, val)
+			}
+			*dur.field = val
+		}
+	}
+
+	for _, lim := range []struct {
+		key   string
+		field *int64
+	}{{
+		key:   succeededRunLimitKey,
+		field: &c.SucceededRunLimit,
+	}, {
+		key:   failedRunLimitKey,
+		field: &c.FailedRunLimit,
+	}} {
+		if raw, ok := configMap.Data[lim.key]; ok {
+			val, err := strconv.ParseInt(raw, 10 /*base*/, 64 /*bit count*/)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %q: %w", lim.key, err)
+			} else if val < Unlimited {
+				return nil, fmt.Errorf("%s must be non-negative or %d, was: %d", lim.key, Unlimited, val)
+			}
+			*lim.field = val
+		}
+	}
+
+	return c, nil
+}
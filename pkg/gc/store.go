@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gc
+
+import (
+	"context"
+
+	"knative.dev/pkg/configmap"
+)
+
+type cfgKey struct{}
+
+// FromContext extracts the Config attached to the provided context.
+func FromContext(ctx context.Context) *Config {
+	if c, ok := ctx.Value(cfgKey{}).(*Config); ok {
+		return c
+	}
+	return defaultConfig()
+}
+
+// ToContext attaches the provided Config to the provided context.
+func ToContext(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, cfgKey{}, c)
+}
+
+// Store is a typed wrapper around configmap.UntypedStore to handle config-gc.
+type Store struct {
+	*configmap.UntypedStore
+}
+
+// NewStore creates a new Store, and optionally calls functions when
+// config-gc is updated.
+func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *Store {
+	return &Store{
+		UntypedStore: configmap.NewUntypedStore(
+			"gc",
+			logger,
+			configmap.Constructors{
+				ConfigName: NewConfigFromConfigMap,
+			},
+			onAfterStore...,
+		),
+	}
+}
+
+// ToContext attaches the current Config state to the provided context.
+func (s *Store) ToContext(ctx context.Context) context.Context {
+	return ToContext(ctx, s.Load())
+}
+
+// Load fetches the current Config state of the Store.
+func (s *Store) Load() *Config {
+	return s.UntypedLoad(ConfigName).(*Config)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kafkabroker runs the Brokers of the KafkaBroker class in the
+// dataplane.  Every replica accepts events for any Broker, writing them to
+// its topic, and joins the consumer group of each of its Triggers, across
+// which Kafka balances the topic's partitions.
+package kafkabroker
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Shopify/sarama"
+	"knative.dev/eventing-contrib/kafka/channel/pkg/utils"
+	"knative.dev/eventing/pkg/apis/eventing"
+	"knative.dev/eventing/pkg/apis/eventing/v1alpha1"
+)
+
+const (
+	// BrokerClass is the value of eventing.knative.dev/broker.class with
+	// which Brokers select us.
+	BrokerClass = "KafkaBroker"
+
+	// PartitionsAnnotation and ReplicationFactorAnnotation configure the
+	// topic of a Broker when it is created.
+	PartitionsAnnotation        = "kafka.eventing.mink.knative.dev/partitions"
+	ReplicationFactorAnnotation = "kafka.eventing.mink.knative.dev/replication-factor"
+
+	// DeliveryOrderAnnotation selects how the events of a Trigger are
+	// delivered: "unordered" (the default) delivers the events of each
+	// partition concurrently, while "ordered" waits for each event to be
+	// delivered before the next.
+	DeliveryOrderAnnotation = "kafka.eventing.mink.knative.dev/delivery-order"
+	Ordered                 = "ordered"
+	Unordered               = "unordered"
+
+	// PartitionKeyExtension is the CloudEvents extension by which events
+	// are partitioned, so that ordered Triggers see the events with the
+	// same key in order.
+	PartitionKeyExtension = "partitionkey"
+)
+
+// IsKafkaBroker returns whether the Broker is of our class.
+func IsKafkaBroker(b *v1alpha1.Broker) bool {
+	return b.Annotations[eventing.BrokerClassKey] == BrokerClass
+}
+
+// TopicName returns the name of the topic holding the events of the
+// named Broker.
+func TopicName(namespace, name string) string {
+	return fmt.Sprintf("knative-broker.%s.%s", namespace, name)
+}
+
+// TopicDetail returns how the topic of the Broker is created.
+func TopicDetail(b *v1alpha1.Broker) (*sarama.TopicDetail, error) {
+	td := &sarama.TopicDetail{
+		NumPartitions:     utils.DefaultNumPartitions,
+		ReplicationFactor: utils.DefaultReplicationFactor,
+	}
+	if v, ok := b.Annotations[PartitionsAnnotation]; ok {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid %s: %q", PartitionsAnnotation, v)
+		}
+		td.NumPartitions = int32(n)
+	}
+	if v, ok := b.Annotations[ReplicationFactorAnnotation]; ok {
+		n, err := strconv.ParseInt(v, 10, 16)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid %s: %q", ReplicationFactorAnnotation, v)
+		}
+		td.ReplicationFactor = int16(n)
+	}
+	return td, nil
+}
+
+// GroupID returns the consumer group of the Trigger, which holds its
+// offsets.
+func GroupID(t *v1alpha1.Trigger) string {
+	return fmt.Sprintf("knative-trigger.%s.%s.%s", t.Namespace, t.Name, t.UID)
+}
+
+// IsOrdered returns whether the Trigger asked for ordered delivery.
+func IsOrdered(t *v1alpha1.Trigger) bool {
+	return t.Annotations[DeliveryOrderAnnotation] == Ordered
+}
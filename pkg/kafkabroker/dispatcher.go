@@ -0,0 +1,516 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkabroker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	protocolkafka "github.com/cloudevents/sdk-go/v2/protocol/kafka_sarama"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
+	"knative.dev/eventing/pkg/apis/eventing/v1alpha1"
+	brokerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1alpha1/broker"
+	triggerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1alpha1/trigger"
+	listers "knative.dev/eventing/pkg/client/listers/eventing/v1alpha1"
+	broker "knative.dev/eventing/pkg/mtbroker"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/resolver"
+
+	"github.com/mattmoor/mink/pkg/kafkachannel"
+)
+
+const (
+	controllerAgentName = "kafka-broker"
+
+	// retryPeriod is how long we wait before rejoining a consumer group
+	// after an error.
+	retryPeriod = 2 * time.Second
+
+	// maxInFlight bounds the events of each partition that an unordered
+	// Trigger delivers at once.
+	maxInFlight = 100
+
+	// The bounds of the backoff with which we retry writing replies.
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 30 * time.Second
+)
+
+// EnvConfig configures the Broker ingress.
+type EnvConfig struct {
+	Port int `envconfig:"KAFKA_BROKER_PORT" default:"8097"`
+}
+
+// Dispatcher implements controller.Reconciler, running the consumer groups
+// of the Triggers of KafkaBrokers, and http.Handler, accepting the events
+// of the Brokers.
+type Dispatcher struct {
+	// ctx bounds the lifetime of our consumers.
+	ctx    context.Context
+	logger *zap.SugaredLogger
+
+	brokerLister  listers.BrokerLister
+	triggerLister listers.TriggerLister
+	uriResolver   *resolver.URIResolver
+
+	client   sarama.Client
+	producer sarama.SyncProducer
+	ceclient cloudevents.Client
+
+	m         sync.Mutex
+	consumers map[string]*consumer
+}
+
+// Check that our Dispatcher implements controller.Reconciler and http.Handler
+var _ controller.Reconciler = (*Dispatcher)(nil)
+var _ http.Handler = (*Dispatcher)(nil)
+
+// consumer is a running consumer group of a single Trigger.
+type consumer struct {
+	// settings is what the consumer was started with, restarting it
+	// when they change.
+	settings settings
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+type settings struct {
+	Filter     *v1alpha1.TriggerFilter
+	Subscriber string
+	DeadLetter string
+	Delivery   *eventingduckv1beta1.DeliverySpec
+	Ordered    bool
+}
+
+// NewController creates a Dispatcher producing to and consuming from the
+// given brokers.
+func NewController(ctx context.Context, brokers []string) (*controller.Impl, *Dispatcher, error) {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	brokerInformer := brokerinformer.Get(ctx)
+	triggerInformer := triggerinformer.Get(ctx)
+
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_0_0_0
+	cfg.ClientID = controllerAgentName
+	// New Triggers see the events sent after they were created, rather
+	// than the whole history of their Broker.
+	cfg.Consumer.Offsets.Initial = sarama.OffsetNewest
+	// We only accept events once every in-sync replica has them.
+	cfg.Producer.RequiredAcks = sarama.WaitForAll
+	cfg.Producer.Return.Successes = true
+	client, err := sarama.NewClient(brokers, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating Kafka client: %w", err)
+	}
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("creating Kafka producer: %w", err)
+	}
+	ceclient, err := cloudevents.NewDefaultClient()
+	if err != nil {
+		producer.Close()
+		return nil, nil, fmt.Errorf("creating CloudEvents client: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		producer.Close()
+	}()
+
+	d := &Dispatcher{
+		ctx:           ctx,
+		logger:        logger,
+		brokerLister:  brokerInformer.Lister(),
+		triggerLister: triggerInformer.Lister(),
+		client:        client,
+		producer:      producer,
+		ceclient:      ceclient,
+		consumers:     make(map[string]*consumer),
+	}
+	impl := controller.NewImpl(d, logger, controllerAgentName)
+	d.uriResolver = resolver.NewURIResolver(ctx, impl.EnqueueKey)
+
+	logger.Info("Setting up event handlers")
+	triggerInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	// Start or stop the consumers of the Triggers of Brokers that change.
+	brokerInformer.Informer().AddEventHandler(controller.HandleAll(func(obj interface{}) {
+		b, err := kmeta.DeletionHandlingAccessor(obj)
+		if err != nil {
+			return
+		}
+		triggers, err := d.triggerLister.Triggers(b.GetNamespace()).List(labels.Everything())
+		if err != nil {
+			return
+		}
+		for _, t := range triggers {
+			if t.Spec.Broker == b.GetName() {
+				impl.Enqueue(t)
+			}
+		}
+	}))
+
+	return impl, d, nil
+}
+
+// ServeHTTP implements http.Handler, writing the events sent to
+// /<namespace>/<broker> to the topic of the Broker.
+func (d *Dispatcher) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) != 2 {
+		http.NotFound(w, req)
+		return
+	}
+
+	b, err := d.brokerLister.Brokers(parts[0]).Get(parts[1])
+	if apierrs.IsNotFound(err) || (err == nil && !IsKafkaBroker(b)) {
+		http.NotFound(w, req)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	} else if !topicReady(b) {
+		http.Error(w, "the Broker's topic is not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	e, err := binding.ToEvent(req.Context(), cehttp.NewMessageFromHttpRequest(req))
+	if err != nil {
+		http.Error(w, "malformed CloudEvent: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !decrementTTL(e) {
+		d.logger.Debugw("Dropping event whose TTL expired", zap.String("broker", req.URL.Path), zap.String("id", e.ID()))
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if err := d.produce(req.Context(), b.Namespace, b.Name, e); err != nil {
+		d.logger.Errorw("Error writing event to Kafka", zap.String("broker", req.URL.Path), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// produce writes the event to the topic of the named Broker, returning
+// once Kafka has acknowledged it.
+func (d *Dispatcher) produce(ctx context.Context, namespace, name string, e *event.Event) error {
+	pm := &sarama.ProducerMessage{
+		Topic: TopicName(namespace, name),
+	}
+	if v, ok := e.Extensions()[PartitionKeyExtension]; ok {
+		if key, err := types.Format(v); err == nil {
+			pm.Key = sarama.StringEncoder(key)
+		}
+	}
+	if err := protocolkafka.WriteProducerMessage(ctx, binding.ToMessage(e), pm); err != nil {
+		return err
+	}
+	_, _, err := d.producer.SendMessage(pm)
+	return err
+}
+
+// topicReady returns whether the controlplane has created the topic of
+// the Broker.
+func topicReady(b *v1alpha1.Broker) bool {
+	return b.DeletionTimestamp == nil && b.Status.GetCondition(v1alpha1.BrokerConditionTriggerChannel).IsTrue()
+}
+
+// Reconcile implements controller.Reconciler
+func (d *Dispatcher) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	t, err := d.triggerLister.Triggers(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		d.stop(key)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	b, err := d.brokerLister.Brokers(namespace).Get(t.Spec.Broker)
+	if apierrs.IsNotFound(err) {
+		d.stop(key)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// Until the controlplane has created the topic and resolved the
+	// subscriber, there is nothing to consume.
+	if !IsKafkaBroker(b) || !topicReady(b) || t.DeletionTimestamp != nil || t.Status.SubscriberURI.IsEmpty() {
+		d.stop(key)
+		return nil
+	}
+
+	want := settings{
+		Filter:     t.Spec.Filter,
+		Subscriber: t.Status.SubscriberURI.String(),
+		Delivery:   b.Spec.Delivery,
+		Ordered:    IsOrdered(t),
+	}
+	if b.Spec.Delivery != nil && b.Spec.Delivery.DeadLetterSink != nil {
+		dls := b.Spec.Delivery.DeadLetterSink.DeepCopy()
+		if dls.Ref != nil && dls.Ref.Namespace == "" {
+			dls.Ref.Namespace = namespace
+		}
+		uri, err := d.uriResolver.URIFromDestinationV1(*dls, t)
+		if err != nil {
+			return fmt.Errorf("resolving the dead letter sink of Broker %q: %w", b.Name, err)
+		}
+		want.DeadLetter = uri.String()
+	}
+
+	d.m.Lock()
+	c, ok := d.consumers[key]
+	d.m.Unlock()
+	if ok && equality.Semantic.DeepEqual(c.settings, want) {
+		return nil
+	}
+	d.stop(key)
+
+	rp, err := kafkachannel.NewRetryPolicy(want.Delivery)
+	if err != nil {
+		logger.Warnw("Invalid delivery spec, using the defaults", zap.Error(err))
+	}
+	cctx, cancel := context.WithCancel(d.ctx)
+	c = &consumer{
+		settings: want,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	d.m.Lock()
+	d.consumers[key] = c
+	d.m.Unlock()
+
+	topic := TopicName(namespace, b.Name)
+	groupID := GroupID(t)
+	h := &handler{
+		logger:   d.logger.With(zap.String("trigger", key)),
+		settings: want,
+		retry:    rp,
+		ceclient: d.ceclient,
+		produce: func(ctx context.Context, e *event.Event) error {
+			return d.produce(ctx, namespace, b.Name, e)
+		},
+	}
+	go func() {
+		defer close(c.done)
+		for cctx.Err() == nil {
+			group, err := sarama.NewConsumerGroupFromClient(groupID, d.client)
+			if err == nil {
+				h.logger.Infof("Joining consumer group %q", groupID)
+				for cctx.Err() == nil && err == nil {
+					// Consume returns whenever the group rebalances.
+					err = group.Consume(cctx, []string{topic}, h)
+				}
+				group.Close()
+			}
+			if err != nil && cctx.Err() == nil {
+				h.logger.Errorw("Error consuming "+topic, zap.Error(err))
+				select {
+				case <-cctx.Done():
+				case <-time.After(retryPeriod):
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// stop stops the consumer of the given Trigger, if any, waiting for it to
+// leave its consumer group.
+func (d *Dispatcher) stop(key string) {
+	d.m.Lock()
+	c, ok := d.consumers[key]
+	delete(d.consumers, key)
+	d.m.Unlock()
+	if ok {
+		c.cancel()
+		<-c.done
+	}
+}
+
+// handler implements sarama.ConsumerGroupHandler, delivering the events
+// of a Broker that pass the filter of a Trigger to its subscriber.
+type handler struct {
+	logger   *zap.SugaredLogger
+	settings settings
+	retry    kafkachannel.RetryPolicy
+	ceclient cloudevents.Client
+	// produce writes replies back to the Broker.
+	produce func(context.Context, *event.Event) error
+}
+
+var _ sarama.ConsumerGroupHandler = (*handler)(nil)
+
+// Setup implements sarama.ConsumerGroupHandler
+func (h *handler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup implements sarama.ConsumerGroupHandler
+func (h *handler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim implements sarama.ConsumerGroupHandler.  We mark messages
+// once they have been delivered, or given up on after retries, so that a
+// replica taking over the partition resumes from there.
+func (h *handler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	if h.settings.Ordered {
+		for msg := range claim.Messages() {
+			if err := h.handle(ctx, msg); err != nil {
+				return err
+			}
+			session.MarkMessage(msg, "")
+		}
+		return nil
+	}
+
+	offsets := newOffsetTracker(func(offset int64) {
+		session.MarkOffset(claim.Topic(), claim.Partition(), offset, "")
+	})
+	inflight := make(chan struct{}, maxInFlight)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for msg := range claim.Messages() {
+		select {
+		case inflight <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+		offsets.start(msg.Offset)
+		wg.Add(1)
+		go func(msg *sarama.ConsumerMessage) {
+			defer wg.Done()
+			defer func() { <-inflight }()
+			if err := h.handle(ctx, msg); err == nil {
+				offsets.finish(msg.Offset)
+			}
+		}(msg)
+	}
+	return nil
+}
+
+// handle delivers the message to the subscriber if it passes the filter,
+// and writes its reply back to the Broker.  It only fails when we lose the
+// claim on the message.
+func (h *handler) handle(ctx context.Context, cm *sarama.ConsumerMessage) error {
+	e, err := binding.ToEvent(ctx, protocolkafka.NewMessageFromConsumerMessage(cm))
+	if err != nil {
+		// Retrying won't make a malformed message any better.
+		h.logger.Errorw(fmt.Sprintf("Dropping malformed message at partition %d, offset %d", cm.Partition, cm.Offset), zap.Error(err))
+		return nil
+	}
+	if !passesFilter(h.settings.Filter, e) {
+		return nil
+	}
+
+	// Subscribers don't see the TTL, which we carry over to their replies.
+	ttl, ok := getTTL(e)
+	if !ok {
+		ttl = defaultTTL
+	}
+	e.SetExtension(broker.TTLAttribute, nil)
+
+	reply, err := h.deliver(ctx, e)
+	if err != nil || reply == nil {
+		return err
+	}
+	reply.SetExtension(broker.TTLAttribute, ttl)
+	if !decrementTTL(reply) {
+		h.logger.Debugw("Dropping reply whose TTL expired", zap.String("id", reply.ID()))
+		return nil
+	}
+	for backoff := minBackoff; ; backoff *= 2 {
+		err := h.produce(ctx, reply)
+		if err == nil {
+			return nil
+		}
+		h.logger.Warnw("Failed to write reply to Kafka", zap.String("id", reply.ID()), zap.Error(err))
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// deliver sends the event to the subscriber, returning its reply, if any.
+// It retries according to the delivery spec of the Broker before handing
+// the event to the dead letter sink, and only fails when we lose the
+// claim on the message.
+func (h *handler) deliver(ctx context.Context, e *event.Event) (*event.Event, error) {
+	for attempt := 0; ; attempt++ {
+		reply, result := h.ceclient.Request(cloudevents.ContextWithTarget(ctx, h.settings.Subscriber), *e)
+		if cloudevents.IsACK(result) {
+			return reply, nil
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		} else if attempt >= int(h.retry.Retry) {
+			h.deadLetter(ctx, e, result)
+			return nil, nil
+		}
+		h.logger.Warnw("Failed to deliver event, retrying", zap.String("id", e.ID()), zap.Error(result))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(h.retry.Backoff(attempt)):
+		}
+	}
+}
+
+// deadLetter hands an event that we failed to deliver to the dead letter
+// sink, if there is one.
+func (h *handler) deadLetter(ctx context.Context, e *event.Event, cause error) {
+	if h.settings.DeadLetter == "" {
+		h.logger.Errorw("Giving up on event", zap.String("id", e.ID()), zap.Error(cause))
+		return
+	}
+	if result := h.ceclient.Send(cloudevents.ContextWithTarget(ctx, h.settings.DeadLetter), *e); !cloudevents.IsACK(result) {
+		h.logger.Errorw("Giving up on event, which the dead letter sink rejected", zap.String("id", e.ID()),
+			zap.NamedError("cause", cause), zap.Error(result))
+	}
+}
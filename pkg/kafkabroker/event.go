@@ -0,0 +1,102 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkabroker
+
+import (
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"knative.dev/eventing/pkg/apis/eventing/v1alpha1"
+	broker "knative.dev/eventing/pkg/mtbroker"
+)
+
+// defaultTTL is how many times an event may be replied through a Broker,
+// as with the mt broker.
+const defaultTTL = 255
+
+// getTTL returns the TTL of the event, if it has a valid one.
+func getTTL(e *event.Event) (int32, bool) {
+	v, ok := e.Extensions()[broker.TTLAttribute]
+	if !ok {
+		return 0, false
+	}
+	ttl, err := types.ToInteger(v)
+	return ttl, err == nil
+}
+
+// decrementTTL counts the event passing through a Broker once more,
+// returning false if it has done so too many times.
+func decrementTTL(e *event.Event) bool {
+	ttl, ok := getTTL(e)
+	if !ok {
+		ttl = defaultTTL
+	} else {
+		ttl--
+	}
+	if ttl <= 0 {
+		return false
+	}
+	e.SetExtension(broker.TTLAttribute, ttl)
+	return true
+}
+
+// passesFilter returns whether the event matches the filter of the
+// Trigger, with the exact matching of attributes of the mt broker.
+func passesFilter(filter *v1alpha1.TriggerFilter, e *event.Event) bool {
+	if filter == nil {
+		return true
+	}
+	var want map[string]string
+	switch {
+	case filter.DeprecatedSourceAndType != nil:
+		want = map[string]string{
+			"type":   filter.DeprecatedSourceAndType.Type,
+			"source": filter.DeprecatedSourceAndType.Source,
+		}
+	case filter.Attributes != nil:
+		want = *filter.Attributes
+	default:
+		return true
+	}
+
+	have := map[string]string{
+		"specversion":     e.SpecVersion(),
+		"type":            e.Type(),
+		"source":          e.Source(),
+		"subject":         e.Subject(),
+		"id":              e.ID(),
+		"dataschema":      e.DataSchema(),
+		"schemaurl":       e.DataSchema(),
+		"datacontenttype": e.DataContentType(),
+		"datamediatype":   e.DataMediaType(),
+	}
+	if t := e.Time(); !t.IsZero() {
+		have["time"] = types.FormatTime(t)
+	}
+	for k, v := range e.Extensions() {
+		if s, err := types.Format(v); err == nil {
+			have[k] = s
+		}
+	}
+
+	for k, v := range want {
+		value, ok := have[k]
+		if !ok || (v != v1alpha1.TriggerAnyFilter && v != value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkabroker
+
+import (
+	"sync"
+)
+
+// offsetTracker tracks the messages of a partition that are delivered out
+// of order, so that we only ever mark the offset below which every message
+// has been delivered.
+type offsetTracker struct {
+	m sync.Mutex
+	// pending holds the offsets in flight, in the order they were claimed.
+	pending []int64
+	done    map[int64]bool
+	// mark is called with each new offset below which we're done.
+	mark func(offset int64)
+}
+
+func newOffsetTracker(mark func(int64)) *offsetTracker {
+	return &offsetTracker{
+		done: make(map[int64]bool),
+		mark: mark,
+	}
+}
+
+// start records that the message at the given offset is in flight.
+func (t *offsetTracker) start(offset int64) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.pending = append(t.pending, offset)
+}
+
+// finish records that the message at the given offset has been handled,
+// marking the offset past the messages handled since the last mark.
+func (t *offsetTracker) finish(offset int64) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.done[offset] = true
+
+	next := int64(-1)
+	for len(t.pending) > 0 && t.done[t.pending[0]] {
+		delete(t.done, t.pending[0])
+		next = t.pending[0] + 1
+		t.pending = t.pending[1:]
+	}
+	if next >= 0 {
+		// We mark under the lock, so that marks are never reordered.
+		t.mark(next)
+	}
+}
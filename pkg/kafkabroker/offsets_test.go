@@ -0,0 +1,106 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkabroker
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  []int64
+		finish []int64
+		want   []int64
+	}{{
+		name:   "in order",
+		start:  []int64{1, 2, 3},
+		finish: []int64{1, 2, 3},
+		want:   []int64{2, 3, 4},
+	}, {
+		name:   "reverse order",
+		start:  []int64{1, 2, 3},
+		finish: []int64{3, 2, 1},
+		want:   []int64{4},
+	}, {
+		name:   "gap held open",
+		start:  []int64{1, 2, 3},
+		finish: []int64{2, 3},
+	}, {
+		name:   "gap filled",
+		start:  []int64{10, 11, 12, 13},
+		finish: []int64{11, 10, 13, 12},
+		want:   []int64{12, 14},
+	}, {
+		name:   "sparse offsets",
+		start:  []int64{5, 9, 20},
+		finish: []int64{9, 5, 20},
+		want:   []int64{10, 21},
+	}, {
+		name:   "nothing finished",
+		start:  []int64{1, 2},
+		finish: nil,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []int64
+			tracker := newOffsetTracker(func(offset int64) {
+				got = append(got, offset)
+			})
+			for _, offset := range test.start {
+				tracker.start(offset)
+			}
+			for _, offset := range test.finish {
+				tracker.finish(offset)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("marks = %v, wanted %v", got, test.want)
+			}
+			// Only the offsets still in flight are remembered.
+			if len(tracker.done) > len(tracker.pending) {
+				t.Errorf("done = %v, wanted a subset of pending %v", tracker.done, tracker.pending)
+			}
+		})
+	}
+}
+
+func TestOffsetTrackerInterleaved(t *testing.T) {
+	var got []int64
+	tracker := newOffsetTracker(func(offset int64) {
+		got = append(got, offset)
+	})
+
+	// Messages keep arriving while earlier ones are still in flight.
+	tracker.start(1)
+	tracker.start(2)
+	tracker.finish(2)
+	tracker.start(3)
+	tracker.finish(1)
+	tracker.finish(3)
+	tracker.start(4)
+	tracker.finish(4)
+
+	if want := []int64{3, 4, 5}; !cmp.Equal(got, want) {
+		t.Errorf("marks = %v, wanted %v", got, want)
+	}
+	if len(tracker.pending) != 0 || len(tracker.done) != 0 {
+		t.Errorf("pending = %v, done = %v, wanted both empty", tracker.pending, tracker.done)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkachannel
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
+)
+
+const (
+	// The retries of subscriptions that don't specify their delivery.
+	defaultRetry         = 3
+	defaultBackoffPolicy = eventingduckv1beta1.BackoffPolicyExponential
+	defaultBackoffDelay  = 100 * time.Millisecond
+
+	// maxBackoffDelay caps the delay between retries.
+	maxBackoffDelay = time.Minute
+)
+
+// RetryPolicy is how often, and how patiently, we retry delivering a
+// message to a subscriber.
+type RetryPolicy struct {
+	Retry  int32
+	Policy eventingduckv1beta1.BackoffPolicyType
+	Delay  time.Duration
+}
+
+// NewRetryPolicy returns the retry policy of the given delivery spec,
+// filling in our defaults for what it leaves out.
+func NewRetryPolicy(ds *eventingduckv1beta1.DeliverySpec) (RetryPolicy, error) {
+	rp := RetryPolicy{
+		Retry:  defaultRetry,
+		Policy: defaultBackoffPolicy,
+		Delay:  defaultBackoffDelay,
+	}
+	if ds == nil {
+		return rp, nil
+	}
+	if ds.Retry != nil {
+		rp.Retry = *ds.Retry
+	}
+	if ds.BackoffPolicy != nil {
+		rp.Policy = *ds.BackoffPolicy
+	}
+	if ds.BackoffDelay != nil {
+		d, err := parseISO8601(*ds.BackoffDelay)
+		if err != nil {
+			return rp, err
+		}
+		rp.Delay = d
+	}
+	return rp, nil
+}
+
+// Backoff returns how long to wait before the given (zero-based) retry.
+func (rp RetryPolicy) Backoff(attempt int) time.Duration {
+	d := rp.Delay
+	if rp.Policy == eventingduckv1beta1.BackoffPolicyLinear {
+		d *= time.Duration(attempt + 1)
+	} else {
+		for i := 0; i < attempt && d < maxBackoffDelay; i++ {
+			d *= 2
+		}
+	}
+	if d > maxBackoffDelay {
+		d = maxBackoffDelay
+	}
+	return d
+}
+
+// iso8601 matches the day and time components of ISO 8601 durations,
+// such as PT0.2S or P1DT12H.
+var iso8601 = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+func parseISO8601(s string) (time.Duration, error) {
+	m := iso8601.FindStringSubmatch(s)
+	if m == nil || s == "P" || s == "PT" {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if m[i+1] == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(f * float64(unit))
+	}
+	return d, nil
+}
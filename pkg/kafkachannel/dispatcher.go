@@ -0,0 +1,376 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kafkachannel dispatches the events of KafkaChannels from the
+// dataplane.  Every replica accepts events for any channel, writing them
+// to its topic, and joins the consumer group of each of its subscribers,
+// across which Kafka balances the topic's partitions.
+package kafkachannel
+
+import (
+	"context"
+	"fmt"
+	nethttp "net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	protocolkafka "github.com/cloudevents/sdk-go/v2/protocol/kafka_sarama"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/eventing-contrib/kafka/channel/pkg/apis/messaging/v1alpha1"
+	kafkachannelinformer "knative.dev/eventing-contrib/kafka/channel/pkg/client/injection/informers/messaging/v1alpha1/kafkachannel"
+	listers "knative.dev/eventing-contrib/kafka/channel/pkg/client/listers/messaging/v1alpha1"
+	"knative.dev/eventing-contrib/kafka/channel/pkg/utils"
+	eventingduckv1alpha1 "knative.dev/eventing/pkg/apis/duck/v1alpha1"
+	eventingchannels "knative.dev/eventing/pkg/channel"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	controllerAgentName = "kafka-channel-dispatcher"
+
+	// retryPeriod is how long we wait before rejoining a consumer group
+	// after an error.
+	retryPeriod = 2 * time.Second
+)
+
+// EnvConfig configures the dispatcher.
+type EnvConfig struct {
+	Port int `envconfig:"KAFKA_CHANNEL_DISPATCHER_PORT" default:"8096"`
+}
+
+// Dispatcher implements controller.Reconciler, tracking the hosts and
+// subscribers of KafkaChannels, and http.Handler, accepting their events.
+type Dispatcher struct {
+	// ctx bounds the lifetime of our consumers.
+	ctx    context.Context
+	logger *zap.SugaredLogger
+	lister listers.KafkaChannelLister
+
+	client     sarama.Client
+	producer   sarama.SyncProducer
+	receiver   *eventingchannels.MessageReceiver
+	dispatcher *eventingchannels.MessageDispatcherImpl
+
+	m        sync.Mutex
+	hosts    map[string]eventingchannels.ChannelReference
+	channels map[string]*channel
+}
+
+// Check that our Dispatcher implements controller.Reconciler and http.Handler
+var _ controller.Reconciler = (*Dispatcher)(nil)
+var _ nethttp.Handler = (*Dispatcher)(nil)
+
+// channel is a KafkaChannel that we dispatch.
+type channel struct {
+	host          string
+	subscriptions map[types.UID]*subscription
+}
+
+// subscription is a running consumer group of a single subscriber.
+type subscription struct {
+	spec   eventingduckv1alpha1.SubscriberSpec
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewController creates a Dispatcher producing to and consuming from the
+// given brokers.
+func NewController(ctx context.Context, brokers []string) (*controller.Impl, *Dispatcher, error) {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	channelInformer := kafkachannelinformer.Get(ctx)
+
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_0_0_0
+	cfg.ClientID = controllerAgentName
+	cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+	// We only accept events once every in-sync replica has them.
+	cfg.Producer.RequiredAcks = sarama.WaitForAll
+	cfg.Producer.Return.Successes = true
+	client, err := sarama.NewClient(brokers, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating Kafka client: %w", err)
+	}
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("creating Kafka producer: %w", err)
+	}
+
+	d := &Dispatcher{
+		ctx:        ctx,
+		logger:     logger,
+		lister:     channelInformer.Lister(),
+		client:     client,
+		producer:   producer,
+		dispatcher: eventingchannels.NewMessageDispatcher(logger.Desugar()),
+		hosts:      make(map[string]eventingchannels.ChannelReference),
+		channels:   make(map[string]*channel),
+	}
+	d.receiver, err = eventingchannels.NewMessageReceiver(d.produce, logger.Desugar(),
+		eventingchannels.ResolveMessageChannelFromHostHeader(d.channelFromHost))
+	if err != nil {
+		producer.Close()
+		return nil, nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		producer.Close()
+	}()
+
+	impl := controller.NewImpl(d, logger, controllerAgentName)
+
+	logger.Info("Setting up event handlers")
+	channelInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	return impl, d, nil
+}
+
+// ServeHTTP implements http.Handler
+func (d *Dispatcher) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	d.receiver.ServeHTTP(w, r)
+}
+
+// produce writes the event to the topic of its channel, returning once
+// Kafka has acknowledged it.
+func (d *Dispatcher) produce(ctx context.Context, ref eventingchannels.ChannelReference, msg binding.Message, transformers []binding.Transformer, _ nethttp.Header) error {
+	pm := &sarama.ProducerMessage{
+		Topic: utils.TopicName(utils.KafkaChannelSeparator, ref.Namespace, ref.Name),
+	}
+	if err := protocolkafka.WriteProducerMessage(ctx, msg, pm, transformers...); err != nil {
+		return err
+	}
+	_, _, err := d.producer.SendMessage(pm)
+	return err
+}
+
+func (d *Dispatcher) channelFromHost(host string) (eventingchannels.ChannelReference, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	ref, ok := d.hosts[host]
+	if !ok {
+		return ref, eventingchannels.UnknownHostError(host)
+	}
+	return ref, nil
+}
+
+// Reconcile implements controller.Reconciler
+func (d *Dispatcher) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	kc, err := d.lister.KafkaChannels(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		d.forget(key)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// Until the controlplane has created the topic, there is nothing to
+	// produce to or consume from.
+	topic := kc.Status.GetCondition(v1alpha1.KafkaChannelConditionTopicReady)
+	if kc.DeletionTimestamp != nil || topic == nil || !topic.IsTrue() || kc.Status.Address == nil {
+		d.forget(key)
+		return nil
+	}
+
+	d.m.Lock()
+	ch, ok := d.channels[key]
+	if !ok {
+		ch = &channel{subscriptions: make(map[types.UID]*subscription)}
+		d.channels[key] = ch
+	}
+	if ch.host != kc.Status.Address.Hostname {
+		delete(d.hosts, ch.host)
+		ch.host = kc.Status.Address.Hostname
+		d.hosts[ch.host] = eventingchannels.ChannelReference{Namespace: namespace, Name: name}
+	}
+	d.m.Unlock()
+
+	want := make(map[types.UID]eventingduckv1alpha1.SubscriberSpec)
+	if kc.Spec.Subscribable != nil {
+		for _, sub := range kc.Spec.Subscribable.Subscribers {
+			want[sub.UID] = sub
+		}
+	}
+	for uid, sub := range ch.subscriptions {
+		if spec, ok := want[uid]; !ok || !equality.Semantic.DeepEqual(spec, sub.spec) {
+			sub.stop()
+			delete(ch.subscriptions, uid)
+		}
+	}
+	for uid, spec := range want {
+		if _, ok := ch.subscriptions[uid]; !ok {
+			ch.subscriptions[uid] = d.subscribe(kc, spec)
+		}
+	}
+	return nil
+}
+
+// forget stops dispatching the given channel.
+func (d *Dispatcher) forget(key string) {
+	d.m.Lock()
+	ch, ok := d.channels[key]
+	if ok {
+		delete(d.channels, key)
+		delete(d.hosts, ch.host)
+	}
+	d.m.Unlock()
+	if ok {
+		for _, sub := range ch.subscriptions {
+			sub.stop()
+		}
+	}
+}
+
+// subscribe joins the consumer group of the subscriber, delivering the
+// events of the channel to it until stopped.
+func (d *Dispatcher) subscribe(kc *v1alpha1.KafkaChannel, spec eventingduckv1alpha1.SubscriberSpec) *subscription {
+	ctx, cancel := context.WithCancel(d.ctx)
+	sub := &subscription{
+		spec:   spec,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	topic := utils.TopicName(utils.KafkaChannelSeparator, kc.Namespace, kc.Name)
+	groupID := fmt.Sprintf("kafka.%s.%s.%s", kc.Namespace, kc.Name, spec.UID)
+	logger := d.logger.With(zap.String("channel", kc.Namespace+"/"+kc.Name), zap.String("subscriber", string(spec.UID)))
+
+	go func() {
+		defer close(sub.done)
+		h := &handler{
+			logger:     logger,
+			spec:       spec,
+			dispatcher: d.dispatcher,
+		}
+		for ctx.Err() == nil {
+			group, err := sarama.NewConsumerGroupFromClient(groupID, d.client)
+			if err == nil {
+				logger.Infof("Joining consumer group %q", groupID)
+				for ctx.Err() == nil && err == nil {
+					// Consume returns whenever the group rebalances.
+					err = group.Consume(ctx, []string{topic}, h)
+				}
+				group.Close()
+			}
+			if err != nil && ctx.Err() == nil {
+				logger.Errorw("Error consuming "+topic, zap.Error(err))
+				select {
+				case <-ctx.Done():
+				case <-time.After(retryPeriod):
+				}
+			}
+		}
+	}()
+	return sub
+}
+
+// stop stops the subscription, waiting for it to leave its consumer group.
+func (s *subscription) stop() {
+	s.cancel()
+	<-s.done
+}
+
+// handler implements sarama.ConsumerGroupHandler, delivering the messages
+// of a channel to one of its subscribers.
+type handler struct {
+	logger     *zap.SugaredLogger
+	spec       eventingduckv1alpha1.SubscriberSpec
+	dispatcher *eventingchannels.MessageDispatcherImpl
+}
+
+var _ sarama.ConsumerGroupHandler = (*handler)(nil)
+
+// Setup implements sarama.ConsumerGroupHandler
+func (h *handler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup implements sarama.ConsumerGroupHandler
+func (h *handler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim implements sarama.ConsumerGroupHandler.  We mark messages
+// once they have been delivered, or given up on after retries, so that a
+// replica taking over the partition resumes from there.
+func (h *handler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	for msg := range claim.Messages() {
+		if err := h.deliver(ctx, msg); err != nil {
+			return err
+		}
+		session.MarkMessage(msg, "")
+	}
+	return nil
+}
+
+// deliver sends the message to the subscriber and its reply to the
+// reply URI, retrying according to the subscriber's delivery spec before
+// handing it to the dead letter sink.  It only fails when we lose the
+// claim on the message.
+func (h *handler) deliver(ctx context.Context, cm *sarama.ConsumerMessage) error {
+	msg := protocolkafka.NewMessageFromConsumerMessage(cm)
+	if msg.ReadEncoding() == binding.EncodingUnknown {
+		h.logger.Errorf("Dropping message with unknown encoding at partition %d, offset %d", cm.Partition, cm.Offset)
+		return nil
+	}
+	rp, err := NewRetryPolicy(h.spec.Delivery)
+	if err != nil {
+		h.logger.Warnw("Invalid delivery spec, using the defaults", zap.Error(err))
+	}
+	destination, reply, deadLetter := toURL(h.spec.SubscriberURI), toURL(h.spec.ReplyURI), toURL(h.spec.DeadLetterSinkURI)
+
+	for attempt := 0; ; attempt++ {
+		// Only the last attempt falls back on the dead letter sink.
+		var dls *url.URL
+		if attempt >= int(rp.Retry) {
+			dls = deadLetter
+		}
+		err := h.dispatcher.DispatchMessage(ctx, msg, nil, destination, reply, dls)
+		if err == nil {
+			return nil
+		} else if attempt >= int(rp.Retry) {
+			h.logger.Errorw(fmt.Sprintf("Giving up on message at partition %d, offset %d", cm.Partition, cm.Offset), zap.Error(err))
+			return nil
+		}
+		h.logger.Warnw("Failed to deliver message, retrying", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(rp.Backoff(attempt)):
+		}
+	}
+}
+
+func toURL(u *apis.URL) *url.URL {
+	if u.IsEmpty() {
+		return nil
+	}
+	return u.URL()
+}
@@ -0,0 +1,381 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkasource
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"knative.dev/eventing-contrib/kafka/source/pkg/apis/sources/v1alpha1"
+	kafkasourceinformer "knative.dev/eventing-contrib/kafka/source/pkg/client/injection/informers/sources/v1alpha1/kafkasource"
+	listers "knative.dev/eventing-contrib/kafka/source/pkg/client/listers/sources/v1alpha1"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	secretinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/secret"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+)
+
+const (
+	controllerAgentName = "kafka-source-adapter"
+
+	// The timings with which we lease buckets.
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+
+	// The bounds of the backoff with which we retry delivery to a sink.
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 30 * time.Second
+)
+
+// Adapter implements controller.Reconciler, consuming the topics of the
+// KafkaSources in the buckets that we lead.
+type Adapter struct {
+	// ctx bounds the lifetime of our consumers.
+	ctx      context.Context
+	identity string
+
+	kubeclient   kubernetes.Interface
+	ceclient     cloudevents.Client
+	lister       listers.KafkaSourceLister
+	secretLister corev1listers.SecretLister
+
+	// enqueue queues the KafkaSources of buckets that change hands.
+	enqueue func(obj interface{})
+
+	// newConsumerGroup joins the consumer group of a KafkaSource.
+	newConsumerGroup func(addrs []string, groupID string, cfg *sarama.Config) (sarama.ConsumerGroup, error)
+
+	m         sync.Mutex
+	buckets   sets.Int
+	consumers map[string]*consumer
+}
+
+// Check that our Adapter implements controller.Reconciler
+var _ controller.Reconciler = (*Adapter)(nil)
+
+// consumer is a running consumer group of a single KafkaSource.
+type consumer struct {
+	// settings is what the consumer was started with, restarting it
+	// when they change.
+	settings settings
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+type settings struct {
+	Spec    v1alpha1.KafkaSourceSpec
+	Labels  map[string]string
+	Sink    string
+	Secrets map[string]string
+}
+
+// NewController creates the Adapter of this replica, identified by the
+// given name, and starts leasing buckets.
+func NewController(ctx context.Context, identity string) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	sourceInformer := kafkasourceinformer.Get(ctx)
+	secretInformer := secretinformer.Get(ctx)
+
+	ceclient, err := cloudevents.NewDefaultClient()
+	if err != nil {
+		logger.Fatalw("Failed to create CloudEvents client", zap.Error(err))
+	}
+
+	a := &Adapter{
+		ctx:          ctx,
+		identity:     identity,
+		kubeclient:   kubeclient.Get(ctx),
+		ceclient:     ceclient,
+		lister:       sourceInformer.Lister(),
+		secretLister: secretInformer.Lister(),
+
+		newConsumerGroup: sarama.NewConsumerGroup,
+
+		buckets:   sets.NewInt(),
+		consumers: make(map[string]*consumer),
+	}
+	impl := controller.NewImpl(a, logger, controllerAgentName)
+	a.enqueue = impl.Enqueue
+
+	logger.Info("Setting up event handlers")
+	sourceInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	// Restart the consumers of sources whose credentials change.
+	secretInformer.Informer().AddEventHandler(controller.HandleAll(func(obj interface{}) {
+		s, ok := obj.(*corev1.Secret)
+		if !ok {
+			return
+		}
+		srcs, err := a.lister.KafkaSources(s.Namespace).List(labels.Everything())
+		if err != nil {
+			return
+		}
+		for _, src := range srcs {
+			if sets.NewString(SecretNames(src)...).Has(s.Name) {
+				impl.Enqueue(src)
+			}
+		}
+	}))
+
+	for b := 0; b < Buckets; b++ {
+		go a.lease(ctx, b)
+	}
+	return impl
+}
+
+// lease contends for the Lease of the given bucket until the context is
+// cancelled, consuming its KafkaSources while we hold it.
+func (a *Adapter) lease(ctx context.Context, bucket int) {
+	logger := logging.FromContext(ctx)
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Namespace: system.Namespace(),
+			Name:      LeaseName(bucket),
+		},
+		Client:     a.kubeclient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: a.identity},
+	}
+	for ctx.Err() == nil {
+		le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   leaseDuration,
+			RenewDeadline:   renewDeadline,
+			RetryPeriod:     retryPeriod,
+			ReleaseOnCancel: true,
+			Name:            LeaseName(bucket),
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(context.Context) {
+					logger.Infof("Leading bucket %d", bucket)
+					a.setBucket(bucket, true)
+				},
+				OnStoppedLeading: func() {
+					logger.Infof("Stopped leading bucket %d", bucket)
+					a.setBucket(bucket, false)
+				},
+			},
+		})
+		if err != nil {
+			logger.Fatalw("Failed to create leader elector", zap.Error(err))
+		}
+		// Run returns when we lose the Lease, after which we contend again.
+		le.Run(ctx)
+	}
+}
+
+// setBucket records whether we lead the given bucket, and queues its
+// KafkaSources to start or stop their consumers.
+func (a *Adapter) setBucket(bucket int, leading bool) {
+	a.m.Lock()
+	if leading {
+		a.buckets.Insert(bucket)
+	} else {
+		a.buckets.Delete(bucket)
+	}
+	a.m.Unlock()
+
+	srcs, err := a.lister.List(labels.Everything())
+	if err != nil {
+		return
+	}
+	for _, src := range srcs {
+		if Bucket(src) == bucket {
+			a.enqueue(src)
+		}
+	}
+}
+
+// Reconcile implements controller.Reconciler
+func (a *Adapter) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	src, err := a.lister.KafkaSources(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		a.stop(key)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	a.m.Lock()
+	leading := a.buckets.Has(Bucket(src))
+	a.m.Unlock()
+	if !leading || src.DeletionTimestamp != nil || src.Status.SinkURI == nil {
+		a.stop(key)
+		return nil
+	}
+
+	cfg, err := NewConfig(src, a.secretLister)
+	if err != nil {
+		a.stop(key)
+		return err
+	}
+	want := settings{
+		Spec:    src.Spec,
+		Labels:  src.Labels,
+		Sink:    src.Status.SinkURI.String(),
+		Secrets: make(map[string]string),
+	}
+	for _, name := range SecretNames(src) {
+		if s, err := a.secretLister.Secrets(namespace).Get(name); err == nil {
+			want.Secrets[name] = s.ResourceVersion
+		}
+	}
+
+	a.m.Lock()
+	c, ok := a.consumers[key]
+	a.m.Unlock()
+	if ok && equality.Semantic.DeepEqual(c.settings, want) {
+		return nil
+	}
+	a.stop(key)
+
+	group, err := a.newConsumerGroup(Servers(src), src.Spec.ConsumerGroup, cfg)
+	if err != nil {
+		return err
+	}
+	cctx, cancel := context.WithCancel(a.ctx)
+	c = &consumer{
+		settings: want,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	a.m.Lock()
+	a.consumers[key] = c
+	a.m.Unlock()
+
+	logger.Infof("Starting consumer group %q of %s", src.Spec.ConsumerGroup, key)
+	go func() {
+		defer close(c.done)
+		defer group.Close()
+		h := &handler{
+			logger:   logger,
+			src:      src.DeepCopy(),
+			sink:     want.Sink,
+			ceclient: a.ceclient,
+		}
+		for cctx.Err() == nil {
+			// Consume returns whenever the group rebalances.
+			if err := group.Consume(cctx, Topics(src), h); err != nil {
+				logger.Errorw("Error consuming "+key, zap.Error(err))
+				select {
+				case <-cctx.Done():
+				case <-time.After(retryPeriod):
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// stop stops the consumer of the given KafkaSource, if any, waiting for
+// it to leave its consumer group.
+func (a *Adapter) stop(key string) {
+	a.m.Lock()
+	c, ok := a.consumers[key]
+	delete(a.consumers, key)
+	a.m.Unlock()
+	if ok {
+		c.cancel()
+		<-c.done
+	}
+}
+
+// handler implements sarama.ConsumerGroupHandler, delivering the messages
+// of a KafkaSource to its sink.
+type handler struct {
+	logger   *zap.SugaredLogger
+	src      *v1alpha1.KafkaSource
+	sink     string
+	ceclient cloudevents.Client
+}
+
+var _ sarama.ConsumerGroupHandler = (*handler)(nil)
+
+// Setup implements sarama.ConsumerGroupHandler
+func (h *handler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup implements sarama.ConsumerGroupHandler
+func (h *handler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim implements sarama.ConsumerGroupHandler.  We only mark a
+// message once its sink has accepted it, so a partition stalls on a sink
+// that keeps failing rather than skipping over messages.
+func (h *handler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	for msg := range claim.Messages() {
+		event, err := toEvent(ctx, h.src, msg)
+		if err != nil {
+			// Retrying won't make a malformed message any better.
+			h.logger.Errorw("Dropping malformed message", zap.String("topic", msg.Topic),
+				zap.Int32("partition", msg.Partition), zap.Int64("offset", msg.Offset), zap.Error(err))
+			session.MarkMessage(msg, "")
+			continue
+		}
+		if err := h.send(ctx, event); err != nil {
+			return err
+		}
+		session.MarkMessage(msg, "")
+	}
+	return nil
+}
+
+// send delivers the event to the sink, backing off until it succeeds or
+// we lose the claim.
+func (h *handler) send(ctx context.Context, event *cloudevents.Event) error {
+	ctx = cloudevents.ContextWithTarget(ctx, h.sink)
+	for backoff := minBackoff; ; backoff *= 2 {
+		result := h.ceclient.Send(ctx, *event)
+		if cloudevents.IsACK(result) {
+			return nil
+		}
+		h.logger.Warnw("Failed to deliver event", zap.String("id", event.ID()), zap.Error(result))
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
@@ -0,0 +1,401 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/eventing-contrib/kafka/source/pkg/apis/sources/v1alpha1"
+	listers "knative.dev/eventing-contrib/kafka/source/pkg/client/listers/sources/v1alpha1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/logging"
+)
+
+const timeout = 5 * time.Second
+
+// fakeSession implements sarama.ConsumerGroupSession, recording the
+// offsets of the messages marked consumed.
+type fakeSession struct {
+	ctx context.Context
+
+	m      sync.Mutex
+	marked []int64
+}
+
+var _ sarama.ConsumerGroupSession = (*fakeSession)(nil)
+
+func (s *fakeSession) Claims() map[string][]int32                        { return nil }
+func (s *fakeSession) MemberID() string                                  { return "member" }
+func (s *fakeSession) GenerationID() int32                               { return 1 }
+func (s *fakeSession) MarkOffset(string, int32, int64, string)           {}
+func (s *fakeSession) ResetOffset(string, int32, int64, string)          {}
+func (s *fakeSession) Context() context.Context                          { return s.ctx }
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) { s.mark(msg.Offset) }
+
+func (s *fakeSession) mark(offset int64) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.marked = append(s.marked, offset)
+}
+
+func (s *fakeSession) offsets() []int64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]int64(nil), s.marked...)
+}
+
+// fakeClaim implements sarama.ConsumerGroupClaim over a channel of
+// messages.
+type fakeClaim struct {
+	messages chan *sarama.ConsumerMessage
+}
+
+var _ sarama.ConsumerGroupClaim = (*fakeClaim)(nil)
+
+func (c *fakeClaim) Topic() string                            { return "topic" }
+func (c *fakeClaim) Partition() int32                         { return 0 }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return 0 }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// fakeGroup implements sarama.ConsumerGroup, handing its single claim to
+// the handler for as long as the session lasts, like a group that never
+// rebalances.
+type fakeGroup struct {
+	topics  []string
+	claim   *fakeClaim
+	session *fakeSession
+	closed  chan struct{}
+}
+
+var _ sarama.ConsumerGroup = (*fakeGroup)(nil)
+
+func newFakeGroup() *fakeGroup {
+	return &fakeGroup{
+		claim:   &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 10)},
+		session: &fakeSession{},
+		closed:  make(chan struct{}),
+	}
+}
+
+func (g *fakeGroup) Consume(ctx context.Context, topics []string, h sarama.ConsumerGroupHandler) error {
+	g.topics = topics
+	g.session.ctx = ctx
+	if err := h.Setup(g.session); err != nil {
+		return err
+	}
+	errCh := make(chan error)
+	go func() {
+		errCh <- h.ConsumeClaim(g.session, g.claim)
+	}()
+	// Like sarama, end the claim when the session ends.
+	<-ctx.Done()
+	close(g.claim.messages)
+	if err := <-errCh; err != nil {
+		return err
+	}
+	return h.Cleanup(g.session)
+}
+
+func (g *fakeGroup) Errors() <-chan error { return nil }
+
+func (g *fakeGroup) Close() error {
+	close(g.closed)
+	return nil
+}
+
+// fakeClient implements cloudevents.Client, failing to send the first
+// `failures` events.
+type fakeClient struct {
+	m        sync.Mutex
+	failures int
+	sent     []cloudevents.Event
+}
+
+var _ cloudevents.Client = (*fakeClient)(nil)
+
+func (c *fakeClient) Send(ctx context.Context, event cloudevents.Event) protocol.Result {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.sent = append(c.sent, event)
+	if c.failures > 0 {
+		c.failures--
+		return errors.New("sink unavailable")
+	}
+	return nil
+}
+
+func (c *fakeClient) Request(ctx context.Context, event cloudevents.Event) (*cloudevents.Event, protocol.Result) {
+	return nil, c.Send(ctx, event)
+}
+
+func (c *fakeClient) StartReceiver(context.Context, interface{}) error {
+	return nil
+}
+
+func (c *fakeClient) events() []cloudevents.Event {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return append([]cloudevents.Event(nil), c.sent...)
+}
+
+func source(name string) *v1alpha1.KafkaSource {
+	return &v1alpha1.KafkaSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      name,
+		},
+		Spec: v1alpha1.KafkaSourceSpec{
+			BootstrapServers: "kafka:9092",
+			Topics:           "topic",
+			ConsumerGroup:    "group",
+		},
+		Status: v1alpha1.KafkaSourceStatus{
+			SourceStatus: duckv1.SourceStatus{
+				SinkURI: apis.HTTP("sink.ns.svc.cluster.local"),
+			},
+		},
+	}
+}
+
+// sourcesInBuckets returns a source in each of two different buckets.
+func sourcesInBuckets() (*v1alpha1.KafkaSource, *v1alpha1.KafkaSource) {
+	first := source("source-0")
+	for i := 1; ; i++ {
+		if other := source(fmt.Sprintf("source-%d", i)); Bucket(other) != Bucket(first) {
+			return first, other
+		}
+	}
+}
+
+type testAdapter struct {
+	*Adapter
+
+	ceclient *fakeClient
+	enqueued []string
+	groups   []*fakeGroup
+}
+
+func newTestAdapter(t *testing.T, srcs ...*v1alpha1.KafkaSource) *testAdapter {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, src := range srcs {
+		indexer.Add(src)
+	}
+	ta := &testAdapter{ceclient: &fakeClient{}}
+	ta.Adapter = &Adapter{
+		ctx:          ctx,
+		identity:     "adapter-0",
+		ceclient:     ta.ceclient,
+		lister:       listers.NewKafkaSourceLister(indexer),
+		secretLister: corev1listers.NewSecretLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+		enqueue: func(obj interface{}) {
+			key, _ := cache.MetaNamespaceKeyFunc(obj)
+			ta.enqueued = append(ta.enqueued, key)
+		},
+		newConsumerGroup: func([]string, string, *sarama.Config) (sarama.ConsumerGroup, error) {
+			g := newFakeGroup()
+			ta.groups = append(ta.groups, g)
+			return g, nil
+		},
+		buckets:   sets.NewInt(),
+		consumers: make(map[string]*consumer),
+	}
+	return ta
+}
+
+func TestBucket(t *testing.T) {
+	src := source("source")
+	b := Bucket(src)
+	if b < 0 || b >= Buckets {
+		t.Errorf("Bucket() = %d, wanted [0, %d)", b, Buckets)
+	}
+	if got := Bucket(source("source")); got != b {
+		t.Errorf("Bucket() = %d, wanted the stable %d", got, b)
+	}
+	if got, want := LeaseName(3), "kafkasource-bucket-03"; got != want {
+		t.Errorf("LeaseName() = %q, wanted %q", got, want)
+	}
+}
+
+func TestSetBucket(t *testing.T) {
+	first, other := sourcesInBuckets()
+	ta := newTestAdapter(t, first, other)
+
+	ta.setBucket(Bucket(first), true)
+	if !ta.buckets.Has(Bucket(first)) {
+		t.Errorf("buckets = %v, wanted %d", ta.buckets.List(), Bucket(first))
+	}
+	if got, want := ta.enqueued, []string{"ns/" + first.Name}; !equalStrings(got, want) {
+		t.Errorf("leading enqueued %v, wanted %v", got, want)
+	}
+
+	ta.enqueued = nil
+	ta.setBucket(Bucket(first), false)
+	if ta.buckets.Len() != 0 {
+		t.Errorf("buckets = %v, wanted none", ta.buckets.List())
+	}
+	if got, want := ta.enqueued, []string{"ns/" + first.Name}; !equalStrings(got, want) {
+		t.Errorf("losing enqueued %v, wanted %v", got, want)
+	}
+}
+
+func TestReconcileConsumesLedBuckets(t *testing.T) {
+	src := source("source")
+	key := "ns/" + src.Name
+	ta := newTestAdapter(t, src)
+
+	// We don't consume the sources of buckets we don't lead.
+	if err := ta.Reconcile(context.Background(), key); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+	if len(ta.groups) != 0 {
+		t.Fatalf("Reconcile() joined %d consumer groups without leading the bucket", len(ta.groups))
+	}
+
+	ta.setBucket(Bucket(src), true)
+	if err := ta.Reconcile(context.Background(), key); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+	if len(ta.groups) != 1 {
+		t.Fatalf("Reconcile() joined %d consumer groups, wanted 1", len(ta.groups))
+	}
+	g := ta.groups[0]
+	g.claim.messages <- &sarama.ConsumerMessage{
+		Topic:     "topic",
+		Partition: 0,
+		Offset:    42,
+		Value:     []byte(`{"hello":"world"}`),
+		Timestamp: time.Now(),
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, timeout, func() (bool, error) {
+		return len(g.session.offsets()) == 1, nil
+	}); err != nil {
+		t.Fatal("Timed out waiting for the message to be marked")
+	}
+	if got, want := g.topics, []string{"topic"}; !equalStrings(got, want) {
+		t.Errorf("Consume() topics = %v, wanted %v", got, want)
+	}
+	events := ta.ceclient.events()
+	if len(events) != 1 {
+		t.Fatalf("Sent %d events, wanted 1", len(events))
+	}
+	if got, want := events[0].ID(), "partition:0/offset:42"; got != want {
+		t.Errorf("ID() = %q, wanted %q", got, want)
+	}
+	if got, want := events[0].Source(), v1alpha1.KafkaEventSource("ns", src.Name, "topic"); got != want {
+		t.Errorf("Source() = %q, wanted %q", got, want)
+	}
+
+	// Reconciling unchanged settings keeps the running consumer.
+	if err := ta.Reconcile(context.Background(), key); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+	if len(ta.groups) != 1 {
+		t.Errorf("Reconcile() joined %d consumer groups, wanted 1", len(ta.groups))
+	}
+
+	// Losing the bucket stops the consumer.
+	ta.setBucket(Bucket(src), false)
+	if err := ta.Reconcile(context.Background(), key); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+	select {
+	case <-g.closed:
+	case <-time.After(timeout):
+		t.Fatal("Timed out waiting for the consumer group to close")
+	}
+	if _, ok := ta.consumers[key]; ok {
+		t.Error("The consumer is still running after losing the bucket")
+	}
+}
+
+func TestConsumeClaimRetriesDelivery(t *testing.T) {
+	ceclient := &fakeClient{failures: 2}
+	h := &handler{
+		logger:   logging.FromContext(context.Background()),
+		src:      source("source"),
+		sink:     "http://sink.ns.svc.cluster.local",
+		ceclient: ceclient,
+	}
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "topic", Offset: 7, Value: []byte("hello")}
+	close(claim.messages)
+
+	if err := h.ConsumeClaim(session, claim); err != nil {
+		t.Fatalf("ConsumeClaim() = %v", err)
+	}
+	if got, want := len(ceclient.events()), 3; got != want {
+		t.Errorf("Sent %d times, wanted %d", got, want)
+	}
+	if got := session.offsets(); len(got) != 1 || got[0] != 7 {
+		t.Errorf("Marked %v, wanted [7]", got)
+	}
+}
+
+func TestConsumeClaimStopsWithSession(t *testing.T) {
+	ceclient := &fakeClient{failures: 1000}
+	h := &handler{
+		logger:   logging.FromContext(context.Background()),
+		src:      source("source"),
+		sink:     "http://sink.ns.svc.cluster.local",
+		ceclient: ceclient,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &fakeSession{ctx: ctx}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "topic", Offset: 7, Value: []byte("hello")}
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- h.ConsumeClaim(session, claim)
+	}()
+	cancel()
+	select {
+	case err := <-errCh:
+		if err != context.Canceled {
+			t.Errorf("ConsumeClaim() = %v, wanted %v", err, context.Canceled)
+		}
+	case <-time.After(timeout):
+		t.Fatal("Timed out waiting for ConsumeClaim to return")
+	}
+	// The message the sink never accepted is left for the next session.
+	if got := session.offsets(); len(got) != 0 {
+		t.Errorf("Marked %v, wanted none", got)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	return sets.NewString(a...).Equal(sets.NewString(b...)) && len(a) == len(b)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kafkasource consumes the topics of every KafkaSource in the
+// cluster from a shared, horizontally scalable adapter.  The sources are
+// hashed into buckets, each of which is leased by a single replica.
+package kafkasource
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/eventing-contrib/kafka/source/pkg/apis/sources/v1alpha1"
+)
+
+// Buckets is the number of buckets into which we hash KafkaSources.  It
+// bounds how many adapter replicas may share the work.
+const Buckets = 10
+
+// Bucket returns the bucket of the given KafkaSource.
+func Bucket(src *v1alpha1.KafkaSource) int {
+	h := fnv.New32a()
+	h.Write([]byte(src.Namespace + "/" + src.Name))
+	return int(h.Sum32() % Buckets)
+}
+
+// LeaseName returns the name of the Lease held by the adapter replica
+// consuming the KafkaSources in the given bucket.
+func LeaseName(bucket int) string {
+	return fmt.Sprintf("kafkasource-bucket-%02d", bucket)
+}
+
+// Servers returns the bootstrap servers of the KafkaSource.
+func Servers(src *v1alpha1.KafkaSource) []string {
+	return splitOnCommas(src.Spec.BootstrapServers)
+}
+
+// Topics returns the topics consumed by the KafkaSource.
+func Topics(src *v1alpha1.KafkaSource) []string {
+	return splitOnCommas(src.Spec.Topics)
+}
+
+func splitOnCommas(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// SecretNames returns the names of the Secrets referenced by the
+// KafkaSource's SASL and TLS settings.
+func SecretNames(src *v1alpha1.KafkaSource) []string {
+	var names []string
+	for _, v := range secretValues(src) {
+		if v.SecretKeyRef != nil {
+			names = append(names, v.SecretKeyRef.Name)
+		}
+	}
+	return names
+}
+
+func secretValues(src *v1alpha1.KafkaSource) []v1alpha1.SecretValueFromSource {
+	return []v1alpha1.SecretValueFromSource{
+		src.Spec.Net.SASL.User,
+		src.Spec.Net.SASL.Password,
+		src.Spec.Net.TLS.Cert,
+		src.Spec.Net.TLS.Key,
+		src.Spec.Net.TLS.CACert,
+	}
+}
+
+// NewConfig returns the configuration with which we connect to the
+// brokers of the KafkaSource, reading its credentials from Secrets in
+// its namespace.
+func NewConfig(src *v1alpha1.KafkaSource, secretLister corev1listers.SecretLister) (*sarama.Config, error) {
+	if len(Servers(src)) == 0 {
+		return nil, errors.New("no bootstrap servers specified")
+	}
+	if len(Topics(src)) == 0 {
+		return nil, errors.New("no topics specified")
+	}
+
+	value := func(v v1alpha1.SecretValueFromSource) (string, error) {
+		if v.SecretKeyRef == nil {
+			return "", nil
+		}
+		s, err := secretLister.Secrets(src.Namespace).Get(v.SecretKeyRef.Name)
+		if err != nil {
+			return "", err
+		}
+		b, ok := s.Data[v.SecretKeyRef.Key]
+		if !ok {
+			return "", fmt.Errorf("secret %q has no key %q", s.Name, v.SecretKeyRef.Key)
+		}
+		return string(b), nil
+	}
+
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_0_0_0
+	cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	if sasl := src.Spec.Net.SASL; sasl.Enable {
+		user, err := value(sasl.User)
+		if err != nil {
+			return nil, err
+		}
+		password, err := value(sasl.Password)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Net.SASL.Enable = true
+		cfg.Net.SASL.User = user
+		cfg.Net.SASL.Password = password
+	}
+
+	if t := src.Spec.Net.TLS; t.Enable {
+		cert, err := value(t.Cert)
+		if err != nil {
+			return nil, err
+		}
+		key, err := value(t.Key)
+		if err != nil {
+			return nil, err
+		}
+		caCert, err := value(t.CACert)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig := &tls.Config{}
+		if cert != "" && key != "" {
+			pair, err := tls.X509KeyPair([]byte(cert), []byte(key))
+			if err != nil {
+				return nil, fmt.Errorf("loading the client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+		if caCert != "" {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM([]byte(caCert)) {
+				return nil, errors.New("no certificates found in the CA certificate")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		cfg.Net.TLS.Enable = true
+		cfg.Net.TLS.Config = tlsConfig
+	}
+
+	return cfg, cfg.Validate()
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkasource
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/protocol/kafka_sarama"
+	"knative.dev/eventing-contrib/kafka/source/pkg/apis/sources/v1alpha1"
+)
+
+var badExtensionChars = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// toEvent returns the CloudEvent carried by the message, or else wraps
+// its value in one attributed to the KafkaSource.
+func toEvent(ctx context.Context, src *v1alpha1.KafkaSource, cm *sarama.ConsumerMessage) (*cloudevents.Event, error) {
+	msg := kafka_sarama.NewMessageFromConsumerMessage(cm)
+	if msg.ReadEncoding() != binding.EncodingUnknown {
+		return binding.ToEvent(ctx, msg)
+	}
+
+	event := cloudevents.NewEvent()
+	event.SetID(fmt.Sprintf("partition:%d/offset:%d", cm.Partition, cm.Offset))
+	event.SetTime(cm.Timestamp)
+	event.SetType(v1alpha1.KafkaEventType)
+	event.SetSource(v1alpha1.KafkaEventSource(src.Namespace, src.Name, cm.Topic))
+	event.SetSubject(fmt.Sprintf("partition:%d#%d", cm.Partition, cm.Offset))
+	if cm.Key != nil {
+		event.SetExtension("key", keyValue(src.Labels[v1alpha1.KafkaKeyTypeLabel], cm.Key))
+	}
+	for k, v := range msg.Headers {
+		event.SetExtension("kafkaheader"+badExtensionChars.ReplaceAllString(strings.ToLower(k), ""), string(v))
+	}
+	if err := event.SetData(msg.ContentType, msg.Value); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// keyValue decodes the message key according to the key type label of
+// the KafkaSource, falling back to the raw bytes when they don't fit.
+func keyValue(keyType string, key []byte) interface{} {
+	switch keyType {
+	case "int":
+		switch len(key) {
+		case 4:
+			return int32(binary.BigEndian.Uint32(key))
+		case 8:
+			return int64(binary.BigEndian.Uint64(key))
+		}
+		return key
+	case "float":
+		switch len(key) {
+		case 4:
+			return strconv.FormatFloat(float64(math.Float32frombits(binary.BigEndian.Uint32(key))), 'f', -1, 64)
+		case 8:
+			return strconv.FormatFloat(math.Float64frombits(binary.BigEndian.Uint64(key)), 'f', -1, 64)
+		}
+		return key
+	case "byte-array":
+		return key
+	default:
+		return string(key)
+	}
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package messagesink receives the CloudEvents sent to SlackSinks and
+// TwitterSinks, and posts them as messages.
+package messagesink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/dghubble/oauth1"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	secretinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/secret"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+	slacksinkinformer "github.com/mattmoor/mink/pkg/client/injection/informers/sinks/v1alpha1/slacksink"
+	twittersinkinformer "github.com/mattmoor/mink/pkg/client/injection/informers/sinks/v1alpha1/twittersink"
+)
+
+const (
+	// SlackSinksResource and TwitterSinksResource prefix the paths at
+	// which we receive the events of each kind of sink.
+	SlackSinksResource   = "slacksinks"
+	TwitterSinksResource = "twittersinks"
+
+	// maxAttempts is how many times we try to post a message before we
+	// let the sender retry the event.
+	maxAttempts = 3
+
+	// maxWait is how long we hold on to an event waiting for a rate
+	// limit, beyond which we ask the sender to retry it later.
+	maxWait = 10 * time.Second
+)
+
+// EnvConfig configures the receiver.  The base URLs of the APIs may be
+// pointed at fakes.
+type EnvConfig struct {
+	Port       int    `envconfig:"MESSAGE_SINK_PORT" default:"8095"`
+	SlackURL   string `envconfig:"SLACK_API_URL" default:"https://slack.com/api"`
+	TwitterURL string `envconfig:"TWITTER_API_URL" default:"https://api.twitter.com"`
+}
+
+// errNotFound is returned for events sent to sinks that don't exist.
+var errNotFound = errors.New("sink not found")
+
+// Handler accepts CloudEvents sent to SlackSinks and TwitterSinks at
+// /<resource>/<namespace>/<name> and posts a message for each of them.
+type Handler struct {
+	logger *zap.SugaredLogger
+	env    EnvConfig
+	client *http.Client
+
+	slackLister   cache.GenericLister
+	twitterLister cache.GenericLister
+	secretLister  corev1listers.SecretLister
+
+	m       sync.Mutex
+	senders map[string]*sender
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// sender posts the messages of a single sink, within its rate limit.
+type sender struct {
+	// version identifies the sink and secret we were configured from.
+	version string
+
+	tmpl    *template.Template
+	poster  poster
+	limiter *rate.Limiter
+}
+
+// NewHandler creates a Handler, watching the sinks and their secrets.
+func NewHandler(ctx context.Context, env EnvConfig) *Handler {
+	slackInformer := slacksinkinformer.Get(ctx).Informer()
+	twitterInformer := twittersinkinformer.Get(ctx).Informer()
+
+	h := &Handler{
+		logger: logging.FromContext(ctx),
+		env:    env,
+		client: &http.Client{Timeout: 30 * time.Second},
+		slackLister: cache.NewGenericLister(slackInformer.GetIndexer(),
+			v1alpha1.Resource(SlackSinksResource)),
+		twitterLister: cache.NewGenericLister(twitterInformer.GetIndexer(),
+			v1alpha1.Resource(TwitterSinksResource)),
+		secretLister: secretinformer.Get(ctx).Lister(),
+		senders:      make(map[string]*sender),
+	}
+	slackInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{DeleteFunc: h.forget(SlackSinksResource)})
+	twitterInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{DeleteFunc: h.forget(TwitterSinksResource)})
+	return h
+}
+
+// forget returns a function dropping the senders of deleted sinks.
+func (h *Handler) forget(resource string) func(interface{}) {
+	return func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		if acc, err := meta(obj); err == nil {
+			h.m.Lock()
+			defer h.m.Unlock()
+			delete(h.senders, resource+"/"+acc.GetNamespace()+"/"+acc.GetName())
+		}
+	}
+}
+
+func meta(obj interface{}) (metav1.Object, error) {
+	acc, ok := obj.(metav1.Object)
+	if !ok {
+		return nil, fmt.Errorf("%T is not an object", obj)
+	}
+	return acc, nil
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) != 3 {
+		http.NotFound(w, req)
+		return
+	}
+
+	s, err := h.sender(parts[0], parts[1], parts[2])
+	if err == errNotFound {
+		http.NotFound(w, req)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	ev, err := binding.ToEvent(req.Context(), cehttp.NewMessageFromHttpRequest(req))
+	if err != nil {
+		http.Error(w, "malformed CloudEvent: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	text, err := render(s.tmpl, ev)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch err := send(req.Context(), s, text).(type) {
+	case nil:
+		w.WriteHeader(http.StatusAccepted)
+	case *apiError:
+		h.logger.Warnw("Error posting message", zap.String("sink", req.URL.Path), zap.Error(err))
+		if err.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+		}
+		http.Error(w, err.Message, err.StatusCode)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// sender returns the sender of the named sink, (re)configuring it when
+// the sink or its secret changed.
+func (h *Handler) sender(resource, namespace, name string) (*sender, error) {
+	var (
+		lister cache.GenericLister
+		limit  rate.Limit
+		burst  int
+	)
+	switch resource {
+	case SlackSinksResource:
+		lister, limit, burst = h.slackLister, slackRate, 1
+	case TwitterSinksResource:
+		lister, limit, burst = h.twitterLister, twitterRate, 300
+	default:
+		return nil, errNotFound
+	}
+	obj, err := lister.ByNamespace(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil, errNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var secretName, tmpl string
+	switch sink := obj.(type) {
+	case *v1alpha1.SlackSink:
+		secretName, tmpl = sink.Spec.Secret.Name, sink.Spec.Template
+	case *v1alpha1.TwitterSink:
+		secretName, tmpl = sink.Spec.Secret.Name, sink.Spec.Template
+	}
+	secret, err := h.secretLister.Secrets(namespace).Get(secretName)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := meta(obj)
+	if err != nil {
+		return nil, err
+	}
+	key := resource + "/" + namespace + "/" + name
+	version := fmt.Sprintf("%d/%s", acc.GetGeneration(), secret.ResourceVersion)
+
+	h.m.Lock()
+	defer h.m.Unlock()
+	old, ok := h.senders[key]
+	if ok && old.version == version {
+		return old, nil
+	}
+
+	s := &sender{version: version}
+	if ok {
+		// Changes to the sink don't reset its rate limit.
+		s.limiter = old.limiter
+	} else {
+		s.limiter = rate.NewLimiter(limit, burst)
+	}
+	if s.tmpl, err = template.New("template").Parse(tmpl); err != nil {
+		return nil, err
+	}
+	switch sink := obj.(type) {
+	case *v1alpha1.SlackSink:
+		s.poster, err = h.slackPoster(sink, secret)
+	case *v1alpha1.TwitterSink:
+		s.poster, err = h.twitterPoster(secret)
+	}
+	if err != nil {
+		return nil, err
+	}
+	h.senders[key] = s
+	return s, nil
+}
+
+func (h *Handler) slackPoster(sink *v1alpha1.SlackSink, secret *corev1.Secret) (poster, error) {
+	token, ok := secret.Data[SlackTokenKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %q has no key %q", secret.Name, SlackTokenKey)
+	}
+	return &slackPoster{
+		client:  h.client,
+		url:     h.env.SlackURL,
+		token:   string(token),
+		channel: sink.Spec.Channel,
+	}, nil
+}
+
+func (h *Handler) twitterPoster(secret *corev1.Secret) (poster, error) {
+	keys := []string{TwitterConsumerKeyKey, TwitterConsumerSecretKeyKey, TwitterAccessTokenKey, TwitterAccessSecretKey}
+	values := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v, ok := secret.Data[k]
+		if !ok {
+			return nil, fmt.Errorf("secret %q has no key %q", secret.Name, k)
+		}
+		values = append(values, string(v))
+	}
+	config := oauth1.NewConfig(values[0], values[1])
+	// The signing client wraps ours, which it takes from the context.
+	ctx := context.WithValue(context.Background(), oauth1.HTTPClient, h.client)
+	return &twitterPoster{
+		client: config.Client(ctx, oauth1.NewToken(values[2], values[3])),
+		url:    h.env.TwitterURL,
+	}, nil
+}
+
+// send posts the message within the sender's rate limit, retrying the
+// failures that may be temporary.
+func send(ctx context.Context, s *sender, text string) error {
+	for attempt := 1; ; attempt++ {
+		r := s.limiter.Reserve()
+		if d := r.Delay(); d > maxWait {
+			r.Cancel()
+			return &apiError{StatusCode: http.StatusTooManyRequests, RetryAfter: d, Message: "rate limited"}
+		} else if err := sleep(ctx, d); err != nil {
+			return err
+		}
+
+		err := s.poster.post(ctx, text)
+		ae, ok := err.(*apiError)
+		if err == nil || !ok || !ae.temporary() || attempt == maxAttempts {
+			return err
+		}
+		delay := ae.RetryAfter
+		if delay == 0 {
+			delay = time.Duration(1<<uint(attempt)) * 250 * time.Millisecond
+		}
+		if delay > maxWait {
+			return ae
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messagesink
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// poster posts a message through an API.
+type poster interface {
+	post(ctx context.Context, text string) error
+}
+
+// apiError is a failure to post a message.
+type apiError struct {
+	// StatusCode is the HTTP status with which we answer the sender,
+	// which tells it whether to retry.
+	StatusCode int
+
+	// RetryAfter is how long the API asked us to wait, if it did.
+	RetryAfter time.Duration
+
+	Message string
+}
+
+// Error implements error
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+}
+
+// temporary returns whether posting the message again may succeed.
+func (e *apiError) temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// errorFromResponse returns the apiError of an unsuccessful response.
+// Errors of the client's making are permanent, but our sender may retry
+// when the API is unavailable or limits our rate.
+func errorFromResponse(resp *http.Response, message string) *apiError {
+	e := &apiError{StatusCode: resp.StatusCode, Message: message}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			e.RetryAfter = time.Duration(s) * time.Second
+		}
+	case resp.StatusCode >= 500:
+		e.StatusCode = http.StatusBadGateway
+	default:
+		e.StatusCode = http.StatusUnprocessableEntity
+	}
+	return e
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messagesink
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+)
+
+// templateData is what the templates of messages are executed against.
+type templateData struct {
+	ID              string
+	Source          string
+	Type            string
+	Subject         string
+	DataContentType string
+	Time            time.Time
+	Extensions      map[string]interface{}
+
+	// Data is the event's data, decoded when it holds JSON.
+	Data interface{}
+}
+
+// render executes the template of a message against the event.
+func render(tmpl *template.Template, ev *event.Event) (string, error) {
+	td := templateData{
+		ID:              ev.ID(),
+		Source:          ev.Source(),
+		Type:            ev.Type(),
+		Subject:         ev.Subject(),
+		DataContentType: ev.DataContentType(),
+		Time:            ev.Time(),
+		Extensions:      ev.Extensions(),
+	}
+	if data := ev.Data(); len(data) > 0 {
+		if err := json.Unmarshal(data, &td.Data); err != nil {
+			td.Data = string(data)
+		}
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, td); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
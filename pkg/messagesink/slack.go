@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messagesink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// SlackTokenKey is the key of the Slack token in the secrets of
+// SlackSinks, as with SlackBinding.
+const SlackTokenKey = "token"
+
+// slackRate is the rate at which Slack lets us post to a channel,
+// bursts excepted.
+var slackRate = rate.Every(time.Second)
+
+// slackPoster posts messages to a Slack channel with chat.postMessage.
+type slackPoster struct {
+	client  *http.Client
+	url     string
+	token   string
+	channel string
+}
+
+var _ poster = (*slackPoster)(nil)
+
+// post implements poster
+func (sp *slackPoster) post(ctx context.Context, text string) error {
+	body, err := json.Marshal(map[string]string{
+		"channel": sp.channel,
+		"text":    text,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, sp.url+"/chat.postMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+sp.token)
+
+	resp, err := sp.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return &apiError{StatusCode: http.StatusBadGateway, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errorFromResponse(resp, "chat.postMessage failed")
+	}
+
+	// Slack reports most failures in the body of successful responses.
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return &apiError{StatusCode: http.StatusBadGateway, Message: err.Error()}
+	}
+	switch {
+	case result.OK:
+		return nil
+	case result.Error == "internal_error" || result.Error == "fatal_error" ||
+		result.Error == "service_unavailable" || result.Error == "request_timeout":
+		return &apiError{StatusCode: http.StatusBadGateway, Message: result.Error}
+	default:
+		return &apiError{StatusCode: http.StatusUnprocessableEntity, Message: result.Error}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messagesink
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// The keys of the Twitter credentials in the secrets of TwitterSinks,
+	// as with TwitterBinding.
+	TwitterConsumerKeyKey       = "consumerKey"
+	TwitterConsumerSecretKeyKey = "consumerSecretKey"
+	TwitterAccessTokenKey       = "accessToken"
+	TwitterAccessSecretKey      = "accessSecret"
+
+	// maxTweetLength is the number of characters in a tweet, beyond which
+	// we truncate the text.
+	maxTweetLength = 280
+
+	// duplicateStatusCode is Twitter's error code for tweets identical to
+	// a recent one, e.g. when an event is redelivered.
+	duplicateStatusCode = 187
+)
+
+// twitterRate is the rate at which Twitter lets a user tweet, which is
+// 300 tweets every three hours.
+var twitterRate = rate.Every(3 * time.Hour / 300)
+
+// twitterPoster tweets with statuses/update.  Its client signs requests
+// with the user's credentials.
+type twitterPoster struct {
+	client *http.Client
+	url    string
+}
+
+var _ poster = (*twitterPoster)(nil)
+
+// post implements poster
+func (tp *twitterPoster) post(ctx context.Context, text string) error {
+	if r := []rune(text); len(r) > maxTweetLength {
+		text = string(r[:maxTweetLength-1]) + "…"
+	}
+	form := url.Values{"status": {text}}
+	req, err := http.NewRequest(http.MethodPost, tp.url+"/1.1/statuses/update.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := tp.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return &apiError{StatusCode: http.StatusBadGateway, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var result struct {
+		Errors []struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	message := "statuses/update failed"
+	if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && len(result.Errors) > 0 {
+		if result.Errors[0].Code == duplicateStatusCode {
+			// We already tweeted this.
+			return nil
+		}
+		message = result.Errors[0].Message
+	}
+	e := errorFromResponse(resp, message)
+	if e.StatusCode == http.StatusTooManyRequests && e.RetryAfter == 0 {
+		// Twitter tells us when our rate limit window resets instead.
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-Rate-Limit-Reset"), 10, 64); err == nil {
+			e.RetryAfter = time.Until(time.Unix(reset, 0))
+		}
+	}
+	return e
+}
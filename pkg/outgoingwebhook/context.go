@@ -0,0 +1,37 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package outgoingwebhook
+
+import (
+	"context"
+)
+
+// sinkImageKey is the key of the sidecar's image in the context.
+type sinkImageKey struct{}
+
+// WithSinkImage returns a context carrying the image of the sidecar that
+// WebhookBindings add to their subjects.
+func WithSinkImage(ctx context.Context, image string) context.Context {
+	return context.WithValue(ctx, sinkImageKey{}, image)
+}
+
+// SinkImageFrom returns the image of the sidecar, or "" when the context
+// carries none, in which case subjects are bound without the sidecar.
+func SinkImageFrom(ctx context.Context) string {
+	image, _ := ctx.Value(sinkImageKey{}).(string)
+	return image
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package outgoingwebhook signs the requests that workloads bound by a
+// WebhookBinding make to external webhooks, and implements the sidecar
+// that signs and delivers the CloudEvents those workloads send to it.
+package outgoingwebhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// URLEnv holds the external endpoint of the webhook.
+	URLEnv = "WEBHOOK_URL"
+
+	// SecretEnv holds the secret with which requests to the webhook are
+	// signed.
+	SecretEnv = "WEBHOOK_SECRET"
+
+	// SinkEnv holds the URL of the sidecar to which CloudEvents may be
+	// sent to be signed and delivered to the webhook.
+	SinkEnv = "WEBHOOK_SINK"
+
+	// DefaultSecretKey is the key of the signing secret in its Secret,
+	// unless the binding says otherwise.
+	DefaultSecretKey = "secret"
+
+	// SignatureHeader carries the signature of a request, which is the
+	// hex-encoded HMAC-SHA256 of its timestamp, a ".", and its body,
+	// prefixed with "sha256=".
+	SignatureHeader = "X-Mink-Signature"
+
+	// TimestampHeader carries the Unix time at which a request was signed,
+	// which receivers should check to reject replays.
+	TimestampHeader = "X-Mink-Timestamp"
+)
+
+var (
+	errNoSignature = errors.New("request is not signed")
+	errBadSig      = errors.New("signature does not match")
+	errExpired     = errors.New("signature has expired")
+)
+
+// Sign returns the signature of a request with the given body, signed with
+// the secret at the given time.
+func Sign(secret []byte, ts time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d.", ts.Unix())
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest signs the request with the secret, reading (and replacing)
+// its body.
+func SignRequest(req *http.Request, secret []byte) error {
+	var body []byte
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+		body = b
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	now := time.Now()
+	req.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(secret, now, body))
+	return nil
+}
+
+// Verify checks the signature of a request with the given body, as received
+// by a webhook, rejecting those signed longer than tolerance ago.
+func Verify(header http.Header, body, secret []byte, tolerance time.Duration) error {
+	sig, ts := header.Get(SignatureHeader), header.Get(TimestampHeader)
+	if sig == "" || ts == "" {
+		return errNoSignature
+	}
+	secs, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed %s: %v", TimestampHeader, err)
+	}
+	signed := time.Unix(secs, 0)
+	if !hmac.Equal([]byte(sig), []byte(Sign(secret, signed, body))) {
+		return errBadSig
+	}
+	if age := time.Since(signed); age > tolerance || age < -tolerance {
+		return errExpired
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package outgoingwebhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.uber.org/zap"
+)
+
+const (
+	// SinkContainerName is the name of the sidecar in bound Pods.
+	SinkContainerName = "webhook-sink"
+
+	// SinkURL is where the containers of bound Pods reach the sidecar.
+	SinkURL = "http://localhost:8099"
+
+	// maxAttempts is how many times we try to deliver an event before we
+	// let the sender retry it.
+	maxAttempts = 5
+
+	// maxWait is how long we wait between attempts, beyond which we ask
+	// the sender to retry the event later.
+	maxWait = 10 * time.Second
+)
+
+// EnvConfig configures the sidecar, from the environment its binding
+// injects.
+type EnvConfig struct {
+	URL    string `envconfig:"WEBHOOK_URL" required:"true"`
+	Secret string `envconfig:"WEBHOOK_SECRET" required:"true"`
+	Port   int    `envconfig:"WEBHOOK_SINK_PORT" default:"8099"`
+}
+
+// Handler accepts CloudEvents, and delivers each of them to the webhook in
+// the structured content mode, signed with the webhook's secret.
+type Handler struct {
+	logger *zap.SugaredLogger
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler creates a Handler delivering to the configured webhook.
+func NewHandler(logger *zap.SugaredLogger, env EnvConfig) *Handler {
+	return &Handler{
+		logger: logger,
+		url:    env.URL,
+		secret: []byte(env.Secret),
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// deliveryError is a failure to deliver an event to the webhook.
+type deliveryError struct {
+	// StatusCode is the HTTP status with which we answer the sender,
+	// which tells it whether to retry.
+	StatusCode int
+
+	// RetryAfter is how long the webhook asked us to wait, if it did.
+	RetryAfter time.Duration
+
+	Message string
+}
+
+// Error implements error
+func (e *deliveryError) Error() string {
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+}
+
+// temporary returns whether delivering the event again may succeed.
+func (e *deliveryError) temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	ev, err := binding.ToEvent(req.Context(), cehttp.NewMessageFromHttpRequest(req))
+	if err != nil {
+		http.Error(w, "malformed CloudEvent: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch err := h.deliver(req.Context(), body).(type) {
+	case nil:
+		w.WriteHeader(http.StatusAccepted)
+	case *deliveryError:
+		h.logger.Warnw("Error delivering event", zap.String("id", ev.ID()), zap.Error(err))
+		if err.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+		}
+		http.Error(w, err.Message, err.StatusCode)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// deliver posts the event to the webhook, retrying the failures that may
+// be temporary with exponential backoff.
+func (h *Handler) deliver(ctx context.Context, body []byte) error {
+	for attempt := 1; ; attempt++ {
+		err := h.post(ctx, body)
+		de, ok := err.(*deliveryError)
+		if err == nil || !ok || !de.temporary() || attempt == maxAttempts {
+			return err
+		}
+		delay := de.RetryAfter
+		if delay == 0 {
+			delay = time.Duration(1<<uint(attempt)) * 250 * time.Millisecond
+		}
+		if delay > maxWait {
+			return de
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// post makes a single, freshly signed, attempt at delivering the event.
+func (h *Handler) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", cloudevents.ApplicationCloudEventsJSON)
+	if err := SignRequest(req, h.secret); err != nil {
+		return err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &deliveryError{StatusCode: http.StatusBadGateway, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	de := &deliveryError{StatusCode: resp.StatusCode, Message: string(msg)}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			de.RetryAfter = time.Duration(s) * time.Second
+		}
+	case resp.StatusCode >= 500:
+		de.StatusCode = http.StatusBadGateway
+	default:
+		// The webhook rejected the event, which won't get any better.
+		de.StatusCode = http.StatusUnprocessableEntity
+	}
+	return de
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/logging"
+
+	bindingsql "github.com/mattmoor/bindings/pkg/sql"
+
+	// The Postgres driver.
+	_ "github.com/lib/pq"
+)
+
+const (
+	// EventTypePrefix is the prefix of the CloudEvent types we emit, which
+	// are followed by the operation, for example:
+	//   dev.mink.postgres.update
+	EventTypePrefix = "dev.mink.postgres."
+
+	// peekQuery reads (without consuming) up to $2 changes from the slot
+	// $1, in whole transactions, as pgoutput messages of the publication $3.
+	peekQuery = `SELECT lsn::text, data FROM pg_logical_slot_peek_binary_changes($1, NULL, $2, 'proto_version', '1', 'publication_names', $3)`
+
+	// advanceQuery confirms that we are done with the changes of the slot
+	// $1 up to the LSN $2, so that we resume after them.
+	advanceQuery = `SELECT pg_replication_slot_advance($1, $2::pg_lsn)`
+)
+
+type envConfig struct {
+	// Include the standard adapter.EnvConfig used by all adapters.
+	adapter.EnvConfig
+
+	// EventSource is the source of the events we emit.
+	EventSource string `envconfig:"EVENT_SOURCE" required:"true"`
+
+	// SlotName is the logical replication slot we consume.
+	SlotName string `envconfig:"SLOT_NAME" required:"true"`
+
+	// Publication is the publication whose tables we decode.
+	Publication string `envconfig:"PUBLICATION" required:"true"`
+
+	// PollInterval is how long we wait for changes once we've caught up.
+	PollInterval time.Duration `envconfig:"POLL_INTERVAL" default:"1s"`
+
+	// BatchSize is roughly how many changes we read at once.
+	BatchSize int `envconfig:"BATCH_SIZE" default:"1000"`
+}
+
+// NewEnv creates the envConfig of the adapter.
+func NewEnv() adapter.EnvConfigAccessor { return &envConfig{} }
+
+// Adapter emits a CloudEvent for each row inserted, updated or deleted in
+// the tables of a publication, as they are decoded from a replication
+// slot.  Changes are only confirmed once their events have been sent, so
+// after a restart we resume from the slot's confirmed LSN, which may
+// resend the events of the transaction we were sending.
+type Adapter struct {
+	env     *envConfig
+	client  cloudevents.Client
+	logger  *zap.SugaredLogger
+	db      *sql.DB
+	decoder *Decoder
+}
+
+var _ adapter.Adapter = (*Adapter)(nil)
+
+// NewAdapter creates an Adapter that reads the slot through the database
+// connection of its SQLBinding.
+func NewAdapter(ctx context.Context, aEnv adapter.EnvConfigAccessor, ceClient cloudevents.Client) adapter.Adapter {
+	env := aEnv.(*envConfig)
+	logger := logging.FromContext(ctx)
+
+	connStr, err := bindingsql.ReadKey("connectionstr")
+	if err != nil {
+		logger.Fatalw("Error reading the connection string", "error", err)
+	}
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		logger.Fatalw("Error opening the database", "error", err)
+	}
+
+	return &Adapter{
+		env:     env,
+		client:  ceClient,
+		logger:  logger,
+		db:      db,
+		decoder: NewDecoder(),
+	}
+}
+
+// Start implements adapter.Adapter
+func (a *Adapter) Start(stopCh <-chan struct{}) error {
+	a.logger.Infof("Starting to consume slot %q of publication %q", a.env.SlotName, a.env.Publication)
+	defer a.db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	for {
+		n, err := a.poll(ctx)
+		if err != nil {
+			a.logger.Errorw("Error consuming the replication slot", "error", err)
+		}
+		if n > 0 && err == nil {
+			// There may be more changes waiting.
+			continue
+		}
+		select {
+		case <-stopCh:
+			a.logger.Info("Shutting down...")
+			return nil
+		case <-time.After(a.env.PollInterval):
+		}
+	}
+}
+
+// change is a row change awaiting the commit of its transaction.
+type change struct {
+	op       string
+	relation *Relation
+	before   Tuple
+	after    Tuple
+}
+
+// message is a pgoutput message read from the slot.
+type message struct {
+	lsn  string
+	data []byte
+}
+
+// peek reads a batch of messages from the slot.  We read them all before
+// acting on them, since the slot is in use until the query completes.
+func (a *Adapter) peek(ctx context.Context) ([]message, error) {
+	rows, err := a.db.QueryContext(ctx, peekQuery, a.env.SlotName, a.env.BatchSize, a.env.Publication)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []message
+	for rows.Next() {
+		var m message
+		if err := rows.Scan(&m.lsn, &m.data); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, rows.Err()
+}
+
+// poll sends the events for a batch of the slot's changes, confirming
+// each transaction as its events are sent, and returns the number of
+// messages it read.
+func (a *Adapter) poll(ctx context.Context) (int, error) {
+	msgs, err := a.peek(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		begin   *Begin
+		changes []change
+	)
+	for _, raw := range msgs {
+		msg, err := a.decoder.Decode(raw.data)
+		if err != nil {
+			return len(msgs), fmt.Errorf("failed to decode message at %s: %w", raw.lsn, err)
+		}
+		switch m := msg.(type) {
+		case *Begin:
+			begin, changes = m, nil
+		case *Insert:
+			changes = a.appendChange(changes, "insert", m.RelationID, nil, m.New)
+		case *Update:
+			changes = a.appendChange(changes, "update", m.RelationID, m.Old, m.New)
+		case *Delete:
+			changes = a.appendChange(changes, "delete", m.RelationID, m.Old, nil)
+		case *Commit:
+			if begin == nil {
+				return len(msgs), fmt.Errorf("commit at %s without a transaction", raw.lsn)
+			}
+			if err := a.send(ctx, begin, m, changes); err != nil {
+				return len(msgs), err
+			}
+			if _, err := a.db.ExecContext(ctx, advanceQuery, a.env.SlotName, m.EndLSN.String()); err != nil {
+				return len(msgs), fmt.Errorf("failed to advance slot to %s: %w", m.EndLSN, err)
+			}
+			begin, changes = nil, nil
+		}
+	}
+	return len(msgs), nil
+}
+
+func (a *Adapter) appendChange(changes []change, op string, relationID uint32, before, after Tuple) []change {
+	rel, _ := a.decoder.Relation(relationID)
+	return append(changes, change{
+		op:       op,
+		relation: rel,
+		before:   before,
+		after:    after,
+	})
+}
+
+// send emits the events for the changes of a committed transaction.
+func (a *Adapter) send(ctx context.Context, begin *Begin, commit *Commit, changes []change) error {
+	for i, c := range changes {
+		if c.relation == nil {
+			return fmt.Errorf("change %d of %s is to an unknown relation", i, commit.CommitLSN)
+		}
+		event := cloudevents.NewEvent()
+		// The ID is stable, so that consumers can drop the events we
+		// resend after a restart.
+		event.SetID(fmt.Sprintf("%s/%d", commit.CommitLSN, i))
+		event.SetType(EventTypePrefix + c.op)
+		event.SetSource(a.env.EventSource)
+		event.SetSubject(c.relation.Namespace + "." + c.relation.Name)
+		event.SetTime(commit.CommitTime)
+		event.SetExtension("table", c.relation.Name)
+
+		data := map[string]interface{}{
+			"schema": c.relation.Namespace,
+			"table":  c.relation.Name,
+			"op":     c.op,
+			"lsn":    commit.CommitLSN.String(),
+			"xid":    begin.XID,
+		}
+		// Before images carry all of the old values when the table's
+		// replica identity is FULL, and otherwise just its key.
+		if c.before != nil {
+			data["before"] = c.before
+		}
+		if c.after != nil {
+			data["after"] = c.after
+		}
+		if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+			return err
+		}
+		if result := a.client.Send(ctx, event); !cloudevents.IsACK(result) {
+			return fmt.Errorf("failed to send %s: %w", event.ID(), result)
+		}
+	}
+	return nil
+}
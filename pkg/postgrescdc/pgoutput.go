@@ -0,0 +1,305 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LSN is a position in the Postgres write-ahead log.
+type LSN uint64
+
+// String renders the LSN in the form Postgres uses, e.g. 16/B374D848.
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint32(l>>32), uint32(l))
+}
+
+// ParseLSN parses the textual form of an LSN.
+func ParseLSN(s string) (LSN, error) {
+	var hi, lo uint32
+	if _, err := fmt.Sscanf(s, "%X/%X", &hi, &lo); err != nil {
+		return 0, fmt.Errorf("invalid LSN %q: %w", s, err)
+	}
+	return LSN(uint64(hi)<<32 | uint64(lo)), nil
+}
+
+// postgresEpoch is the epoch of the timestamps in pgoutput messages.
+var postgresEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// The messages of version 1 of the pgoutput logical replication protocol
+// that we act upon.  See:
+// https://www.postgresql.org/docs/current/protocol-logicalrep-message-formats.html
+
+// Begin starts a transaction.
+type Begin struct {
+	FinalLSN   LSN
+	CommitTime time.Time
+	XID        uint32
+}
+
+// Commit ends a transaction.
+type Commit struct {
+	CommitLSN  LSN
+	EndLSN     LSN
+	CommitTime time.Time
+}
+
+// Column describes a column of a Relation.
+type Column struct {
+	Name string
+	Key  bool
+	Type uint32
+}
+
+// Relation describes a table, and precedes the first change to it.
+type Relation struct {
+	ID        uint32
+	Namespace string
+	Name      string
+	Columns   []Column
+}
+
+// Tuple holds the values of a row by column name.  Null columns hold
+// nil, and unchanged TOASTed columns (whose values aren't sent) are
+// absent.
+type Tuple map[string]interface{}
+
+// Insert carries a new row.
+type Insert struct {
+	RelationID uint32
+	New        Tuple
+}
+
+// Update carries an updated row, and its old key or values when the
+// table's replica identity includes them.
+type Update struct {
+	RelationID uint32
+	Old        Tuple
+	New        Tuple
+}
+
+// Delete carries the old key or values of a deleted row.
+type Delete struct {
+	RelationID uint32
+	Old        Tuple
+}
+
+// Decoder decodes pgoutput messages, remembering the relations it has
+// seen to interpret the tuples of later messages.
+type Decoder struct {
+	relations map[uint32]*Relation
+}
+
+// NewDecoder creates a new Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		relations: make(map[uint32]*Relation),
+	}
+}
+
+// Relation returns the relation with the provided ID, if it is known.
+func (d *Decoder) Relation(id uint32) (*Relation, bool) {
+	r, ok := d.relations[id]
+	return r, ok
+}
+
+// Decode decodes a message, returning one of *Begin, *Commit, *Relation,
+// *Insert, *Update or *Delete, or nil for messages we ignore (e.g.
+// origins, types and truncations).
+func (d *Decoder) Decode(data []byte) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty message")
+	}
+	r := &reader{buf: data[1:]}
+	var msg interface{}
+	switch data[0] {
+	case 'B':
+		msg = &Begin{
+			FinalLSN:   LSN(r.uint64()),
+			CommitTime: r.time(),
+			XID:        r.uint32(),
+		}
+	case 'C':
+		r.uint8() // flags, currently unused.
+		msg = &Commit{
+			CommitLSN:  LSN(r.uint64()),
+			EndLSN:     LSN(r.uint64()),
+			CommitTime: r.time(),
+		}
+	case 'R':
+		rel := &Relation{
+			ID:        r.uint32(),
+			Namespace: r.string(),
+			Name:      r.string(),
+		}
+		r.uint8() // replica identity setting.
+		n := int(r.uint16())
+		for i := 0; i < n && r.err == nil; i++ {
+			flags := r.uint8()
+			col := Column{
+				Name: r.string(),
+				Key:  flags&1 == 1,
+				Type: r.uint32(),
+			}
+			r.uint32() // type modifier.
+			rel.Columns = append(rel.Columns, col)
+		}
+		if r.err == nil {
+			d.relations[rel.ID] = rel
+		}
+		msg = rel
+	case 'I':
+		ins := &Insert{RelationID: r.uint32()}
+		if kind := r.uint8(); kind != 'N' && r.err == nil {
+			return nil, fmt.Errorf("unexpected tuple kind %q in insert", kind)
+		}
+		ins.New = d.tuple(r, ins.RelationID)
+		msg = ins
+	case 'U':
+		upd := &Update{RelationID: r.uint32()}
+		kind := r.uint8()
+		if kind == 'K' || kind == 'O' {
+			upd.Old = d.tuple(r, upd.RelationID)
+			kind = r.uint8()
+		}
+		if kind != 'N' && r.err == nil {
+			return nil, fmt.Errorf("unexpected tuple kind %q in update", kind)
+		}
+		upd.New = d.tuple(r, upd.RelationID)
+		msg = upd
+	case 'D':
+		del := &Delete{RelationID: r.uint32()}
+		if kind := r.uint8(); kind != 'K' && kind != 'O' && r.err == nil {
+			return nil, fmt.Errorf("unexpected tuple kind %q in delete", kind)
+		}
+		del.Old = d.tuple(r, del.RelationID)
+		msg = del
+	default:
+		return nil, nil
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("malformed %q message: %w", data[0], r.err)
+	}
+	return msg, nil
+}
+
+// tuple reads TupleData, naming its columns after those of the relation.
+func (d *Decoder) tuple(r *reader, relationID uint32) Tuple {
+	rel, ok := d.relations[relationID]
+	if !ok {
+		r.fail(fmt.Errorf("unknown relation %d", relationID))
+		return nil
+	}
+	n := int(r.uint16())
+	if r.err == nil && n != len(rel.Columns) {
+		r.fail(fmt.Errorf("relation %d has %d columns, tuple has %d", relationID, len(rel.Columns), n))
+		return nil
+	}
+	t := make(Tuple, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		name := rel.Columns[i].Name
+		switch kind := r.uint8(); kind {
+		case 'n':
+			t[name] = nil
+		case 'u':
+			// Unchanged TOASTed values aren't sent.
+		case 't':
+			t[name] = string(r.bytes(int(r.uint32())))
+		default:
+			r.fail(fmt.Errorf("unexpected column kind %q", kind))
+		}
+	}
+	return t
+}
+
+// reader consumes the big-endian fields of a message, remembering the
+// first error it encounters.
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.buf) < n {
+		r.fail(errors.New("message truncated"))
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) uint8() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) uint16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) uint64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+// time reads a timestamp in microseconds since the Postgres epoch.
+func (r *reader) time() time.Time {
+	return postgresEpoch.Add(time.Duration(int64(r.uint64())) * time.Microsecond)
+}
+
+// string reads a null-terminated string.
+func (r *reader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	i := bytes.IndexByte(r.buf, 0)
+	if i < 0 {
+		r.fail(errors.New("unterminated string"))
+		return ""
+	}
+	s := string(r.buf[:i])
+	r.buf = r.buf[i+1:]
+	return s
+}
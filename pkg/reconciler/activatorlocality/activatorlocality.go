@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package activatorlocality
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	contourclientset "knative.dev/net-contour/pkg/client/clientset/versioned"
+	contourlisters "knative.dev/net-contour/pkg/client/listers/projectcontour/v1"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	netlisters "knative.dev/serving/pkg/client/listers/networking/v1alpha1"
+)
+
+// Reconciler implements controller.Reconciler for ServerlessServices,
+// maintaining their node-local activator Services and routing the
+// HTTPProxies in their namespace through them while in Proxy mode.
+type Reconciler struct {
+	kubeclient    kubernetes.Interface
+	contourclient contourclientset.Interface
+
+	// listers index properties about resources
+	sksLister       netlisters.ServerlessServiceLister
+	serviceLister   corev1listers.ServiceLister
+	httpProxyLister contourlisters.HTTPProxyLister
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	sks, err := r.sksLister.ServerlessServices(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		// Our Service is garbage collected along with the SKS, but the
+		// HTTPProxies still need to be routed directly.
+		return r.reconcileHTTPProxies(ctx, namespace)
+	} else if err != nil {
+		return err
+	}
+	if sks.GetDeletionTimestamp() != nil {
+		return nil
+	}
+
+	desired := MakeLocalService(sks)
+	existing, err := r.serviceLister.Services(namespace).Get(desired.Name)
+	if apierrs.IsNotFound(err) {
+		if _, err := r.kubeclient.CoreV1().Services(namespace).Create(desired); err != nil {
+			return err
+		}
+		// We localize the HTTPProxies once the new Service is in our
+		// informer's cache, which re-enqueues this ServerlessService.
+		return nil
+	} else if err != nil {
+		return err
+	} else if !equality.Semantic.DeepEqual(existing.Spec.ExternalName, desired.Spec.ExternalName) ||
+		!equality.Semantic.DeepEqual(existing.Spec.Ports, desired.Spec.Ports) {
+		update := existing.DeepCopy()
+		update.Spec.Type = desired.Spec.Type
+		update.Spec.ExternalName = desired.Spec.ExternalName
+		update.Spec.Ports = desired.Spec.Ports
+		if _, err := r.kubeclient.CoreV1().Services(namespace).Update(update); err != nil {
+			return err
+		}
+	}
+
+	return r.reconcileHTTPProxies(ctx, namespace)
+}
+
+// reconcileHTTPProxies localizes the HTTPProxies in the namespace.  Our
+// webhook applies the same rewrite to the updates net-contour makes.
+func (r *Reconciler) reconcileHTTPProxies(ctx context.Context, namespace string) error {
+	hps, err := r.httpProxyLister.HTTPProxies(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	ctx = WithListers(ctx, r.sksLister, r.serviceLister)
+	var local, remote int64
+	for _, hp := range hps {
+		update := hp.DeepCopy()
+		Localize(ctx, update)
+		hpLocal, hpRemote := countRoutes(ctx, update)
+		local, remote = local+hpLocal, remote+hpRemote
+		if equality.Semantic.DeepEqual(hp.Spec, update.Spec) {
+			continue
+		}
+		logging.FromContext(ctx).Infof("Rerouting HTTPProxy %s/%s", namespace, hp.Name)
+		if _, err := r.contourclient.ProjectcontourV1().HTTPProxies(namespace).Update(update); err != nil {
+			return err
+		}
+	}
+	reportRoutes(ctx, namespace, local, remote)
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package activatorlocality
+
+import (
+	"context"
+
+	"k8s.io/client-go/tools/cache"
+	contourclient "knative.dev/net-contour/pkg/client/injection/client"
+	httpproxyinformer "knative.dev/net-contour/pkg/client/injection/informers/projectcontour/v1/httpproxy"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	serviceinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/service"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	pkgreconciler "knative.dev/pkg/reconciler"
+	sksinformer "knative.dev/serving/pkg/client/injection/informers/networking/v1alpha1/serverlessservice"
+)
+
+const controllerAgentName = "activator-locality-controller"
+
+// NewController creates a new controller that routes Envoy's traffic for
+// ServerlessServices in Proxy mode to the activator on the same node.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	sksInformer := sksinformer.Get(ctx)
+	serviceInformer := serviceinformer.Get(ctx)
+
+	c := &Reconciler{
+		kubeclient:      kubeclient.Get(ctx),
+		contourclient:   contourclient.Get(ctx),
+		sksLister:       sksInformer.Lister(),
+		serviceLister:   serviceInformer.Lister(),
+		httpProxyLister: httpproxyinformer.Get(ctx).Lister(),
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+
+	logger.Info("Setting up event handlers")
+	sksInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+	serviceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: pkgreconciler.LabelExistsFilterFunc(ServerlessServiceLabelKey),
+		Handler:    controller.HandleAll(impl.EnqueueLabelOfNamespaceScopedResource("", ServerlessServiceLabelKey)),
+	})
+
+	return impl
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package activatorlocality
+
+import (
+	"context"
+
+	contourv1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/serving/pkg/apis/networking/v1alpha1"
+	netlisters "knative.dev/serving/pkg/client/listers/networking/v1alpha1"
+)
+
+const (
+	// ServerlessServiceLabelKey labels the node-local activator Services
+	// with the name of the ServerlessService they stand in for.
+	ServerlessServiceLabelKey = "networking.mink.knative.dev/serverlessservice"
+
+	// activatorHost is how Envoy reaches the activator on its own node:
+	// every dataplane pod runs an activator beside its Envoys, and shares
+	// their network namespace.
+	activatorHost = "localhost"
+
+	// httpPort and h2cPort are the ports of the ServerlessService's public
+	// Service over which we route HTTP/1 and h2c traffic, and
+	// activatorHTTPPort and activatorH2CPort those on which the activator
+	// accepts it.
+	httpPort          = 80
+	h2cPort           = 81
+	activatorHTTPPort = 8012
+	activatorH2CPort  = 8013
+)
+
+// LocalServiceName returns the name of the ExternalName Service through
+// which Envoy reaches the node-local activator for the ServerlessService.
+func LocalServiceName(sksName string) string {
+	return kmeta.ChildName(sksName, "-activator")
+}
+
+// MakeLocalService creates the ExternalName Service that routes to the
+// activator on behalf of the provided ServerlessService.  Envoy resolves
+// it to the activator in its own dataplane pod, so traffic never leaves
+// the node.  Should that activator be unready, so is the pod, and the
+// Envoy Services fall back on the Envoys (and activators) of other nodes.
+func MakeLocalService(sks *v1alpha1.ServerlessService) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LocalServiceName(sks.Name),
+			Namespace: sks.Namespace,
+			Labels: map[string]string{
+				ServerlessServiceLabelKey: sks.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(sks)},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: activatorHost,
+			Ports: []corev1.ServicePort{{
+				Name: "http",
+				Port: activatorHTTPPort,
+			}, {
+				Name: "http2",
+				Port: activatorH2CPort,
+			}},
+		},
+	}
+}
+
+type listersKey struct{}
+
+type listers struct {
+	sks     netlisters.ServerlessServiceLister
+	service corev1listers.ServiceLister
+}
+
+// WithListers attaches the listers used to localize HTTPProxies to the
+// provided context.
+func WithListers(ctx context.Context, sksLister netlisters.ServerlessServiceLister, serviceLister corev1listers.ServiceLister) context.Context {
+	return context.WithValue(ctx, listersKey{}, &listers{
+		sks:     sksLister,
+		service: serviceLister,
+	})
+}
+
+// Localize rewrites the services of the HTTPProxy's routes, so that those
+// backed by ServerlessServices in Proxy mode are reached through the
+// node-local activator, and the others directly.
+func Localize(ctx context.Context, hp *contourv1.HTTPProxy) {
+	l, ok := ctx.Value(listersKey{}).(*listers)
+	if !ok {
+		return
+	}
+	for i := range hp.Spec.Routes {
+		for j := range hp.Spec.Routes[i].Services {
+			svc := &hp.Spec.Routes[i].Services[j]
+			svc.Name, svc.Port = l.serviceFor(hp.Namespace, svc)
+		}
+	}
+}
+
+// countRoutes returns the number of the HTTPProxy's routes to
+// ServerlessServices in Proxy mode that reach the activator through the
+// node-local Service, and those that reach it through the ServerlessService.
+func countRoutes(ctx context.Context, hp *contourv1.HTTPProxy) (local, remote int64) {
+	l, ok := ctx.Value(listersKey{}).(*listers)
+	if !ok {
+		return 0, 0
+	}
+	for _, route := range hp.Spec.Routes {
+		for _, svc := range route.Services {
+			if s, err := l.service.Services(hp.Namespace).Get(svc.Name); err == nil && s.Labels[ServerlessServiceLabelKey] != "" {
+				local++
+			} else if sks, err := l.sks.ServerlessServices(hp.Namespace).Get(svc.Name); err == nil && sks.Spec.Mode == v1alpha1.SKSOperationModeProxy {
+				remote++
+			}
+		}
+	}
+	return local, remote
+}
+
+// Unlocalize returns the name and port of the ServerlessService to which
+// the service routes, given the Service it names, undoing any localization.
+func Unlocalize(svc *contourv1.Service, s *corev1.Service) (string, int) {
+	name := s.Labels[ServerlessServiceLabelKey]
+	if name == "" {
+		return svc.Name, svc.Port
+	}
+	switch svc.Port {
+	case activatorHTTPPort:
+		return name, httpPort
+	case activatorH2CPort:
+		return name, h2cPort
+	}
+	return name, svc.Port
+}
+
+// serviceFor returns the name and port of the Service through which the
+// route should reach the ServerlessService behind svc.
+func (l *listers) serviceFor(namespace string, svc *contourv1.Service) (string, int) {
+	// Undo any earlier localization to recover the ServerlessService.
+	sksName, port := svc.Name, svc.Port
+	if s, err := l.service.Services(namespace).Get(svc.Name); err == nil {
+		sksName, port = Unlocalize(svc, s)
+	}
+
+	var localPort int
+	switch port {
+	case httpPort:
+		localPort = activatorHTTPPort
+	case h2cPort:
+		localPort = activatorH2CPort
+	default:
+		return sksName, port
+	}
+	sks, err := l.sks.ServerlessServices(namespace).Get(sksName)
+	if err != nil || sks.Spec.Mode != v1alpha1.SKSOperationModeProxy {
+		return sksName, port
+	}
+	// Only route through the local Service once it exists.
+	if _, err := l.service.Services(namespace).Get(LocalServiceName(sksName)); err != nil {
+		return sksName, port
+	}
+	return LocalServiceName(sksName), localPort
+}
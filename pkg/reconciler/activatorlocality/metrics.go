@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package activatorlocality
+
+import (
+	"context"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	pkgmetrics "knative.dev/pkg/metrics"
+)
+
+// Envoy reaches the activator through a route's local Service on its own
+// node, and through the ServerlessService itself on any node.  The
+// controlplane records how many routes to ServerlessServices in Proxy mode
+// take each path, which should converge on all of them being local.  The
+// dataplane of each node records the requests its Envoys send down each
+// path, and how long they take, from Envoy's per-cluster stats.
+var (
+	activatorRoutesM = stats.Int64(
+		"activator_routes",
+		"Number of routes to ServerlessServices in Proxy mode, by whether they reach the activator on the same node as Envoy",
+		stats.UnitDimensionless)
+	activatorRequestsM = stats.Int64(
+		"activator_route_request_count",
+		"Number of requests Envoy sent to ServerlessServices in Proxy mode, by node and whether they reached the activator on that node",
+		stats.UnitDimensionless)
+	activatorLatencyM = stats.Float64(
+		"activator_route_request_latency",
+		"Mean latency of the requests Envoy sent to ServerlessServices in Proxy mode since the last scrape, by node and whether they reached the activator on that node",
+		stats.UnitMilliseconds)
+
+	namespaceKey = tag.MustNewKey("namespace_name")
+	localityKey  = tag.MustNewKey("locality")
+	nodeKey      = tag.MustNewKey("node_name")
+)
+
+const (
+	localityLocal  = "local"
+	localityRemote = "remote"
+)
+
+func init() {
+	if err := view.Register(
+		&view.View{
+			Description: "Number of routes to ServerlessServices in Proxy mode, by whether they reach the activator on the same node as Envoy",
+			Measure:     activatorRoutesM,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceKey, localityKey},
+		},
+		&view.View{
+			Description: "Number of requests Envoy sent to ServerlessServices in Proxy mode, by node and whether they reached the activator on that node",
+			Measure:     activatorRequestsM,
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{namespaceKey, localityKey, nodeKey},
+		},
+		&view.View{
+			Description: "Mean latency of the requests Envoy sent to ServerlessServices in Proxy mode since the last scrape, by node and whether they reached the activator on that node",
+			Measure:     activatorLatencyM,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceKey, localityKey, nodeKey},
+		},
+	); err != nil {
+		panic(err)
+	}
+}
+
+// reportRoutes records the number of local and remote routes to the
+// activators from the HTTPProxies in the namespace.
+func reportRoutes(ctx context.Context, namespace string, local, remote int64) {
+	for locality, count := range map[string]int64{
+		localityLocal:  local,
+		localityRemote: remote,
+	} {
+		ctx, err := tag.New(ctx,
+			tag.Upsert(namespaceKey, namespace),
+			tag.Upsert(localityKey, locality))
+		if err != nil {
+			continue
+		}
+		pkgmetrics.Record(ctx, activatorRoutesM.M(count))
+	}
+}
+
+// reportRequests records the requests that Envoy on the node sent to the
+// activators on behalf of the namespace since the last scrape.
+func reportRequests(ctx context.Context, node, namespace, locality string, c counts) {
+	ctx, err := tag.New(ctx,
+		tag.Upsert(namespaceKey, namespace),
+		tag.Upsert(localityKey, locality),
+		tag.Upsert(nodeKey, node))
+	if err != nil {
+		return
+	}
+	pkgmetrics.Record(ctx, activatorRequestsM.M(int64(c.requests)))
+	if c.timed > 0 {
+		pkgmetrics.Record(ctx, activatorLatencyM.M(c.timeMs/float64(c.timed)))
+	}
+}
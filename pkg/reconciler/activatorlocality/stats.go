@@ -0,0 +1,205 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package activatorlocality
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"knative.dev/serving/pkg/apis/networking/v1alpha1"
+	netlisters "knative.dev/serving/pkg/client/listers/networking/v1alpha1"
+)
+
+const (
+	// clusterLabel is the label of Envoy's Prometheus stats that names
+	// their cluster, which Contour names <namespace>_<service>_<port>.
+	clusterLabel = "envoy_cluster_name"
+
+	requestsFamily = "envoy_cluster_upstream_rq_total"
+	latencyFamily  = "envoy_cluster_upstream_rq_time"
+)
+
+// counts are the requests sent to a cluster, and how many of those were
+// timed, taking how many milliseconds in total.
+type counts struct {
+	requests uint64
+	timed    uint64
+	timeMs   float64
+}
+
+func (c counts) add(o counts) counts {
+	return counts{
+		requests: c.requests + o.requests,
+		timed:    c.timed + o.timed,
+		timeMs:   c.timeMs + o.timeMs,
+	}
+}
+
+// since returns the counts added since the last, which are all of them
+// when Envoy restarted in the meantime.
+func (c counts) since(last counts) counts {
+	if c.requests < last.requests || c.timed < last.timed {
+		return c
+	}
+	return counts{
+		requests: c.requests - last.requests,
+		timed:    c.timed - last.timed,
+		timeMs:   c.timeMs - last.timeMs,
+	}
+}
+
+type route struct {
+	namespace string
+	locality  string
+}
+
+// Scraper records the requests that the Envoys on a node send to the
+// activators, from the stats of their admin servers.
+type Scraper struct {
+	node      string
+	admins    []string
+	client    *http.Client
+	sksLister netlisters.ServerlessServiceLister
+
+	// last holds the counts of each cluster of each admin server as of
+	// the last scrape.
+	last map[string]map[string]counts
+}
+
+// NewScraper creates a Scraper for the Envoys on the named node, which
+// serve their stats at the provided admin URLs.
+func NewScraper(node string, admins []string, sksLister netlisters.ServerlessServiceLister) *Scraper {
+	return &Scraper{
+		node:      node,
+		admins:    admins,
+		client:    http.DefaultClient,
+		sksLister: sksLister,
+		last:      make(map[string]map[string]counts, len(admins)),
+	}
+}
+
+// Scrape records the requests each Envoy sent since the last Scrape.  The
+// first Scrape of each Envoy only notes its counts, which may predate us.
+func (s *Scraper) Scrape(ctx context.Context) error {
+	totals := make(map[route]counts)
+	for _, admin := range s.admins {
+		current, err := s.fetch(ctx, admin)
+		if err != nil {
+			return err
+		}
+		last, seen := s.last[admin]
+		s.last[admin] = current
+		if !seen {
+			continue
+		}
+		for cluster, c := range current {
+			r, ok := s.classify(cluster)
+			if !ok {
+				continue
+			}
+			totals[r] = totals[r].add(c.since(last[cluster]))
+		}
+	}
+	for r, c := range totals {
+		reportRequests(ctx, s.node, r.namespace, r.locality, c)
+	}
+	return nil
+}
+
+// fetch returns the counts of each cluster of the Envoy with the provided
+// admin URL.
+func (s *Scraper) fetch(ctx context.Context, admin string) (map[string]counts, error) {
+	req, err := http.NewRequest(http.MethodGet, admin+"/stats/prometheus?usedonly", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s = %d", req.URL, resp.StatusCode)
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]counts)
+	if f, ok := families[requestsFamily]; ok {
+		for _, m := range f.Metric {
+			c := current[clusterOf(m)]
+			c.requests = uint64(m.GetCounter().GetValue())
+			current[clusterOf(m)] = c
+		}
+	}
+	if f, ok := families[latencyFamily]; ok {
+		for _, m := range f.Metric {
+			c := current[clusterOf(m)]
+			c.timed = m.GetHistogram().GetSampleCount()
+			c.timeMs = m.GetHistogram().GetSampleSum()
+			current[clusterOf(m)] = c
+		}
+	}
+	return current, nil
+}
+
+func clusterOf(m *dto.Metric) string {
+	for _, l := range m.Label {
+		if l.GetName() == clusterLabel {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
+
+// classify returns the namespace of the cluster, and whether it reaches
+// the activator on Envoy's node or, through a ServerlessService in Proxy
+// mode, on any node.  Clusters that reach neither are not routes to the
+// activator.
+func (s *Scraper) classify(cluster string) (route, bool) {
+	// Neither namespaces nor Services may have underscores in their names.
+	parts := strings.Split(cluster, "_")
+	if len(parts) != 3 {
+		return route{}, false
+	}
+	namespace, name := parts[0], parts[1]
+	port, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return route{}, false
+	}
+
+	switch port {
+	case activatorHTTPPort, activatorH2CPort:
+		if strings.HasSuffix(name, "-activator") {
+			return route{namespace: namespace, locality: localityLocal}, true
+		}
+	case httpPort, h2cPort:
+		sks, err := s.sksLister.ServerlessServices(namespace).Get(name)
+		if err == nil && sks.Spec.Mode == v1alpha1.SKSOperationModeProxy {
+			return route{namespace: namespace, locality: localityRemote}, true
+		}
+	}
+	return route{}, false
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package activatorlocality
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/serving/pkg/apis/networking/v1alpha1"
+	netlisters "knative.dev/serving/pkg/client/listers/networking/v1alpha1"
+)
+
+func TestClassify(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for name, mode := range map[string]v1alpha1.ServerlessServiceOperationMode{
+		"proxied": v1alpha1.SKSOperationModeProxy,
+		"served":  v1alpha1.SKSOperationModeServe,
+	} {
+		indexer.Add(&v1alpha1.ServerlessService{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec:       v1alpha1.ServerlessServiceSpec{Mode: mode},
+		})
+	}
+	s := NewScraper("node", nil, netlisters.NewServerlessServiceLister(indexer))
+
+	tests := []struct {
+		cluster string
+		want    route
+		wantOK  bool
+	}{{
+		cluster: "ns_proxied-activator_8012",
+		want:    route{namespace: "ns", locality: localityLocal},
+		wantOK:  true,
+	}, {
+		cluster: "ns_proxied-activator_8013",
+		want:    route{namespace: "ns", locality: localityLocal},
+		wantOK:  true,
+	}, {
+		cluster: "ns_proxied_80",
+		want:    route{namespace: "ns", locality: localityRemote},
+		wantOK:  true,
+	}, {
+		cluster: "ns_proxied_81",
+		want:    route{namespace: "ns", locality: localityRemote},
+		wantOK:  true,
+	}, {
+		cluster: "ns_served_80",
+	}, {
+		cluster: "ns_missing_80",
+	}, {
+		cluster: "ns_proxied_8080",
+	}, {
+		cluster: "mink-system_contour_8001",
+	}, {
+		cluster: "service-stats",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.cluster, func(t *testing.T) {
+			got, ok := s.classify(test.cluster)
+			if got != test.want || ok != test.wantOK {
+				t.Errorf("classify() = %v, %v, wanted %v, %v", got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestSince(t *testing.T) {
+	tests := []struct {
+		name    string
+		current counts
+		last    counts
+		want    counts
+	}{{
+		name:    "first",
+		current: counts{requests: 3, timed: 2, timeMs: 10},
+		want:    counts{requests: 3, timed: 2, timeMs: 10},
+	}, {
+		name:    "more",
+		current: counts{requests: 5, timed: 4, timeMs: 25},
+		last:    counts{requests: 3, timed: 2, timeMs: 10},
+		want:    counts{requests: 2, timed: 2, timeMs: 15},
+	}, {
+		name:    "restarted",
+		current: counts{requests: 1, timed: 1, timeMs: 4},
+		last:    counts{requests: 3, timed: 2, timeMs: 10},
+		want:    counts{requests: 1, timed: 1, timeMs: 4},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.current.since(test.last); got != test.want {
+				t.Errorf("since() = %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stats/prometheus" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `# TYPE envoy_cluster_upstream_rq_total counter
+envoy_cluster_upstream_rq_total{envoy_cluster_name="ns_foo-activator_8012"} 7
+envoy_cluster_upstream_rq_total{envoy_cluster_name="ns_foo_80"} 2
+# TYPE envoy_cluster_upstream_rq_time histogram
+envoy_cluster_upstream_rq_time_bucket{envoy_cluster_name="ns_foo-activator_8012",le="+Inf"} 6
+envoy_cluster_upstream_rq_time_sum{envoy_cluster_name="ns_foo-activator_8012"} 42.5
+envoy_cluster_upstream_rq_time_count{envoy_cluster_name="ns_foo-activator_8012"} 6
+`)
+	}))
+	defer srv.Close()
+
+	s := NewScraper("node", []string{srv.URL}, nil)
+	got, err := s.fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("fetch() = %v", err)
+	}
+	want := map[string]counts{
+		"ns_foo-activator_8012": {requests: 7, timed: 6, timeMs: 42.5},
+		"ns_foo_80":             {requests: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("fetch() = %v, wanted %v", got, want)
+	}
+	for cluster, c := range want {
+		if got[cluster] != c {
+			t.Errorf("fetch()[%q] = %v, wanted %v", cluster, got[cluster], c)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserversource
+
+import (
+	"context"
+
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/eventing/pkg/apis/sources/v1alpha2"
+	eventingclient "knative.dev/eventing/pkg/client/injection/client"
+	apiserversourceinformer "knative.dev/eventing/pkg/client/injection/informers/sources/v1alpha2/apiserversource"
+	upstream "knative.dev/eventing/pkg/reconciler/apiserversource"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	deploymentinformer "knative.dev/pkg/client/injection/kube/informers/apps/v1/deployment"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/resolver"
+)
+
+const controllerAgentName = "apiserversource-controller"
+
+// NewController creates a new controller that reports the status of
+// ApiServerSources watched by the shared adapter.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	sourceInformer := apiserversourceinformer.Get(ctx)
+	deploymentInformer := deploymentinformer.Get(ctx)
+
+	c := &Reconciler{
+		kubeclient:       kubeclient.Get(ctx),
+		client:           eventingclient.Get(ctx),
+		lister:           sourceInformer.Lister(),
+		deploymentLister: deploymentInformer.Lister(),
+		source:           upstream.GetCfgHost(ctx),
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+	c.enqueueAfter = impl.EnqueueAfter
+	c.sinkResolver = resolver.NewURIResolver(ctx, impl.EnqueueKey)
+
+	logger.Info("Setting up event handlers")
+	sourceInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	// Clean up the adapters that upstream created before we took over.
+	deploymentInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterGroupKind(v1alpha2.Kind("ApiServerSource")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	return impl
+}
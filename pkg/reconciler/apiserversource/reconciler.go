@@ -0,0 +1,239 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserversource
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/eventing/pkg/apis/sources/v1alpha2"
+	clientset "knative.dev/eventing/pkg/client/clientset/versioned"
+	listers "knative.dev/eventing/pkg/client/listers/sources/v1alpha2"
+	"knative.dev/eventing/pkg/reconciler/apiserversource/resources"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/resolver"
+	"knative.dev/pkg/system"
+
+	"github.com/mattmoor/mink/pkg/apiserversource"
+)
+
+// leaseInterval is how often we check that the adapter holds its Lease.
+const leaseInterval = 30 * time.Second
+
+// Reconciler implements controller.Reconciler for ApiServerSources, whose
+// resources are watched by the shared adapter in the dataplane.
+type Reconciler struct {
+	kubeclient   kubernetes.Interface
+	client       clientset.Interface
+	sinkResolver *resolver.URIResolver
+
+	// listers index properties about resources
+	lister           listers.ApiServerSourceLister
+	deploymentLister appsv1listers.DeploymentLister
+
+	// source is the CloudEvents source of the adapter's events.
+	source string
+
+	// enqueueAfter requeues the ApiServerSource to refresh its adapter's
+	// availability.
+	enqueueAfter func(obj interface{}, after time.Duration)
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	original, err := r.lister.ApiServerSources(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if original.DeletionTimestamp != nil {
+		return nil
+	}
+
+	src := original.DeepCopy()
+	src.Status.InitializeConditions()
+	reconcileErr := r.reconcile(ctx, src)
+	src.Status.ObservedGeneration = src.Generation
+
+	if equality.Semantic.DeepEqual(original.Status, src.Status) {
+		return reconcileErr
+	}
+	if _, err := r.client.SourcesV1alpha2().ApiServerSources(namespace).UpdateStatus(src); err != nil {
+		logger.Warnw("Failed to update ApiServerSource status", "error", err)
+		return err
+	}
+	return reconcileErr
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, src *v1alpha2.ApiServerSource) error {
+	dest := src.Spec.Sink.DeepCopy()
+	if dest.Ref != nil && dest.Ref.Namespace == "" {
+		dest.Ref.Namespace = src.Namespace
+	}
+	uri, err := r.sinkResolver.URIFromDestinationV1(*dest, src)
+	if err != nil {
+		src.Status.MarkNoSink("NotFound", "Unable to resolve the sink: %v", err)
+		return err
+	}
+	src.Status.MarkSink(uri)
+
+	if err := r.reconcileAccess(src); err != nil {
+		return err
+	}
+
+	src.Status.CloudEventAttributes = make([]duckv1.CloudEventAttributes, 0, len(v1alpha2.ApiServerSourceEventTypes))
+	for _, t := range v1alpha2.ApiServerSourceEventTypes {
+		src.Status.CloudEventAttributes = append(src.Status.CloudEventAttributes, duckv1.CloudEventAttributes{
+			Type:   t,
+			Source: r.source,
+		})
+	}
+
+	if err := r.reconcileDeployments(src); err != nil {
+		return err
+	}
+	if err := r.reconcileLease(src); err != nil {
+		return err
+	}
+	r.enqueueAfter(src, leaseInterval)
+	return nil
+}
+
+// reconcileAccess checks that the ServiceAccount of the ApiServerSource
+// may watch its resources, since the adapter impersonates it to do so.
+func (r *Reconciler) reconcileAccess(src *v1alpha2.ApiServerSource) error {
+	user := apiserversource.UserName(src)
+	var missing []string
+	for _, res := range src.Spec.Resources {
+		gv, err := schema.ParseGroupVersion(res.APIVersion)
+		if err != nil {
+			src.Status.MarkNoSufficientPermissions("InvalidResource", "Unable to parse apiVersion %q: %v", res.APIVersion, err)
+			return nil
+		}
+		gvr, _ := meta.UnsafeGuessKindToResource(gv.WithKind(res.Kind))
+		var verbs []string
+		for _, verb := range []string{"get", "list", "watch"} {
+			sar, err := r.kubeclient.AuthorizationV1().SubjectAccessReviews().Create(&authorizationv1.SubjectAccessReview{
+				Spec: authorizationv1.SubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace: src.Namespace,
+						Verb:      verb,
+						Group:     gv.Group,
+						Resource:  gvr.Resource,
+					},
+					User: user,
+				},
+			})
+			if err != nil {
+				return err
+			}
+			if !sar.Status.Allowed {
+				verbs = append(verbs, verb)
+			}
+		}
+		if len(verbs) > 0 {
+			missing = append(missing, fmt.Sprintf("%s resource %q in API group %q",
+				strings.Join(verbs, ", "), gvr.Resource, gv.Group))
+		}
+	}
+	if len(missing) > 0 {
+		src.Status.MarkNoSufficientPermissions("Forbidden", "User %s cannot %s", user, strings.Join(missing, ", "))
+		return nil
+	}
+	src.Status.MarkSufficientPermissions()
+	return nil
+}
+
+// reconcileDeployments deletes the adapter Deployment that upstream
+// creates for each ApiServerSource, which the shared adapter replaces.
+func (r *Reconciler) reconcileDeployments(src *v1alpha2.ApiServerSource) error {
+	ds, err := r.deploymentLister.Deployments(src.Namespace).List(labels.SelectorFromSet(resources.Labels(src.Name)))
+	if err != nil {
+		return err
+	}
+	for _, d := range ds {
+		if !metav1.IsControlledBy(d, src) {
+			continue
+		}
+		err := r.kubeclient.AppsV1().Deployments(d.Namespace).Delete(d.Name, &metav1.DeleteOptions{})
+		if err != nil && !apierrs.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileLease marks the ApiServerSource deployed while an adapter
+// replica holds the adapter's Lease.
+func (r *Reconciler) reconcileLease(src *v1alpha2.ApiServerSource) error {
+	// The status only reflects the availability of a Deployment, so we
+	// synthesize one that is available while the Lease is held.
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: system.Namespace(),
+			Name:      apiserversource.LeaseName,
+		},
+		Status: appsv1.DeploymentStatus{
+			Conditions: []appsv1.DeploymentCondition{{
+				Type:   appsv1.DeploymentAvailable,
+				Status: corev1.ConditionFalse,
+			}},
+		},
+	}
+	lease, err := r.kubeclient.CoordinationV1().Leases(system.Namespace()).Get(apiserversource.LeaseName, metav1.GetOptions{})
+	if apierrs.IsNotFound(err) {
+		src.Status.PropagateDeploymentAvailability(d)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	spec := lease.Spec
+	if spec.HolderIdentity != nil && *spec.HolderIdentity != "" &&
+		spec.RenewTime != nil && spec.LeaseDurationSeconds != nil &&
+		spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds)*time.Second).After(time.Now()) {
+		d.Status.Conditions[0].Status = corev1.ConditionTrue
+	}
+	src.Status.PropagateDeploymentAvailability(d)
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domainmapping
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/tracker"
+	netv1alpha1 "knative.dev/serving/pkg/apis/networking/v1alpha1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	servingclient "knative.dev/serving/pkg/client/injection/client"
+	certificateinformer "knative.dev/serving/pkg/client/injection/informers/networking/v1alpha1/certificate"
+	ingressinformer "knative.dev/serving/pkg/client/injection/informers/networking/v1alpha1/ingress"
+	routeinformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/route"
+	"knative.dev/serving/pkg/network"
+	"knative.dev/serving/pkg/reconciler/route/config"
+
+	"github.com/mattmoor/mink/pkg/apis/networking/v1alpha1"
+	minkclient "github.com/mattmoor/mink/pkg/client/injection/client"
+	dminformer "github.com/mattmoor/mink/pkg/client/injection/informers/networking/v1alpha1/domainmapping"
+)
+
+const controllerAgentName = "domainmapping-controller"
+
+// NewController creates a new DomainMapping controller, which maps custom
+// domains onto Knative Services and Routes.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	dmInformer := dminformer.Get(ctx)
+	routeInformer := routeinformer.Get(ctx)
+	ingressInformer := ingressinformer.Get(ctx)
+	certificateInformer := certificateinformer.Get(ctx)
+
+	c := &Reconciler{
+		client:            servingclient.Get(ctx),
+		minkClient:        minkclient.Get(ctx),
+		lister:            dmInformer.Lister(),
+		routeLister:       routeInformer.Lister(),
+		ingressLister:     ingressInformer.Lister(),
+		certificateLister: certificateInformer.Lister(),
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+
+	logger.Info("Setting up ConfigMap receivers")
+	resync := configmap.TypeFilter(&network.Config{})(func(string, interface{}) {
+		impl.GlobalResync(dmInformer.Informer())
+	})
+	c.configStore = config.NewStore(logging.WithLogger(ctx, logger.Named("config-store")), resync)
+	c.configStore.WatchConfigs(cmw)
+
+	logger.Info("Setting up event handlers")
+	// Every DomainMapping for a domain is reconciled when any of them
+	// changes, so that the next oldest takes over a released domain.
+	dmInformer.Informer().AddEventHandler(controller.HandleAll(func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		dm, ok := obj.(kmeta.Accessor)
+		if !ok {
+			return
+		}
+		all, err := dmInformer.Lister().List(labels.Everything())
+		if err != nil {
+			logger.Errorw("Error listing DomainMappings", "error", err)
+			return
+		}
+		for _, other := range all {
+			if other.Name == dm.GetName() {
+				impl.EnqueueKey(types.NamespacedName{Namespace: other.Namespace, Name: other.Name})
+			}
+		}
+	}))
+	handleOwned := cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterGroupKind(v1alpha1.Kind("DomainMapping")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	}
+	ingressInformer.Informer().AddEventHandler(handleOwned)
+	certificateInformer.Informer().AddEventHandler(handleOwned)
+
+	// Track the Routes (and their KIngresses) onto which we map domains.
+	c.tracker = tracker.New(impl.EnqueueKey, controller.GetTrackerLease(ctx))
+	routeInformer.Informer().AddEventHandler(controller.HandleAll(
+		controller.EnsureTypeMeta(
+			c.tracker.OnChanged,
+			servingv1.SchemeGroupVersion.WithKind("Route"),
+		),
+	))
+	ingressInformer.Informer().AddEventHandler(controller.HandleAll(
+		controller.EnsureTypeMeta(
+			c.tracker.OnChanged,
+			netv1alpha1.SchemeGroupVersion.WithKind("Ingress"),
+		),
+	))
+
+	return impl
+}
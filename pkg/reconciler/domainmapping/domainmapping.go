@@ -0,0 +1,268 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domainmapping
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/tracker"
+	netv1alpha1 "knative.dev/serving/pkg/apis/networking/v1alpha1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	clientset "knative.dev/serving/pkg/client/clientset/versioned"
+	netlisters "knative.dev/serving/pkg/client/listers/networking/v1alpha1"
+	servinglisters "knative.dev/serving/pkg/client/listers/serving/v1"
+	"knative.dev/serving/pkg/reconciler/route/config"
+	routeresources "knative.dev/serving/pkg/reconciler/route/resources"
+	"knative.dev/serving/pkg/reconciler/route/resources/names"
+
+	"github.com/mattmoor/mink/pkg/apis/networking/v1alpha1"
+	minkclientset "github.com/mattmoor/mink/pkg/client/clientset/versioned"
+	listers "github.com/mattmoor/mink/pkg/client/listers/networking/v1alpha1"
+)
+
+// Reconciler implements controller.Reconciler for DomainMapping resources.
+type Reconciler struct {
+	client     clientset.Interface
+	minkClient minkclientset.Interface
+	tracker    tracker.Interface
+
+	// listers index properties about resources
+	lister            listers.DomainMappingLister
+	routeLister       servinglisters.RouteLister
+	ingressLister     netlisters.IngressLister
+	certificateLister netlisters.CertificateLister
+
+	configStore *config.Store
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	original, err := r.lister.DomainMappings(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		// Our KIngress and Certificate are garbage collected along with
+		// the DomainMapping.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if original.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	ctx = r.configStore.ToContext(ctx)
+
+	dm := original.DeepCopy()
+	dm.Status.InitializeConditions()
+	reconcileErr := r.reconcile(ctx, dm)
+	dm.Status.ObservedGeneration = dm.Generation
+
+	if equality.Semantic.DeepEqual(original.Status, dm.Status) {
+		return reconcileErr
+	}
+	if _, err := r.minkClient.NetworkingV1alpha1().DomainMappings(namespace).UpdateStatus(dm); err != nil {
+		return err
+	}
+	return reconcileErr
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, dm *v1alpha1.DomainMapping) error {
+	// Only the oldest DomainMapping for a domain gets to map it.
+	owner, err := r.domainOwner(dm)
+	if err != nil {
+		return err
+	}
+	if owner.Namespace != dm.Namespace {
+		dm.Status.MarkDomainAlreadyClaimed(owner.Namespace)
+		dm.Status.URL = nil
+		return nil
+	}
+	dm.Status.MarkDomainClaimed()
+
+	rule, routeIngress, err := r.resolveRoute(ctx, dm)
+	if err != nil || routeIngress == nil {
+		return err
+	}
+	dm.Status.MarkReferenceResolved()
+
+	var (
+		tls        []netv1alpha1.IngressTLS
+		challenges []netv1alpha1.HTTP01Challenge
+	)
+	cfg := config.FromContext(ctx)
+	dm.Status.URL = &apis.URL{Scheme: "http", Host: dm.Name}
+	if cfg.Network.AutoTLS {
+		cert, err := r.reconcileCertificate(dm, MakeCertificate(dm, cfg.Network.DefaultCertificateClass))
+		if err != nil {
+			return err
+		}
+		if cert.Status.IsReady() {
+			dm.Status.MarkCertificateReady(cert.Name)
+			dm.Status.URL.Scheme = "https"
+			tls = append(tls, routeresources.MakeIngressTLS(cert, []string{dm.Name}))
+		} else {
+			dm.Status.MarkCertificateNotReady(cert.Name)
+			challenges = cert.Status.HTTP01Challenges
+		}
+	} else {
+		dm.Status.MarkCertificateNotRequired()
+	}
+
+	ing, err := r.reconcileIngress(dm, MakeIngress(dm, routeIngress, rule, tls, challenges))
+	if err != nil {
+		return err
+	}
+	dm.Status.PropagateIngressStatus(ing.Status)
+	return nil
+}
+
+// domainOwner returns the DomainMapping that owns the provided one's
+// domain, which is the oldest of those with its name.
+func (r *Reconciler) domainOwner(dm *v1alpha1.DomainMapping) (*v1alpha1.DomainMapping, error) {
+	all, err := r.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	owner := dm
+	for _, other := range all {
+		if other.Name != dm.Name || other.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if olderThan(other, owner) {
+			owner = other
+		}
+	}
+	return owner, nil
+}
+
+// olderThan orders DomainMappings by their creation, breaking ties on
+// their namespace.
+func olderThan(l, r *v1alpha1.DomainMapping) bool {
+	if !l.CreationTimestamp.Equal(&r.CreationTimestamp) {
+		return l.CreationTimestamp.Before(&r.CreationTimestamp)
+	}
+	return l.Namespace < r.Namespace
+}
+
+// resolveRoute returns the rule through which the KIngress of the
+// referenced Service or Route serves it, and that KIngress.  When the
+// reference is not (yet) routable, the reason is recorded on the status
+// and a nil KIngress is returned.
+func (r *Reconciler) resolveRoute(ctx context.Context, dm *v1alpha1.DomainMapping) (netv1alpha1.IngressRule, *netv1alpha1.Ingress, error) {
+	ref := dm.Spec.Ref
+
+	// Services and Routes are both served through the Route with their
+	// name, so we watch the Route and its KIngress.
+	for _, gvk := range []struct{ apiVersion, kind string }{
+		{servingv1.SchemeGroupVersion.String(), "Route"},
+		{netv1alpha1.SchemeGroupVersion.String(), "Ingress"},
+	} {
+		if err := r.tracker.TrackReference(tracker.Reference{
+			APIVersion: gvk.apiVersion,
+			Kind:       gvk.kind,
+			Namespace:  ref.Namespace,
+			Name:       ref.Name,
+		}, dm); err != nil {
+			return netv1alpha1.IngressRule{}, nil, err
+		}
+	}
+
+	route, err := r.routeLister.Routes(ref.Namespace).Get(ref.Name)
+	if apierrs.IsNotFound(err) {
+		dm.Status.MarkReferenceNotResolved("NotFound", "%s %q was not found.", ref.Kind, ref.Name)
+		return netv1alpha1.IngressRule{}, nil, nil
+	} else if err != nil {
+		return netv1alpha1.IngressRule{}, nil, err
+	}
+	if route.Status.URL == nil {
+		dm.Status.MarkReferenceNotResolved("RouteNotReady", "Route %q has no URL yet.", route.Name)
+		return netv1alpha1.IngressRule{}, nil, nil
+	}
+
+	ing, err := r.ingressLister.Ingresses(route.Namespace).Get(names.Ingress(route))
+	if apierrs.IsNotFound(err) {
+		dm.Status.MarkReferenceNotResolved("IngressNotFound", "Route %q has no KIngress yet.", route.Name)
+		return netv1alpha1.IngressRule{}, nil, nil
+	} else if err != nil {
+		return netv1alpha1.IngressRule{}, nil, err
+	}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, host := range rule.Hosts {
+			if host == route.Status.URL.Host {
+				return rule, ing, nil
+			}
+		}
+	}
+	dm.Status.MarkReferenceNotResolved("RuleNotFound", "KIngress %q does not route %s.", ing.Name, route.Status.URL.Host)
+	return netv1alpha1.IngressRule{}, nil, nil
+}
+
+func (r *Reconciler) reconcileCertificate(dm *v1alpha1.DomainMapping, desired *netv1alpha1.Certificate) (*netv1alpha1.Certificate, error) {
+	cert, err := r.certificateLister.Certificates(desired.Namespace).Get(desired.Name)
+	if apierrs.IsNotFound(err) {
+		return r.client.NetworkingV1alpha1().Certificates(desired.Namespace).Create(desired)
+	} else if err != nil {
+		return nil, err
+	} else if !metav1.IsControlledBy(cert, dm) {
+		return nil, fmt.Errorf("certificate %s/%s is not owned by DomainMapping %s", cert.Namespace, cert.Name, dm.Name)
+	} else if equality.Semantic.DeepEqual(cert.Spec, desired.Spec) &&
+		equality.Semantic.DeepEqual(cert.Annotations, desired.Annotations) {
+		return cert, nil
+	}
+	update := cert.DeepCopy()
+	update.Spec = desired.Spec
+	update.Annotations = desired.Annotations
+	return r.client.NetworkingV1alpha1().Certificates(update.Namespace).Update(update)
+}
+
+func (r *Reconciler) reconcileIngress(dm *v1alpha1.DomainMapping, desired *netv1alpha1.Ingress) (*netv1alpha1.Ingress, error) {
+	ing, err := r.ingressLister.Ingresses(desired.Namespace).Get(desired.Name)
+	if apierrs.IsNotFound(err) {
+		return r.client.NetworkingV1alpha1().Ingresses(desired.Namespace).Create(desired)
+	} else if err != nil {
+		return nil, err
+	} else if !metav1.IsControlledBy(ing, dm) {
+		return nil, fmt.Errorf("KIngress %s/%s is not owned by DomainMapping %s", ing.Namespace, ing.Name, dm.Name)
+	} else if equality.Semantic.DeepEqual(ing.Spec, desired.Spec) &&
+		equality.Semantic.DeepEqual(ing.Annotations, desired.Annotations) &&
+		equality.Semantic.DeepEqual(ing.Labels, desired.Labels) {
+		return ing, nil
+	}
+	update := ing.DeepCopy()
+	update.Spec = desired.Spec
+	update.Annotations = desired.Annotations
+	update.Labels = desired.Labels
+	return r.client.NetworkingV1alpha1().Ingresses(update.Namespace).Update(update)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domainmapping
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/serving/pkg/apis/networking"
+	netv1alpha1 "knative.dev/serving/pkg/apis/networking/v1alpha1"
+
+	"github.com/mattmoor/mink/pkg/apis/networking/v1alpha1"
+	"github.com/mattmoor/mink/pkg/reconciler/visibilityclass"
+)
+
+// acmeChallengePath is the path prefix under which the HTTP01 challenges
+// of certificates are served.
+const acmeChallengePath = "/.well-known/acme-challenge/"
+
+// MakeCertificate creates the Certificate for the DomainMapping's domain.
+func MakeCertificate(dm *v1alpha1.DomainMapping, certClass string) *netv1alpha1.Certificate {
+	return &netv1alpha1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            dm.Name,
+			Namespace:       dm.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(dm)},
+			Annotations: map[string]string{
+				networking.CertificateClassAnnotationKey: certClass,
+			},
+		},
+		Spec: netv1alpha1.CertificateSpec{
+			DNSNames:   []string{dm.Name},
+			SecretName: dm.Name,
+		},
+	}
+}
+
+// MakeIngress creates the KIngress that routes the DomainMapping's domain
+// the same way the provided rule of the target Route's KIngress routes
+// the Route's own domain.  The class and the policy annotations of the
+// Route's KIngress are carried over, so that our domain is served the
+// same way.
+func MakeIngress(dm *v1alpha1.DomainMapping, routeIngress *netv1alpha1.Ingress, rule netv1alpha1.IngressRule,
+	tls []netv1alpha1.IngressTLS, challenges []netv1alpha1.HTTP01Challenge) *netv1alpha1.Ingress {
+	paths := make([]netv1alpha1.HTTPIngressPath, 0, len(challenges)+len(rule.HTTP.Paths))
+	for _, c := range challenges {
+		if c.URL == nil || c.URL.Host != dm.Name {
+			continue
+		}
+		paths = append(paths, netv1alpha1.HTTPIngressPath{
+			Path: c.URL.Path,
+			Splits: []netv1alpha1.IngressBackendSplit{{
+				IngressBackend: netv1alpha1.IngressBackend{
+					ServiceNamespace: c.ServiceNamespace,
+					ServiceName:      c.ServiceName,
+					ServicePort:      c.ServicePort,
+				},
+				Percent: 100,
+			}},
+		})
+	}
+	for _, p := range rule.HTTP.Paths {
+		// Skip the challenges for the Route's own domain.
+		if strings.HasPrefix(p.Path, acmeChallengePath) {
+			continue
+		}
+		paths = append(paths, *p.DeepCopy())
+	}
+
+	ing := &netv1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            dm.Name,
+			Namespace:       dm.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(dm)},
+			Annotations: kmeta.FilterMap(routeIngress.Annotations, func(key string) bool {
+				return key == corev1.LastAppliedConfigAnnotation
+			}),
+		},
+		Spec: netv1alpha1.IngressSpec{
+			Rules: []netv1alpha1.IngressRule{{
+				Hosts:      []string{dm.Name},
+				Visibility: netv1alpha1.IngressVisibilityExternalIP,
+				HTTP: &netv1alpha1.HTTPIngressRuleValue{
+					Paths: paths,
+				},
+			}},
+			TLS: tls,
+		},
+	}
+	if class, ok := routeIngress.Labels[visibilityclass.LabelKey]; ok {
+		ing.Labels = map[string]string{
+			visibilityclass.LabelKey: class,
+		}
+	}
+	return ing
+}
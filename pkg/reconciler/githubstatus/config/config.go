@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/configmap"
+)
+
+const (
+	// ConfigName is the name of the ConfigMap that configures how the
+	// status of PipelineRuns is reported to GitHub.
+	ConfigName = "config-github"
+
+	baseURLKey   = "base-url"
+	reportKey    = "report"
+	targetURLKey = "target-url"
+
+	// DefaultBaseURL is the URL of the public GitHub API.
+	DefaultBaseURL = "https://api.github.com/"
+)
+
+// Report is the kind of GitHub object through which we report status.
+type Report string
+
+const (
+	// ReportStatus reports PipelineRun status as commit statuses.
+	ReportStatus Report = "status"
+	// ReportCheckRun reports PipelineRun status as check runs, which
+	// requires the token to belong to a GitHub App.
+	ReportCheckRun Report = "check-run"
+)
+
+// Config holds the settings for reporting PipelineRun status to GitHub.
+type Config struct {
+	// BaseURL is the URL of the GitHub API, which may be overridden for
+	// GitHub Enterprise or a fake API server.
+	BaseURL *apis.URL
+
+	// Report is the kind of GitHub object through which we report status.
+	Report Report
+
+	// TargetURL is the template of the link reported with the status of
+	// a PipelineRun, executed with its metadata.  Without it we link the
+	// archived logs of the PipelineRun's TaskRuns.
+	TargetURL *template.Template
+}
+
+func defaultConfig() *Config {
+	u, _ := apis.ParseURL(DefaultBaseURL)
+	return &Config{
+		BaseURL: u,
+		Report:  ReportStatus,
+	}
+}
+
+// NewConfigFromConfigMap creates a Config from the supplied ConfigMap.
+func NewConfigFromConfigMap(configMap *corev1.ConfigMap) (*Config, error) {
+	c := defaultConfig()
+
+	if raw, ok := configMap.Data[baseURLKey]; ok && raw != "" {
+		u, err := apis.ParseURL(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", baseURLKey, err)
+		} else if !u.URL().IsAbs() {
+			return nil, fmt.Errorf("%s must be an absolute URL, was: %q", baseURLKey, raw)
+		}
+		// The GitHub client resolves paths relative to the base URL.
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+		}
+		c.BaseURL = u
+	}
+	if raw, ok := configMap.Data[reportKey]; ok && raw != "" {
+		switch r := Report(raw); r {
+		case ReportStatus, ReportCheckRun:
+			c.Report = r
+		default:
+			return nil, fmt.Errorf("%s must be one of %q or %q, was: %q", reportKey, ReportStatus, ReportCheckRun, raw)
+		}
+	}
+	if raw, ok := configMap.Data[targetURLKey]; ok && raw != "" {
+		t, err := template.New(targetURLKey).Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", targetURLKey, err)
+		}
+		c.TargetURL = t
+	}
+	return c, nil
+}
+
+type cfgKey struct{}
+
+// FromContext extracts the Config attached to the provided context.
+func FromContext(ctx context.Context) *Config {
+	return ctx.Value(cfgKey{}).(*Config)
+}
+
+// ToContext attaches the provided Config to the provided context.
+func ToContext(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, cfgKey{}, c)
+}
+
+// Store is a typed wrapper around configmap.UntypedStore to handle our configmaps.
+type Store struct {
+	*configmap.UntypedStore
+}
+
+// NewStore creates a new Store, and optionally calls functions when
+// config-github is updated.
+func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *Store {
+	return &Store{
+		UntypedStore: configmap.NewUntypedStore(
+			"github",
+			logger,
+			configmap.Constructors{
+				ConfigName: NewConfigFromConfigMap,
+			},
+			onAfterStore...,
+		),
+	}
+}
+
+// ToContext attaches the current Config state to the provided context.
+func (s *Store) ToContext(ctx context.Context) context.Context {
+	return ToContext(ctx, s.Load())
+}
+
+// Load fetches the current Config state of the Store.
+func (s *Store) Load() *Config {
+	return s.UntypedLoad(ConfigName).(*Config)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package githubstatus
+
+import (
+	"context"
+
+	ghinformer "github.com/mattmoor/bindings/pkg/client/injection/informers/bindings/v1alpha1/githubbinding"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	pipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1alpha1/pipelinerun"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/reconciler/githubstatus/config"
+)
+
+const controllerAgentName = "github-status-controller"
+
+// NewController creates a new controller that reports the status of
+// PipelineRuns to the GitHub commits they build.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	pipelineRunInformer := pipelineruninformer.Get(ctx)
+	client := pipelineclient.Get(ctx)
+
+	c := &Reconciler{
+		kubeclient:        kubeclient.Get(ctx),
+		pipelineRunLister: pipelineRunInformer.Lister(),
+		bindingLister:     ghinformer.Get(ctx).Lister(),
+		configStore:       config.NewStore(logger.Named("config-store")),
+	}
+	c.configStore.WatchConfigs(cmw)
+	c.patch = func(namespace, name string, pt types.PatchType, data []byte) error {
+		_, err := client.TektonV1alpha1().PipelineRuns(namespace).Patch(name, pt, data)
+		return err
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+
+	logger.Info("Setting up event handlers")
+	pipelineRunInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			pr, ok := obj.(*v1alpha1.PipelineRun)
+			return ok && reportable(pr)
+		},
+		Handler: controller.HandleAll(impl.Enqueue),
+	})
+
+	return impl
+}
@@ -0,0 +1,383 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package githubstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+	ghlisters "github.com/mattmoor/bindings/pkg/client/listers/bindings/v1alpha1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1alpha1"
+	"golang.org/x/oauth2"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/reconciler/githubstatus/config"
+	"github.com/mattmoor/mink/pkg/reconciler/taskrunlogs"
+)
+
+const (
+	annotationPrefix = "github.mink.knative.dev/"
+
+	// RepoAnnotationKey holds the owner/name of the GitHub repository
+	// whose commit a PipelineRun is building.  Only PipelineRuns with
+	// this and ShaAnnotationKey are reported.
+	RepoAnnotationKey = annotationPrefix + "repo"
+	// ShaAnnotationKey holds the SHA of the commit a PipelineRun is building.
+	ShaAnnotationKey = annotationPrefix + "sha"
+	// BindingAnnotationKey optionally names the GithubBinding whose secret
+	// holds the token used to report.  When omitted, the namespace must
+	// contain exactly one GithubBinding.
+	BindingAnnotationKey = annotationPrefix + "binding"
+	// ContextAnnotationKey optionally overrides the context (or check
+	// name) under which the status is reported.
+	ContextAnnotationKey = annotationPrefix + "context"
+
+	// ReportedAnnotationKey records the last status we reported, so
+	// that we only report changes.
+	ReportedAnnotationKey = annotationPrefix + "reported"
+	// CheckRunAnnotationKey records the ID of the check run we created
+	// for the PipelineRun, so that we may update it.
+	CheckRunAnnotationKey = annotationPrefix + "check-run-id"
+
+	// accessTokenKey is the key of the GithubBinding secret holding the token.
+	accessTokenKey = "accessToken"
+)
+
+// Reconciler implements controller.Reconciler for reporting the status
+// of PipelineRuns to GitHub.
+type Reconciler struct {
+	kubeclient        kubernetes.Interface
+	pipelineRunLister listers.PipelineRunLister
+	bindingLister     ghlisters.GithubBindingLister
+	configStore       *config.Store
+
+	// patch records what we have reported for the named PipelineRun.
+	patch func(namespace, name string, pt types.PatchType, data []byte) error
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// report is the status we report for a PipelineRun.
+type report struct {
+	// State is the commit status state: pending, success, failure or error.
+	State string
+	// Description is a short human readable summary of the state.
+	Description string
+	// TargetURL links to the PipelineRun or its logs, when available.
+	TargetURL string
+}
+
+// String is the form in which we record what was reported.
+func (r report) String() string {
+	return strings.TrimSpace(r.State + " " + r.TargetURL)
+}
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	pr, err := r.pipelineRunLister.PipelineRuns(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !reportable(pr) {
+		return nil
+	}
+	ctx = r.configStore.ToContext(ctx)
+	logger := logging.FromContext(ctx)
+
+	owner, repo, err := splitRepo(pr.Annotations[RepoAnnotationKey])
+	if err != nil {
+		logger.Errorw("Unable to report status", "error", err)
+		return nil
+	}
+	link, err := targetURL(ctx, pr)
+	if err != nil {
+		logger.Errorw("Unable to report status", "error", err)
+		return nil
+	}
+	rpt := reportFor(pr, link)
+	if pr.Annotations[ReportedAnnotationKey] == rpt.String() {
+		return nil
+	}
+
+	client, err := r.clientFor(ctx, pr)
+	if err != nil {
+		return err
+	}
+
+	annotations := map[string]string{
+		ReportedAnnotationKey: rpt.String(),
+	}
+	sha := pr.Annotations[ShaAnnotationKey]
+	switch config.FromContext(ctx).Report {
+	case config.ReportCheckRun:
+		id, err := r.reportCheckRun(ctx, client, owner, repo, sha, pr, rpt)
+		if err != nil {
+			return err
+		}
+		annotations[CheckRunAnnotationKey] = strconv.FormatInt(id, 10)
+	default:
+		status := &github.RepoStatus{
+			State:       github.String(rpt.State),
+			Description: github.String(rpt.Description),
+			Context:     github.String(contextOf(pr)),
+		}
+		if rpt.TargetURL != "" {
+			status.TargetURL = github.String(rpt.TargetURL)
+		}
+		if _, _, err := client.Repositories.CreateStatus(ctx, owner, repo, sha, status); err != nil {
+			return fmt.Errorf("creating status for %s/%s@%s: %w", owner, repo, sha, err)
+		}
+	}
+	logger.Infof("Reported %q to %s/%s@%s", rpt.State, owner, repo, sha)
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return r.patch(namespace, name, types.MergePatchType, patch)
+}
+
+// reportCheckRun creates or updates the check run for the PipelineRun,
+// returning its ID.
+func (r *Reconciler) reportCheckRun(ctx context.Context, client *github.Client, owner, repo, sha string,
+	pr *v1alpha1.PipelineRun, rpt report) (int64, error) {
+	status, conclusion := "in_progress", ""
+	switch rpt.State {
+	case "success":
+		status, conclusion = "completed", "success"
+	case "failure":
+		status, conclusion = "completed", "failure"
+	case "error":
+		status, conclusion = "completed", "cancelled"
+	}
+	output := &github.CheckRunOutput{
+		Title:   github.String(rpt.Description),
+		Summary: github.String(rpt.Description),
+	}
+	var details *string
+	if rpt.TargetURL != "" {
+		details = github.String(rpt.TargetURL)
+	}
+	var completedAt *github.Timestamp
+	if conclusion != "" {
+		completedAt = &github.Timestamp{Time: completionTime(pr).Time}
+	}
+
+	if raw, ok := pr.Annotations[CheckRunAnnotationKey]; ok {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err == nil {
+			opts := github.UpdateCheckRunOptions{
+				Name:        contextOf(pr),
+				DetailsURL:  details,
+				Status:      github.String(status),
+				CompletedAt: completedAt,
+				Output:      output,
+			}
+			if conclusion != "" {
+				opts.Conclusion = github.String(conclusion)
+			}
+			if _, _, err := client.Checks.UpdateCheckRun(ctx, owner, repo, id, opts); err != nil {
+				return 0, fmt.Errorf("updating check run %d for %s/%s: %w", id, owner, repo, err)
+			}
+			return id, nil
+		}
+		logging.FromContext(ctx).Warnf("Ignoring malformed check run ID %q", raw)
+	}
+
+	opts := github.CreateCheckRunOptions{
+		Name:        contextOf(pr),
+		HeadSHA:     sha,
+		DetailsURL:  details,
+		ExternalID:  github.String(string(pr.UID)),
+		Status:      github.String(status),
+		CompletedAt: completedAt,
+		Output:      output,
+	}
+	if conclusion != "" {
+		opts.Conclusion = github.String(conclusion)
+	}
+	cr, _, err := client.Checks.CreateCheckRun(ctx, owner, repo, opts)
+	if err != nil {
+		return 0, fmt.Errorf("creating check run for %s/%s@%s: %w", owner, repo, sha, err)
+	}
+	return cr.GetID(), nil
+}
+
+// clientFor returns a GitHub client authenticated with the token from the
+// secret of the GithubBinding that applies to the PipelineRun.
+func (r *Reconciler) clientFor(ctx context.Context, pr *v1alpha1.PipelineRun) (*github.Client, error) {
+	var secretName string
+	if name, ok := pr.Annotations[BindingAnnotationKey]; ok {
+		gb, err := r.bindingLister.GithubBindings(pr.Namespace).Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("getting GithubBinding %q: %w", name, err)
+		}
+		secretName = gb.Spec.Secret.Name
+	} else {
+		gbs, err := r.bindingLister.GithubBindings(pr.Namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		if len(gbs) != 1 {
+			return nil, fmt.Errorf("expected exactly one GithubBinding in %q (or the %s annotation), found %d",
+				pr.Namespace, BindingAnnotationKey, len(gbs))
+		}
+		secretName = gbs[0].Spec.Secret.Name
+	}
+
+	secret, err := r.kubeclient.CoreV1().Secrets(pr.Namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	token, ok := secret.Data[accessTokenKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %q has no %q key", secretName, accessTokenKey)
+	}
+
+	client := github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: strings.TrimSpace(string(token))},
+	)))
+	client.BaseURL = (*url.URL)(config.FromContext(ctx).BaseURL.DeepCopy())
+	return client, nil
+}
+
+// reportable returns whether the PipelineRun asks to have its status reported.
+func reportable(pr *v1alpha1.PipelineRun) bool {
+	return pr.Annotations[RepoAnnotationKey] != "" && pr.Annotations[ShaAnnotationKey] != ""
+}
+
+func splitRepo(s string) (string, string, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("%s must have the form owner/name, was: %q", RepoAnnotationKey, s)
+	}
+	return parts[0], parts[1], nil
+}
+
+// contextOf returns the context under which we report the PipelineRun's
+// status, which defaults to mink/<pipeline>.
+func contextOf(pr *v1alpha1.PipelineRun) string {
+	if c := pr.Annotations[ContextAnnotationKey]; c != "" {
+		return c
+	}
+	if pr.Spec.PipelineRef != nil && pr.Spec.PipelineRef.Name != "" {
+		return "mink/" + pr.Spec.PipelineRef.Name
+	}
+	return "mink/" + pr.Name
+}
+
+func completionTime(pr *v1alpha1.PipelineRun) metav1.Time {
+	if pr.Status.CompletionTime != nil {
+		return *pr.Status.CompletionTime
+	}
+	return metav1.Now()
+}
+
+func reportFor(pr *v1alpha1.PipelineRun, link string) report {
+	cond := pr.Status.GetCondition(apis.ConditionSucceeded)
+	rpt := report{TargetURL: link}
+	switch {
+	case !pr.IsDone():
+		rpt.State, rpt.Description = "pending", "The PipelineRun is running."
+	case pr.IsCancelled():
+		rpt.State, rpt.Description = "error", "The PipelineRun was cancelled."
+	case cond.IsTrue():
+		rpt.State, rpt.Description = "success", "The PipelineRun succeeded."
+	default:
+		rpt.State, rpt.Description = "failure", "The PipelineRun failed."
+		if cond != nil && cond.Message != "" {
+			rpt.Description = cond.Message
+		}
+	}
+	// GitHub limits descriptions to 140 characters.
+	if r := []rune(rpt.Description); len(r) > 140 {
+		rpt.Description = string(r[:137]) + "..."
+	}
+	return rpt
+}
+
+// targetURL returns the link reported with the status of the PipelineRun,
+// which is the configured target-url or else the archived logs of its
+// TaskRuns.  The archive server only serves those who may get the runs,
+// so linking it from a public status doesn't expose the logs.
+func targetURL(ctx context.Context, pr *v1alpha1.PipelineRun) (string, error) {
+	t := config.FromContext(ctx).TargetURL
+	if t == nil {
+		return logsURL(pr), nil
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, pr.ObjectMeta); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// logsURL returns a link to the archived logs of the PipelineRun's
+// TaskRuns, preferring those of a TaskRun that failed.
+func logsURL(pr *v1alpha1.PipelineRun) string {
+	names := make([]string, 0, len(pr.Status.TaskRuns))
+	for name := range pr.Status.TaskRuns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var link string
+	for _, name := range names {
+		trs := pr.Status.TaskRuns[name]
+		if trs == nil || trs.Status == nil {
+			continue
+		}
+		archived := trs.Status.GetCondition(taskrunlogs.ConditionLogsArchived)
+		if archived == nil || archived.Message == "" {
+			continue
+		}
+		if trs.Status.GetCondition(apis.ConditionSucceeded).IsFalse() {
+			return archived.Message
+		}
+		if link == "" {
+			link = archived.Message
+		}
+	}
+	return link
+}
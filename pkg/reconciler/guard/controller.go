@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guard
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+	contourclient "knative.dev/net-contour/pkg/client/injection/client"
+	httpproxyinformer "knative.dev/net-contour/pkg/client/injection/informers/projectcontour/v1/httpproxy"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	serviceinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/service"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	pkgnetwork "knative.dev/pkg/network"
+	pkgreconciler "knative.dev/pkg/reconciler"
+	ingressinformer "knative.dev/serving/pkg/client/injection/informers/networking/v1alpha1/ingress"
+)
+
+const (
+	controllerAgentName = "guard-controller"
+
+	// guardPort is the port on which the guard receives the requests that
+	// Envoy routes through it.
+	guardPort = ":8084"
+)
+
+// NewController creates a new controller that routes the traffic for
+// KIngresses that ask for rate limiting or external authorization through
+// the guard, and starts the guard.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	ingressInformer := ingressinformer.Get(ctx)
+	serviceInformer := serviceinformer.Get(ctx)
+
+	c := &Reconciler{
+		kubeclient:      kubeclient.Get(ctx),
+		contourclient:   contourclient.Get(ctx),
+		ingressLister:   ingressInformer.Lister(),
+		serviceLister:   serviceInformer.Lister(),
+		httpProxyLister: httpproxyinformer.Get(ctx).Lister(),
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+
+	logger.Info("Setting up event handlers")
+	ingressInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+	serviceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: pkgreconciler.LabelExistsFilterFunc(IngressLabelKey),
+		Handler:    controller.HandleAll(impl.EnqueueLabelOfNamespaceScopedResource("", IngressLabelKey)),
+	})
+
+	h := newHandler(logger.Named("guard"), ingressInformer.Lister())
+	srv := pkgnetwork.NewServer(guardPort, h)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatalw("Error serving the guard", "error", err)
+		}
+	}()
+	go func() {
+		// Periodically forget the clients that have gone quiet.
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				srv.Close()
+				return
+			case <-ticker.C:
+				h.limiters.forget(time.Hour)
+			}
+		}
+	}()
+
+	return impl
+}
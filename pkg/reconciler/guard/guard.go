@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guard
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	contourclientset "knative.dev/net-contour/pkg/client/clientset/versioned"
+	contourlisters "knative.dev/net-contour/pkg/client/listers/projectcontour/v1"
+	"knative.dev/net-contour/pkg/reconciler/contour/resources"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/serving/pkg/apis/networking/v1alpha1"
+	netlisters "knative.dev/serving/pkg/client/listers/networking/v1alpha1"
+)
+
+// Reconciler implements controller.Reconciler for KIngresses, maintaining
+// the guard Services of those asking for protection and routing their
+// HTTPProxies through them.
+type Reconciler struct {
+	kubeclient    kubernetes.Interface
+	contourclient contourclientset.Interface
+
+	// listers index properties about resources
+	ingressLister   netlisters.IngressLister
+	serviceLister   corev1listers.ServiceLister
+	httpProxyLister contourlisters.HTTPProxyLister
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	ing, err := r.ingressLister.Ingresses(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		// Our Service and the HTTPProxies are garbage collected along with
+		// the KIngress.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if ing.GetDeletionTimestamp() != nil {
+		return nil
+	}
+
+	if policy, _ := ParsePolicy(ing.Annotations); policy == nil {
+		// Route the HTTPProxies directly before removing our Service.
+		if err := r.reconcileHTTPProxies(ctx, ing); err != nil {
+			return err
+		}
+		return r.deleteService(ing)
+	}
+
+	desired := MakeGuardService(ing)
+	existing, err := r.serviceLister.Services(namespace).Get(desired.Name)
+	if apierrs.IsNotFound(err) {
+		if _, err := r.kubeclient.CoreV1().Services(namespace).Create(desired); err != nil {
+			return err
+		}
+		// We guard the HTTPProxies once the new Service is in our
+		// informer's cache, which re-enqueues this KIngress.
+		return nil
+	} else if err != nil {
+		return err
+	} else if existing.Spec.ExternalName != desired.Spec.ExternalName ||
+		!equality.Semantic.DeepEqual(existing.Spec.Ports, desired.Spec.Ports) {
+		update := existing.DeepCopy()
+		update.Spec.Type = desired.Spec.Type
+		update.Spec.ExternalName = desired.Spec.ExternalName
+		update.Spec.Ports = desired.Spec.Ports
+		if _, err := r.kubeclient.CoreV1().Services(namespace).Update(update); err != nil {
+			return err
+		}
+	}
+
+	return r.reconcileHTTPProxies(ctx, ing)
+}
+
+// reconcileHTTPProxies guards the HTTPProxies of the KIngress.  Our
+// webhook applies the same rewrite to the updates net-contour makes.
+func (r *Reconciler) reconcileHTTPProxies(ctx context.Context, ing *v1alpha1.Ingress) error {
+	hps, err := r.httpProxyLister.HTTPProxies(ing.Namespace).List(labels.SelectorFromSet(labels.Set{
+		resources.ParentKey: ing.Name,
+	}))
+	if err != nil {
+		return err
+	}
+	ctx = WithListers(ctx, r.ingressLister, r.serviceLister)
+	for _, hp := range hps {
+		update := hp.DeepCopy()
+		Guard(ctx, update)
+		if equality.Semantic.DeepEqual(hp.Spec, update.Spec) {
+			continue
+		}
+		logging.FromContext(ctx).Infof("Rerouting HTTPProxy %s/%s", hp.Namespace, hp.Name)
+		if _, err := r.contourclient.ProjectcontourV1().HTTPProxies(hp.Namespace).Update(update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) deleteService(ing *v1alpha1.Ingress) error {
+	name := GuardServiceName(ing.Name)
+	if _, err := r.serviceLister.Services(ing.Namespace).Get(name); apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	err := r.kubeclient.CoreV1().Services(ing.Namespace).Delete(name, nil)
+	if apierrs.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guard
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	pkgnetwork "knative.dev/pkg/network"
+	"knative.dev/serving/pkg/apis/networking/v1alpha1"
+	netlisters "knative.dev/serving/pkg/client/listers/networking/v1alpha1"
+	"knative.dev/serving/pkg/network"
+)
+
+// hopHeaders are the hop-by-hop headers, which we don't pass along to the
+// external authorization endpoint.
+var hopHeaders = []string{
+	"Connection",
+	"Content-Length",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// handler protects and forwards the requests that Envoy routes through
+// the guard.
+type handler struct {
+	logger        *zap.SugaredLogger
+	ingressLister netlisters.IngressLister
+	proxy         *httputil.ReverseProxy
+	client        *http.Client
+	limiters      *limiters
+}
+
+func newHandler(logger *zap.SugaredLogger, ingressLister netlisters.IngressLister) *handler {
+	return &handler{
+		logger:        logger,
+		ingressLister: ingressLister,
+		proxy: &httputil.ReverseProxy{
+			// We point the request at its target before proxying it.
+			Director:  func(*http.Request) {},
+			Transport: pkgnetwork.NewAutoTransport(),
+			// Flush immediately, so that we don't hold up streaming responses.
+			FlushInterval: -1,
+		},
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		limiters: &limiters{
+			entries: make(map[string]*limiterEntry),
+		},
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ing, target, err := h.route(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	policy, err := ParsePolicy(ing.Annotations)
+	if err != nil {
+		h.logger.Errorw("Error parsing the policy of "+ing.Namespace+"/"+ing.Name, zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	// Knative's probes are answered by the activator or queue-proxy without
+	// reaching the application, so we let them through lest the KIngress
+	// never becomes ready.  The KIngress may also have just stopped asking
+	// for protection, before Envoy stops routing it through us.
+	if policy != nil && network.KnativeProbeHeader(r) == "" {
+		if policy.RateLimit != nil && !h.allow(r, ing, policy.RateLimit) {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		if policy.Authz != nil && !h.authorize(w, r, policy.Authz) {
+			return
+		}
+	}
+
+	r.URL.Scheme = "http"
+	r.URL.Host = target
+	h.proxy.ServeHTTP(w, r)
+}
+
+// route returns the KIngress on whose behalf Envoy routed the request
+// through the guard, and the address of the service of that KIngress to
+// which we forward it.
+func (h *handler) route(r *http.Request) (*v1alpha1.Ingress, string, error) {
+	key, service := r.Header.Get(IngressHeaderName), r.Header.Get(ServiceHeaderName)
+	r.Header.Del(IngressHeaderName)
+	r.Header.Del(ServiceHeaderName)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil || namespace == "" || name == "" {
+		return nil, "", fmt.Errorf("invalid %s: %q", IngressHeaderName, key)
+	}
+	svcName, svcPort, err := net.SplitHostPort(service)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid %s: %q", ServiceHeaderName, service)
+	}
+	ing, err := h.ingressLister.Ingresses(namespace).Get(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("unknown KIngress %s: %w", key, err)
+	}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			for _, split := range path.Splits {
+				if split.ServiceNamespace == namespace && split.ServiceName == svcName &&
+					split.ServicePort.String() == svcPort {
+					return ing, net.JoinHostPort(pkgnetwork.GetServiceHostname(svcName, namespace), svcPort), nil
+				}
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("%s is not a service of KIngress %s", service, key)
+}
+
+// allow checks whether the client may send another request to the host.
+func (h *handler) allow(r *http.Request, ing *v1alpha1.Ingress, rl *RateLimit) bool {
+	return h.limiters.allow(strings.Join([]string{
+		ing.Namespace, ing.Name, r.Host, rl.String(), clientIP(r),
+	}, "|"), rl)
+}
+
+// authorize asks the external authorization endpoint to admit the request,
+// passing along its method, path and headers (but not its body), and
+// relays the endpoint's response to the client when it denies the request.
+func (h *handler) authorize(w http.ResponseWriter, r *http.Request, authz *apis.URL) bool {
+	req, err := http.NewRequestWithContext(r.Context(), r.Method,
+		strings.TrimSuffix(authz.String(), "/")+r.URL.RequestURI(), nil)
+	if err != nil {
+		h.logger.Errorw("Error creating authorization request", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return false
+	}
+	req.Header = r.Header.Clone()
+	for _, name := range hopHeaders {
+		req.Header.Del(name)
+	}
+	req.Header.Set("X-Forwarded-Host", r.Host)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		h.logger.Errorw("Error calling authorization endpoint", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return true
+	}
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	for _, name := range hopHeaders {
+		w.Header().Del(name)
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+	return false
+}
+
+// clientIP returns the address of the client on whose behalf Envoy sent
+// the request.
+func clientIP(r *http.Request) string {
+	if ip := r.Header.Get("X-Envoy-External-Address"); ip != "" {
+		return ip
+	}
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		return strings.TrimSpace(strings.SplitN(xff, ",", 2)[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limiters holds the rate limiters of the clients that have recently sent
+// requests through the guard.
+type limiters struct {
+	mu      sync.Mutex
+	entries map[string]*limiterEntry
+}
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func (l *limiters) allow(key string, rl *RateLimit) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[key]
+	if !ok {
+		e = &limiterEntry{
+			limiter: rate.NewLimiter(rate.Every(rl.Per/time.Duration(rl.Requests)), rl.Requests),
+		}
+		l.entries[key] = e
+	}
+	e.lastSeen = time.Now()
+	return e.limiter.Allow()
+}
+
+// forget drops the rate limiters of the clients that haven't sent requests
+// for the provided period, whose buckets would be full again anyway.
+func (l *limiters) forget(period time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, e := range l.entries {
+		if time.Since(e.lastSeen) > period {
+			delete(l.entries, key)
+		}
+	}
+}
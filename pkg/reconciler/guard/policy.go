@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guard
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+const (
+	// RateLimitAnnotationKey is the annotation through which Services and
+	// Routes limit the rate of requests each client may send them, in the
+	// form <requests>/<unit>, where the unit is one of s, m or h.
+	RateLimitAnnotationKey = "networking.mink.knative.dev/rate-limit"
+
+	// AuthzAnnotationKey is the annotation through which Services and Routes
+	// specify the URL of the external authorization endpoint that must admit
+	// requests before they are forwarded.
+	AuthzAnnotationKey = "networking.mink.knative.dev/authz"
+)
+
+// RateLimit is the number of requests a client may send per period.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+var units = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// String returns the RateLimit in the form accepted by ParseRateLimit.
+func (rl *RateLimit) String() string {
+	unit := "s"
+	switch rl.Per {
+	case time.Minute:
+		unit = "m"
+	case time.Hour:
+		unit = "h"
+	}
+	return fmt.Sprintf("%d/%s", rl.Requests, unit)
+}
+
+// ParseRateLimit parses a rate limit of the form <requests>/<unit>.
+func ParseRateLimit(raw string) (*RateLimit, error) {
+	parts := strings.SplitN(raw, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("rate limit must have the form <requests>/<unit>, was: %q", raw)
+	}
+	n, err := strconv.Atoi(parts[0])
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("rate limit must allow a positive number of requests, was: %q", raw)
+	}
+	per, ok := units[parts[1]]
+	if !ok {
+		return nil, fmt.Errorf("rate limit unit must be one of s, m or h, was: %q", parts[1])
+	}
+	return &RateLimit{Requests: n, Per: per}, nil
+}
+
+// Policy is the protection that Services and Routes ask of the guard.
+type Policy struct {
+	// RateLimit holds the rate at which each client may send requests, if
+	// limited.
+	RateLimit *RateLimit
+
+	// Authz holds the URL of the external authorization endpoint, if any.
+	Authz *apis.URL
+}
+
+// ParsePolicy parses the Policy from the provided annotations, returning
+// nil when none of our annotations are present.
+func ParsePolicy(annotations map[string]string) (*Policy, error) {
+	var p Policy
+	if raw, ok := annotations[RateLimitAnnotationKey]; ok {
+		rl, err := ParseRateLimit(raw)
+		if err != nil {
+			return nil, err
+		}
+		p.RateLimit = rl
+	}
+	if raw, ok := annotations[AuthzAnnotationKey]; ok {
+		u, err := ParseAuthzURL(raw)
+		if err != nil {
+			return nil, err
+		}
+		p.Authz = u
+	}
+	if p.RateLimit == nil && p.Authz == nil {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// ParseAuthzURL parses the URL of an external authorization endpoint.
+func ParseAuthzURL(raw string) (*apis.URL, error) {
+	u, err := apis.ParseURL(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse authz URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("authz must be an absolute http(s) URL, was: %q", raw)
+	}
+	return u, nil
+}
+
+// Validate checks the annotations through which a Service or Route asks for
+// the protection of the guard.
+func Validate(ctx context.Context, om *metav1.ObjectMeta) (errs *apis.FieldError) {
+	if apis.IsInStatusUpdate(ctx) {
+		return nil
+	}
+	if raw, ok := om.Annotations[RateLimitAnnotationKey]; ok {
+		if _, err := ParseRateLimit(raw); err != nil {
+			errs = errs.Also(&apis.FieldError{
+				Message: err.Error(),
+				Paths:   []string{fmt.Sprintf("metadata.annotations[%s]", RateLimitAnnotationKey)},
+			})
+		}
+	}
+	if raw, ok := om.Annotations[AuthzAnnotationKey]; ok {
+		if _, err := ParseAuthzURL(raw); err != nil {
+			errs = errs.Also(&apis.FieldError{
+				Message: err.Error(),
+				Paths:   []string{fmt.Sprintf("metadata.annotations[%s]", AuthzAnnotationKey)},
+			})
+		}
+	}
+	return errs
+}
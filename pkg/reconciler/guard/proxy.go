@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guard
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	contourv1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/net-contour/pkg/reconciler/contour/resources"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/network"
+	"knative.dev/pkg/system"
+	"knative.dev/serving/pkg/apis/networking/v1alpha1"
+	netlisters "knative.dev/serving/pkg/client/listers/networking/v1alpha1"
+
+	"github.com/mattmoor/mink/pkg/reconciler/activatorlocality"
+)
+
+const (
+	// IngressLabelKey labels the guard Services with the name of the
+	// KIngress on whose behalf they route to the guard.
+	IngressLabelKey = "networking.mink.knative.dev/guarded-ingress"
+
+	// ServiceName is the name of the Service in front of the guard.
+	ServiceName = "guard"
+
+	// The headers through which Envoy tells the guard on behalf of which
+	// KIngress (<namespace>/<name>), and for which of its services
+	// (<name>:<port>), it routes a request.  Envoy sets these on each
+	// request, replacing whatever the client sent.  The guard only forwards
+	// requests to the services of that KIngress, and protects them as its
+	// annotations ask, so neither header can redirect or unprotect them.
+	// The Contour we vendor cannot program Envoy's rate limiting or external
+	// authorization filters itself.
+	IngressHeaderName = "K-Mink-Guard-Ingress"
+	ServiceHeaderName = "K-Mink-Guard-Service"
+
+	// httpPort and h2cPort are the ports over which Envoy reaches the
+	// services of the KIngress with HTTP/1 and h2c respectively, and the
+	// guard, which speaks both.
+	httpPort = 80
+	h2cPort  = 81
+)
+
+// GuardServiceName returns the name of the ExternalName Service through
+// which Envoy reaches the guard on behalf of the KIngress.
+func GuardServiceName(ingName string) string {
+	return kmeta.ChildName(ingName, "-guard")
+}
+
+// MakeGuardService creates the ExternalName Service that routes to the
+// guard on behalf of the provided KIngress.
+func MakeGuardService(ing *v1alpha1.Ingress) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GuardServiceName(ing.Name),
+			Namespace: ing.Namespace,
+			Labels: map[string]string{
+				IngressLabelKey: ing.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ing)},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: network.GetServiceHostname(ServiceName, system.Namespace()),
+			Ports: []corev1.ServicePort{{
+				Name: "http",
+				Port: httpPort,
+			}, {
+				Name: "http2",
+				Port: h2cPort,
+			}},
+		},
+	}
+}
+
+type listersKey struct{}
+
+type listers struct {
+	ingress netlisters.IngressLister
+	service corev1listers.ServiceLister
+}
+
+// WithListers attaches the listers used to guard HTTPProxies to the
+// provided context.
+func WithListers(ctx context.Context, ingressLister netlisters.IngressLister, serviceLister corev1listers.ServiceLister) context.Context {
+	return context.WithValue(ctx, listersKey{}, &listers{
+		ingress: ingressLister,
+		service: serviceLister,
+	})
+}
+
+// Guard rewrites the services of the HTTPProxy's routes, so that those of
+// KIngresses asking for protection are reached through the guard, and the
+// others directly.
+func Guard(ctx context.Context, hp *contourv1.HTTPProxy) {
+	l, ok := ctx.Value(listersKey{}).(*listers)
+	if !ok {
+		return
+	}
+	guarded := l.guarded(hp)
+	for i := range hp.Spec.Routes {
+		for j := range hp.Spec.Routes[i].Services {
+			svc := &hp.Spec.Routes[i].Services[j]
+			unguard(svc)
+			if !guarded {
+				continue
+			}
+			// Guard the services the KIngress routes to, even when
+			// they have since been routed to the node-local activator.
+			if s, err := l.service.Services(hp.Namespace).Get(svc.Name); err == nil {
+				svc.Name, svc.Port = activatorlocality.Unlocalize(svc, s)
+			}
+			// We only guard the ports on which the guard listens, since
+			// ExternalName Services cannot remap ports.
+			if svc.Port != httpPort && svc.Port != h2cPort {
+				continue
+			}
+			guard(svc, hp)
+		}
+	}
+}
+
+// guarded returns whether the KIngress behind the HTTPProxy asks for
+// protection, once its guard Service exists.
+func (l *listers) guarded(hp *contourv1.HTTPProxy) bool {
+	parent := hp.Labels[resources.ParentKey]
+	if parent == "" {
+		return false
+	}
+	ing, err := l.ingress.Ingresses(hp.Namespace).Get(parent)
+	if err != nil {
+		return false
+	}
+	// The webhook has validated the annotations, so treat those it would
+	// reject as though they were absent.
+	policy, err := ParsePolicy(ing.Annotations)
+	if err != nil || policy == nil {
+		return false
+	}
+	_, err = l.service.Services(hp.Namespace).Get(GuardServiceName(parent))
+	return err == nil
+}
+
+// guard routes the service through the guard, telling it on behalf of
+// which KIngress, and for which of its services.
+func guard(svc *contourv1.Service, hp *contourv1.HTTPProxy) {
+	parent := hp.Labels[resources.ParentKey]
+	if svc.RequestHeadersPolicy == nil {
+		svc.RequestHeadersPolicy = &contourv1.HeadersPolicy{}
+	}
+	svc.RequestHeadersPolicy.Set = append(svc.RequestHeadersPolicy.Set, contourv1.HeaderValue{
+		Name:  IngressHeaderName,
+		Value: hp.Namespace + "/" + parent,
+	}, contourv1.HeaderValue{
+		Name:  ServiceHeaderName,
+		Value: net.JoinHostPort(svc.Name, strconv.Itoa(svc.Port)),
+	})
+	svc.Name = GuardServiceName(parent)
+}
+
+// unguard undoes any earlier guarding of the service, recovering the
+// service to which it routed.
+func unguard(svc *contourv1.Service) {
+	if svc.RequestHeadersPolicy == nil {
+		return
+	}
+	set := make([]contourv1.HeaderValue, 0, len(svc.RequestHeadersPolicy.Set))
+	for _, hv := range svc.RequestHeadersPolicy.Set {
+		switch hv.Name {
+		case ServiceHeaderName:
+			if name, _, err := net.SplitHostPort(hv.Value); err == nil {
+				svc.Name = name
+			}
+		case IngressHeaderName:
+		default:
+			set = append(set, hv)
+		}
+	}
+	if len(set) == 0 && len(svc.RequestHeadersPolicy.Remove) == 0 {
+		svc.RequestHeadersPolicy = nil
+		return
+	}
+	svc.RequestHeadersPolicy.Set = set
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkabroker
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/eventing/pkg/apis/eventing"
+	"knative.dev/eventing/pkg/apis/eventing/v1alpha1"
+	eventingclient "knative.dev/eventing/pkg/client/injection/client"
+	brokerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1alpha1/broker"
+	triggerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1alpha1/trigger"
+	endpointsinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/endpoints"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	pkgreconciler "knative.dev/pkg/reconciler"
+	"knative.dev/pkg/resolver"
+	"knative.dev/pkg/system"
+
+	"github.com/mattmoor/mink/pkg/kafkabroker"
+)
+
+const (
+	controllerAgentName = "kafka-broker-controller"
+
+	// configMapName holds the bootstrap servers of the Kafka cluster.
+	configMapName = "config-kafka"
+)
+
+// NewController creates a new controller that reconciles Brokers of the
+// KafkaBroker class.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	brokerInformer := brokerinformer.Get(ctx)
+	triggerInformer := triggerinformer.Get(ctx)
+	endpointsInformer := endpointsinformer.Get(ctx)
+
+	c := &Reconciler{
+		client:          eventingclient.Get(ctx),
+		lister:          brokerInformer.Lister(),
+		triggerLister:   triggerInformer.Lister(),
+		endpointsLister: endpointsInformer.Lister(),
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+	c.uriResolver = resolver.NewURIResolver(ctx, impl.EnqueueKey)
+
+	brokerFilter := pkgreconciler.AnnotationFilterFunc(eventing.BrokerClassKey, kafkabroker.BrokerClass, false /*allowUnset*/)
+	resync := func(interface{}) {
+		impl.FilteredGlobalResync(brokerFilter, brokerInformer.Informer())
+	}
+
+	logger.Info("Setting up ConfigMap receivers")
+	cmw.Watch(configMapName, func(cm *corev1.ConfigMap) {
+		c.updateKafkaConfig(cm)
+		resync(cm)
+	})
+
+	logger.Info("Setting up event handlers")
+	brokerInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: brokerFilter,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	triggerInformer.Informer().AddEventHandler(controller.HandleAll(func(obj interface{}) {
+		if t, ok := obj.(*v1alpha1.Trigger); ok {
+			impl.EnqueueKey(types.NamespacedName{Namespace: t.Namespace, Name: t.Spec.Broker})
+		}
+	}))
+
+	// The ingress is shared by every Broker.
+	endpointsInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterWithNameAndNamespace(system.Namespace(), ServiceName),
+		Handler:    controller.HandleAll(resync),
+	})
+
+	return impl
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkabroker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Shopify/sarama"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/eventing-contrib/kafka/channel/pkg/reconciler/controller/resources"
+	"knative.dev/eventing-contrib/kafka/channel/pkg/utils"
+	eventingduckv1alpha1 "knative.dev/eventing/pkg/apis/duck/v1alpha1"
+	"knative.dev/eventing/pkg/apis/eventing/v1alpha1"
+	messagingv1alpha1 "knative.dev/eventing/pkg/apis/messaging/v1alpha1"
+	clientset "knative.dev/eventing/pkg/client/clientset/versioned"
+	listers "knative.dev/eventing/pkg/client/listers/eventing/v1alpha1"
+	"knative.dev/pkg/apis"
+	duckv1alpha1 "knative.dev/pkg/apis/duck/v1alpha1"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/network"
+	"knative.dev/pkg/resolver"
+	"knative.dev/pkg/system"
+
+	"github.com/mattmoor/mink/pkg/kafkabroker"
+)
+
+const (
+	// ServiceName is the name of the Service in front of the Broker
+	// ingresses in the dataplane, to which Brokers send their events.
+	ServiceName = "kafka-broker-ingress"
+
+	// finalizerName is the finalizer with which we delete the topics of
+	// deleted Brokers.
+	finalizerName = "kafkabroker.eventing.mink.knative.dev"
+)
+
+// Reconciler implements controller.Reconciler for Brokers of the
+// KafkaBroker class, creating their topics and resolving the subscribers
+// of their Triggers, whose consumer groups the dataplane runs.
+type Reconciler struct {
+	client      clientset.Interface
+	uriResolver *resolver.URIResolver
+
+	// listers index properties about resources
+	lister          listers.BrokerLister
+	triggerLister   listers.TriggerLister
+	endpointsLister corev1listers.EndpointsLister
+
+	// kafkaConfig is read from config-kafka, or else kafkaConfigErr
+	// explains why it couldn't be.
+	m              sync.RWMutex
+	kafkaConfig    *utils.KafkaConfig
+	kafkaConfigErr error
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	original, err := r.lister.Brokers(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// We finalize Brokers that were ours, even if their class changed.
+	if original.DeletionTimestamp != nil {
+		return r.finalize(ctx, original)
+	}
+	if !kafkabroker.IsKafkaBroker(original) {
+		return nil
+	}
+	if !sets.NewString(original.Finalizers...).Has(finalizerName) {
+		// The next Reconcile will pick up the updated Broker.
+		return r.setFinalizers(original, append(original.Finalizers, finalizerName))
+	}
+
+	b := original.DeepCopy()
+	b.Status.InitializeConditions()
+	reconcileErr := r.reconcile(ctx, b)
+	b.Status.ObservedGeneration = b.Generation
+
+	if !equality.Semantic.DeepEqual(original.Status, b.Status) {
+		if _, err := r.client.EventingV1alpha1().Brokers(namespace).UpdateStatus(b); err != nil {
+			logger.Warnw("Failed to update Broker status", "error", err)
+			return err
+		}
+	}
+	if err := r.reconcileTriggers(ctx, b); err != nil {
+		return err
+	}
+	return reconcileErr
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, b *v1alpha1.Broker) error {
+	td, err := kafkabroker.TopicDetail(b)
+	if err != nil {
+		// There is no point retrying until the Broker changes.
+		b.Status.MarkTriggerChannelFailed("InvalidTopic", "%v", err)
+		return nil
+	}
+	admin, err := r.clusterAdmin()
+	if err != nil {
+		b.Status.MarkTriggerChannelFailed("InvalidConfiguration", "Unable to connect to Kafka: %v", err)
+		return err
+	}
+	defer admin.Close()
+
+	topic := kafkabroker.TopicName(b.Namespace, b.Name)
+	err = admin.CreateTopic(topic, td, false)
+	if terr, ok := err.(*sarama.TopicError); ok && terr.Err == sarama.ErrTopicAlreadyExists {
+		err = nil
+	}
+	if err != nil {
+		b.Status.MarkTriggerChannelFailed("TopicCreateFailed", "Unable to create topic %q: %v", topic, err)
+		return err
+	}
+	// The topic takes the place of the trigger channel of the mt broker,
+	// which is ready once it is addressable.
+	b.Status.PropagateTriggerChannelReadiness(&eventingduckv1alpha1.ChannelableStatus{
+		AddressStatus: duckv1alpha1.AddressStatus{Address: &duckv1alpha1.Addressable{}},
+	})
+
+	// The ingress and the consumers of Triggers run in the same dataplane
+	// pods, so both are available when the ingress is.
+	e, err := r.endpointsLister.Endpoints(system.Namespace()).Get(ServiceName)
+	if err != nil {
+		b.Status.MarkIngressFailed("ServiceFailure", "%v", err)
+		b.Status.MarkFilterFailed("ServiceFailure", "%v", err)
+		return err
+	}
+	b.Status.PropagateIngressAvailability(e)
+	b.Status.PropagateFilterAvailability(e)
+
+	b.Status.SetAddress(&apis.URL{
+		Scheme: "http",
+		Host:   network.GetServiceHostname(ServiceName, system.Namespace()),
+		Path:   fmt.Sprintf("/%s/%s", b.Namespace, b.Name),
+	})
+	return nil
+}
+
+// reconcileTriggers reconciles the Triggers of the Broker.
+func (r *Reconciler) reconcileTriggers(ctx context.Context, b *v1alpha1.Broker) error {
+	triggers, err := r.triggerLister.Triggers(b.Namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, original := range triggers {
+		if original.Spec.Broker != b.Name || original.DeletionTimestamp != nil {
+			continue
+		}
+		t := original.DeepCopy()
+		t.Status.InitializeConditions()
+		if b.DeletionTimestamp != nil {
+			t.Status.MarkBrokerFailed("BrokerDoesNotExist", "Broker %q does not exist", b.Name)
+		} else {
+			r.reconcileTrigger(ctx, b, t)
+		}
+		t.Status.ObservedGeneration = t.Generation
+
+		if equality.Semantic.DeepEqual(original.Status, t.Status) {
+			continue
+		}
+		if _, err := r.client.EventingV1alpha1().Triggers(t.Namespace).UpdateStatus(t); err != nil {
+			errs = append(errs, fmt.Errorf("updating the status of Trigger %q: %w", t.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// reconcileTrigger resolves the subscriber of the Trigger, to which the
+// dataplane delivers the events of the Broker once it is ready.
+func (r *Reconciler) reconcileTrigger(ctx context.Context, b *v1alpha1.Broker, t *v1alpha1.Trigger) {
+	t.Status.PropagateBrokerStatus(&b.Status)
+	// We don't (yet) support the knative.dev/dependency annotation.
+	t.Status.MarkDependencySucceeded()
+
+	subscriber := t.Spec.Subscriber.DeepCopy()
+	if subscriber.Ref != nil {
+		subscriber.Ref.Namespace = t.Namespace
+	}
+	uri, err := r.uriResolver.URIFromDestinationV1(*subscriber, b)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Unable to resolve the subscriber of Trigger "+t.Name, "error", err)
+		t.Status.SubscriberURI = nil
+		t.Status.MarkSubscriberResolvedFailed("Unable to get the Subscriber's URI", "%v", err)
+		return
+	}
+	t.Status.SubscriberURI = uri
+	t.Status.MarkSubscriberResolvedSucceeded()
+
+	switch order := t.Annotations[kafkabroker.DeliveryOrderAnnotation]; order {
+	case "", kafkabroker.Ordered, kafkabroker.Unordered:
+	default:
+		t.Status.MarkNotSubscribed("InvalidDeliveryOrder", "%s must be %q or %q, got %q",
+			kafkabroker.DeliveryOrderAnnotation, kafkabroker.Ordered, kafkabroker.Unordered, order)
+		return
+	}
+
+	// Every replica of the dataplane joins the consumer group of every
+	// Trigger, so they are subscribed once the Broker is ready.
+	if b.Status.IsReady() {
+		ss := &messagingv1alpha1.SubscriptionStatus{}
+		ss.InitializeConditions()
+		ss.MarkReferencesResolved()
+		ss.MarkChannelReady()
+		ss.MarkAddedToChannel()
+		t.Status.PropagateSubscriptionStatus(ss)
+	} else {
+		t.Status.MarkSubscribedUnknown("BrokerNotReady", "Broker %q is not ready", b.Name)
+	}
+}
+
+// finalize deletes the topic of the Broker.
+func (r *Reconciler) finalize(ctx context.Context, b *v1alpha1.Broker) error {
+	logger := logging.FromContext(ctx)
+	finalizers := sets.NewString(b.Finalizers...)
+	if !finalizers.Has(finalizerName) {
+		return nil
+	}
+
+	topic := kafkabroker.TopicName(b.Namespace, b.Name)
+	if admin, err := r.clusterAdmin(); err != nil {
+		// Rather than hold on to the Broker until Kafka is configured
+		// (which it may never be), we leave the topic behind.
+		logger.Warnf("Leaving topic %q behind: %v", topic, err)
+	} else {
+		defer admin.Close()
+		if err := admin.DeleteTopic(topic); err != nil && err != sarama.ErrUnknownTopicOrPartition {
+			return fmt.Errorf("deleting topic %q: %w", topic, err)
+		}
+	}
+	if err := r.reconcileTriggers(ctx, b); err != nil {
+		return err
+	}
+
+	finalizers.Delete(finalizerName)
+	return r.setFinalizers(b, finalizers.List())
+}
+
+func (r *Reconciler) setFinalizers(b *v1alpha1.Broker, finalizers []string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers":      finalizers,
+			"resourceVersion": b.ResourceVersion,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.client.EventingV1alpha1().Brokers(b.Namespace).Patch(b.Name, types.MergePatchType, patch)
+	return err
+}
+
+// clusterAdmin connects to the Kafka cluster of config-kafka.
+func (r *Reconciler) clusterAdmin() (sarama.ClusterAdmin, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	if r.kafkaConfigErr != nil {
+		return nil, r.kafkaConfigErr
+	} else if r.kafkaConfig == nil {
+		return nil, errors.New("config-kafka has not been read")
+	}
+	return resources.MakeClient(controllerAgentName, r.kafkaConfig.Brokers)
+}
+
+// updateKafkaConfig reads the Kafka configuration from config-kafka.
+func (r *Reconciler) updateKafkaConfig(cm *corev1.ConfigMap) {
+	kafkaConfig, err := utils.GetKafkaConfig(cm.Data)
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.kafkaConfig, r.kafkaConfigErr = kafkaConfig, err
+}
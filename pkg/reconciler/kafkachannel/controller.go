@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkachannel
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/eventing-contrib/kafka/channel/pkg/apis/messaging/v1alpha1"
+	kafkaclient "knative.dev/eventing-contrib/kafka/channel/pkg/client/injection/client"
+	kafkachannelinformer "knative.dev/eventing-contrib/kafka/channel/pkg/client/injection/informers/messaging/v1alpha1/kafkachannel"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	daemonsetinformer "knative.dev/pkg/client/injection/kube/informers/apps/v1/daemonset"
+	endpointsinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/endpoints"
+	serviceinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/service"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+)
+
+const (
+	controllerAgentName = "kafka-channel-controller"
+
+	// configMapName holds the bootstrap servers of the Kafka cluster.
+	configMapName = "config-kafka"
+)
+
+// NewController creates a new controller that reconciles KafkaChannels.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	channelInformer := kafkachannelinformer.Get(ctx)
+	serviceInformer := serviceinformer.Get(ctx)
+	endpointsInformer := endpointsinformer.Get(ctx)
+	daemonSetInformer := daemonsetinformer.Get(ctx)
+
+	c := &Reconciler{
+		kubeclient:      kubeclient.Get(ctx),
+		client:          kafkaclient.Get(ctx),
+		lister:          channelInformer.Lister(),
+		serviceLister:   serviceInformer.Lister(),
+		endpointsLister: endpointsInformer.Lister(),
+		daemonSetLister: daemonSetInformer.Lister(),
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+
+	logger.Info("Setting up ConfigMap receivers")
+	cmw.Watch(configMapName, func(cm *corev1.ConfigMap) {
+		c.updateKafkaConfig(cm)
+		impl.GlobalResync(channelInformer.Informer())
+	})
+
+	logger.Info("Setting up event handlers")
+	channelInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	serviceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterGroupKind(v1alpha1.Kind("KafkaChannel")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	// The dispatchers are shared by every KafkaChannel.
+	grCh := func(interface{}) {
+		impl.GlobalResync(channelInformer.Informer())
+	}
+	endpointsInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterWithNameAndNamespace(system.Namespace(), ServiceName),
+		Handler:    controller.HandleAll(grCh),
+	})
+	daemonSetInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterWithNameAndNamespace(system.Namespace(), dataplaneName),
+		Handler:    controller.HandleAll(grCh),
+	})
+
+	return impl
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkachannel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Shopify/sarama"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/eventing-contrib/kafka/channel/pkg/apis/messaging/v1alpha1"
+	clientset "knative.dev/eventing-contrib/kafka/channel/pkg/client/clientset/versioned"
+	listers "knative.dev/eventing-contrib/kafka/channel/pkg/client/listers/messaging/v1alpha1"
+	"knative.dev/eventing-contrib/kafka/channel/pkg/reconciler/controller/resources"
+	"knative.dev/eventing-contrib/kafka/channel/pkg/utils"
+	eventingduckv1alpha1 "knative.dev/eventing/pkg/apis/duck/v1alpha1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/network"
+	"knative.dev/pkg/system"
+)
+
+const (
+	// ServiceName is the name of the Service in front of the dataplane's
+	// dispatchers, to which the Service of each KafkaChannel points.
+	ServiceName = "kafkachannel-dispatcher"
+
+	// dataplaneName is the name of the DaemonSet running the dispatchers.
+	dataplaneName = "dataplane"
+
+	// finalizerName is the finalizer with which we delete the topics of
+	// deleted KafkaChannels.
+	finalizerName = "kafkachannel.messaging.mink.knative.dev"
+)
+
+// Reconciler implements controller.Reconciler for KafkaChannels, creating
+// their topics and pointing them at the dispatchers in the dataplane.
+type Reconciler struct {
+	kubeclient kubernetes.Interface
+	client     clientset.Interface
+
+	// listers index properties about resources
+	lister          listers.KafkaChannelLister
+	serviceLister   corev1listers.ServiceLister
+	endpointsLister corev1listers.EndpointsLister
+	daemonSetLister appsv1listers.DaemonSetLister
+
+	// kafkaConfig is read from config-kafka, or else kafkaConfigErr
+	// explains why it couldn't be.
+	m              sync.RWMutex
+	kafkaConfig    *utils.KafkaConfig
+	kafkaConfigErr error
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	original, err := r.lister.KafkaChannels(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if original.DeletionTimestamp != nil {
+		return r.finalize(ctx, original)
+	}
+	if !sets.NewString(original.Finalizers...).Has(finalizerName) {
+		// The next Reconcile will pick up the updated KafkaChannel.
+		return r.setFinalizers(original, append(original.Finalizers, finalizerName))
+	}
+
+	kc := original.DeepCopy()
+	kc.SetDefaults(ctx)
+	kc.Status.InitializeConditions()
+	reconcileErr := r.reconcile(ctx, kc)
+	kc.Status.ObservedGeneration = kc.Generation
+
+	if equality.Semantic.DeepEqual(original.Status, kc.Status) {
+		return reconcileErr
+	}
+	if _, err := r.client.MessagingV1alpha1().KafkaChannels(namespace).UpdateStatus(kc); err != nil {
+		logger.Warnw("Failed to update KafkaChannel status", "error", err)
+		return err
+	}
+	return reconcileErr
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, kc *v1alpha1.KafkaChannel) error {
+	admin, err := r.clusterAdmin()
+	if err != nil {
+		kc.Status.MarkConfigFailed("InvalidConfiguration", "Unable to connect to Kafka: %v", err)
+		return err
+	}
+	defer admin.Close()
+	kc.Status.MarkConfigTrue()
+
+	topic := utils.TopicName(utils.KafkaChannelSeparator, kc.Namespace, kc.Name)
+	err = admin.CreateTopic(topic, &sarama.TopicDetail{
+		NumPartitions:     kc.Spec.NumPartitions,
+		ReplicationFactor: kc.Spec.ReplicationFactor,
+	}, false)
+	if terr, ok := err.(*sarama.TopicError); ok && terr.Err == sarama.ErrTopicAlreadyExists {
+		err = nil
+	}
+	if err != nil {
+		kc.Status.MarkTopicFailed("TopicCreateFailed", "Unable to create topic %q: %v", topic, err)
+		return err
+	}
+	kc.Status.MarkTopicTrue()
+
+	if err := r.reconcileDispatcher(kc); err != nil {
+		return err
+	}
+
+	svc, err := r.reconcileChannelService(kc)
+	if err != nil {
+		kc.Status.MarkChannelServiceFailed("ChannelServiceFailed", "Unable to reconcile the channel Service: %v", err)
+		return err
+	}
+	kc.Status.MarkChannelServiceTrue()
+	kc.Status.SetAddress(&apis.URL{
+		Scheme: "http",
+		Host:   network.GetServiceHostname(svc.Name, svc.Namespace),
+	})
+
+	// Every replica of the dispatcher joins the consumer group of every
+	// subscriber, so they are ready once the dispatchers are.
+	kc.Status.SubscribableStatus = nil
+	if kc.Spec.Subscribable != nil && kc.Status.IsReady() {
+		kc.Status.SubscribableStatus = &eventingduckv1alpha1.SubscribableStatus{}
+		for _, sub := range kc.Spec.Subscribable.Subscribers {
+			kc.Status.SubscribableStatus.Subscribers = append(kc.Status.SubscribableStatus.Subscribers,
+				eventingduckv1alpha1.SubscriberStatus{
+					UID:                sub.UID,
+					ObservedGeneration: sub.Generation,
+					Ready:              corev1.ConditionTrue,
+				})
+		}
+	}
+	return nil
+}
+
+// reconcileDispatcher reflects the readiness of the dispatchers in the
+// dataplane, and of the Service in front of them.
+func (r *Reconciler) reconcileDispatcher(kc *v1alpha1.KafkaChannel) error {
+	ds, err := r.daemonSetLister.DaemonSets(system.Namespace()).Get(dataplaneName)
+	if err != nil {
+		kc.Status.MarkDispatcherUnknown("DataplaneUnavailable", "Unable to get the dataplane: %v", err)
+		return err
+	}
+	// The dispatchers run in the dataplane DaemonSet, whose availability we
+	// surface the way the KafkaChannel expects of a Deployment.
+	available := corev1.ConditionFalse
+	if ds.Status.NumberAvailable > 0 {
+		available = corev1.ConditionTrue
+	}
+	kc.Status.PropagateDispatcherStatus(&appsv1.DeploymentStatus{
+		Conditions: []appsv1.DeploymentCondition{{
+			Type:    appsv1.DeploymentAvailable,
+			Status:  available,
+			Reason:  "DataplaneAvailability",
+			Message: fmt.Sprintf("%d of %d dataplane pods are available.", ds.Status.NumberAvailable, ds.Status.DesiredNumberScheduled),
+		}},
+	})
+
+	if _, err := r.serviceLister.Services(system.Namespace()).Get(ServiceName); err != nil {
+		kc.Status.MarkServiceFailed("DispatcherServiceFailed", "Unable to get the dispatcher Service: %v", err)
+		return err
+	}
+	kc.Status.MarkServiceTrue()
+
+	e, err := r.endpointsLister.Endpoints(system.Namespace()).Get(ServiceName)
+	if err != nil {
+		kc.Status.MarkEndpointsFailed("DispatcherEndpointsFailed", "Unable to get the dispatcher Endpoints: %v", err)
+		return err
+	}
+	for _, subset := range e.Subsets {
+		if len(subset.Addresses) > 0 {
+			kc.Status.MarkEndpointsTrue()
+			return nil
+		}
+	}
+	kc.Status.MarkEndpointsFailed("DispatcherEndpointsNotReady", "There are no ready dispatchers.")
+	return nil
+}
+
+// reconcileChannelService points the Service of the KafkaChannel at the
+// dispatchers.
+func (r *Reconciler) reconcileChannelService(kc *v1alpha1.KafkaChannel) (*corev1.Service, error) {
+	want, err := resources.MakeK8sService(kc, resources.ExternalService(system.Namespace(), ServiceName))
+	if err != nil {
+		return nil, err
+	}
+	have, err := r.serviceLister.Services(kc.Namespace).Get(want.Name)
+	if apierrs.IsNotFound(err) {
+		return r.kubeclient.CoreV1().Services(kc.Namespace).Create(want)
+	} else if err != nil {
+		return nil, err
+	} else if !metav1.IsControlledBy(have, kc) {
+		return nil, fmt.Errorf("service %q is not owned by KafkaChannel %q", have.Name, kc.Name)
+	} else if !equality.Semantic.DeepEqual(want.Spec, have.Spec) {
+		have = have.DeepCopy()
+		have.Spec = want.Spec
+		return r.kubeclient.CoreV1().Services(kc.Namespace).Update(have)
+	}
+	return have, nil
+}
+
+// finalize deletes the topic of the KafkaChannel.
+func (r *Reconciler) finalize(ctx context.Context, kc *v1alpha1.KafkaChannel) error {
+	logger := logging.FromContext(ctx)
+	finalizers := sets.NewString(kc.Finalizers...)
+	if !finalizers.Has(finalizerName) {
+		return nil
+	}
+
+	topic := utils.TopicName(utils.KafkaChannelSeparator, kc.Namespace, kc.Name)
+	if admin, err := r.clusterAdmin(); err != nil {
+		// Rather than hold on to the channel until Kafka is configured
+		// (which it may never be), we leave the topic behind.
+		logger.Warnf("Leaving topic %q behind: %v", topic, err)
+	} else {
+		defer admin.Close()
+		if err := admin.DeleteTopic(topic); err != nil && err != sarama.ErrUnknownTopicOrPartition {
+			return fmt.Errorf("deleting topic %q: %w", topic, err)
+		}
+	}
+
+	finalizers.Delete(finalizerName)
+	return r.setFinalizers(kc, finalizers.List())
+}
+
+func (r *Reconciler) setFinalizers(kc *v1alpha1.KafkaChannel, finalizers []string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers":      finalizers,
+			"resourceVersion": kc.ResourceVersion,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.client.MessagingV1alpha1().KafkaChannels(kc.Namespace).Patch(kc.Name, types.MergePatchType, patch)
+	return err
+}
+
+// clusterAdmin connects to the Kafka cluster of config-kafka.
+func (r *Reconciler) clusterAdmin() (sarama.ClusterAdmin, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	if r.kafkaConfigErr != nil {
+		return nil, r.kafkaConfigErr
+	} else if r.kafkaConfig == nil {
+		return nil, errors.New("config-kafka has not been read")
+	}
+	return resources.MakeClient(controllerAgentName, r.kafkaConfig.Brokers)
+}
+
+// updateKafkaConfig reads the Kafka configuration from config-kafka.
+func (r *Reconciler) updateKafkaConfig(cm *corev1.ConfigMap) {
+	kafkaConfig, err := utils.GetKafkaConfig(cm.Data)
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.kafkaConfig, r.kafkaConfigErr = kafkaConfig, err
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkasource
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/Shopify/sarama"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/eventing-contrib/kafka/source/pkg/apis/sources/v1alpha1"
+
+	"github.com/mattmoor/mink/pkg/kafkasource"
+)
+
+// clients caches the clients with which we read the lag of KafkaSources,
+// sharing them between the sources with the same bootstrap servers and
+// credentials.  A client is closed once no source uses it.
+type clients struct {
+	m sync.Mutex
+	// byKey holds the cached clients by their key.
+	byKey map[string]*cachedClient
+	// keys holds the key of the client of each KafkaSource.
+	keys map[string]string
+
+	newClient func(servers []string, cfg *sarama.Config) (sarama.Client, sarama.ClusterAdmin, error)
+}
+
+type cachedClient struct {
+	client sarama.Client
+	admin  sarama.ClusterAdmin
+	users  int
+}
+
+func newClients() *clients {
+	return &clients{
+		byKey: make(map[string]*cachedClient),
+		keys:  make(map[string]string),
+		newClient: func(servers []string, cfg *sarama.Config) (sarama.Client, sarama.ClusterAdmin, error) {
+			client, err := sarama.NewClient(servers, cfg)
+			if err != nil {
+				return nil, nil, err
+			}
+			admin, err := sarama.NewClusterAdminFromClient(client)
+			if err != nil {
+				client.Close()
+				return nil, nil, err
+			}
+			return client, admin, nil
+		},
+	}
+}
+
+// clientKey identifies the client of the KafkaSource by its bootstrap
+// servers and credentials, including the versions of the Secrets holding
+// them, so that rotated credentials get a new client.
+func clientKey(src *v1alpha1.KafkaSource, secretLister corev1listers.SecretLister) (string, error) {
+	net, err := json.Marshal(src.Spec.Net)
+	if err != nil {
+		return "", err
+	}
+	parts := []string{strings.Join(kafkasource.Servers(src), ","), string(net)}
+	if names := kafkasource.SecretNames(src); len(names) > 0 {
+		// Secrets are namespaced, so only share clients within one.
+		parts = append(parts, src.Namespace)
+		for _, name := range names {
+			s, err := secretLister.Secrets(src.Namespace).Get(name)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, name+"@"+s.ResourceVersion)
+		}
+	}
+	return strings.Join(parts, "|"), nil
+}
+
+// get returns the client (and its admin) with the given key for the named
+// KafkaSource, creating it with the provided config when needed.
+func (c *clients) get(source, key string, servers []string, cfg *sarama.Config) (sarama.Client, sarama.ClusterAdmin, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if old, ok := c.keys[source]; ok && old != key {
+		c.releaseLocked(source)
+	}
+	cc, ok := c.byKey[key]
+	if !ok || cc.client.Closed() {
+		client, admin, err := c.newClient(servers, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			cc = &cachedClient{}
+			c.byKey[key] = cc
+		}
+		cc.client, cc.admin = client, admin
+	}
+	if _, ok := c.keys[source]; !ok {
+		c.keys[source] = key
+		cc.users++
+	}
+	return cc.client, cc.admin, nil
+}
+
+// discard closes the client with the given key (e.g. after it failed),
+// so that the next get creates a new one for its sources.
+func (c *clients) discard(key string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if cc, ok := c.byKey[key]; ok && !cc.client.Closed() {
+		// Closing the admin closes its client.
+		cc.admin.Close()
+	}
+}
+
+// release stops the named KafkaSource from using its client, which is
+// closed once no source uses it.
+func (c *clients) release(source string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.releaseLocked(source)
+}
+
+func (c *clients) releaseLocked(source string) {
+	key, ok := c.keys[source]
+	if !ok {
+		return
+	}
+	delete(c.keys, source)
+	cc := c.byKey[key]
+	if cc.users--; cc.users > 0 {
+		return
+	}
+	delete(c.byKey, key)
+	if !cc.client.Closed() {
+		cc.admin.Close()
+	}
+}
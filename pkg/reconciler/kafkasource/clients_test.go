@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkasource
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+// fakeClient implements the parts of sarama.Client and sarama.ClusterAdmin
+// that the cache uses.
+type fakeClient struct {
+	sarama.Client
+	sarama.ClusterAdmin
+	closed bool
+}
+
+func (f *fakeClient) Closed() bool { return f.closed }
+
+func (f *fakeClient) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestClients(t *testing.T) {
+	var created []*fakeClient
+	c := newClients()
+	c.newClient = func([]string, *sarama.Config) (sarama.Client, sarama.ClusterAdmin, error) {
+		f := &fakeClient{}
+		created = append(created, f)
+		return f, f, nil
+	}
+	get := func(source, key string) *fakeClient {
+		client, _, err := c.get(source, key, nil, nil)
+		if err != nil {
+			t.Fatalf("get() = %v", err)
+		}
+		return client.(*fakeClient)
+	}
+
+	a := get("ns/a", "servers")
+	if b := get("ns/b", "servers"); b != a {
+		t.Error("Sources with the same key got different clients")
+	}
+	if a2 := get("ns/a", "servers"); a2 != a {
+		t.Error("A source got a new client for the same key")
+	}
+	if len(created) != 1 {
+		t.Errorf("Created %d clients, wanted 1", len(created))
+	}
+
+	// Moving a to new credentials leaves the shared client to b.
+	rotated := get("ns/a", "servers|rotated")
+	if rotated == a {
+		t.Error("A source kept its client after its key changed")
+	}
+	if a.closed {
+		t.Error("Closed a client still used by another source")
+	}
+
+	// Once b is gone, nothing uses the original client.
+	c.release("ns/b")
+	if !a.closed {
+		t.Error("Didn't close a client once no source used it")
+	}
+
+	// A discarded client is replaced on the next get.
+	c.discard("servers|rotated")
+	if !rotated.closed {
+		t.Error("Didn't close the discarded client")
+	}
+	if replaced := get("ns/a", "servers|rotated"); replaced == rotated {
+		t.Error("Got the discarded client back")
+	}
+	c.release("ns/a")
+	if n := len(c.byKey) + len(c.keys); n != 0 {
+		t.Errorf("Cache holds %d entries after every source was released", n)
+	}
+}
+
+func TestRoundLag(t *testing.T) {
+	tests := []struct {
+		lag  int64
+		want string
+	}{
+		{lag: 0, want: "caught up"},
+		{lag: -3, want: "caught up"},
+		{lag: 1, want: "1 message behind"},
+		{lag: 2, want: "at least 2 messages behind"},
+		{lag: 3, want: "at least 2 messages behind"},
+		{lag: 1000, want: "at least 512 messages behind"},
+		{lag: 1024, want: "at least 1024 messages behind"},
+	}
+
+	for _, test := range tests {
+		if got := roundLag(test.lag); got != test.want {
+			t.Errorf("roundLag(%d) = %q, wanted %q", test.lag, got, test.want)
+		}
+	}
+}
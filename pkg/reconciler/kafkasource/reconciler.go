@@ -0,0 +1,297 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkasource
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"time"
+
+	"github.com/Shopify/sarama"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/eventing-contrib/kafka/source/pkg/apis/sources/v1alpha1"
+	clientset "knative.dev/eventing-contrib/kafka/source/pkg/client/clientset/versioned"
+	listers "knative.dev/eventing-contrib/kafka/source/pkg/client/listers/sources/v1alpha1"
+	"knative.dev/eventing-contrib/kafka/source/pkg/reconciler/resources"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/resolver"
+	"knative.dev/pkg/system"
+
+	"github.com/mattmoor/mink/pkg/kafkasource"
+)
+
+const (
+	// KafkaConditionConsumerLag is an informational condition reporting
+	// how many messages the consumer group has yet to deliver.
+	KafkaConditionConsumerLag apis.ConditionType = "ConsumerLag"
+
+	// lagInterval is how often we refresh the consumer lag.
+	lagInterval = 30 * time.Second
+)
+
+// Reconciler implements controller.Reconciler for KafkaSources, which are
+// consumed by the shared adapter in the dataplane.
+type Reconciler struct {
+	kubeclient   kubernetes.Interface
+	client       clientset.Interface
+	sinkResolver *resolver.URIResolver
+
+	// listers index properties about resources
+	lister           listers.KafkaSourceLister
+	secretLister     corev1listers.SecretLister
+	deploymentLister appsv1listers.DeploymentLister
+
+	// clients caches the clients with which we read the consumer lag.
+	clients *clients
+
+	// enqueueAfter requeues the KafkaSource to refresh its lag.
+	enqueueAfter func(obj interface{}, after time.Duration)
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	original, err := r.lister.KafkaSources(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		r.clients.release(key)
+		return nil
+	} else if err != nil {
+		return err
+	} else if original.DeletionTimestamp != nil {
+		r.clients.release(key)
+		return nil
+	}
+
+	src := original.DeepCopy()
+	src.Status.InitializeConditions()
+	reconcileErr := r.reconcile(ctx, src)
+	src.Status.ObservedGeneration = src.Generation
+
+	if equality.Semantic.DeepEqual(original.Status, src.Status) {
+		return reconcileErr
+	}
+	if _, err := r.client.SourcesV1alpha1().KafkaSources(namespace).UpdateStatus(src); err != nil {
+		logger.Warnw("Failed to update KafkaSource status", "error", err)
+		return err
+	}
+	return reconcileErr
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, src *v1alpha1.KafkaSource) error {
+	if err := r.deleteReceiveAdapters(ctx, src); err != nil {
+		return err
+	}
+
+	if src.Spec.Sink == nil {
+		src.Status.MarkNoSink("SinkMissing", "The sink of the KafkaSource is missing.")
+		return nil
+	}
+	dest := src.Spec.Sink.DeepCopy()
+	if dest.Ref != nil && dest.Ref.Namespace == "" {
+		dest.Ref.Namespace = src.Namespace
+	}
+	uri, err := r.sinkResolver.URIFromDestinationV1(*dest, src)
+	if err != nil {
+		src.Status.MarkNoSink("NotFound", "Unable to resolve the sink: %v", err)
+		return err
+	}
+	src.Status.MarkSink(uri)
+
+	src.Status.CloudEventAttributes = nil
+	for _, topic := range kafkasource.Topics(src) {
+		src.Status.CloudEventAttributes = append(src.Status.CloudEventAttributes, duckv1.CloudEventAttributes{
+			Type:   v1alpha1.KafkaEventType,
+			Source: v1alpha1.KafkaEventSource(src.Namespace, src.Name, topic),
+		})
+	}
+
+	if err := r.reconcileLease(src); err != nil {
+		return err
+	}
+
+	r.reconcileLag(src)
+	r.enqueueAfter(src, lagInterval)
+	return nil
+}
+
+// deleteReceiveAdapters deletes the per-source receive adapter that the
+// upstream controller deployed (from KAFKA_RA_IMAGE) before the shared
+// adapter took over, so the two don't consume the topics side by side.
+func (r *Reconciler) deleteReceiveAdapters(ctx context.Context, src *v1alpha1.KafkaSource) error {
+	logger := logging.FromContext(ctx)
+	selector := labels.SelectorFromSet(resources.GetLabels(src.Name))
+	ds, err := r.deploymentLister.Deployments(src.Namespace).List(selector)
+	if err != nil {
+		return err
+	}
+	for _, d := range ds {
+		if !metav1.IsControlledBy(d, src) {
+			continue
+		}
+		logger.Infof("Deleting the receive adapter %q of KafkaSource %q", d.Name, src.Name)
+		err := r.kubeclient.AppsV1().Deployments(d.Namespace).Delete(d.Name, &metav1.DeleteOptions{})
+		if err != nil && !apierrs.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileLease marks the KafkaSource deployed while an adapter replica
+// holds the Lease of its bucket.
+func (r *Reconciler) reconcileLease(src *v1alpha1.KafkaSource) error {
+	name := kafkasource.LeaseName(kafkasource.Bucket(src))
+	lease, err := r.kubeclient.CoordinationV1().Leases(system.Namespace()).Get(name, metav1.GetOptions{})
+	if apierrs.IsNotFound(err) {
+		src.Status.MarkDeploying("Unassigned", "No adapter has claimed Lease %q yet.", name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || *spec.HolderIdentity == "" ||
+		spec.RenewTime == nil || spec.LeaseDurationSeconds == nil ||
+		spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds)*time.Second).Before(time.Now()) {
+		src.Status.MarkDeploying("Unassigned", "No adapter holds Lease %q.", name)
+		return nil
+	}
+	v1alpha1.KafkaSourceCondSet.Manage(&src.Status).MarkTrue(v1alpha1.KafkaConditionDeployed)
+	return nil
+}
+
+// reconcileLag reports how far the consumer group of the KafkaSource
+// trails its topics.
+func (r *Reconciler) reconcileLag(src *v1alpha1.KafkaSource) {
+	condSet := v1alpha1.KafkaSourceCondSet.Manage(&src.Status)
+	lag, err := r.lag(src)
+	if err != nil {
+		condSet.SetCondition(apis.Condition{
+			Type:     KafkaConditionConsumerLag,
+			Status:   corev1.ConditionUnknown,
+			Severity: apis.ConditionSeverityInfo,
+			Reason:   "LagUnknown",
+			Message:  fmt.Sprintf("Unable to determine the consumer lag: %v", err),
+		})
+		return
+	}
+	condSet.SetCondition(apis.Condition{
+		Type:     KafkaConditionConsumerLag,
+		Status:   corev1.ConditionTrue,
+		Severity: apis.ConditionSeverityInfo,
+		Reason:   "GroupLag",
+		Message:  fmt.Sprintf("The consumer group %q is %s.", src.Spec.ConsumerGroup, roundLag(lag)),
+	})
+}
+
+// roundLag describes the lag of a consumer group rounded down to a power
+// of two, so that its status only changes when the lag doubles or halves.
+func roundLag(lag int64) string {
+	switch {
+	case lag <= 0:
+		return "caught up"
+	case lag == 1:
+		return "1 message behind"
+	}
+	return fmt.Sprintf("at least %d messages behind", int64(1)<<uint(bits.Len64(uint64(lag))-1))
+}
+
+// lag reads the consumer lag of the KafkaSource with the cached client for
+// its bootstrap servers and credentials.
+func (r *Reconciler) lag(src *v1alpha1.KafkaSource) (int64, error) {
+	cfg, err := kafkasource.NewConfig(src, r.secretLister)
+	if err != nil {
+		return 0, err
+	}
+	key, err := clientKey(src, r.secretLister)
+	if err != nil {
+		return 0, err
+	}
+	client, admin, err := r.clients.get(src.Namespace+"/"+src.Name, key, kafkasource.Servers(src), cfg)
+	if err != nil {
+		return 0, err
+	}
+	lag, err := consumerLag(src, client, admin)
+	if err != nil {
+		// Start over with a new client, in case this one is broken.
+		r.clients.discard(key)
+	}
+	return lag, err
+}
+
+// consumerLag sums the number of messages past the committed offsets of
+// the consumer group, over every partition of its topics.
+func consumerLag(src *v1alpha1.KafkaSource, client sarama.Client, admin sarama.ClusterAdmin) (int64, error) {
+	partitions := make(map[string][]int32)
+	for _, topic := range kafkasource.Topics(src) {
+		ps, err := client.Partitions(topic)
+		if err != nil {
+			return 0, err
+		}
+		partitions[topic] = ps
+	}
+	offsets, err := admin.ListConsumerGroupOffsets(src.Spec.ConsumerGroup, partitions)
+	if err != nil {
+		return 0, err
+	}
+
+	var lag int64
+	for topic, ps := range partitions {
+		for _, p := range ps {
+			newest, err := client.GetOffset(topic, p, sarama.OffsetNewest)
+			if err != nil {
+				return 0, err
+			}
+			committed := int64(-1)
+			if block := offsets.GetBlock(topic, p); block != nil {
+				if block.Err != sarama.ErrNoError {
+					return 0, block.Err
+				}
+				committed = block.Offset
+			}
+			if committed < 0 {
+				// Without a committed offset we start from the oldest.
+				if committed, err = client.GetOffset(topic, p, sarama.OffsetOldest); err != nil {
+					return 0, err
+				}
+			}
+			lag += newest - committed
+		}
+	}
+	return lag, nil
+}
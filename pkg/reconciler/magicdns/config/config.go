@@ -0,0 +1,147 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	contourconfig "knative.dev/net-contour/pkg/reconciler/contour/config"
+	"knative.dev/pkg/configmap"
+)
+
+const (
+	// ConfigName is the name of the ConfigMap that configures the magic
+	// DNS domain we detect for the cluster.
+	ConfigName = "config-magic-dns"
+
+	patternKey = "pattern"
+
+	// DefaultPattern is the magic DNS pattern we use by default.
+	DefaultPattern = "{{.IP}}.xip.io"
+)
+
+// MagicDNS holds the pattern from which we derive the cluster's domain
+// from the address of its external Envoy.
+type MagicDNS struct {
+	// Pattern renders the domain, or is nil when detection is disabled.
+	Pattern *template.Template
+}
+
+// domainInput is what patterns are rendered with.
+type domainInput struct {
+	// IP is the address of the external Envoy, e.g. 1.2.3.4
+	IP string
+	// DashedIP is that address with dashes, e.g. 1-2-3-4
+	DashedIP string
+}
+
+// Domain renders the domain for the provided address of the external Envoy.
+func (m *MagicDNS) Domain(ip net.IP) (string, error) {
+	in := domainInput{
+		IP:       ip.String(),
+		DashedIP: strings.NewReplacer(".", "-", ":", "-").Replace(ip.String()),
+	}
+	var buf bytes.Buffer
+	if err := m.Pattern.Execute(&buf, in); err != nil {
+		return "", err
+	}
+	domain := buf.String()
+	if errs := validation.IsDNS1123Subdomain(domain); len(errs) > 0 {
+		return "", fmt.Errorf("%s rendered invalid domain %q: %s", patternKey, domain, strings.Join(errs, ", "))
+	}
+	return domain, nil
+}
+
+// NewMagicDNSFromConfigMap creates a MagicDNS from the supplied ConfigMap.
+func NewMagicDNSFromConfigMap(configMap *corev1.ConfigMap) (*MagicDNS, error) {
+	raw, ok := configMap.Data[patternKey]
+	if !ok {
+		raw = DefaultPattern
+	}
+	if raw == "" {
+		return &MagicDNS{}, nil
+	}
+	tmpl, err := template.New("magic-dns").Option("missingkey=error").Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", patternKey, err)
+	}
+	m := &MagicDNS{Pattern: tmpl}
+	// Check that the pattern renders a valid domain.
+	if _, err := m.Domain(net.IPv4(1, 2, 3, 4)); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Config holds the configuration of the magic DNS reconciler.
+type Config struct {
+	MagicDNS *MagicDNS
+	Contour  *contourconfig.Contour
+}
+
+type cfgKey struct{}
+
+// FromContext extracts the Config attached to the provided context.
+func FromContext(ctx context.Context) *Config {
+	return ctx.Value(cfgKey{}).(*Config)
+}
+
+// ToContext attaches the provided Config to the provided context.
+func ToContext(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, cfgKey{}, c)
+}
+
+// Store is a typed wrapper around configmap.UntypedStore to handle our configmaps.
+type Store struct {
+	*configmap.UntypedStore
+}
+
+// NewStore creates a new Store, and optionally calls functions when
+// config-magic-dns or config-contour are updated.
+func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *Store {
+	return &Store{
+		UntypedStore: configmap.NewUntypedStore(
+			"magic-dns",
+			logger,
+			configmap.Constructors{
+				ConfigName:                      NewMagicDNSFromConfigMap,
+				contourconfig.ContourConfigName: contourconfig.NewContourFromConfigMap,
+			},
+			onAfterStore...,
+		),
+	}
+}
+
+// ToContext attaches the current Config state to the provided context.
+func (s *Store) ToContext(ctx context.Context) context.Context {
+	return ToContext(ctx, s.Load())
+}
+
+// Load fetches the current Config state of the Store.
+func (s *Store) Load() *Config {
+	return &Config{
+		MagicDNS: s.UntypedLoad(ConfigName).(*MagicDNS),
+		Contour:  s.UntypedLoad(contourconfig.ContourConfigName).(*contourconfig.Contour),
+	}
+}
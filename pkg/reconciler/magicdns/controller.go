@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package magicdns
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	serviceinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/service"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+	"knative.dev/serving/pkg/apis/networking/v1alpha1"
+	routecfg "knative.dev/serving/pkg/reconciler/route/config"
+
+	"github.com/mattmoor/mink/pkg/reconciler/magicdns/config"
+)
+
+const controllerAgentName = "magic-dns-controller"
+
+// NewController creates a new controller that keeps config-domain's default
+// domain pointed at the magic DNS name of the external Envoy, unless an
+// operator has configured one.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	serviceInformer := serviceinformer.Get(ctx)
+
+	c := &Reconciler{
+		kubeclient:    kubeclient.Get(ctx),
+		serviceLister: serviceInformer.Lister(),
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+
+	// We only ever reconcile config-domain.
+	sentinel := types.NamespacedName{Namespace: system.Namespace(), Name: routecfg.DomainConfigName}
+	enqueue := impl.EnqueueSentinel(sentinel)
+
+	logger.Info("Setting up ConfigMap receivers")
+	c.configStore = config.NewStore(logger.Named("config-store"), func(string, interface{}) {
+		enqueue(nil)
+	})
+	c.configStore.WatchConfigs(cmw)
+	cmw.Watch(routecfg.DomainConfigName, func(*corev1.ConfigMap) {
+		enqueue(nil)
+	})
+
+	logger.Info("Setting up event handlers")
+	serviceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return false
+			}
+			return c.configStore.Load().Contour.VisibilityKeys[v1alpha1.IngressVisibilityExternalIP].Has(key)
+		},
+		Handler: controller.HandleAll(enqueue),
+	})
+
+	return impl
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package magicdns
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+	"knative.dev/serving/pkg/apis/networking/v1alpha1"
+	routecfg "knative.dev/serving/pkg/reconciler/route/config"
+
+	"github.com/mattmoor/mink/pkg/reconciler/magicdns/config"
+)
+
+// DetectedAnnotationKey is the annotation with which we record on
+// config-domain the domain we detected, so that we can tell it apart from
+// domains that operators configure.
+const DetectedAnnotationKey = "networking.mink.knative.dev/detected-domain"
+
+// Reconciler keeps the default domain in config-domain pointed at the
+// magic DNS name of the external Envoy's load-balancer address.
+type Reconciler struct {
+	kubeclient kubernetes.Interface
+
+	// listers index properties about resources
+	serviceLister corev1listers.ServiceLister
+
+	configStore *config.Store
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+	ctx = r.configStore.ToContext(ctx)
+	cfg := config.FromContext(ctx)
+	if cfg.MagicDNS.Pattern == nil {
+		return nil
+	}
+
+	ip, err := r.externalAddress(ctx, cfg)
+	if err != nil || ip == nil {
+		return err
+	}
+	domain, err := cfg.MagicDNS.Domain(ip)
+	if err != nil {
+		return err
+	}
+
+	cm, err := r.kubeclient.CoreV1().ConfigMaps(system.Namespace()).Get(routecfg.DomainConfigName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	domains, err := routecfg.NewDomainFromConfigMap(cm)
+	if err != nil {
+		return err
+	}
+	current := domains.LookupDomainForLabels(map[string]string{})
+	detected := cm.Annotations[DetectedAnnotationKey]
+	switch {
+	case current == domain && detected == domain:
+		return nil
+	case current == routecfg.DefaultDomain, current == detected, current == domain:
+		// Either no domain is configured, or it is one we detected
+		// (possibly by the Job that preceded us).
+	default:
+		logger.Infof("Domain is configured as %s, not detecting one", current)
+		return nil
+	}
+
+	update := cm.DeepCopy()
+	if detected != "" {
+		delete(update.Data, detected)
+	}
+	if update.Data == nil {
+		update.Data = make(map[string]string, 1)
+	}
+	update.Data[domain] = ""
+	if update.Annotations == nil {
+		update.Annotations = make(map[string]string, 1)
+	}
+	update.Annotations[DetectedAnnotationKey] = domain
+	if _, err := r.kubeclient.CoreV1().ConfigMaps(update.Namespace).Update(update); err != nil {
+		return err
+	}
+	logger.Infof("Updated default domain to: %s", domain)
+	return nil
+}
+
+// externalAddress returns the IP address of the external Envoy's load
+// balancer, or nil when it has none (yet).
+func (r *Reconciler) externalAddress(ctx context.Context, cfg *config.Config) (net.IP, error) {
+	keys := cfg.Contour.VisibilityKeys[v1alpha1.IngressVisibilityExternalIP]
+	if keys.Len() == 0 {
+		return nil, nil
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(keys.List()[0])
+	if err != nil {
+		return nil, err
+	}
+	svc, err := r.serviceLister.Services(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return resolve(ctx, svc.Status.LoadBalancer.Ingress)
+}
+
+// resolve returns the IPv4 address of the first load-balancer ingress,
+// looking up its hostname when it has no IP.
+func resolve(ctx context.Context, ingresses []corev1.LoadBalancerIngress) (net.IP, error) {
+	if len(ingresses) == 0 {
+		return nil, nil
+	}
+	lbi := ingresses[0]
+	if lbi.IP != "" {
+		ip := net.ParseIP(lbi.IP)
+		if ip == nil {
+			return nil, fmt.Errorf("load balancer has invalid IP %q", lbi.IP)
+		}
+		return ip, nil
+	}
+	if lbi.Hostname == "" {
+		return nil, nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, lbi.Hostname)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving the IP address of %q: %w", lbi.Hostname, err)
+	}
+	for _, addr := range addrs {
+		if ip4 := addr.IP.To4(); ip4 != nil {
+			return ip4, nil
+		}
+	}
+	return nil, fmt.Errorf("load balancer hostname %q has no IPv4 address", lbi.Hostname)
+}
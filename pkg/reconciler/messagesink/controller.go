@@ -0,0 +1,101 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messagesink
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	secretinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/secret"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+	minkclient "github.com/mattmoor/mink/pkg/client/injection/client"
+	slacksinkinformer "github.com/mattmoor/mink/pkg/client/injection/informers/sinks/v1alpha1/slacksink"
+	twittersinkinformer "github.com/mattmoor/mink/pkg/client/injection/informers/sinks/v1alpha1/twittersink"
+	"github.com/mattmoor/mink/pkg/messagesink"
+)
+
+// NewSlackController creates a new SlackSink controller.  The events sent
+// to SlackSinks are posted by the dataplane.
+func NewSlackController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	return newController(ctx, "slacksink-controller",
+		v1alpha1.SchemeGroupVersion.WithResource(messagesink.SlackSinksResource),
+		slacksinkinformer.Get(ctx).Informer(),
+		func(obj interface{}) string { return obj.(*v1alpha1.SlackSink).Spec.Secret.Name })
+}
+
+// NewTwitterController creates a new TwitterSink controller.  The events
+// sent to TwitterSinks are tweeted by the dataplane.
+func NewTwitterController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	return newController(ctx, "twittersink-controller",
+		v1alpha1.SchemeGroupVersion.WithResource(messagesink.TwitterSinksResource),
+		twittersinkinformer.Get(ctx).Informer(),
+		func(obj interface{}) string { return obj.(*v1alpha1.TwitterSink).Spec.Secret.Name })
+}
+
+func newController(ctx context.Context, agentName string, gvr schema.GroupVersionResource,
+	informer cache.SharedIndexInformer, secretOf func(interface{}) string) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(agentName)
+	lister := cache.NewGenericLister(informer.GetIndexer(), gvr.GroupResource())
+	secretInformer := secretinformer.Get(ctx)
+
+	c := &Reconciler{
+		client:       minkclient.Get(ctx),
+		gvr:          gvr,
+		lister:       lister,
+		secretLister: secretInformer.Lister(),
+	}
+	impl := controller.NewImpl(c, logger, agentName)
+
+	logger.Info("Setting up event handlers")
+	informer.AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	// Reconcile the sinks that use a Secret when it changes.
+	secretInformer.Informer().AddEventHandler(controller.HandleAll(func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return
+		}
+		sinks, err := lister.ByNamespace(secret.Namespace).List(labels.Everything())
+		if err != nil {
+			logger.Errorw("Error listing sinks", "error", err)
+			return
+		}
+		for _, s := range sinks {
+			if secretOf(s) == secret.Name {
+				impl.Enqueue(s)
+			}
+		}
+	}))
+
+	return impl
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messagesink
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/network"
+	"knative.dev/pkg/system"
+
+	"github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+	clientset "github.com/mattmoor/mink/pkg/client/clientset/versioned"
+	"github.com/mattmoor/mink/pkg/messagesink"
+)
+
+// ServiceName is the name of the Service in front of the dataplane's
+// receivers of messages.
+const ServiceName = "messagesink"
+
+// Reconciler implements controller.Reconciler for SlackSink and
+// TwitterSink resources.
+type Reconciler struct {
+	client       clientset.Interface
+	gvr          schema.GroupVersionResource
+	lister       cache.GenericLister
+	secretLister corev1listers.SecretLister
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	obj, err := r.lister.ByNamespace(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	switch original := obj.(type) {
+	case *v1alpha1.SlackSink:
+		if original.GetDeletionTimestamp() != nil {
+			return nil
+		}
+		s := original.DeepCopy()
+		s.Status.InitializeConditions()
+		if err := r.checkSecret(namespace, s.Spec.Secret.Name, messagesink.SlackTokenKey); err != nil {
+			s.Status.MarkSecretNotReady("SecretNotReady", "%v", err)
+		} else {
+			s.Status.MarkSecretReady()
+		}
+		s.Status.MarkAddress(r.addressOf(namespace, name))
+		s.Status.ObservedGeneration = s.Generation
+
+		if equality.Semantic.DeepEqual(original.Status, s.Status) {
+			return nil
+		}
+		_, err := r.client.SinksV1alpha1().SlackSinks(namespace).UpdateStatus(s)
+		return err
+
+	case *v1alpha1.TwitterSink:
+		if original.GetDeletionTimestamp() != nil {
+			return nil
+		}
+		s := original.DeepCopy()
+		s.Status.InitializeConditions()
+		if err := r.checkSecret(namespace, s.Spec.Secret.Name, messagesink.TwitterConsumerKeyKey,
+			messagesink.TwitterConsumerSecretKeyKey, messagesink.TwitterAccessTokenKey,
+			messagesink.TwitterAccessSecretKey); err != nil {
+			s.Status.MarkSecretNotReady("SecretNotReady", "%v", err)
+		} else {
+			s.Status.MarkSecretReady()
+		}
+		s.Status.MarkAddress(r.addressOf(namespace, name))
+		s.Status.ObservedGeneration = s.Generation
+
+		if equality.Semantic.DeepEqual(original.Status, s.Status) {
+			return nil
+		}
+		_, err := r.client.SinksV1alpha1().TwitterSinks(namespace).UpdateStatus(s)
+		return err
+
+	default:
+		return fmt.Errorf("unexpected %T", obj)
+	}
+}
+
+// checkSecret checks that the named secret holds the provided keys.
+func (r *Reconciler) checkSecret(namespace, name string, keys ...string) error {
+	secret, err := r.secretLister.Secrets(namespace).Get(name)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if _, ok := secret.Data[k]; !ok {
+			return fmt.Errorf("secret %q has no key %q", name, k)
+		}
+	}
+	return nil
+}
+
+// addressOf returns the URL at which the dataplane accepts events for
+// the named sink.
+func (r *Reconciler) addressOf(namespace, name string) *apis.URL {
+	return &apis.URL{
+		Scheme: "http",
+		Host:   network.GetServiceHostname(ServiceName, system.Namespace()),
+		Path:   "/" + r.gvr.Resource + "/" + namespace + "/" + name,
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerunsink
+
+import (
+	"context"
+	"net/http"
+
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	pipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1alpha1/pipelinerun"
+	"k8s.io/apimachinery/pkg/types"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	minkclient "github.com/mattmoor/mink/pkg/client/injection/client"
+	prsinformer "github.com/mattmoor/mink/pkg/client/injection/informers/sinks/v1alpha1/pipelinerunsink"
+)
+
+const (
+	controllerAgentName = "pipelinerunsink-controller"
+
+	// receiverPort is the port on which we receive events for all of
+	// the PipelineRunSinks in the cluster.
+	receiverPort = ":8082"
+)
+
+// NewController creates a new PipelineRunSink controller, and starts the
+// receiver that creates PipelineRuns for the events sent to those sinks.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	informer := prsinformer.Get(ctx)
+
+	rcv := &receiver{
+		logger:            logger.Named("receiver"),
+		sinkLister:        informer.Lister(),
+		pipelineRunLister: pipelineruninformer.Get(ctx).Lister(),
+		client:            pipelineclient.Get(ctx),
+		kubeclient:        kubeclient.Get(ctx),
+		sinks:             make(map[types.NamespacedName]*sinkState),
+	}
+	c := &Reconciler{
+		client:   minkclient.Get(ctx),
+		lister:   informer.Lister(),
+		receiver: rcv,
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+
+	logger.Info("Setting up event handlers")
+	informer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	srv := &http.Server{Addr: receiverPort, Handler: rcv}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatalw("Error serving PipelineRunSink events", "error", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	return impl
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerunsink
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/kmeta"
+
+	"github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+)
+
+// dedupWindow is how long we remember the events delivered to a sink,
+// which must outlast the retries of their senders.
+const dedupWindow = 24 * time.Hour
+
+// ledger durably records the events delivered to a sink, so that we keep
+// deduplicating them after the PipelineRuns they created are deleted.
+// It keeps them in a ConfigMap owned by the sink, mapping the hash of each
+// event's (source, id) to when it was delivered.
+type ledger struct {
+	client kubernetes.Interface
+	prs    *v1alpha1.PipelineRunSink
+
+	// cm caches the ConfigMap, which only we write.
+	cm *corev1.ConfigMap
+}
+
+// ledgerName returns the name of the ConfigMap of the sink's ledger.
+func ledgerName(prs *v1alpha1.PipelineRunSink) string {
+	return kmeta.ChildName(prs.Name, "-events")
+}
+
+// get returns the (cached) ConfigMap, which may not exist yet.
+func (l *ledger) get() (*corev1.ConfigMap, error) {
+	if l.cm != nil {
+		return l.cm, nil
+	}
+	cm, err := l.client.CoreV1().ConfigMaps(l.prs.Namespace).Get(ledgerName(l.prs), metav1.GetOptions{})
+	if apierrs.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            ledgerName(l.prs),
+				Namespace:       l.prs.Namespace,
+				Labels:          map[string]string{SinkLabelKey: l.prs.Name},
+				OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(l.prs)},
+			},
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	l.cm = cm
+	return cm, nil
+}
+
+// delivered returns whether the event with the hash was delivered.
+func (l *ledger) delivered(hash string) (bool, error) {
+	cm, err := l.get()
+	if err != nil {
+		return false, err
+	}
+	_, ok := cm.Data[hash]
+	return ok, nil
+}
+
+// record notes that the event with the hash was delivered, and drops
+// those delivered before the dedup window.
+func (l *ledger) record(hash string, now time.Time) error {
+	cm, err := l.get()
+	if err != nil {
+		return err
+	}
+	cm = cm.DeepCopy()
+	if cm.Data == nil {
+		cm.Data = make(map[string]string, 1)
+	}
+	expire(cm.Data, now)
+	cm.Data[hash] = now.Format(time.RFC3339)
+
+	cms := l.client.CoreV1().ConfigMaps(l.prs.Namespace)
+	if cm.ResourceVersion == "" {
+		cm, err = cms.Create(cm)
+	} else {
+		cm, err = cms.Update(cm)
+	}
+	if err != nil {
+		// Reread the ConfigMap the next time around.
+		l.cm = nil
+		return err
+	}
+	l.cm = cm
+	return nil
+}
+
+// expire drops the entries of the ledger delivered before the dedup window.
+func expire(data map[string]string, now time.Time) {
+	for k, v := range data {
+		if t, err := time.Parse(time.RFC3339, v); err != nil || now.Sub(t) > dedupWindow {
+			delete(data, k)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerunsink
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/network"
+	"knative.dev/pkg/system"
+
+	"github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+	clientset "github.com/mattmoor/mink/pkg/client/clientset/versioned"
+	listers "github.com/mattmoor/mink/pkg/client/listers/sinks/v1alpha1"
+)
+
+// ServiceName is the name of the Service in front of our receiver.
+const ServiceName = "pipelinerunsink"
+
+// Reconciler implements controller.Reconciler for PipelineRunSink resources.
+type Reconciler struct {
+	client clientset.Interface
+	lister listers.PipelineRunSinkLister
+
+	// receiver is told to forget deleted sinks.
+	receiver *receiver
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	original, err := r.lister.PipelineRunSinks(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		// The ledger of delivered events is garbage collected along
+		// with the sink.
+		r.receiver.forget(types.NamespacedName{Namespace: namespace, Name: name})
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if original.GetDeletionTimestamp() != nil {
+		return nil
+	}
+
+	prs := original.DeepCopy()
+	prs.Status.InitializeConditions()
+	prs.Status.MarkAddress(addressOf(prs))
+	prs.Status.ObservedGeneration = prs.Generation
+
+	if equality.Semantic.DeepEqual(original.Status, prs.Status) {
+		return nil
+	}
+	_, err = r.client.SinksV1alpha1().PipelineRunSinks(namespace).UpdateStatus(prs)
+	return err
+}
+
+// addressOf returns the URL at which the receiver accepts events for the
+// provided sink.
+func addressOf(prs *v1alpha1.PipelineRunSink) *apis.URL {
+	return &apis.URL{
+		Scheme: "http",
+		Host:   network.GetServiceHostname(ServiceName, system.Namespace()),
+		Path:   "/" + prs.Namespace + "/" + prs.Name,
+	}
+}
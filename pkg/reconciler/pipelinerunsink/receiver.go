@@ -0,0 +1,340 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerunsink
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	tknv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tknv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	clientset "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1alpha1"
+	"go.uber.org/zap"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/kmeta"
+
+	"github.com/mattmoor/mink/pkg/apis/sinks"
+	"github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+	sinklisters "github.com/mattmoor/mink/pkg/client/listers/sinks/v1alpha1"
+	"github.com/mattmoor/mink/pkg/extract"
+)
+
+const (
+	// SinkLabelKey is the label placed on PipelineRuns to record the
+	// PipelineRunSink that created them.
+	SinkLabelKey = sinks.GroupName + "/pipelinerunsink"
+
+	// EventIDAnnotationKey, EventSourceAnnotationKey and
+	// EventTypeAnnotationKey record the CloudEvent that triggered a
+	// PipelineRun.
+	EventIDAnnotationKey     = sinks.GroupName + "/event-id"
+	EventSourceAnnotationKey = sinks.GroupName + "/event-source"
+	EventTypeAnnotationKey   = sinks.GroupName + "/event-type"
+
+	// pendingTimeout bounds how long we count a PipelineRun we created
+	// against the concurrency limit before the lister shows it.
+	pendingTimeout = time.Minute
+)
+
+// receiver accepts CloudEvents sent to PipelineRunSinks at
+// /<namespace>/<name> and creates a PipelineRun for each of them.
+type receiver struct {
+	logger *zap.SugaredLogger
+
+	sinkLister        sinklisters.PipelineRunSinkLister
+	pipelineRunLister listers.PipelineRunLister
+	client            clientset.Interface
+	kubeclient        kubernetes.Interface
+
+	m     sync.Mutex
+	sinks map[types.NamespacedName]*sinkState
+}
+
+// sinkState is the state of our deliveries to a single sink.
+type sinkState struct {
+	m      sync.Mutex
+	ledger *ledger
+
+	// pending holds the PipelineRuns we created, with when we did, until
+	// the lister shows them.
+	pending map[string]time.Time
+}
+
+var _ http.Handler = (*receiver)(nil)
+
+// ServeHTTP implements http.Handler
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) != 2 {
+		http.NotFound(w, req)
+		return
+	}
+	namespace, name := parts[0], parts[1]
+
+	prs, err := r.sinkLister.PipelineRunSinks(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		http.NotFound(w, req)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logger := r.logger.With(zap.String("sink", namespace+"/"+name))
+
+	ev, err := binding.ToEvent(req.Context(), cehttp.NewMessageFromHttpRequest(req))
+	if err != nil {
+		http.Error(w, "malformed CloudEvent: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params, err := extractParams(prs.Spec.Params, ev)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Deliveries to a sink are serialized, so that they are counted
+	// against its concurrency limit one at a time.
+	st := r.state(prs)
+	st.m.Lock()
+	defer st.m.Unlock()
+
+	// Events are deduplicated by the hash of their (source, id), which the
+	// spec says is unique.  We record those we delivered in the sink's
+	// ledger, and also name the PipelineRun after it in case we crashed
+	// before recording one (kmeta.ChildName shortens it to an MD5 hash of
+	// the sink's name and the event's hash).
+	hash := eventHash(ev)
+	if delivered, err := st.ledger.delivered(hash); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	} else if delivered {
+		logger.Infof("Ignoring duplicate event %s from %s", ev.ID(), ev.Source())
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	prName := kmeta.ChildName(prs.Name, "-"+hash)
+	_, err = r.pipelineRunLister.PipelineRuns(namespace).Get(prName)
+	if apierrs.IsNotFound(err) {
+		if limit := prs.Spec.Concurrency; limit > 0 {
+			active, err := r.active(prs, st)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if active >= int(limit) {
+				// Let the sender retry the event once capacity frees up.
+				http.Error(w, fmt.Sprintf("%d PipelineRuns are already running", active), http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		pr := makePipelineRun(prs, prName, ev, params)
+		if _, err := r.client.TektonV1alpha1().PipelineRuns(namespace).Create(pr); apierrs.IsAlreadyExists(err) {
+			logger.Infof("Ignoring duplicate event %s from %s", ev.ID(), ev.Source())
+		} else if err != nil {
+			logger.Errorw("Error creating PipelineRun", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		} else {
+			logger.Infof("Created PipelineRun %s for event %s from %s", prName, ev.ID(), ev.Source())
+			st.pending[prName] = time.Now()
+		}
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	} else {
+		logger.Infof("Ignoring duplicate event %s from %s", ev.ID(), ev.Source())
+	}
+
+	if err := st.ledger.record(hash, time.Now()); err != nil {
+		// The sender's retry will find the PipelineRun and record it.
+		logger.Errorw("Error recording delivered event", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// state returns the state of our deliveries to the provided sink.
+func (r *receiver) state(prs *v1alpha1.PipelineRunSink) *sinkState {
+	r.m.Lock()
+	defer r.m.Unlock()
+	key := types.NamespacedName{Namespace: prs.Namespace, Name: prs.Name}
+	if st, ok := r.sinks[key]; ok && st.ledger.prs.UID == prs.UID {
+		return st
+	}
+	st := &sinkState{
+		ledger:  &ledger{client: r.kubeclient, prs: prs},
+		pending: make(map[string]time.Time),
+	}
+	r.sinks[key] = st
+	return st
+}
+
+// forget drops the state of our deliveries to a deleted sink.
+func (r *receiver) forget(key types.NamespacedName) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	delete(r.sinks, key)
+}
+
+// active returns the number of PipelineRuns created by the provided sink
+// that have not yet completed, including those we created that the lister
+// does not show yet.  Callers must hold the lock of the sink's state.
+func (r *receiver) active(prs *v1alpha1.PipelineRunSink, st *sinkState) (int, error) {
+	prl, err := r.pipelineRunLister.PipelineRuns(prs.Namespace).List(
+		labels.SelectorFromSet(labels.Set{SinkLabelKey: prs.Name}))
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, pr := range prl {
+		delete(st.pending, pr.Name)
+		if !pr.IsDone() {
+			count++
+		}
+	}
+	for name, created := range st.pending {
+		if time.Since(created) > pendingTimeout {
+			// It was deleted before the lister ever showed it.
+			delete(st.pending, name)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// eventHash returns the hash of the event's (source, id).  We hash them
+// as a JSON array, so that no other (source, id) has the same encoding,
+// and keep the full hash, since it is the key by which we deduplicate.
+func eventHash(ev *event.Event) string {
+	b, _ := json.Marshal([]string{ev.Source(), ev.ID()})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func makePipelineRun(prs *v1alpha1.PipelineRunSink, name string, ev *event.Event, params []tknv1beta1.Param) *tknv1alpha1.PipelineRun {
+	l := kmeta.UnionMaps(prs.Spec.Template.Metadata.Labels, map[string]string{
+		SinkLabelKey: prs.Name,
+	})
+	a := kmeta.UnionMaps(prs.Spec.Template.Metadata.Annotations, map[string]string{
+		EventIDAnnotationKey:     ev.ID(),
+		EventSourceAnnotationKey: ev.Source(),
+		EventTypeAnnotationKey:   ev.Type(),
+	})
+
+	spec := prs.Spec.Template.Spec.DeepCopy()
+	// Extracted parameters take precedence over those in the template.
+	for _, p := range params {
+		replaced := false
+		for i := range spec.Params {
+			if spec.Params[i].Name == p.Name {
+				spec.Params[i] = p
+				replaced = true
+			}
+		}
+		if !replaced {
+			spec.Params = append(spec.Params, p)
+		}
+	}
+
+	return &tknv1alpha1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       prs.Namespace,
+			Labels:          l,
+			Annotations:     a,
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(prs)},
+		},
+		Spec: *spec,
+	}
+}
+
+// extractParams returns the values of the provided parameters in the event.
+func extractParams(specs []v1alpha1.PipelineRunSinkParam, ev *event.Event) ([]tknv1beta1.Param, error) {
+	var (
+		data   interface{}
+		parsed bool
+	)
+	params := make([]tknv1beta1.Param, 0, len(specs))
+	for _, spec := range specs {
+		var (
+			value string
+			found bool
+			err   error
+		)
+		if spec.Attribute != "" {
+			value, found, err = extract.Attribute(ev, spec.Attribute)
+		} else {
+			if !parsed && len(ev.Data()) > 0 {
+				if err := json.Unmarshal(ev.Data(), &data); err != nil {
+					return nil, fmt.Errorf("unable to parse event data as JSON: %w", err)
+				}
+			}
+			parsed = true
+			value, found, err = lookup(data, spec.Path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("param %q: %w", spec.Name, err)
+		}
+		if !found {
+			if spec.Default == nil {
+				return nil, fmt.Errorf("param %q: not found in event", spec.Name)
+			}
+			value = *spec.Default
+		}
+		params = append(params, tknv1beta1.Param{
+			Name:  spec.Name,
+			Value: tknv1beta1.NewArrayOrString(value),
+		})
+	}
+	return params, nil
+}
+
+// lookup returns the value at the dot-separated path within the decoded
+// JSON data.  Strings are returned verbatim and other values as JSON.
+func lookup(data interface{}, path string) (string, bool, error) {
+	value, ok := extract.Path(data, path)
+	if !ok {
+		return "", false, nil
+	}
+	s, err := extract.String(value)
+	if err != nil {
+		return "", false, err
+	}
+	return s, true, nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerunsink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+
+	"github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+)
+
+func newEvent(source, id string) *event.Event {
+	ev := event.New()
+	ev.SetSource(source)
+	ev.SetID(id)
+	ev.SetType("dev.mink.test")
+	return &ev
+}
+
+func TestEventHash(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b *event.Event
+		same bool
+	}{{
+		name: "same source and id",
+		a:    newEvent("/source", "1"),
+		b:    newEvent("/source", "1"),
+		same: true,
+	}, {
+		name: "different id",
+		a:    newEvent("/source", "1"),
+		b:    newEvent("/source", "2"),
+	}, {
+		name: "different source",
+		a:    newEvent("/source", "1"),
+		b:    newEvent("/other", "1"),
+	}, {
+		name: "same concatenation",
+		a:    newEvent("/a/b", "c"),
+		b:    newEvent("/a", "b/c"),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := eventHash(test.a), eventHash(test.b)
+			if len(a) != 64 {
+				t.Errorf("eventHash() = %q, wanted a full sha256", a)
+			}
+			if got := a == b; got != test.same {
+				t.Errorf("eventHash() = %q and %q, wanted same = %v", a, b, test.same)
+			}
+		})
+	}
+}
+
+func TestExpire(t *testing.T) {
+	now := time.Now()
+	data := map[string]string{
+		"recent":    now.Add(-time.Hour).Format(time.RFC3339),
+		"expired":   now.Add(-dedupWindow - time.Minute).Format(time.RFC3339),
+		"malformed": "yesterday",
+	}
+	expire(data, now)
+	if _, ok := data["recent"]; !ok || len(data) != 1 {
+		t.Errorf("expire() left %v, wanted only recent", data)
+	}
+}
+
+func TestExtractParams(t *testing.T) {
+	def := "main"
+	ev := newEvent("/source", "1")
+	ev.SetSubject("my-subject")
+	if err := ev.SetData(event.ApplicationJSON, map[string]interface{}{
+		"head_commit": map[string]interface{}{"id": "abc123"},
+		"count":       3,
+	}); err != nil {
+		t.Fatalf("SetData() = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		specs   []v1alpha1.PipelineRunSinkParam
+		want    map[string]string
+		wantErr bool
+	}{{
+		name:  "attribute",
+		specs: []v1alpha1.PipelineRunSinkParam{{Name: "subject", Attribute: "subject"}},
+		want:  map[string]string{"subject": "my-subject"},
+	}, {
+		name:  "path",
+		specs: []v1alpha1.PipelineRunSinkParam{{Name: "sha", Path: "head_commit.id"}},
+		want:  map[string]string{"sha": "abc123"},
+	}, {
+		name:  "non-string path",
+		specs: []v1alpha1.PipelineRunSinkParam{{Name: "count", Path: "count"}},
+		want:  map[string]string{"count": "3"},
+	}, {
+		name:  "default",
+		specs: []v1alpha1.PipelineRunSinkParam{{Name: "branch", Path: "ref", Default: &def}},
+		want:  map[string]string{"branch": "main"},
+	}, {
+		name:    "missing",
+		specs:   []v1alpha1.PipelineRunSinkParam{{Name: "branch", Path: "ref"}},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params, err := extractParams(test.specs, ev)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("extractParams() = %v, wanted error %v", err, test.wantErr)
+			}
+			got := make(map[string]string, len(params))
+			for _, p := range params {
+				got[p.Name] = p.Value.StringVal
+			}
+			for k, v := range test.want {
+				if got[k] != v {
+					t.Errorf("param %q = %q, wanted %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"context"
+
+	"github.com/kelseyhightower/envconfig"
+	bindingsclient "github.com/mattmoor/bindings/pkg/client/injection/client"
+	sqlbindinginformer "github.com/mattmoor/bindings/pkg/client/injection/informers/bindings/v1alpha1/sqlbinding"
+	"github.com/vaikas/postgressource/pkg/apis/sources/v1alpha1"
+	sourcesclient "github.com/vaikas/postgressource/pkg/client/injection/client"
+	postgressourceinformer "github.com/vaikas/postgressource/pkg/client/injection/informers/sources/v1alpha1/postgressource"
+	"k8s.io/client-go/tools/cache"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	deploymentinformer "knative.dev/pkg/client/injection/kube/informers/apps/v1/deployment"
+	secretinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/secret"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
+	"knative.dev/pkg/logging"
+	pkgreconciler "knative.dev/pkg/reconciler"
+	"knative.dev/pkg/resolver"
+)
+
+const controllerAgentName = "postgres-cdc-controller"
+
+// NewController creates a new controller that captures the changes of
+// PostgresSources in logical-replication mode.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	sourceInformer := postgressourceinformer.Get(ctx)
+	deploymentInformer := deploymentinformer.Get(ctx)
+	sqlbindingInformer := sqlbindinginformer.Get(ctx)
+
+	c := &Reconciler{
+		kubeclient:       kubeclient.Get(ctx),
+		client:           sourcesclient.Get(ctx),
+		bindingsclient:   bindingsclient.Get(ctx),
+		lister:           sourceInformer.Lister(),
+		deploymentLister: deploymentInformer.Lister(),
+		secretLister:     secretinformer.Get(ctx).Lister(),
+		sqlbindingLister: sqlbindingInformer.Lister(),
+	}
+	if err := envconfig.Process("", c); err != nil {
+		logger.Panicf("required environment variable is not defined: %v", err)
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+	c.enqueueAfter = impl.EnqueueAfter
+	c.sinkResolver = resolver.NewURIResolver(ctx, impl.EnqueueKey)
+
+	logger.Info("Setting up event handlers")
+	sourceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: pkgreconciler.AnnotationFilterFunc(ModeAnnotationKey, ModeLogicalReplication, false),
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	deploymentInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterGroupKind(v1alpha1.Kind("PostgresSource")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	sqlbindingInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterGroupKind(v1alpha1.Kind("PostgresSource")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	return impl
+}
+
+// ExcludeLogicalReplication wraps the constructor of the trigger-based
+// PostgresSource controller, so that it leaves the sources that capture
+// changes through logical replication to ours.
+func ExcludeLogicalReplication(ctor injection.ControllerConstructor) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		impl := ctor(ctx, cmw)
+		impl.Reconciler = &skipLogicalReplication{
+			Reconciler: impl.Reconciler,
+			lister:     postgressourceinformer.Get(ctx).Lister(),
+		}
+		return impl
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vaikas/postgressource/pkg/apis/sources/v1alpha1"
+	listers "github.com/vaikas/postgressource/pkg/client/listers/sources/v1alpha1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+)
+
+const (
+	// ModeAnnotationKey is the annotation with which PostgresSources select
+	// how they capture changes.  It may not be changed once set.
+	ModeAnnotationKey = "sources.mink.knative.dev/postgres-mode"
+
+	// ModeTriggers captures changes with triggers that NOTIFY the
+	// adapter, which is the default.
+	ModeTriggers = "triggers"
+
+	// ModeLogicalReplication captures changes by consuming a logical
+	// replication slot with the pgoutput plugin.
+	ModeLogicalReplication = "logical-replication"
+)
+
+// IsLogicalReplication returns whether the PostgresSource captures changes
+// through logical replication.
+func IsLogicalReplication(src *v1alpha1.PostgresSource) bool {
+	return src.Annotations[ModeAnnotationKey] == ModeLogicalReplication
+}
+
+// Validate checks the capture mode of the PostgresSource, that it is
+// unchanged from the previous source (if any), and that logically
+// replicated sources name their tables.
+func Validate(ctx context.Context, src, previous *v1alpha1.PostgresSource) (errs *apis.FieldError) {
+	if apis.IsInStatusUpdate(ctx) {
+		return nil
+	}
+	path := fmt.Sprintf("metadata.annotations[%s]", ModeAnnotationKey)
+	mode := src.Annotations[ModeAnnotationKey]
+	switch mode {
+	case "", ModeTriggers, ModeLogicalReplication:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(mode, path))
+	}
+	if previous != nil && IsLogicalReplication(previous) != IsLogicalReplication(src) {
+		errs = errs.Also(&apis.FieldError{
+			Message: "Immutable field changed",
+			Paths:   []string{path},
+			Details: fmt.Sprintf("{%q: %q}", "previous", previous.Annotations[ModeAnnotationKey]),
+		})
+	}
+	if IsLogicalReplication(src) && len(src.Spec.Tables) == 0 {
+		errs = errs.Also(apis.ErrMissingField("spec.tables"))
+	}
+	return errs
+}
+
+// skipLogicalReplication is a controller.Reconciler that hands the
+// PostgresSources that don't capture changes through logical replication
+// to the Reconciler it wraps.
+type skipLogicalReplication struct {
+	controller.Reconciler
+
+	lister listers.PostgresSourceLister
+}
+
+// Reconcile implements controller.Reconciler
+func (s *skipLogicalReplication) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return s.Reconciler.Reconcile(ctx, key)
+	}
+	src, err := s.lister.PostgresSources(namespace).Get(name)
+	if err != nil || !IsLogicalReplication(src) {
+		// Let the wrapped Reconciler deal with missing sources.
+		return s.Reconciler.Reconcile(ctx, key)
+	}
+	return nil
+}
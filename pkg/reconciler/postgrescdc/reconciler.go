@@ -0,0 +1,416 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	bindingsclientset "github.com/mattmoor/bindings/pkg/client/clientset/versioned"
+	bindingslisters "github.com/mattmoor/bindings/pkg/client/listers/bindings/v1alpha1"
+	"github.com/vaikas/postgressource/pkg/apis/sources/v1alpha1"
+	clientset "github.com/vaikas/postgressource/pkg/client/clientset/versioned"
+	listers "github.com/vaikas/postgressource/pkg/client/listers/sources/v1alpha1"
+	"github.com/vaikas/postgressource/pkg/reconciler/postgressource/resources"
+	"github.com/vaikas/postgressource/pkg/reconciler/postgressource/resources/names"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/eventing/pkg/apis/duck"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/resolver"
+)
+
+const (
+	// finalizerName is the finalizer with which we drop the replication
+	// slot and publication of deleted PostgresSources.
+	finalizerName = "postgrescdc.sources.mink.knative.dev"
+
+	// PostgresPublicationReady has status True when the publication of
+	// the PostgresSource's tables is up to date.
+	PostgresPublicationReady apis.ConditionType = "PublicationReady"
+
+	// PostgresSlotReady has status True when the replication slot the
+	// adapter consumes exists.
+	PostgresSlotReady apis.ConditionType = "SlotReady"
+
+	// PostgresReplicationLag is an informational condition reporting how
+	// far the adapter trails the database's write-ahead log.
+	PostgresReplicationLag apis.ConditionType = "ReplicationLag"
+
+	// lagInterval is how often we refresh the replication lag.
+	lagInterval = 30 * time.Second
+)
+
+// CondSet is the condition set of PostgresSources that capture changes
+// through logical replication, which create a publication and slot in
+// place of the function and triggers of v1alpha1.PostgresCondSet.
+var CondSet = apis.NewLivingConditionSet(
+	v1alpha1.PostgresConditionSinkProvided,
+	v1alpha1.PostgresConditionDeployed,
+	PostgresPublicationReady,
+	PostgresSlotReady,
+	v1alpha1.PostgresAdapterBindingReady,
+)
+
+// Reconciler implements controller.Reconciler for PostgresSources that
+// capture changes through logical replication.
+type Reconciler struct {
+	kubeclient     kubernetes.Interface
+	client         clientset.Interface
+	bindingsclient bindingsclientset.Interface
+	sinkResolver   *resolver.URIResolver
+
+	// listers index properties about resources
+	lister           listers.PostgresSourceLister
+	deploymentLister appsv1listers.DeploymentLister
+	secretLister     corev1listers.SecretLister
+	sqlbindingLister bindingslisters.SQLBindingLister
+
+	// enqueueAfter requeues the PostgresSource to refresh its lag.
+	enqueueAfter func(obj interface{}, after time.Duration)
+
+	// Image is the image of the adapter consuming the replication slot.
+	Image string `envconfig:"POSTGRES_CDC_RA_IMAGE" required:"true"`
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	original, err := r.lister.PostgresSources(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if !IsLogicalReplication(original) {
+		return nil
+	}
+
+	if original.DeletionTimestamp != nil {
+		return r.finalize(ctx, original.DeepCopy())
+	}
+	if !sets.NewString(original.Finalizers...).Has(finalizerName) {
+		// The next Reconcile will pick up the updated PostgresSource.
+		return r.setFinalizers(original, append(original.Finalizers, finalizerName))
+	}
+
+	src := original.DeepCopy()
+	condSet := CondSet.Manage(&src.Status)
+	// Drop the trigger-mode conditions, which we don't maintain.
+	condSet.ClearCondition(v1alpha1.PostgresFunctionCreated)
+	condSet.ClearCondition(v1alpha1.PostgresTriggersCreated)
+	condSet.InitializeConditions()
+	reconcileErr := r.reconcile(ctx, src)
+	src.Status.ObservedGeneration = src.Generation
+
+	if equality.Semantic.DeepEqual(original.Status, src.Status) {
+		return reconcileErr
+	}
+	if _, err := r.client.SourcesV1alpha1().PostgresSources(namespace).UpdateStatus(src); err != nil {
+		logger.Warnw("Failed to update PostgresSource status", "error", err)
+		return err
+	}
+	return reconcileErr
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, src *v1alpha1.PostgresSource) error {
+	condSet := CondSet.Manage(&src.Status)
+
+	db, err := r.getDB(src)
+	if err != nil {
+		condSet.MarkFalse(PostgresPublicationReady, "NoConnection", "Unable to connect to the database: %v", err)
+		return err
+	}
+	defer db.Close()
+
+	if err := reconcilePublication(db, src); err != nil {
+		condSet.MarkFalse(PostgresPublicationReady, "PublicationFailed", "Unable to reconcile the publication: %v", err)
+		return err
+	}
+	condSet.MarkTrue(PostgresPublicationReady)
+
+	lag, err := reconcileSlot(db, src)
+	if err != nil {
+		condSet.MarkFalse(PostgresSlotReady, "SlotFailed", "Unable to reconcile the replication slot: %v", err)
+		return err
+	}
+	condSet.MarkTrue(PostgresSlotReady)
+	// The lag is rounded, so that refreshing it only updates our status
+	// when it changes appreciably.
+	condSet.SetCondition(apis.Condition{
+		Type:     PostgresReplicationLag,
+		Status:   corev1.ConditionTrue,
+		Severity: apis.ConditionSeverityInfo,
+		Reason:   "SlotLag",
+		Message:  fmt.Sprintf("The replication slot is %s behind.", roundLag(lag)),
+	})
+	r.enqueueAfter(src, lagInterval)
+
+	dest := src.Spec.Sink.DeepCopy()
+	if dest.Ref != nil && dest.Ref.Namespace == "" {
+		dest.Ref.Namespace = src.Namespace
+	}
+	uri, err := r.sinkResolver.URIFromDestinationV1(*dest, src)
+	if err != nil {
+		condSet.MarkFalse(v1alpha1.PostgresConditionSinkProvided, "NotFound", "Unable to resolve the sink: %v", err)
+		return err
+	}
+	src.Status.SinkURI = uri
+	condSet.MarkTrue(v1alpha1.PostgresConditionSinkProvided)
+
+	if err := r.reconcileSQLBinding(ctx, src); err != nil {
+		return err
+	}
+	return r.reconcileDeployment(ctx, src, uri.String())
+}
+
+func (r *Reconciler) reconcileSQLBinding(ctx context.Context, src *v1alpha1.PostgresSource) error {
+	want := resources.MakeSQLBinding(ctx, src)
+	have, err := r.sqlbindingLister.SQLBindings(src.Namespace).Get(want.Name)
+	if apierrs.IsNotFound(err) {
+		have, err = r.bindingsclient.BindingsV1alpha1().SQLBindings(src.Namespace).Create(want)
+		if err != nil {
+			return fmt.Errorf("creating SQLBinding %q: %w", want.Name, err)
+		}
+	} else if err != nil {
+		return err
+	} else if !metav1.IsControlledBy(have, src) {
+		return fmt.Errorf("SQLBinding %q is not owned by PostgresSource %q", have.Name, src.Name)
+	} else if !equality.Semantic.DeepEqual(want.Spec, have.Spec) {
+		have = have.DeepCopy()
+		have.Spec = want.Spec
+		have, err = r.bindingsclient.BindingsV1alpha1().SQLBindings(src.Namespace).Update(have)
+		if err != nil {
+			return fmt.Errorf("updating SQLBinding %q: %w", want.Name, err)
+		}
+	}
+	// Our condition set differs from the one PropagateAuthStatus uses to
+	// compute readiness, so we propagate the SQLBinding's status ourselves.
+	condSet := CondSet.Manage(&src.Status)
+	switch cond := have.Status.Status.GetCondition(apis.ConditionReady); {
+	case cond == nil:
+		condSet.MarkUnknown(v1alpha1.PostgresAdapterBindingReady, "", "")
+	case cond.Status == corev1.ConditionTrue:
+		condSet.MarkTrue(v1alpha1.PostgresAdapterBindingReady)
+	case cond.Status == corev1.ConditionFalse:
+		condSet.MarkFalse(v1alpha1.PostgresAdapterBindingReady, cond.Reason, "%s", cond.Message)
+	default:
+		condSet.MarkUnknown(v1alpha1.PostgresAdapterBindingReady, cond.Reason, "%s", cond.Message)
+	}
+	return nil
+}
+
+func (r *Reconciler) reconcileDeployment(ctx context.Context, src *v1alpha1.PostgresSource, sink string) error {
+	condSet := CondSet.Manage(&src.Status)
+
+	want := MakeReceiveAdapter(src, r.Image, sink)
+	have, err := r.deploymentLister.Deployments(src.Namespace).Get(want.Name)
+	if apierrs.IsNotFound(err) {
+		have, err = r.kubeclient.AppsV1().Deployments(src.Namespace).Create(want)
+		if err != nil {
+			condSet.MarkFalse(v1alpha1.PostgresConditionDeployed, "CreationFailed", "Unable to create Deployment %q: %v", want.Name, err)
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if !metav1.IsControlledBy(have, src) {
+		condSet.MarkFalse(v1alpha1.PostgresConditionDeployed, "NotOwned", "Deployment %q is not owned by this PostgresSource.", have.Name)
+		return fmt.Errorf("deployment %q is not owned by PostgresSource %q", have.Name, src.Name)
+	} else if !equality.Semantic.DeepDerivative(want.Spec, have.Spec) {
+		have = have.DeepCopy()
+		have.Spec = want.Spec
+		have, err = r.kubeclient.AppsV1().Deployments(src.Namespace).Update(have)
+		if err != nil {
+			condSet.MarkFalse(v1alpha1.PostgresConditionDeployed, "UpdateFailed", "Unable to update Deployment %q: %v", want.Name, err)
+			return err
+		}
+	}
+	if duck.DeploymentIsAvailable(&have.Status, false) {
+		condSet.MarkTrue(v1alpha1.PostgresConditionDeployed)
+	} else {
+		condSet.MarkFalse(v1alpha1.PostgresConditionDeployed, "DeploymentUnavailable", "The Deployment %q is unavailable.", have.Name)
+	}
+	return nil
+}
+
+// finalize stops the adapter, and then drops the replication slot, which
+// would otherwise keep the database from recycling its write-ahead log.
+func (r *Reconciler) finalize(ctx context.Context, src *v1alpha1.PostgresSource) error {
+	logger := logging.FromContext(ctx)
+	finalizers := sets.NewString(src.Finalizers...)
+	if !finalizers.Has(finalizerName) {
+		return nil
+	}
+
+	err := r.kubeclient.AppsV1().Deployments(src.Namespace).Delete(names.Deployment(src), &metav1.DeleteOptions{})
+	if err != nil && !apierrs.IsNotFound(err) {
+		return err
+	}
+
+	db, err := r.getDB(src)
+	if apierrs.IsNotFound(err) {
+		logger.Warnf("Leaving replication slot %q behind, the Secret %q is gone", SlotName(src), src.Spec.Secret.Name)
+	} else if err != nil {
+		return err
+	} else {
+		defer db.Close()
+		// Dropping the slot fails while the adapter still consumes it, in
+		// which case we retry once its Pod has terminated.
+		if _, err := db.Exec(`SELECT pg_drop_replication_slot(slot_name) FROM pg_replication_slots WHERE slot_name = $1`,
+			SlotName(src)); err != nil {
+			return fmt.Errorf("dropping replication slot %q: %w", SlotName(src), err)
+		}
+		if _, err := db.Exec("DROP PUBLICATION IF EXISTS " + pq.QuoteIdentifier(SlotName(src))); err != nil {
+			return fmt.Errorf("dropping publication %q: %w", SlotName(src), err)
+		}
+	}
+
+	finalizers.Delete(finalizerName)
+	return r.setFinalizers(src, finalizers.List())
+}
+
+func (r *Reconciler) setFinalizers(src *v1alpha1.PostgresSource, finalizers []string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers":      finalizers,
+			"resourceVersion": src.ResourceVersion,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.client.SourcesV1alpha1().PostgresSources(src.Namespace).Patch(src.Name, types.MergePatchType, patch)
+	return err
+}
+
+func (r *Reconciler) getDB(src *v1alpha1.PostgresSource) (*sql.DB, error) {
+	if src.Spec.Secret.Name == "" {
+		return nil, errors.New("database credentials not specified")
+	}
+	s, err := r.secretLister.Secrets(src.Namespace).Get(src.Spec.Secret.Name)
+	if err != nil {
+		return nil, err
+	}
+	connstr, ok := s.Data["connectionstr"]
+	if !ok {
+		return nil, fmt.Errorf("secret %q has no key %q", s.Name, "connectionstr")
+	}
+	return sql.Open("postgres", string(connstr))
+}
+
+// reconcilePublication creates the publication of the PostgresSource's
+// tables, or brings its tables up to date.
+func reconcilePublication(db *sql.DB, src *v1alpha1.PostgresSource) error {
+	pub := SlotName(src)
+	want := sets.NewString()
+	for _, t := range src.Spec.Tables {
+		name := strings.ToLower(t.Name)
+		if !strings.Contains(name, ".") {
+			name = "public." + name
+		}
+		want.Insert(name)
+	}
+
+	var exists bool
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_publication WHERE pubname = $1)`, pub).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		_, err := db.Exec(fmt.Sprintf("CREATE PUBLICATION %s FOR TABLE %s", pq.QuoteIdentifier(pub), tableList(src)))
+		return err
+	}
+
+	rows, err := db.Query(`SELECT schemaname, tablename FROM pg_publication_tables WHERE pubname = $1`, pub)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	have := sets.NewString()
+	for rows.Next() {
+		var schema, table string
+		if err := rows.Scan(&schema, &table); err != nil {
+			return err
+		}
+		have.Insert(schema + "." + table)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if have.Equal(want) {
+		return nil
+	}
+	_, err = db.Exec(fmt.Sprintf("ALTER PUBLICATION %s SET TABLE %s", pq.QuoteIdentifier(pub), tableList(src)))
+	return err
+}
+
+// reconcileSlot creates the replication slot of the PostgresSource if it
+// is missing, and returns how many bytes of write-ahead log it has yet to
+// confirm.
+func reconcileSlot(db *sql.DB, src *v1alpha1.PostgresSource) (int64, error) {
+	slot := SlotName(src)
+	var lag sql.NullFloat64
+	err := db.QueryRow(`SELECT pg_wal_lsn_diff(pg_current_wal_lsn(), confirmed_flush_lsn)
+		FROM pg_replication_slots WHERE slot_name = $1`, slot).Scan(&lag)
+	if err == sql.ErrNoRows {
+		_, err = db.Exec(`SELECT pg_create_logical_replication_slot($1, 'pgoutput')`, slot)
+		return 0, err
+	} else if err != nil {
+		return 0, err
+	}
+	return int64(lag.Float64), nil
+}
+
+// roundLag formats the lag of a replication slot rounded down to a power
+// of two, so that it only changes when the lag doubles or halves.
+func roundLag(lag int64) string {
+	if lag <= 0 {
+		return "0B"
+	}
+	rounded := int64(1) << uint(bits.Len64(uint64(lag))-1)
+	for _, unit := range []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"} {
+		if rounded < 1024 {
+			return fmt.Sprintf("at least %d%s", rounded, unit)
+		}
+		rounded /= 1024
+	}
+	return fmt.Sprintf("at least %dEiB", rounded)
+}
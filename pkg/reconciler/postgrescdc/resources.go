@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrescdc
+
+import (
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/vaikas/postgressource/pkg/apis/sources/v1alpha1"
+	"github.com/vaikas/postgressource/pkg/reconciler/postgressource/resources"
+	"github.com/vaikas/postgressource/pkg/reconciler/postgressource/resources/names"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/ptr"
+)
+
+// SlotName returns the name of the replication slot and publication of
+// the PostgresSource, which may hold lowercase letters, digits and
+// underscores.
+func SlotName(src *v1alpha1.PostgresSource) string {
+	return strings.NewReplacer("-", "_", ".", "_").Replace(names.Deployment(src))
+}
+
+// quoteTable quotes a (possibly schema qualified) table name, folding it
+// to lowercase as Postgres does for unquoted names.
+func quoteTable(table string) string {
+	parts := strings.Split(strings.ToLower(table), ".")
+	for i, p := range parts {
+		parts[i] = pq.QuoteIdentifier(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// tableList returns the quoted tables of the PostgresSource.
+func tableList(src *v1alpha1.PostgresSource) string {
+	tables := make([]string, 0, len(src.Spec.Tables))
+	for _, t := range src.Spec.Tables {
+		tables = append(tables, quoteTable(t.Name))
+	}
+	return strings.Join(tables, ", ")
+}
+
+// MakeReceiveAdapter creates the Deployment of the adapter that consumes
+// the PostgresSource's replication slot.  It shares its name with the
+// trigger-based adapter, so that the source's SQLBinding applies to it.
+func MakeReceiveAdapter(src *v1alpha1.PostgresSource, image, sink string) *appsv1.Deployment {
+	labels := resources.Labels(src.Name)
+	serviceAccountName := src.Spec.ServiceAccountName
+	if serviceAccountName == "" {
+		serviceAccountName = "default"
+	}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            names.Deployment(src),
+			Namespace:       src.Namespace,
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(src)},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			// Only one consumer may use a replication slot at a time.
+			Replicas: ptr.Int32(1),
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: serviceAccountName,
+					Containers: []corev1.Container{{
+						Name:  "receive-adapter",
+						Image: image,
+						Env: []corev1.EnvVar{{
+							Name:  "EVENT_SOURCE",
+							Value: src.Namespace + "/" + src.Name,
+						}, {
+							Name: "NAMESPACE",
+							ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{
+									FieldPath: "metadata.namespace",
+								},
+							},
+						}, {
+							Name: "NAME",
+							ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{
+									FieldPath: "metadata.name",
+								},
+							},
+						}, {
+							Name:  "K_SINK",
+							Value: sink,
+						}, {
+							Name:  "SLOT_NAME",
+							Value: SlotName(src),
+						}, {
+							Name:  "PUBLICATION",
+							Value: SlotName(src),
+						}, {
+							Name:  "METRICS_DOMAIN",
+							Value: "knative.dev/eventing",
+						}, {
+							Name:  "K_METRICS_CONFIG",
+							Value: "",
+						}, {
+							Name:  "K_LOGGING_CONFIG",
+							Value: "",
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	podinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/pod"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	servingclient "knative.dev/serving/pkg/client/injection/client"
+	serviceinformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/service"
+)
+
+const controllerAgentName = "rollout-controller"
+
+// NewController creates a new controller that progressively rolls out the
+// latest Revisions of Services with a rollout policy.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	serviceInformer := serviceinformer.Get(ctx)
+
+	eventBroadcaster := record.NewBroadcaster()
+	watch := eventBroadcaster.StartRecordingToSink(
+		&typedcorev1.EventSinkImpl{Interface: kubeclient.Get(ctx).CoreV1().Events("")})
+	go func() {
+		<-ctx.Done()
+		watch.Stop()
+	}()
+
+	c := &Reconciler{
+		client:        servingclient.Get(ctx),
+		recorder:      eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName}),
+		httpClient:    &http.Client{Timeout: 3 * time.Second},
+		serviceLister: serviceInformer.Lister(),
+		podLister:     podinformer.Get(ctx).Lister(),
+		steps:         make(map[string]*step),
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+	c.enqueueAfter = impl.EnqueueAfter
+
+	logger.Info("Setting up event handlers")
+	serviceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			svc, ok := obj.(*v1.Service)
+			if !ok {
+				// Let deletions through, so we forget their rollouts.
+				return true
+			}
+			_, ok = svc.Annotations[StepsAnnotationKey]
+			return ok
+		},
+		Handler: controller.HandleAll(impl.Enqueue),
+	})
+
+	return impl
+}
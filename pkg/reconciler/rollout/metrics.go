@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	// The metrics queue-proxy reports for the requests it proxies to the
+	// user container.
+	requestCountMetric     = "revision_request_count"
+	requestLatenciesMetric = "revision_request_latencies"
+
+	responseCodeClassLabel = "response_code_class"
+)
+
+// sample holds the cumulative request metrics of a single pod.
+type sample struct {
+	requests float64
+	errors   float64
+	// buckets maps the upper bounds of the latency histogram (in
+	// milliseconds) onto the number of requests within them.
+	buckets map[float64]float64
+}
+
+// errorRate returns the fraction of the sampled requests that failed.
+func (s *sample) errorRate() float64 {
+	if s.requests == 0 {
+		return 0
+	}
+	return s.errors / s.requests
+}
+
+// quantile estimates the latency below which the provided fraction of the
+// sampled requests completed, as the upper bound of the histogram bucket in
+// which that quantile falls.
+func (s *sample) quantile(q float64) time.Duration {
+	bounds := make([]float64, 0, len(s.buckets))
+	for bound := range s.buckets {
+		bounds = append(bounds, bound)
+	}
+	sort.Float64s(bounds)
+	if len(bounds) == 0 {
+		return 0
+	}
+	total := s.buckets[bounds[len(bounds)-1]]
+	if total == 0 {
+		return 0
+	}
+	for _, bound := range bounds {
+		if s.buckets[bound] >= q*total {
+			if math.IsInf(bound, 1) {
+				break
+			}
+			return time.Duration(bound * float64(time.Millisecond))
+		}
+	}
+	// The quantile falls beyond the largest finite bucket, so report that.
+	for i := len(bounds) - 1; i >= 0; i-- {
+		if !math.IsInf(bounds[i], 1) {
+			return time.Duration(bounds[i] * float64(time.Millisecond))
+		}
+	}
+	return 0
+}
+
+// snapshot holds the samples of a Revision's pods, keyed by pod name.
+type snapshot map[string]*sample
+
+// since returns the requests the pods in the snapshot served since the
+// provided baseline.  Pods that are new since the baseline contribute all
+// of their requests.
+func (s snapshot) since(baseline snapshot) *sample {
+	delta := &sample{buckets: make(map[float64]float64)}
+	for pod, cur := range s {
+		prev, ok := baseline[pod]
+		if !ok {
+			prev = &sample{}
+		}
+		delta.requests += cur.requests - prev.requests
+		delta.errors += cur.errors - prev.errors
+		for bound, count := range cur.buckets {
+			delta.buckets[bound] += count - prev.buckets[bound]
+		}
+	}
+	return delta
+}
+
+// scrape reads the request metrics queue-proxy reports at the provided URL.
+func scrape(client *http.Client, url string) (*sample, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("GET request for URL %q returned HTTP status %v", url, resp.StatusCode)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading text format failed: %w", err)
+	}
+
+	s := &sample{buckets: make(map[float64]float64)}
+	if mf, ok := families[requestCountMetric]; ok {
+		for _, m := range mf.Metric {
+			if m.Counter == nil {
+				continue
+			}
+			s.requests += m.Counter.GetValue()
+			if label(m, responseCodeClassLabel) == "5xx" {
+				s.errors += m.Counter.GetValue()
+			}
+		}
+	}
+	if mf, ok := families[requestLatenciesMetric]; ok {
+		for _, m := range mf.Metric {
+			if m.Histogram == nil {
+				continue
+			}
+			for _, b := range m.Histogram.Bucket {
+				// The text format may list the +Inf bucket, which
+				// is the sample count we add below.
+				if math.IsInf(b.GetUpperBound(), 1) {
+					continue
+				}
+				s.buckets[b.GetUpperBound()] += float64(b.GetCumulativeCount())
+			}
+			s.buckets[math.Inf(1)] += float64(m.Histogram.GetSampleCount())
+		}
+	}
+	return s, nil
+}
+
+func label(m *dto.Metric, name string) string {
+	for _, l := range m.Label {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuantile(t *testing.T) {
+	inf := math.Inf(1)
+	tests := []struct {
+		name    string
+		buckets map[float64]float64
+		q       float64
+		want    time.Duration
+	}{{
+		name: "empty",
+		q:    0.95,
+	}, {
+		name:    "no requests",
+		buckets: map[float64]float64{10: 0, 100: 0, inf: 0},
+		q:       0.95,
+	}, {
+		name:    "in the first bucket",
+		buckets: map[float64]float64{10: 100, 100: 100, inf: 100},
+		q:       0.95,
+		want:    10 * time.Millisecond,
+	}, {
+		name:    "in a later bucket",
+		buckets: map[float64]float64{10: 50, 100: 94, 1000: 100, inf: 100},
+		q:       0.95,
+		want:    time.Second,
+	}, {
+		name:    "on a bucket's bound",
+		buckets: map[float64]float64{10: 50, 100: 95, 1000: 100, inf: 100},
+		q:       0.95,
+		want:    100 * time.Millisecond,
+	}, {
+		name:    "median",
+		buckets: map[float64]float64{10: 50, 100: 95, 1000: 100, inf: 100},
+		q:       0.5,
+		want:    10 * time.Millisecond,
+	}, {
+		name:    "beyond the largest finite bucket",
+		buckets: map[float64]float64{10: 50, 100: 60, inf: 100},
+		q:       0.95,
+		want:    100 * time.Millisecond,
+	}, {
+		name:    "only the infinite bucket",
+		buckets: map[float64]float64{inf: 100},
+		q:       0.95,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &sample{buckets: test.buckets}
+			if got := s.quantile(test.q); got != test.want {
+				t.Errorf("quantile(%v) = %v, wanted %v", test.q, got, test.want)
+			}
+		})
+	}
+}
+
+func TestErrorRate(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample sample
+		want   float64
+	}{{
+		name: "no requests",
+	}, {
+		name:   "no errors",
+		sample: sample{requests: 10},
+	}, {
+		name:   "some errors",
+		sample: sample{requests: 200, errors: 5},
+		want:   0.025,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.sample.errorRate(); got != test.want {
+				t.Errorf("errorRate() = %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSince(t *testing.T) {
+	inf := math.Inf(1)
+	tests := []struct {
+		name     string
+		baseline snapshot
+		current  snapshot
+		want     *sample
+	}{{
+		name: "no pods",
+		want: &sample{buckets: map[float64]float64{}},
+	}, {
+		name: "new pod",
+		current: snapshot{
+			"a": {requests: 10, errors: 1, buckets: map[float64]float64{10: 8, inf: 10}},
+		},
+		want: &sample{requests: 10, errors: 1, buckets: map[float64]float64{10: 8, inf: 10}},
+	}, {
+		name: "since the baseline",
+		baseline: snapshot{
+			"a": {requests: 10, errors: 1, buckets: map[float64]float64{10: 8, inf: 10}},
+		},
+		current: snapshot{
+			"a": {requests: 30, errors: 2, buckets: map[float64]float64{10: 20, inf: 30}},
+			"b": {requests: 5, buckets: map[float64]float64{10: 5, inf: 5}},
+		},
+		want: &sample{requests: 25, errors: 1, buckets: map[float64]float64{10: 17, inf: 25}},
+	}, {
+		name: "pod gone since the baseline",
+		baseline: snapshot{
+			"a": {requests: 10, buckets: map[float64]float64{inf: 10}},
+		},
+		current: snapshot{
+			"b": {requests: 5, buckets: map[float64]float64{inf: 5}},
+		},
+		want: &sample{requests: 5, buckets: map[float64]float64{inf: 5}},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.current.since(test.baseline)
+			if !cmp.Equal(got, test.want, cmp.AllowUnexported(sample{})) {
+				t.Errorf("since() = %+v, wanted %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScrape(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `# TYPE revision_request_count counter
+revision_request_count{response_code="200",response_code_class="2xx"} 90
+revision_request_count{response_code="503",response_code_class="5xx"} 10
+# TYPE revision_request_latencies histogram
+revision_request_latencies_bucket{response_code="200",le="10"} 80
+revision_request_latencies_bucket{response_code="200",le="100"} 90
+revision_request_latencies_bucket{response_code="200",le="+Inf"} 90
+revision_request_latencies_sum{response_code="200"} 900
+revision_request_latencies_count{response_code="200"} 90
+revision_request_latencies_bucket{response_code="503",le="10"} 0
+revision_request_latencies_bucket{response_code="503",le="100"} 10
+revision_request_latencies_bucket{response_code="503",le="+Inf"} 10
+revision_request_latencies_sum{response_code="503"} 500
+revision_request_latencies_count{response_code="503"} 10
+`)
+	}))
+	defer srv.Close()
+
+	got, err := scrape(srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatalf("scrape() = %v", err)
+	}
+	want := &sample{
+		requests: 100,
+		errors:   10,
+		buckets:  map[float64]float64{10: 80, 100: 100, math.Inf(1): 100},
+	}
+	if !cmp.Equal(got, want, cmp.AllowUnexported(sample{})) {
+		t.Errorf("scrape() = %+v, wanted %+v", got, want)
+	}
+	if got, want := got.quantile(latencyQuantile), 100*time.Millisecond; got != want {
+		t.Errorf("quantile() = %v, wanted %v", got, want)
+	}
+}
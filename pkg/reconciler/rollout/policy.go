@@ -0,0 +1,186 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+const (
+	// StepsAnnotationKey is the annotation through which Services ask for
+	// progressive rollouts, as the comma-separated, increasing percentages
+	// of traffic the latest Revision receives on its way to 100%,
+	// e.g. "10,25,50".
+	StepsAnnotationKey = "serving.mink.knative.dev/rollout-steps"
+
+	// IntervalAnnotationKey is the annotation through which Services
+	// specify how long each step of a rollout lasts before the latest
+	// Revision's metrics are evaluated, e.g. "5m".
+	IntervalAnnotationKey = "serving.mink.knative.dev/rollout-interval"
+
+	// MaxErrorRateAnnotationKey is the annotation through which Services
+	// specify the fraction of the latest Revision's requests that may fail
+	// with a 5xx during a step before the rollout is rolled back, e.g. "0.01".
+	MaxErrorRateAnnotationKey = "serving.mink.knative.dev/rollout-max-error-rate"
+
+	// MaxLatencyAnnotationKey is the annotation through which Services
+	// specify the 95th percentile latency the latest Revision may exhibit
+	// during a step before the rollout is rolled back, e.g. "500ms".
+	MaxLatencyAnnotationKey = "serving.mink.knative.dev/rollout-max-latency"
+
+	// MinRequestsAnnotationKey is the annotation through which Services
+	// specify how many requests the latest Revision must serve during a
+	// step before it is evaluated, e.g. "100".  Until then, the rollout
+	// holds at that step.
+	MinRequestsAnnotationKey = "serving.mink.knative.dev/rollout-min-requests"
+
+	// RolledBackAnnotationKey is the annotation with which we record the
+	// Revision whose rollout we rolled back, so that we do not try it again.
+	RolledBackAnnotationKey = "serving.mink.knative.dev/rolled-back-revision"
+
+	// StepStartedAnnotationKey is the annotation with which we record when
+	// the current step of a rollout started, so that restarts don't begin
+	// it anew.
+	StepStartedAnnotationKey = "serving.mink.knative.dev/rollout-step-started"
+
+	defaultInterval     = time.Minute
+	defaultMaxErrorRate = 0.05
+	defaultMinRequests  = 100
+)
+
+// Policy is how a Service asks that its latest Revision be rolled out.
+type Policy struct {
+	// Steps holds the increasing percentages of traffic the latest
+	// Revision receives before it receives all of it.
+	Steps []int
+
+	// Interval is how long each step lasts.
+	Interval time.Duration
+
+	// MaxErrorRate is the fraction of the latest Revision's requests that
+	// may fail during a step.
+	MaxErrorRate float64
+
+	// MaxLatency is the 95th percentile latency the latest Revision may
+	// exhibit during a step, if checked.
+	MaxLatency time.Duration
+
+	// MinRequests is the number of requests the latest Revision must serve
+	// during a step before it is evaluated.
+	MinRequests int
+}
+
+// ParseSteps parses a comma-separated list of increasing percentages.
+func ParseSteps(raw string) ([]int, error) {
+	parts := strings.Split(raw, ",")
+	steps := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n <= 0 || n >= 100 {
+			return nil, fmt.Errorf("rollout steps must be percentages between 1 and 99, was: %q", part)
+		}
+		if len(steps) > 0 && n <= steps[len(steps)-1] {
+			return nil, fmt.Errorf("rollout steps must be increasing, was: %q", raw)
+		}
+		steps = append(steps, n)
+	}
+	return steps, nil
+}
+
+// ParsePolicy parses the Policy from the provided annotations, returning
+// nil when the Service does not ask for progressive rollouts.
+func ParsePolicy(annotations map[string]string) (*Policy, error) {
+	raw, ok := annotations[StepsAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+	steps, err := ParseSteps(raw)
+	if err != nil {
+		return nil, err
+	}
+	p := &Policy{
+		Steps:        steps,
+		Interval:     defaultInterval,
+		MaxErrorRate: defaultMaxErrorRate,
+		MinRequests:  defaultMinRequests,
+	}
+	if raw, ok := annotations[IntervalAnnotationKey]; ok {
+		if p.Interval, err = parseDuration(raw); err != nil {
+			return nil, fmt.Errorf("failed to parse rollout interval: %w", err)
+		}
+	}
+	if raw, ok := annotations[MaxErrorRateAnnotationKey]; ok {
+		if p.MaxErrorRate, err = strconv.ParseFloat(raw, 64); err != nil || p.MaxErrorRate < 0 || p.MaxErrorRate > 1 {
+			return nil, fmt.Errorf("rollout max error rate must be between 0 and 1, was: %q", raw)
+		}
+	}
+	if raw, ok := annotations[MaxLatencyAnnotationKey]; ok {
+		if p.MaxLatency, err = parseDuration(raw); err != nil {
+			return nil, fmt.Errorf("failed to parse rollout max latency: %w", err)
+		}
+	}
+	if raw, ok := annotations[MinRequestsAnnotationKey]; ok {
+		if p.MinRequests, err = strconv.Atoi(raw); err != nil || p.MinRequests <= 0 {
+			return nil, fmt.Errorf("rollout min requests must be a positive number, was: %q", raw)
+		}
+	}
+	return p, nil
+}
+
+func parseDuration(raw string) (time.Duration, error) {
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive, was: %q", raw)
+	}
+	return d, nil
+}
+
+// Validate checks the annotations through which a Service asks for
+// progressive rollouts.
+func Validate(ctx context.Context, om *metav1.ObjectMeta) *apis.FieldError {
+	if apis.IsInStatusUpdate(ctx) {
+		return nil
+	}
+	if _, ok := om.Annotations[StepsAnnotationKey]; !ok {
+		for _, key := range []string{IntervalAnnotationKey, MaxErrorRateAnnotationKey, MaxLatencyAnnotationKey, MinRequestsAnnotationKey} {
+			if _, ok := om.Annotations[key]; ok {
+				return &apis.FieldError{
+					Message: fmt.Sprintf("requires the %s annotation", StepsAnnotationKey),
+					Paths:   []string{fmt.Sprintf("metadata.annotations[%s]", key)},
+				}
+			}
+		}
+		return nil
+	}
+	if _, err := ParsePolicy(om.Annotations); err != nil {
+		return &apis.FieldError{
+			Message: err.Error(),
+			Paths:   []string{"metadata.annotations"},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []int
+		wantErr bool
+	}{{
+		name: "one step",
+		raw:  "50",
+		want: []int{50},
+	}, {
+		name: "several steps",
+		raw:  "10, 25,50",
+		want: []int{10, 25, 50},
+	}, {
+		name:    "empty",
+		raw:     "",
+		wantErr: true,
+	}, {
+		name:    "zero",
+		raw:     "0,50",
+		wantErr: true,
+	}, {
+		name:    "all of the traffic",
+		raw:     "50,100",
+		wantErr: true,
+	}, {
+		name:    "not increasing",
+		raw:     "10,50,50",
+		wantErr: true,
+	}, {
+		name:    "decreasing",
+		raw:     "50,10",
+		wantErr: true,
+	}, {
+		name:    "not a number",
+		raw:     "ten",
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseSteps(test.raw)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseSteps() = %v, wanted error %v", err, test.wantErr)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("ParseSteps() = %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        *Policy
+		wantErr     bool
+	}{{
+		name: "no rollout",
+	}, {
+		name: "defaults",
+		annotations: map[string]string{
+			StepsAnnotationKey: "10,50",
+		},
+		want: &Policy{
+			Steps:        []int{10, 50},
+			Interval:     defaultInterval,
+			MaxErrorRate: defaultMaxErrorRate,
+			MinRequests:  defaultMinRequests,
+		},
+	}, {
+		name: "everything",
+		annotations: map[string]string{
+			StepsAnnotationKey:        "25",
+			IntervalAnnotationKey:     "5m",
+			MaxErrorRateAnnotationKey: "0.01",
+			MaxLatencyAnnotationKey:   "500ms",
+			MinRequestsAnnotationKey:  "1000",
+		},
+		want: &Policy{
+			Steps:        []int{25},
+			Interval:     5 * time.Minute,
+			MaxErrorRate: 0.01,
+			MaxLatency:   500 * time.Millisecond,
+			MinRequests:  1000,
+		},
+	}, {
+		name: "negative interval",
+		annotations: map[string]string{
+			StepsAnnotationKey:    "25",
+			IntervalAnnotationKey: "-5m",
+		},
+		wantErr: true,
+	}, {
+		name: "error rate above one",
+		annotations: map[string]string{
+			StepsAnnotationKey:        "25",
+			MaxErrorRateAnnotationKey: "1.5",
+		},
+		wantErr: true,
+	}, {
+		name: "no min requests",
+		annotations: map[string]string{
+			StepsAnnotationKey:       "25",
+			MinRequestsAnnotationKey: "0",
+		},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParsePolicy(test.annotations)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParsePolicy() = %v, wanted error %v", err, test.wantErr)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("ParsePolicy() = %+v, wanted %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/ptr"
+	"knative.dev/serving/pkg/apis/networking"
+	"knative.dev/serving/pkg/apis/serving"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	clientset "knative.dev/serving/pkg/client/clientset/versioned"
+	servinglisters "knative.dev/serving/pkg/client/listers/serving/v1"
+)
+
+// latencyQuantile is the quantile of the latest Revision's latencies that
+// is held to the policy's MaxLatency.
+const latencyQuantile = 0.95
+
+// step holds the metrics of the latest Revision at the start of a
+// Service's current step.
+type step struct {
+	candidate string
+	percent   int
+	started   time.Time
+	baseline  snapshot
+}
+
+// Reconciler implements controller.Reconciler for Services, shifting the
+// traffic of those with a rollout policy from their previous Revision to
+// their latest one, one step at a time.
+type Reconciler struct {
+	client     clientset.Interface
+	recorder   record.EventRecorder
+	httpClient *http.Client
+
+	// listers index properties about resources
+	serviceLister servinglisters.ServiceLister
+	podLister     corev1listers.PodLister
+
+	// enqueueAfter requeues the Service once its current step is over.
+	enqueueAfter func(obj interface{}, after time.Duration)
+
+	// steps holds the baselines of the ongoing rollouts' current steps.
+	// When they start is recorded on the Services, but their baselines
+	// live in memory, so after a restart a step is evaluated against the
+	// requests since, or all those the latest Revision's pods have served
+	// if it is already over.
+	m     sync.Mutex
+	steps map[string]*step
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	svc, err := r.serviceLister.Services(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		r.forget(key)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if svc.GetDeletionTimestamp() != nil {
+		r.forget(key)
+		return nil
+	}
+
+	policy, err := ParsePolicy(svc.Annotations)
+	if err != nil {
+		// Our webhook rejects invalid policies, so this is left over
+		// from before it was installed.
+		logger.Warnw("Ignoring invalid rollout policy", "error", err)
+		return nil
+	} else if policy == nil {
+		r.forget(key)
+		return nil
+	}
+
+	candidate := svc.Status.LatestReadyRevisionName
+	if candidate == "" {
+		return nil
+	}
+
+	targets, ok := pinnedTargets(svc.Spec.Traffic)
+	if !ok {
+		// Pin the traffic to the Revisions currently serving it, so that
+		// new Revisions no longer receive all of it as they become ready.
+		stable := servingRevision(svc)
+		if stable == "" {
+			return nil
+		}
+		return r.setTraffic(svc, stable, 100, "", 0, nil)
+	}
+
+	switch len(targets) {
+	case 1:
+		stable := targets[0].RevisionName
+		if stable == candidate || svc.Annotations[RolledBackAnnotationKey] == candidate {
+			r.forget(key)
+			return nil
+		}
+		r.recorder.Eventf(svc, corev1.EventTypeNormal, "RolloutStarted",
+			"Rolling out Revision %q from %q, starting with %d%% of traffic.", candidate, stable, policy.Steps[0])
+		return r.setTraffic(svc, stable, 100-policy.Steps[0], candidate, policy.Steps[0], nil)
+
+	case 2:
+		stable, current := targets[0].RevisionName, targets[1].RevisionName
+		if current != candidate {
+			// A newer Revision became ready during the rollout, so
+			// roll it out from the stable Revision instead.
+			r.forget(key)
+			r.recorder.Eventf(svc, corev1.EventTypeNormal, "RolloutRestarted",
+				"Rolling out Revision %q from %q in place of %q, starting with %d%% of traffic.",
+				candidate, stable, current, policy.Steps[0])
+			return r.setTraffic(svc, stable, 100-policy.Steps[0], candidate, policy.Steps[0], nil)
+		}
+		return r.advance(ctx, svc, policy, stable, candidate, int(percentOf(targets[1])))
+
+	default:
+		logger.Warnf("Not rolling out Service with %d traffic targets", len(targets))
+		return nil
+	}
+}
+
+// advance evaluates the candidate at the end of its current step, and
+// either moves on to the next step or rolls it back.
+func (r *Reconciler) advance(ctx context.Context, svc *v1.Service, policy *Policy, stable, candidate string, percent int) error {
+	key := svc.Namespace + "/" + svc.Name
+
+	started, err := time.Parse(time.RFC3339, svc.Annotations[StepStartedAnnotationKey])
+	if err != nil {
+		// Record when the step started, which re-enqueues the Service.
+		return r.setTraffic(svc, stable, 100-percent, candidate, percent, nil)
+	}
+
+	r.m.Lock()
+	s, ok := r.steps[key]
+	r.m.Unlock()
+	if !ok || s.candidate != candidate || s.percent != percent || !s.started.Equal(started) {
+		s = &step{
+			candidate: candidate,
+			percent:   percent,
+			started:   started,
+		}
+		if time.Since(started) < policy.Interval {
+			if s.baseline, err = r.scrape(ctx, svc.Namespace, candidate); err != nil {
+				return err
+			}
+		}
+		r.m.Lock()
+		r.steps[key] = s
+		r.m.Unlock()
+	}
+	if remaining := policy.Interval - time.Since(started); remaining > 0 {
+		r.enqueueAfter(svc, remaining)
+		return nil
+	}
+
+	current, err := r.scrape(ctx, svc.Namespace, candidate)
+	if err != nil {
+		return err
+	}
+	delta := current.since(s.baseline)
+	if delta.requests < float64(policy.MinRequests) {
+		// Without enough requests (e.g. because the candidate's pods could
+		// not be scraped, or received no traffic), we cannot tell whether
+		// the candidate is healthy, so we hold the step and check again
+		// after another interval.
+		r.recorder.Eventf(svc, corev1.EventTypeNormal, "RolloutHeld",
+			"Holding Revision %q at %d%% of traffic after %d requests (at least %d are needed).",
+			candidate, percent, int(delta.requests), policy.MinRequests)
+		r.enqueueAfter(svc, policy.Interval)
+		return nil
+	}
+	r.forget(key)
+
+	if rate := delta.errorRate(); rate > policy.MaxErrorRate {
+		r.recorder.Eventf(svc, corev1.EventTypeWarning, "RolloutRolledBack",
+			"Rolled back Revision %q to %q: %.2f%% of %d requests failed at %d%% of traffic (at most %.2f%% may).",
+			candidate, stable, 100*rate, int(delta.requests), percent, 100*policy.MaxErrorRate)
+		return r.setTraffic(svc, stable, 100, "", 0, map[string]string{RolledBackAnnotationKey: candidate})
+	}
+	if latency := delta.quantile(latencyQuantile); policy.MaxLatency > 0 && latency > policy.MaxLatency {
+		r.recorder.Eventf(svc, corev1.EventTypeWarning, "RolloutRolledBack",
+			"Rolled back Revision %q to %q: 95th percentile latency was %v at %d%% of traffic (at most %v may be).",
+			candidate, stable, latency, percent, policy.MaxLatency)
+		return r.setTraffic(svc, stable, 100, "", 0, map[string]string{RolledBackAnnotationKey: candidate})
+	}
+
+	for _, next := range policy.Steps {
+		if next > percent {
+			r.recorder.Eventf(svc, corev1.EventTypeNormal, "RolloutStep",
+				"Shifting %d%% of traffic to Revision %q after %d requests at %d%%.",
+				next, candidate, int(delta.requests), percent)
+			return r.setTraffic(svc, stable, 100-next, candidate, next, nil)
+		}
+	}
+	r.recorder.Eventf(svc, corev1.EventTypeNormal, "RolloutComplete",
+		"Shifted all traffic to Revision %q after %d requests at %d%%.", candidate, int(delta.requests), percent)
+	return r.setTraffic(svc, candidate, 100, "", 0, nil)
+}
+
+// setTraffic splits the Service's traffic between the provided Revisions,
+// the second of which is omitted when it receives none, and sets the
+// provided annotations.  A rollout that starts clears the record of the
+// last one rolled back, and each of its steps records when it started.
+func (r *Reconciler) setTraffic(svc *v1.Service, first string, firstPercent int, second string, secondPercent int, annotations map[string]string) error {
+	update := svc.DeepCopy()
+	update.Spec.Traffic = []v1.TrafficTarget{{
+		RevisionName:   first,
+		LatestRevision: ptr.Bool(false),
+		Percent:        ptr.Int64(int64(firstPercent)),
+	}}
+	if second != "" {
+		update.Spec.Traffic = append(update.Spec.Traffic, v1.TrafficTarget{
+			RevisionName:   second,
+			LatestRevision: ptr.Bool(false),
+			Percent:        ptr.Int64(int64(secondPercent)),
+		})
+		delete(update.Annotations, RolledBackAnnotationKey)
+		update.Annotations[StepStartedAnnotationKey] = time.Now().Format(time.RFC3339)
+	} else {
+		delete(update.Annotations, StepStartedAnnotationKey)
+	}
+	for k, v := range annotations {
+		update.Annotations[k] = v
+	}
+	_, err := r.client.ServingV1().Services(update.Namespace).Update(update)
+	return err
+}
+
+// scrape reads the request metrics of the Revision's pods.  Pods that
+// cannot be scraped are left out, so their requests don't count towards
+// those the Revision must serve during a step.
+func (r *Reconciler) scrape(ctx context.Context, namespace, revision string) (snapshot, error) {
+	pods, err := r.podLister.Pods(namespace).List(labels.SelectorFromSet(labels.Set{
+		serving.RevisionLabelKey: revision,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	snap := make(snapshot, len(pods))
+	for _, pod := range pods {
+		if pod.Status.PodIP == "" || pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+		url := fmt.Sprintf("http://%s/metrics", pod.Status.PodIP+":"+strconv.Itoa(networking.UserQueueMetricsPort))
+		s, err := scrape(r.httpClient, url)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Error scraping pod "+pod.Name, "error", err)
+			continue
+		}
+		snap[pod.Name] = s
+	}
+	return snap, nil
+}
+
+func (r *Reconciler) forget(key string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	delete(r.steps, key)
+}
+
+// pinnedTargets returns the Service's traffic targets if they name the
+// Revisions that receive traffic without tagging them, as our rollouts
+// leave them.
+func pinnedTargets(traffic []v1.TrafficTarget) ([]v1.TrafficTarget, bool) {
+	if len(traffic) == 0 {
+		return nil, false
+	}
+	for _, tt := range traffic {
+		if tt.RevisionName == "" || tt.Tag != "" {
+			return nil, false
+		}
+	}
+	return traffic, true
+}
+
+// servingRevision returns the Revision that currently receives most of the
+// Service's traffic.
+func servingRevision(svc *v1.Service) string {
+	var (
+		name    string
+		percent int64 = -1
+	)
+	for _, tt := range svc.Status.Traffic {
+		if tt.RevisionName != "" && tt.Tag == "" && percentOf(tt) > percent {
+			name, percent = tt.RevisionName, percentOf(tt)
+		}
+	}
+	return name
+}
+
+func percentOf(tt v1.TrafficTarget) int64 {
+	if tt.Percent == nil {
+		return 0
+	}
+	return *tt.Percent
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/configmap"
+)
+
+const (
+	// ConfigName is the name of the ConfigMap that configures where the
+	// lifecycle events of TaskRuns and PipelineRuns are sent.
+	ConfigName = "config-run-events"
+
+	brokerKey  = "broker"
+	sinkURIKey = "sink-uri"
+
+	// DefaultBroker is the name of the Broker in each run's namespace to
+	// which we send events by default.
+	DefaultBroker = "default"
+)
+
+// Config holds the settings for emitting run lifecycle events.
+type Config struct {
+	// Broker is the name of the Broker in the run's namespace to which
+	// events are sent, unless SinkURI is specified.
+	Broker string
+
+	// SinkURI is an absolute URI to which events are sent, regardless of
+	// the run's namespace.
+	SinkURI *apis.URL
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Broker: DefaultBroker,
+	}
+}
+
+// NewConfigFromConfigMap creates a Config from the supplied ConfigMap.
+func NewConfigFromConfigMap(configMap *corev1.ConfigMap) (*Config, error) {
+	c := defaultConfig()
+
+	if raw, ok := configMap.Data[brokerKey]; ok {
+		c.Broker = raw
+	}
+	if raw, ok := configMap.Data[sinkURIKey]; ok && raw != "" {
+		u, err := apis.ParseURL(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", sinkURIKey, err)
+		} else if !u.URL().IsAbs() {
+			return nil, fmt.Errorf("%s must be an absolute URI, was: %q", sinkURIKey, raw)
+		}
+		c.SinkURI = u
+	}
+	if c.Broker == "" && c.SinkURI == nil {
+		return nil, fmt.Errorf("one of %s or %s must be specified", brokerKey, sinkURIKey)
+	}
+	return c, nil
+}
+
+type cfgKey struct{}
+
+// FromContext extracts the Config attached to the provided context.
+func FromContext(ctx context.Context) *Config {
+	return ctx.Value(cfgKey{}).(*Config)
+}
+
+// ToContext attaches the provided Config to the provided context.
+func ToContext(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, cfgKey{}, c)
+}
+
+// Store is a typed wrapper around configmap.UntypedStore to handle our configmaps.
+type Store struct {
+	*configmap.UntypedStore
+}
+
+// NewStore creates a new Store, and optionally calls functions when
+// config-run-events is updated.
+func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *Store {
+	return &Store{
+		UntypedStore: configmap.NewUntypedStore(
+			"run-events",
+			logger,
+			configmap.Constructors{
+				ConfigName: NewConfigFromConfigMap,
+			},
+			onAfterStore...,
+		),
+	}
+}
+
+// ToContext attaches the current Config state to the provided context.
+func (s *Store) ToContext(ctx context.Context) context.Context {
+	return ToContext(ctx, s.Load())
+}
+
+// Load fetches the current Config state of the Store.
+func (s *Store) Load() *Config {
+	return s.UntypedLoad(ConfigName).(*Config)
+}
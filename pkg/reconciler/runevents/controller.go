@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runevents
+
+import (
+	"context"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	pipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1alpha1/pipelinerun"
+	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1alpha1/taskrun"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	brokerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1beta1/broker"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/reconciler/runevents/config"
+)
+
+const (
+	taskRunAgentName     = "taskrun-events-controller"
+	pipelineRunAgentName = "pipelinerun-events-controller"
+)
+
+// newEmitter creates the emitter for runs of the given kind, which requeues
+// the runs (from their informer) whose events it may be holding when there
+// may now be somewhere to send them.
+func newEmitter(ctx context.Context, cmw configmap.Watcher, logger *zap.SugaredLogger, l *ledger,
+	impl *controller.Impl, informer cache.SharedInformer) *emitter {
+	ceclient, err := cloudevents.NewDefaultClient()
+	if err != nil {
+		logger.Fatalw("Error creating CloudEvents client", "error", err)
+	}
+	brokerInformer := brokerinformer.Get(ctx)
+
+	e := &emitter{
+		ceclient:     ceclient,
+		brokerLister: brokerInformer.Lister(),
+		ledger:       l,
+	}
+
+	e.configStore = config.NewStore(logger.Named("config-store"), func(string, interface{}) {
+		impl.GlobalResync(informer)
+	})
+	e.configStore.WatchConfigs(cmw)
+
+	brokerInformer.Informer().AddEventHandler(controller.HandleAll(func(obj interface{}) {
+		b, err := kmeta.DeletionHandlingAccessor(obj)
+		if err != nil {
+			return
+		}
+		impl.FilteredGlobalResync(func(obj interface{}) bool {
+			r, err := kmeta.DeletionHandlingAccessor(obj)
+			return err == nil && r.GetNamespace() == b.GetNamespace()
+		}, informer)
+	}))
+	return e
+}
+
+// NewTaskRunController creates a new controller that sends CloudEvents as
+// TaskRuns start, succeed, fail or are cancelled.
+func NewTaskRunController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(taskRunAgentName)
+	taskRunInformer := taskruninformer.Get(ctx)
+
+	c := &taskRunReconciler{
+		taskRunLister: taskRunInformer.Lister(),
+	}
+	impl := controller.NewImpl(c, logger, taskRunAgentName)
+	l := newLedger(func(namespace, name string, data []byte) error {
+		_, err := pipelineclient.Get(ctx).TektonV1alpha1().TaskRuns(namespace).Patch(name, types.MergePatchType, data)
+		return err
+	})
+	c.emitter = newEmitter(ctx, cmw, logger, l, impl, taskRunInformer.Informer())
+
+	logger.Info("Setting up event handlers")
+	taskRunInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	return impl
+}
+
+// NewPipelineRunController creates a new controller that sends CloudEvents
+// as PipelineRuns start, succeed, fail or are cancelled.
+func NewPipelineRunController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(pipelineRunAgentName)
+	pipelineRunInformer := pipelineruninformer.Get(ctx)
+
+	c := &pipelineRunReconciler{
+		pipelineRunLister: pipelineRunInformer.Lister(),
+	}
+	impl := controller.NewImpl(c, logger, pipelineRunAgentName)
+	l := newLedger(func(namespace, name string, data []byte) error {
+		_, err := pipelineclient.Get(ctx).TektonV1alpha1().PipelineRuns(namespace).Patch(name, types.MergePatchType, data)
+		return err
+	})
+	c.emitter = newEmitter(ctx, cmw, logger, l, impl, pipelineRunInformer.Informer())
+
+	logger.Info("Setting up event handlers")
+	pipelineRunInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	return impl
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runevents
+
+import (
+	"encoding/json"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DeliveredAnnotationKey is the annotation in which we record the last
+// lifecycle state of a run for which we delivered an event.
+const DeliveredAnnotationKey = "pipeline.mink.knative.dev/delivered-event"
+
+// ledger durably records the last lifecycle event delivered for each run
+// on the run itself, so what it records goes away along with the run.
+type ledger struct {
+	// patch applies a merge patch to the run of our kind with the given
+	// namespace and name.
+	patch func(namespace, name string, data []byte) error
+
+	// recorded holds what we recorded for runs until our informer sees
+	// it, so that we don't resend events from a stale copy of the run.
+	m        sync.Mutex
+	recorded map[types.UID]state
+}
+
+func newLedger(patch func(namespace, name string, data []byte) error) *ledger {
+	return &ledger{
+		patch:    patch,
+		recorded: make(map[types.UID]state),
+	}
+}
+
+// last returns the last state for which we delivered an event for the run.
+func (l *ledger) last(r *run) state {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	annotated := state(r.Annotations[DeliveredAnnotationKey])
+	s, ok := l.recorded[r.UID]
+	if !ok {
+		return annotated
+	} else if s == annotated {
+		delete(l.recorded, r.UID)
+	}
+	return s
+}
+
+// record notes that the event for the run entering the state was delivered.
+func (l *ledger) record(r *run, s state) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				DeliveredAnnotationKey: string(s),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := l.patch(r.Namespace, r.Name, patch); err != nil {
+		return err
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.recorded[r.UID] = s
+	return nil
+}
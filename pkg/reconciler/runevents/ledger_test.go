@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runevents
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPending(t *testing.T) {
+	tests := []struct {
+		name  string
+		state state
+		last  state
+		want  []state
+	}{{
+		name:  "not started",
+		state: stateNone,
+	}, {
+		name:  "started",
+		state: stateStarted,
+		want:  []state{stateStarted},
+	}, {
+		name:  "started, delivered",
+		state: stateStarted,
+		last:  stateStarted,
+	}, {
+		name:  "succeeded after start",
+		state: stateSucceeded,
+		last:  stateStarted,
+		want:  []state{stateSucceeded},
+	}, {
+		name:  "failed without start",
+		state: stateFailed,
+		want:  []state{stateStarted, stateFailed},
+	}, {
+		name:  "cancelled, delivered",
+		state: stateCancelled,
+		last:  stateCancelled,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &run{State: test.state}
+			got := r.pending(test.last)
+			if len(got) != len(test.want) {
+				t.Fatalf("pending() = %v, wanted %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("pending() = %v, wanted %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLedger(t *testing.T) {
+	var patches []string
+	fail := false
+	l := newLedger(func(namespace, name string, data []byte) error {
+		if fail {
+			return errors.New("boom")
+		}
+		patches = append(patches, namespace+"/"+name+" "+string(data))
+		return nil
+	})
+	r := &run{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "run", UID: "uid"}}
+
+	if got := l.last(r); got != stateNone {
+		t.Errorf("last() = %q, wanted none", got)
+	}
+
+	if err := l.record(r, stateStarted); err != nil {
+		t.Fatalf("record() = %v", err)
+	}
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{DeliveredAnnotationKey: "started"},
+		},
+	})
+	if want := "ns/run " + string(patch); len(patches) != 1 || patches[0] != want {
+		t.Errorf("patches = %v, wanted [%s]", patches, want)
+	}
+
+	// Until the informer sees our patch, we remember what we recorded.
+	if got := l.last(r); got != stateStarted {
+		t.Errorf("last() = %q from a stale run, wanted %q", got, stateStarted)
+	}
+	r.Annotations = map[string]string{DeliveredAnnotationKey: string(stateStarted)}
+	if got := l.last(r); got != stateStarted {
+		t.Errorf("last() = %q, wanted %q", got, stateStarted)
+	}
+	if len(l.recorded) != 0 {
+		t.Errorf("recorded = %v, wanted it forgotten once annotated", l.recorded)
+	}
+
+	// What we fail to record isn't remembered.
+	fail = true
+	if err := l.record(r, stateSucceeded); err == nil {
+		t.Error("record() = nil, wanted an error")
+	}
+	if got := l.last(r); got != stateStarted {
+		t.Errorf("last() = %q, wanted %q", got, stateStarted)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runevents
+
+import (
+	"context"
+
+	listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1alpha1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+)
+
+// taskRunReconciler implements controller.Reconciler for TaskRun events.
+type taskRunReconciler struct {
+	*emitter
+
+	// listers index properties about resources
+	taskRunLister listers.TaskRunLister
+}
+
+// Check that our reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*taskRunReconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *taskRunReconciler) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	tr, err := r.taskRunLister.TaskRuns(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return r.emit(ctx, fromTaskRun(tr))
+}
+
+// pipelineRunReconciler implements controller.Reconciler for PipelineRun events.
+type pipelineRunReconciler struct {
+	*emitter
+
+	// listers index properties about resources
+	pipelineRunLister listers.PipelineRunLister
+}
+
+// Check that our reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*pipelineRunReconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *pipelineRunReconciler) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	pr, err := r.pipelineRunLister.PipelineRuns(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return r.emit(ctx, fromPipelineRun(pr))
+}
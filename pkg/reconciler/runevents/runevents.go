@@ -0,0 +1,273 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runevents
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	eventinglisters "knative.dev/eventing/pkg/client/listers/eventing/v1beta1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/reconciler/runevents/config"
+)
+
+// EventTypePrefix is the prefix of the CloudEvent types we emit, which
+// are followed by the lowercase kind and state, for example:
+//
+//	dev.mink.taskrun.succeeded
+const EventTypePrefix = "dev.mink."
+
+// state is a lifecycle state of a TaskRun or PipelineRun.
+type state string
+
+const (
+	stateNone      state = ""
+	stateStarted   state = "started"
+	stateSucceeded state = "succeeded"
+	stateFailed    state = "failed"
+	stateCancelled state = "cancelled"
+)
+
+// result is a named result of a run.
+type result struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// run is the common view of TaskRuns and PipelineRuns that we use to
+// produce lifecycle events.
+type run struct {
+	metav1.ObjectMeta
+
+	// Kind is the kind of the run, e.g. TaskRun.
+	Kind string
+	// Resource is the plural resource name of the run, e.g. taskruns.
+	Resource string
+	// Ref is the name of the Task or Pipeline that was run, if any.
+	Ref string
+
+	State   state
+	Reason  string
+	Message string
+
+	StartTime      *metav1.Time
+	CompletionTime *metav1.Time
+
+	Results []result
+}
+
+func stateOf(started, done, cancelled bool, cond *apis.Condition) state {
+	switch {
+	case !started:
+		return stateNone
+	case !done:
+		return stateStarted
+	case cancelled:
+		return stateCancelled
+	case cond.IsTrue():
+		return stateSucceeded
+	default:
+		return stateFailed
+	}
+}
+
+func fromTaskRun(tr *v1alpha1.TaskRun) *run {
+	cond := tr.Status.GetCondition(apis.ConditionSucceeded)
+	r := &run{
+		ObjectMeta:     tr.ObjectMeta,
+		Kind:           "TaskRun",
+		Resource:       "taskruns",
+		State:          stateOf(tr.HasStarted(), tr.IsDone(), tr.IsCancelled(), cond),
+		StartTime:      tr.Status.StartTime,
+		CompletionTime: tr.Status.CompletionTime,
+	}
+	if tr.Spec.TaskRef != nil {
+		r.Ref = tr.Spec.TaskRef.Name
+	}
+	if cond != nil {
+		r.Reason, r.Message = cond.Reason, cond.Message
+	}
+	for _, res := range tr.Status.TaskRunResults {
+		r.Results = append(r.Results, result{Name: res.Name, Value: res.Value})
+	}
+	return r
+}
+
+func fromPipelineRun(pr *v1alpha1.PipelineRun) *run {
+	cond := pr.Status.GetCondition(apis.ConditionSucceeded)
+	r := &run{
+		ObjectMeta:     pr.ObjectMeta,
+		Kind:           "PipelineRun",
+		Resource:       "pipelineruns",
+		State:          stateOf(pr.HasStarted(), pr.IsDone(), pr.IsCancelled(), cond),
+		StartTime:      pr.Status.StartTime,
+		CompletionTime: pr.Status.CompletionTime,
+	}
+	if pr.Spec.PipelineRef != nil {
+		r.Ref = pr.Spec.PipelineRef.Name
+	}
+	if cond != nil {
+		r.Reason, r.Message = cond.Reason, cond.Message
+	}
+	for _, res := range pr.Status.PipelineResults {
+		r.Results = append(r.Results, result{Name: res.Name, Value: res.Value})
+	}
+	return r
+}
+
+// pending returns the states for which we have yet to emit an event, in
+// the order they should be sent, given the last one we delivered.
+func (r *run) pending(last state) []state {
+	switch {
+	case r.State == stateNone || r.State == last:
+		return nil
+	case r.State == stateStarted || last == stateStarted:
+		return []state{r.State}
+	default:
+		// We never saw the run start, so emit that first.
+		return []state{stateStarted, r.State}
+	}
+}
+
+// nonAlphanumeric matches the characters that aren't allowed in the names
+// of CloudEvent extension attributes.
+var nonAlphanumeric = regexp.MustCompile("[^a-z0-9]")
+
+// toEvent produces the CloudEvent for the run entering the provided state.
+func (r *run) toEvent(s state) (*cloudevents.Event, error) {
+	kind := strings.ToLower(r.Kind)
+
+	event := cloudevents.NewEvent()
+	event.SetID(fmt.Sprintf("%s.%s", r.UID, s))
+	event.SetType(EventTypePrefix + kind + "." + string(s))
+	event.SetSource(fmt.Sprintf("/apis/%s/namespaces/%s/%s/%s",
+		v1alpha1.SchemeGroupVersion.String(), r.Namespace, r.Resource, r.Name))
+	event.SetSubject(r.Name)
+	if r.Ref != "" {
+		event.SetExtension(strings.TrimSuffix(kind, "run"), r.Ref)
+	}
+
+	data := map[string]interface{}{
+		"kind":      r.Kind,
+		"namespace": r.Namespace,
+		"name":      r.Name,
+		"state":     string(s),
+	}
+	if r.StartTime != nil {
+		event.SetTime(r.StartTime.Time)
+	}
+	if s != stateStarted {
+		if r.CompletionTime != nil {
+			event.SetTime(r.CompletionTime.Time)
+			if r.StartTime != nil {
+				d := r.CompletionTime.Sub(r.StartTime.Time)
+				event.SetExtension("durationseconds", strconv.FormatInt(int64(d/time.Second), 10))
+				data["duration"] = d.String()
+			}
+		}
+		if r.Reason != "" {
+			event.SetExtension("reason", r.Reason)
+			data["reason"], data["message"] = r.Reason, r.Message
+		}
+		// Surface results as attributes, so that Triggers may filter on them.
+		for _, res := range r.Results {
+			event.SetExtension("result"+nonAlphanumeric.ReplaceAllString(strings.ToLower(res.Name), ""), res.Value)
+		}
+		if len(r.Results) > 0 {
+			data["results"] = r.Results
+		}
+	}
+	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// emitter sends lifecycle events for runs, and records what it has sent.
+type emitter struct {
+	ceclient     cloudevents.Client
+	brokerLister eventinglisters.BrokerLister
+	configStore  *config.Store
+	ledger       *ledger
+}
+
+// sinkFor determines where we should send the events for the provided run,
+// returning nil when there is nowhere to send them.
+func (e *emitter) sinkFor(ctx context.Context, r *run) (*apis.URL, error) {
+	cfg := config.FromContext(ctx)
+	if cfg.SinkURI != nil {
+		return cfg.SinkURI, nil
+	}
+	b, err := e.brokerLister.Brokers(r.Namespace).Get(cfg.Broker)
+	if apierrs.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !b.Status.GetTopLevelCondition().IsTrue() || b.Status.Address.URL == nil {
+		return nil, nil
+	}
+	return b.Status.Address.URL, nil
+}
+
+// emit sends any pending lifecycle events for the provided run.
+func (e *emitter) emit(ctx context.Context, r *run) error {
+	ctx = e.configStore.ToContext(ctx)
+	logger := logging.FromContext(ctx)
+
+	pending := r.pending(e.ledger.last(r))
+	if len(pending) == 0 {
+		return nil
+	}
+
+	sink, err := e.sinkFor(ctx, r)
+	if err != nil {
+		return err
+	}
+	if sink == nil {
+		// Changes to Brokers and to our configuration requeue the runs
+		// whose events we are holding.
+		logger.Debugf("No sink for %s %s/%s, holding %v", r.Kind, r.Namespace, r.Name, pending)
+		return nil
+	}
+
+	ctx = cloudevents.ContextWithTarget(ctx, sink.String())
+	ctx = cloudevents.ContextWithRetriesExponentialBackoff(ctx, 10*time.Millisecond, 5)
+	for _, s := range pending {
+		event, err := r.toEvent(s)
+		if err != nil {
+			return err
+		}
+		if result := e.ceclient.Send(ctx, *event); !cloudevents.IsACK(result) {
+			return fmt.Errorf("failed to send %s: %w", event.Type(), result)
+		}
+		if err := e.ledger.record(r, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
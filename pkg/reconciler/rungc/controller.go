@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rungc
+
+import (
+	"context"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	pipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1alpha1/pipelinerun"
+	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1alpha1/taskrun"
+	"k8s.io/client-go/tools/cache"
+	pvcinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/persistentvolumeclaim"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/gc"
+)
+
+const (
+	taskRunAgentName     = "taskrun-gc-controller"
+	pipelineRunAgentName = "pipelinerun-gc-controller"
+)
+
+// NewTaskRunController creates a new controller that garbage collects
+// completed TaskRuns according to config-gc.
+func NewTaskRunController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(taskRunAgentName)
+	taskRunInformer := taskruninformer.Get(ctx)
+	pipelineRunInformer := pipelineruninformer.Get(ctx)
+	pvcInformer := pvcinformer.Get(ctx)
+
+	c := &taskRunReconciler{
+		referencer: &referencer{
+			taskRunLister:     taskRunInformer.Lister(),
+			pipelineRunLister: pipelineRunInformer.Lister(),
+			pvcLister:         pvcInformer.Lister(),
+		},
+		client: pipelineclient.Get(ctx),
+	}
+	impl := controller.NewImpl(c, logger, taskRunAgentName)
+	c.enqueueAfter = impl.EnqueueKeyAfter
+
+	logger.Info("Setting up event handlers")
+	taskRunInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isDone,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+	// Reconsider the TaskRuns that deleted runs referenced.
+	referenced := c.enqueueReferenced(pipeline.TaskRunControllerName, impl.EnqueueKey)
+	taskRunInformer.Informer().AddEventHandler(referenced)
+	pipelineRunInformer.Informer().AddEventHandler(referenced)
+
+	logger.Info("Setting up ConfigMap receivers")
+	c.configStore = gc.NewStore(logger.Named("config-store"), func(string, interface{}) {
+		impl.GlobalResync(taskRunInformer.Informer())
+	})
+	c.configStore.WatchConfigs(cmw)
+
+	return impl
+}
+
+// NewPipelineRunController creates a new controller that garbage collects
+// completed PipelineRuns according to config-gc.
+func NewPipelineRunController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(pipelineRunAgentName)
+	taskRunInformer := taskruninformer.Get(ctx)
+	pipelineRunInformer := pipelineruninformer.Get(ctx)
+	pvcInformer := pvcinformer.Get(ctx)
+
+	c := &pipelineRunReconciler{
+		referencer: &referencer{
+			taskRunLister:     taskRunInformer.Lister(),
+			pipelineRunLister: pipelineRunInformer.Lister(),
+			pvcLister:         pvcInformer.Lister(),
+		},
+		client: pipelineclient.Get(ctx),
+	}
+	impl := controller.NewImpl(c, logger, pipelineRunAgentName)
+	c.enqueueAfter = impl.EnqueueKeyAfter
+
+	logger.Info("Setting up event handlers")
+	pipelineRunInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isDone,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+	// Reconsider the PipelineRuns that deleted runs referenced.
+	referenced := c.enqueueReferenced(pipeline.PipelineRunControllerName, impl.EnqueueKey)
+	taskRunInformer.Informer().AddEventHandler(referenced)
+	pipelineRunInformer.Informer().AddEventHandler(referenced)
+
+	logger.Info("Setting up ConfigMap receivers")
+	c.configStore = gc.NewStore(logger.Named("config-store"), func(string, interface{}) {
+		impl.GlobalResync(pipelineRunInformer.Informer())
+	})
+	c.configStore.WatchConfigs(cmw)
+
+	return impl
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rungc
+
+import (
+	"context"
+	"time"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	clientset "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/gc"
+)
+
+// pipelineRunReconciler implements controller.Reconciler for collecting PipelineRuns.
+type pipelineRunReconciler struct {
+	*referencer
+
+	client clientset.Interface
+
+	configStore  *gc.Store
+	enqueueAfter func(types.NamespacedName, time.Duration)
+}
+
+// Check that our reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*pipelineRunReconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *pipelineRunReconciler) Reconcile(ctx context.Context, key string) error {
+	ctx = r.configStore.ToContext(ctx)
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	pr, err := r.pipelineRunLister.PipelineRuns(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Consider all of the runs of the same Pipeline.  PipelineRuns with
+	// embedded specs have no label, so they are considered together.
+	req, err := labels.NewRequirement(pipeline.GroupName+pipeline.PipelineLabelKey, selection.DoesNotExist, nil)
+	if p, ok := pr.Labels[pipeline.GroupName+pipeline.PipelineLabelKey]; ok {
+		req, err = labels.NewRequirement(pipeline.GroupName+pipeline.PipelineLabelKey, selection.Equals, []string{p})
+	}
+	if err != nil {
+		return err
+	}
+	prs, err := r.pipelineRunLister.PipelineRuns(namespace).List(labels.NewSelector().Add(*req))
+	if err != nil {
+		return err
+	}
+
+	// Protect any PipelineRuns that another run still references.
+	referenced, err := r.referenced(namespace)
+	if err != nil {
+		return err
+	}
+
+	runs := make([]run, 0, len(prs))
+	uids := make(map[string]types.UID, len(prs))
+	for _, pr := range prs {
+		uids[pr.Name] = pr.UID
+		runs = append(runs, pipelineRunToRun(pr, referenced))
+	}
+
+	collect, next := plan(gc.FromContext(ctx), runs, time.Now())
+	for _, name := range collect {
+		// Deleting the PipelineRun cascades to its TaskRuns.
+		logger.Infof("Collecting PipelineRun %s/%s", namespace, name)
+		if err := r.client.TektonV1alpha1().PipelineRuns(namespace).Delete(name, deleteOptions(uids[name])); err != nil && !apierrs.IsNotFound(err) {
+			return err
+		}
+	}
+	if next > 0 {
+		r.enqueueAfter(types.NamespacedName{Namespace: namespace, Name: pr.Name}, next)
+	}
+	return nil
+}
+
+func pipelineRunToRun(pr *v1alpha1.PipelineRun, referenced sets.String) run {
+	r := run{
+		Name:      pr.Name,
+		Done:      pr.IsDone(),
+		Succeeded: pr.Status.GetCondition(apis.ConditionSucceeded).IsTrue(),
+		Protected: referenced.Has(string(pr.UID)),
+	}
+	if pr.Status.CompletionTime != nil {
+		r.Completed = pr.Status.CompletionTime.Time
+	} else if c := pr.Status.GetCondition(apis.ConditionSucceeded); c != nil {
+		r.Completed = c.LastTransitionTime.Inner.Time
+	}
+	return r
+}
+
+// isDone filters runs down to those that have finished executing.
+func isDone(obj interface{}) bool {
+	switch o := obj.(type) {
+	case *v1alpha1.TaskRun:
+		return o.IsDone()
+	case *v1alpha1.PipelineRun:
+		return o.IsDone()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rungc
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mattmoor/mink/pkg/gc"
+)
+
+// run is the subset of a TaskRun or PipelineRun that our collection
+// policy considers.
+type run struct {
+	Name      string
+	Done      bool
+	Succeeded bool
+	Completed time.Time
+
+	// Protected runs are never collected, and do not count against the
+	// run limits.
+	Protected bool
+}
+
+// plan determines which of the supplied runs (all executions of the same
+// Task or Pipeline) should be collected under the provided configuration,
+// and how long until the next of the remaining runs expires (or zero if
+// none will).
+func plan(cfg *gc.Config, runs []run, now time.Time) (collect []string, next time.Duration) {
+	var succeeded, failed []run
+	for _, r := range runs {
+		switch {
+		case !r.Done || r.Protected:
+			continue
+		case r.Succeeded:
+			succeeded = append(succeeded, r)
+		default:
+			failed = append(failed, r)
+		}
+	}
+
+	for _, group := range []struct {
+		runs  []run
+		limit int64
+		ttl   time.Duration
+	}{{
+		runs:  succeeded,
+		limit: cfg.SucceededRunLimit,
+		ttl:   cfg.SucceededRunTTL,
+	}, {
+		runs:  failed,
+		limit: cfg.FailedRunLimit,
+		ttl:   cfg.FailedRunTTL,
+	}} {
+		// Sort by completion time descending, so the newest runs are
+		// the ones we keep.
+		sort.Slice(group.runs, func(i, j int) bool {
+			return group.runs[j].Completed.Before(group.runs[i].Completed)
+		})
+
+		for i, r := range group.runs {
+			if group.limit != gc.Unlimited && int64(i) >= group.limit {
+				collect = append(collect, r.Name)
+				continue
+			}
+			if group.ttl == 0 {
+				continue
+			}
+			if left := r.Completed.Add(group.ttl).Sub(now); left <= 0 {
+				collect = append(collect, r.Name)
+			} else if next == 0 || left < next {
+				next = left
+			}
+		}
+	}
+	return collect, next
+}
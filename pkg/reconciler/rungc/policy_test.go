@@ -0,0 +1,127 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rungc
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/mattmoor/mink/pkg/gc"
+)
+
+func TestPlan(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) time.Time {
+		return now.Add(-d)
+	}
+
+	tests := []struct {
+		name        string
+		cfg         gc.Config
+		runs        []run
+		wantCollect []string
+		wantNext    time.Duration
+	}{{
+		name: "unlimited",
+		cfg: gc.Config{
+			SucceededRunLimit: gc.Unlimited,
+			FailedRunLimit:    gc.Unlimited,
+		},
+		runs: []run{
+			{Name: "a", Done: true, Succeeded: true, Completed: ago(time.Hour)},
+			{Name: "b", Done: true, Completed: ago(time.Hour)},
+		},
+	}, {
+		name: "limits keep the newest",
+		cfg: gc.Config{
+			SucceededRunLimit: 1,
+			FailedRunLimit:    1,
+		},
+		runs: []run{
+			{Name: "old-ok", Done: true, Succeeded: true, Completed: ago(2 * time.Hour)},
+			{Name: "new-ok", Done: true, Succeeded: true, Completed: ago(time.Hour)},
+			{Name: "old-fail", Done: true, Completed: ago(2 * time.Hour)},
+			{Name: "new-fail", Done: true, Completed: ago(time.Hour)},
+		},
+		wantCollect: []string{"old-fail", "old-ok"},
+	}, {
+		name: "running runs are kept",
+		cfg: gc.Config{
+			SucceededRunLimit: 0,
+			FailedRunLimit:    0,
+		},
+		runs: []run{
+			{Name: "running"},
+			{Name: "done", Done: true, Completed: ago(time.Hour)},
+		},
+		wantCollect: []string{"done"},
+	}, {
+		name: "protected runs are kept and not counted",
+		cfg: gc.Config{
+			SucceededRunLimit: 1,
+			FailedRunLimit:    gc.Unlimited,
+		},
+		runs: []run{
+			{Name: "protected", Done: true, Succeeded: true, Completed: ago(time.Minute), Protected: true},
+			{Name: "newer", Done: true, Succeeded: true, Completed: ago(time.Hour)},
+			{Name: "older", Done: true, Succeeded: true, Completed: ago(2 * time.Hour)},
+		},
+		wantCollect: []string{"older"},
+	}, {
+		name: "protected runs outlive their ttl",
+		cfg: gc.Config{
+			SucceededRunLimit: gc.Unlimited,
+			FailedRunLimit:    gc.Unlimited,
+			FailedRunTTL:      time.Hour,
+		},
+		runs: []run{
+			{Name: "protected", Done: true, Completed: ago(2 * time.Hour), Protected: true},
+			{Name: "expired", Done: true, Completed: ago(2 * time.Hour)},
+		},
+		wantCollect: []string{"expired"},
+	}, {
+		name: "next expiry",
+		cfg: gc.Config{
+			SucceededRunLimit: gc.Unlimited,
+			FailedRunLimit:    gc.Unlimited,
+			SucceededRunTTL:   time.Hour,
+			FailedRunTTL:      2 * time.Hour,
+		},
+		runs: []run{
+			{Name: "ok", Done: true, Succeeded: true, Completed: ago(50 * time.Minute)},
+			{Name: "fail", Done: true, Completed: ago(time.Hour)},
+		},
+		wantNext: 10 * time.Minute,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := test.cfg
+			collect, next := plan(&cfg, test.runs, now)
+			sort.Strings(collect)
+			if !cmp.Equal(collect, test.wantCollect) {
+				t.Errorf("plan() collect = %v, wanted %v", collect, test.wantCollect)
+			}
+			if next != test.wantNext {
+				t.Errorf("plan() next = %v, wanted %v", next, test.wantNext)
+			}
+		})
+	}
+}
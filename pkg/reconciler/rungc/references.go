@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rungc
+
+import (
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1alpha1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// referencer looks up which runs other runs still reference.  Collecting
+// those would take what the referencing runs depend on with them: the
+// garbage collector deletes the runs that a collected run owns, and the
+// PersistentVolumeClaims it owns along with their contents.
+type referencer struct {
+	taskRunLister     listers.TaskRunLister
+	pipelineRunLister listers.PipelineRunLister
+	pvcLister         corelisters.PersistentVolumeClaimLister
+}
+
+// referenced returns the UIDs of the runs in the namespace that another run
+// references, by owning it or by owning a PersistentVolumeClaim that it
+// binds as a workspace.
+func (r *referencer) referenced(namespace string) (sets.String, error) {
+	trs, err := r.taskRunLister.TaskRuns(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	prs, err := r.pipelineRunLister.PipelineRuns(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	uids := sets.NewString()
+	// claims maps the claims bound as workspaces to the runs binding them.
+	claims := make(map[string]sets.String)
+	note := func(from types.UID, owners []metav1.OwnerReference, workspaces []v1alpha1.WorkspaceBinding) {
+		for _, or := range owners {
+			if or.UID != from {
+				uids.Insert(string(or.UID))
+			}
+		}
+		for _, ws := range workspaces {
+			if ws.PersistentVolumeClaim == nil {
+				continue
+			}
+			name := ws.PersistentVolumeClaim.ClaimName
+			if claims[name] == nil {
+				claims[name] = sets.NewString()
+			}
+			claims[name].Insert(string(from))
+		}
+	}
+	for _, tr := range trs {
+		note(referrer(tr), tr.OwnerReferences, tr.Spec.Workspaces)
+	}
+	for _, pr := range prs {
+		note(pr.UID, pr.OwnerReferences, pr.Spec.Workspaces)
+	}
+
+	for name, froms := range claims {
+		pvc, err := r.pvcLister.PersistentVolumeClaims(namespace).Get(name)
+		if apierrs.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for _, or := range pvc.OwnerReferences {
+			// Runs may bind the claims they own themselves.
+			if froms.Len() > 1 || !froms.Has(string(or.UID)) {
+				uids.Insert(string(or.UID))
+			}
+		}
+	}
+	return uids, nil
+}
+
+// referrer returns the UID on whose behalf the TaskRun references things.
+// The TaskRuns that a PipelineRun creates act on its behalf, since they are
+// collected along with it.
+func referrer(tr *v1alpha1.TaskRun) types.UID {
+	if c := metav1.GetControllerOf(tr); c != nil && c.Kind == pipeline.PipelineRunControllerName {
+		return c.UID
+	}
+	return tr.UID
+}
+
+// enqueueReferenced returns a handler that, when a run is deleted, enqueues
+// the runs of the given kind that it referenced, which may now be collected.
+func (r *referencer) enqueueReferenced(kind string, enqueue func(types.NamespacedName)) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			var (
+				namespace  string
+				owners     []metav1.OwnerReference
+				workspaces []v1alpha1.WorkspaceBinding
+			)
+			switch o := obj.(type) {
+			case *v1alpha1.TaskRun:
+				namespace, workspaces = o.Namespace, o.Spec.Workspaces
+				owners = append(owners, o.OwnerReferences...)
+			case *v1alpha1.PipelineRun:
+				namespace, workspaces = o.Namespace, o.Spec.Workspaces
+				owners = append(owners, o.OwnerReferences...)
+			default:
+				return
+			}
+			for _, ws := range workspaces {
+				if ws.PersistentVolumeClaim == nil {
+					continue
+				}
+				pvc, err := r.pvcLister.PersistentVolumeClaims(namespace).Get(ws.PersistentVolumeClaim.ClaimName)
+				if err != nil {
+					continue
+				}
+				owners = append(owners, pvc.OwnerReferences...)
+			}
+			for _, or := range owners {
+				if or.Kind == kind {
+					enqueue(types.NamespacedName{Namespace: namespace, Name: or.Name})
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rungc
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func ownedBy(kind, name string, uid types.UID, controller bool) []metav1.OwnerReference {
+	return []metav1.OwnerReference{{
+		Kind:       kind,
+		Name:       name,
+		UID:        uid,
+		Controller: &controller,
+	}}
+}
+
+func claim(name string) []v1alpha1.WorkspaceBinding {
+	return []v1alpha1.WorkspaceBinding{{
+		Name: "ws",
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: name,
+		},
+	}}
+}
+
+func TestReferenced(t *testing.T) {
+	tests := []struct {
+		name string
+		trs  []*v1alpha1.TaskRun
+		prs  []*v1alpha1.PipelineRun
+		pvcs []*corev1.PersistentVolumeClaim
+		want sets.String
+	}{{
+		name: "unreferenced",
+		trs: []*v1alpha1.TaskRun{{
+			ObjectMeta: metav1.ObjectMeta{Name: "tr", UID: "tr-uid"},
+		}},
+		prs: []*v1alpha1.PipelineRun{{
+			ObjectMeta: metav1.ObjectMeta{Name: "pr", UID: "pr-uid"},
+		}},
+		want: sets.NewString(),
+	}, {
+		name: "owned by another run",
+		trs: []*v1alpha1.TaskRun{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "child",
+				UID:             "child-uid",
+				OwnerReferences: ownedBy("TaskRun", "parent", "parent-uid", false),
+			},
+		}, {
+			ObjectMeta: metav1.ObjectMeta{Name: "parent", UID: "parent-uid"},
+		}},
+		want: sets.NewString("parent-uid"),
+	}, {
+		name: "binds a claim that another run owns",
+		prs: []*v1alpha1.PipelineRun{{
+			ObjectMeta: metav1.ObjectMeta{Name: "owner", UID: "owner-uid"},
+		}, {
+			ObjectMeta: metav1.ObjectMeta{Name: "binder", UID: "binder-uid"},
+			Spec:       v1alpha1.PipelineRunSpec{Workspaces: claim("shared")},
+		}},
+		pvcs: []*corev1.PersistentVolumeClaim{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "shared",
+				OwnerReferences: ownedBy("PipelineRun", "owner", "owner-uid", true),
+			},
+		}},
+		want: sets.NewString("owner-uid"),
+	}, {
+		name: "binds only the claim it owns",
+		prs: []*v1alpha1.PipelineRun{{
+			ObjectMeta: metav1.ObjectMeta{Name: "owner", UID: "owner-uid"},
+			Spec:       v1alpha1.PipelineRunSpec{Workspaces: claim("mine")},
+		}},
+		pvcs: []*corev1.PersistentVolumeClaim{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "mine",
+				OwnerReferences: ownedBy("PipelineRun", "owner", "owner-uid", true),
+			},
+		}},
+		want: sets.NewString(),
+	}, {
+		name: "the PipelineRun's TaskRuns bind the claim it owns",
+		trs: []*v1alpha1.TaskRun{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "owner-task",
+				UID:             "task-uid",
+				OwnerReferences: ownedBy(pipeline.PipelineRunControllerName, "owner", "owner-uid", true),
+			},
+			Spec: v1alpha1.TaskRunSpec{Workspaces: claim("mine")},
+		}},
+		prs: []*v1alpha1.PipelineRun{{
+			ObjectMeta: metav1.ObjectMeta{Name: "owner", UID: "owner-uid"},
+			Spec:       v1alpha1.PipelineRunSpec{Workspaces: claim("mine")},
+		}},
+		pvcs: []*corev1.PersistentVolumeClaim{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "mine",
+				OwnerReferences: ownedBy("PipelineRun", "owner", "owner-uid", true),
+			},
+		}},
+		// The TaskRun acts on behalf of the PipelineRun that owns it,
+		// since they are collected together.
+		want: sets.NewString(),
+	}, {
+		name: "binds a missing claim",
+		trs: []*v1alpha1.TaskRun{{
+			ObjectMeta: metav1.ObjectMeta{Name: "tr", UID: "tr-uid"},
+			Spec:       v1alpha1.TaskRunSpec{Workspaces: claim("gone")},
+		}},
+		want: sets.NewString(),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			prIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			pvcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, tr := range test.trs {
+				tr.Namespace = "ns"
+				trIndexer.Add(tr)
+			}
+			for _, pr := range test.prs {
+				pr.Namespace = "ns"
+				prIndexer.Add(pr)
+			}
+			for _, pvc := range test.pvcs {
+				pvc.Namespace = "ns"
+				pvcIndexer.Add(pvc)
+			}
+			r := &referencer{
+				taskRunLister:     listers.NewTaskRunLister(trIndexer),
+				pipelineRunLister: listers.NewPipelineRunLister(prIndexer),
+				pvcLister:         corelisters.NewPersistentVolumeClaimLister(pvcIndexer),
+			}
+
+			got, err := r.referenced("ns")
+			if err != nil {
+				t.Fatalf("referenced() = %v", err)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("referenced() = %v, wanted %v", got.List(), test.want.List())
+			}
+		})
+	}
+}
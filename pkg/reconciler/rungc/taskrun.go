@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rungc
+
+import (
+	"context"
+	"time"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	clientset "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/gc"
+)
+
+// taskRunReconciler implements controller.Reconciler for collecting TaskRuns.
+type taskRunReconciler struct {
+	*referencer
+
+	client clientset.Interface
+
+	configStore  *gc.Store
+	enqueueAfter func(types.NamespacedName, time.Duration)
+}
+
+// Check that our reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*taskRunReconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *taskRunReconciler) Reconcile(ctx context.Context, key string) error {
+	ctx = r.configStore.ToContext(ctx)
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	tr, err := r.taskRunLister.TaskRuns(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// TaskRuns that are part of a PipelineRun are collected along with it.
+	if tr.HasPipelineRunOwnerReference() {
+		return nil
+	}
+
+	// Consider all of the runs of the same Task.  TaskRuns with embedded
+	// specs have no label, so they are considered together.
+	req, err := labels.NewRequirement(pipeline.GroupName+pipeline.TaskLabelKey, selection.DoesNotExist, nil)
+	if task, ok := tr.Labels[pipeline.GroupName+pipeline.TaskLabelKey]; ok {
+		req, err = labels.NewRequirement(pipeline.GroupName+pipeline.TaskLabelKey, selection.Equals, []string{task})
+	}
+	if err != nil {
+		return err
+	}
+	trs, err := r.taskRunLister.TaskRuns(namespace).List(labels.NewSelector().Add(*req))
+	if err != nil {
+		return err
+	}
+
+	// Protect any TaskRuns that another run still references.
+	referenced, err := r.referenced(namespace)
+	if err != nil {
+		return err
+	}
+
+	runs := make([]run, 0, len(trs))
+	uids := make(map[string]types.UID, len(trs))
+	for _, tr := range trs {
+		uids[tr.Name] = tr.UID
+		runs = append(runs, taskRunToRun(tr, referenced))
+	}
+
+	collect, next := plan(gc.FromContext(ctx), runs, time.Now())
+	for _, name := range collect {
+		logger.Infof("Collecting TaskRun %s/%s", namespace, name)
+		if err := r.client.TektonV1alpha1().TaskRuns(namespace).Delete(name, deleteOptions(uids[name])); err != nil && !apierrs.IsNotFound(err) {
+			return err
+		}
+	}
+	if next > 0 {
+		r.enqueueAfter(types.NamespacedName{Namespace: namespace, Name: tr.Name}, next)
+	}
+	return nil
+}
+
+func taskRunToRun(tr *v1alpha1.TaskRun, referenced sets.String) run {
+	r := run{
+		Name:      tr.Name,
+		Done:      tr.IsDone(),
+		Succeeded: tr.Status.GetCondition(apis.ConditionSucceeded).IsTrue(),
+		Protected: tr.HasPipelineRunOwnerReference() || referenced.Has(string(tr.UID)),
+	}
+	if tr.Status.CompletionTime != nil {
+		r.Completed = tr.Status.CompletionTime.Time
+	} else if c := tr.Status.GetCondition(apis.ConditionSucceeded); c != nil {
+		r.Completed = c.LastTransitionTime.Inner.Time
+	}
+	return r
+}
+
+// deleteOptions returns the options for deleting a run (and its children)
+// provided that it hasn't been recreated since we looked at it.
+func deleteOptions(uid types.UID) *metav1.DeleteOptions {
+	propagation := metav1.DeletePropagationBackground
+	return &metav1.DeleteOptions{
+		Preconditions:     &metav1.Preconditions{UID: &uid},
+		PropagationPolicy: &propagation,
+	}
+}
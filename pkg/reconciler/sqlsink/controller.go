@@ -0,0 +1,104 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlsink
+
+import (
+	"context"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	secretinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/secret"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	minkclient "github.com/mattmoor/mink/pkg/client/injection/client"
+	sqlsinkinformer "github.com/mattmoor/mink/pkg/client/injection/informers/sinks/v1alpha1/sqlsink"
+)
+
+const (
+	controllerAgentName = "sqlsink-controller"
+
+	// receiverPort is the port on which we receive events for all of
+	// the SQLSinks in the cluster.
+	receiverPort = ":8085"
+)
+
+// NewController creates a new SQLSink controller, and starts the receiver
+// that writes the events sent to those sinks into their tables.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	sinkInformer := sqlsinkinformer.Get(ctx)
+	secretInformer := secretinformer.Get(ctx)
+
+	rcv := &receiver{
+		logger:       logger.Named("receiver"),
+		sinkLister:   sinkInformer.Lister(),
+		secretLister: secretInformer.Lister(),
+		writers:      make(map[types.NamespacedName]*writer),
+	}
+	c := &Reconciler{
+		client:       minkclient.Get(ctx),
+		lister:       sinkInformer.Lister(),
+		secretLister: secretInformer.Lister(),
+		receiver:     rcv,
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+
+	logger.Info("Setting up event handlers")
+	sinkInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	// Reconcile the sinks that use a Secret when it changes.
+	secretInformer.Informer().AddEventHandler(controller.HandleAll(func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return
+		}
+		sinks, err := sinkInformer.Lister().SQLSinks(secret.Namespace).List(labels.Everything())
+		if err != nil {
+			logger.Errorw("Error listing SQLSinks", "error", err)
+			return
+		}
+		for _, s := range sinks {
+			if s.Spec.Secret.Name == secret.Name {
+				impl.Enqueue(s)
+			}
+		}
+	}))
+
+	srv := &http.Server{Addr: receiverPort, Handler: rcv}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatalw("Error serving SQLSink events", "error", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	return impl
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlsink
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.uber.org/zap"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+	listers "github.com/mattmoor/mink/pkg/client/listers/sinks/v1alpha1"
+	"github.com/mattmoor/mink/pkg/extract"
+
+	// Register the database/sql drivers of our dialects.
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+// ConnectionStringKey is the key of the connection string in the secrets
+// of SQLSinks, as with SQLBinding.
+const ConnectionStringKey = "connectionstr"
+
+// receiver accepts CloudEvents sent to SQLSinks at /<namespace>/<name>
+// and writes a row for each of them.
+type receiver struct {
+	logger *zap.SugaredLogger
+
+	sinkLister   listers.SQLSinkLister
+	secretLister corev1listers.SecretLister
+
+	m       sync.Mutex
+	writers map[types.NamespacedName]*writer
+}
+
+var _ http.Handler = (*receiver)(nil)
+
+// ServeHTTP implements http.Handler
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) != 2 {
+		http.NotFound(w, req)
+		return
+	}
+	key := types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+
+	sink, err := r.sinkLister.SQLSinks(key.Namespace).Get(key.Name)
+	if apierrs.IsNotFound(err) {
+		http.NotFound(w, req)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ev, err := binding.ToEvent(req.Context(), cehttp.NewMessageFromHttpRequest(req))
+	if err != nil {
+		http.Error(w, "malformed CloudEvent: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	values, rowKey, err := extractRow(&sink.Spec, ev)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wr, err := r.writer(sink)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err := wr.submit(req.Context(), values, rowKey); err == errStopped {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// writer returns the writer for the sink's current spec and secret.
+func (r *receiver) writer(sink *v1alpha1.SQLSink) (*writer, error) {
+	secret, err := r.secretLister.Secrets(sink.Namespace).Get(sink.Spec.Secret.Name)
+	if err != nil {
+		return nil, err
+	}
+	key := types.NamespacedName{Namespace: sink.Namespace, Name: sink.Name}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if w, ok := r.writers[key]; ok {
+		if w.generation == sink.Generation && w.secretVersion == secret.ResourceVersion {
+			return w, nil
+		}
+		w.stop()
+		delete(r.writers, key)
+	}
+
+	connstr, ok := secret.Data[ConnectionStringKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %q has no key %q", secret.Name, ConnectionStringKey)
+	}
+	db, err := sql.Open(string(sink.Spec.Dialect), string(connstr))
+	if err != nil {
+		return nil, err
+	}
+	w := newWriter(r.logger.With(zap.String("sink", key.String())),
+		sink.Spec.DeepCopy(), db, sink.Generation, secret.ResourceVersion)
+	r.writers[key] = w
+	return w, nil
+}
+
+// forget stops the writer of a deleted sink.
+func (r *receiver) forget(key types.NamespacedName) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if w, ok := r.writers[key]; ok {
+		w.stop()
+		delete(r.writers, key)
+	}
+}
+
+// extractRow returns the values of the spec's columns in the event, and a
+// string identifying the row by its key.
+func extractRow(spec *v1alpha1.SQLSinkSpec, ev *event.Event) ([]interface{}, string, error) {
+	var (
+		data   interface{}
+		parsed bool
+	)
+	columns := make(map[string]interface{}, len(spec.Columns))
+	values := make([]interface{}, 0, len(spec.Columns))
+	for _, c := range spec.Columns {
+		var value interface{}
+		switch {
+		case c.Attribute == "data":
+			if len(ev.Data()) > 0 {
+				value = string(ev.Data())
+			}
+		case c.Attribute == "time":
+			if t := ev.Time(); !t.IsZero() {
+				value = t
+			}
+		case c.Attribute != "":
+			s, found, err := extract.Attribute(ev, c.Attribute)
+			if err != nil {
+				return nil, "", fmt.Errorf("column %q: %w", c.Name, err)
+			} else if found {
+				value = s
+			}
+		default:
+			if !parsed && len(ev.Data()) > 0 {
+				if err := json.Unmarshal(ev.Data(), &data); err != nil {
+					return nil, "", fmt.Errorf("unable to parse event data as JSON: %w", err)
+				}
+			}
+			parsed = true
+			v, found := extract.Path(data, c.Path)
+			switch v.(type) {
+			case map[string]interface{}, []interface{}:
+				s, err := extract.String(v)
+				if err != nil {
+					return nil, "", fmt.Errorf("column %q: %w", c.Name, err)
+				}
+				value = s
+			default:
+				if found {
+					value = v
+				}
+			}
+		}
+		columns[c.Name] = value
+		values = append(values, value)
+	}
+
+	key := make([]interface{}, 0, len(spec.Key))
+	for _, k := range spec.Key {
+		key = append(key, columns[k])
+	}
+	b, err := json.Marshal(key)
+	if err != nil {
+		return nil, "", err
+	}
+	return values, string(b), nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlsink
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/network"
+	"knative.dev/pkg/system"
+
+	"github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+	clientset "github.com/mattmoor/mink/pkg/client/clientset/versioned"
+	listers "github.com/mattmoor/mink/pkg/client/listers/sinks/v1alpha1"
+)
+
+// ServiceName is the name of the Service in front of our receiver.
+const ServiceName = "sqlsink"
+
+// Reconciler implements controller.Reconciler for SQLSink resources.
+type Reconciler struct {
+	client       clientset.Interface
+	lister       listers.SQLSinkLister
+	secretLister corev1listers.SecretLister
+
+	// receiver is told to forget deleted sinks.
+	receiver *receiver
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	original, err := r.lister.SQLSinks(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		r.receiver.forget(types.NamespacedName{Namespace: namespace, Name: name})
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if original.GetDeletionTimestamp() != nil {
+		return nil
+	}
+
+	s := original.DeepCopy()
+	s.Status.InitializeConditions()
+	r.reconcileSecret(s)
+	s.Status.MarkAddress(addressOf(s))
+	s.Status.ObservedGeneration = s.Generation
+
+	if equality.Semantic.DeepEqual(original.Status, s.Status) {
+		return nil
+	}
+	_, err = r.client.SinksV1alpha1().SQLSinks(namespace).UpdateStatus(s)
+	return err
+}
+
+// reconcileSecret checks that the sink's secret holds a connection string.
+func (r *Reconciler) reconcileSecret(s *v1alpha1.SQLSink) {
+	secret, err := r.secretLister.Secrets(s.Namespace).Get(s.Spec.Secret.Name)
+	if apierrs.IsNotFound(err) {
+		s.Status.MarkSecretNotReady("NotFound", "Secret %q does not exist.", s.Spec.Secret.Name)
+	} else if err != nil {
+		s.Status.MarkSecretNotReady("GetFailed", "Unable to get Secret %q: %v", s.Spec.Secret.Name, err)
+	} else if _, ok := secret.Data[ConnectionStringKey]; !ok {
+		s.Status.MarkSecretNotReady("MissingKey", "Secret %q has no key %q.", secret.Name, ConnectionStringKey)
+	} else {
+		s.Status.MarkSecretReady()
+	}
+}
+
+// addressOf returns the URL at which the receiver accepts events for the
+// provided sink.
+func addressOf(s *v1alpha1.SQLSink) *apis.URL {
+	return &apis.URL{
+		Scheme: "http",
+		Host:   network.GetServiceHostname(ServiceName, system.Namespace()),
+		Path:   "/" + s.Namespace + "/" + s.Name,
+	}
+}
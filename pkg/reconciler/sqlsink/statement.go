@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlsink
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+)
+
+// quote quotes a (possibly schema qualified) identifier in the dialect.
+func quote(dialect v1alpha1.SQLDialect, ident string) string {
+	parts := strings.Split(ident, ".")
+	for i, p := range parts {
+		if dialect == v1alpha1.SQLDialectMySQL {
+			parts[i] = "`" + strings.Replace(p, "`", "``", -1) + "`"
+		} else {
+			parts[i] = pq.QuoteIdentifier(p)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// placeholder returns the nth (starting at 1) parameter in the dialect.
+func placeholder(dialect v1alpha1.SQLDialect, n int) string {
+	if dialect == v1alpha1.SQLDialectMySQL {
+		return "?"
+	}
+	return fmt.Sprintf("$%d", n)
+}
+
+// statement returns the statement (and its arguments) that writes the
+// provided rows, whose values are in the order of the spec's columns.
+func statement(spec *v1alpha1.SQLSinkSpec, rows [][]interface{}) (string, []interface{}) {
+	d := spec.Dialect
+	columns := make([]string, 0, len(spec.Columns))
+	for _, c := range spec.Columns {
+		columns = append(columns, quote(d, c.Name))
+	}
+
+	var (
+		values = make([]string, 0, len(rows))
+		args   = make([]interface{}, 0, len(rows)*len(columns))
+	)
+	for _, row := range rows {
+		params := make([]string, 0, len(row))
+		for _, v := range row {
+			args = append(args, v)
+			params = append(params, placeholder(d, len(args)))
+		}
+		values = append(values, "("+strings.Join(params, ", ")+")")
+	}
+
+	// The columns overwritten by upserts.
+	key := sets.NewString(spec.Key...)
+	var updates []string
+	if spec.Mode == v1alpha1.SQLSinkModeUpsert {
+		for i, c := range spec.Columns {
+			if key.Has(c.Name) {
+				continue
+			}
+			if d == v1alpha1.SQLDialectMySQL {
+				updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", columns[i], columns[i]))
+			} else {
+				updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", columns[i], columns[i]))
+			}
+		}
+	}
+
+	var b strings.Builder
+	switch {
+	case d == v1alpha1.SQLDialectMySQL && len(updates) == 0:
+		b.WriteString("INSERT IGNORE INTO ")
+	default:
+		b.WriteString("INSERT INTO ")
+	}
+	fmt.Fprintf(&b, "%s (%s) VALUES %s", quote(d, spec.Table), strings.Join(columns, ", "), strings.Join(values, ", "))
+
+	switch {
+	case d == v1alpha1.SQLDialectMySQL && len(updates) > 0:
+		b.WriteString(" ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", "))
+	case d == v1alpha1.SQLDialectMySQL:
+	default:
+		conflict := make([]string, 0, len(spec.Key))
+		for _, k := range spec.Key {
+			conflict = append(conflict, quote(d, k))
+		}
+		fmt.Fprintf(&b, " ON CONFLICT (%s) ", strings.Join(conflict, ", "))
+		if len(updates) > 0 {
+			b.WriteString("DO UPDATE SET " + strings.Join(updates, ", "))
+		} else {
+			b.WriteString("DO NOTHING")
+		}
+	}
+	return b.String(), args
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlsink
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+)
+
+// errStopped is returned to the events submitted to a writer that has
+// been replaced, which their senders should retry.
+var errStopped = errors.New("the sink is being reconfigured")
+
+// pending is a row awaiting the commit of its batch.
+type pending struct {
+	values []interface{}
+	// key identifies the row within its batch.
+	key  string
+	done chan error
+}
+
+// writer batches the rows written to a single SQLSink.  It is replaced
+// when the sink or its secret change.
+type writer struct {
+	logger *zap.SugaredLogger
+	spec   *v1alpha1.SQLSinkSpec
+	db     *sql.DB
+
+	// generation and secretVersion are those of the SQLSink and Secret
+	// from which the writer was configured.
+	generation    int64
+	secretVersion string
+
+	ch     chan *pending
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newWriter(logger *zap.SugaredLogger, spec *v1alpha1.SQLSinkSpec, db *sql.DB, generation int64, secretVersion string) *writer {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &writer{
+		logger:        logger,
+		spec:          spec,
+		db:            db,
+		generation:    generation,
+		secretVersion: secretVersion,
+		ch:            make(chan *pending),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	go w.run()
+	return w
+}
+
+// stop makes the writer give up on the rows it has not started writing.
+func (w *writer) stop() {
+	w.cancel()
+}
+
+// submit writes the row with its batch, returning once it commits.
+func (w *writer) submit(ctx context.Context, values []interface{}, key string) error {
+	p := &pending{values: values, key: key, done: make(chan error, 1)}
+	select {
+	case w.ch <- p:
+	case <-w.ctx.Done():
+		return errStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-p.done:
+		return err
+	case <-w.ctx.Done():
+		return errStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *writer) run() {
+	defer w.db.Close()
+	for {
+		var batch []*pending
+		select {
+		case p := <-w.ch:
+			batch = append(batch, p)
+		case <-w.ctx.Done():
+			return
+		}
+
+		timer := time.NewTimer(w.spec.Batch.Delay.Duration)
+	fill:
+		for len(batch) < int(w.spec.Batch.Size) {
+			select {
+			case p := <-w.ch:
+				batch = append(batch, p)
+			case <-timer.C:
+				break fill
+			case <-w.ctx.Done():
+				break fill
+			}
+		}
+		timer.Stop()
+
+		w.write(batch)
+	}
+}
+
+// write writes the batch in a single statement, and reports the outcome to
+// each of its rows.  Rows sharing a key (e.g. redelivered events) are
+// written once, since a statement may not touch the same row twice.  When
+// the statement fails, we write the rows one at a time, so that only those
+// that fail on their own are retried by their senders.
+func (w *writer) write(batch []*pending) {
+	var (
+		rows    = make([][]interface{}, 0, len(batch))
+		members = make([][]*pending, 0, len(batch))
+		index   = make(map[string]int, len(batch))
+	)
+	for _, p := range batch {
+		if i, ok := index[p.key]; ok {
+			rows[i] = p.values
+			members[i] = append(members[i], p)
+			continue
+		}
+		index[p.key] = len(rows)
+		rows = append(rows, p.values)
+		members = append(members, []*pending{p})
+	}
+
+	err := w.exec(rows)
+	if err == nil || len(rows) == 1 || w.ctx.Err() != nil {
+		if err != nil {
+			w.logger.Errorw("Error writing batch", zap.Int("rows", len(rows)), zap.Error(err))
+		}
+		for _, p := range batch {
+			p.done <- err
+		}
+		return
+	}
+
+	w.logger.Warnw("Error writing batch, writing its rows one at a time", zap.Int("rows", len(rows)), zap.Error(err))
+	for i, row := range rows {
+		err := w.exec([][]interface{}{row})
+		if err != nil {
+			w.logger.Errorw("Error writing row", zap.Error(err))
+		}
+		for _, p := range members[i] {
+			p.done <- err
+		}
+	}
+}
+
+// exec writes the rows in a single statement.
+func (w *writer) exec(rows [][]interface{}) error {
+	query, args := statement(w.spec, rows)
+	_, err := w.db.ExecContext(w.ctx, query, args...)
+	return err
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlsink
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/mattmoor/mink/pkg/apis/sinks/v1alpha1"
+)
+
+var errBadRow = errors.New("bad row")
+
+// fakeDriver fails the statements that write any "bad" value, and records
+// how many rows each statement that it runs writes.
+type fakeDriver struct {
+	m          sync.Mutex
+	statements []int
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{d: c.d}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("unsupported") }
+
+type fakeStmt struct{ d *fakeDriver }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	for _, a := range args {
+		if a == "bad" {
+			return nil, errBadRow
+		}
+	}
+	s.d.m.Lock()
+	defer s.d.m.Unlock()
+	s.d.statements = append(s.d.statements, len(args))
+	return driver.RowsAffected(len(args)), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("unsupported")
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name           string
+		rows           []string
+		wantErrs       []error
+		wantStatements []int
+	}{{
+		name:           "batch",
+		rows:           []string{"a", "b", "c"},
+		wantErrs:       []error{nil, nil, nil},
+		wantStatements: []int{3},
+	}, {
+		name:           "one bad row",
+		rows:           []string{"a", "bad", "c"},
+		wantErrs:       []error{nil, errBadRow, nil},
+		wantStatements: []int{1, 1},
+	}, {
+		name:           "redelivered rows",
+		rows:           []string{"a", "a", "b"},
+		wantErrs:       []error{nil, nil, nil},
+		wantStatements: []int{2},
+	}, {
+		name:     "lone bad row",
+		rows:     []string{"bad"},
+		wantErrs: []error{errBadRow},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := &fakeDriver{}
+			db := sql.OpenDB(connector{d})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			w := &writer{
+				logger: zap.NewNop().Sugar(),
+				spec: &v1alpha1.SQLSinkSpec{
+					Table:   "events",
+					Columns: []v1alpha1.SQLSinkColumn{{Name: "id", Attribute: "id"}},
+				},
+				db:  db,
+				ctx: ctx,
+			}
+
+			batch := make([]*pending, 0, len(test.rows))
+			for _, row := range test.rows {
+				batch = append(batch, &pending{
+					values: []interface{}{row},
+					key:    row,
+					done:   make(chan error, 1),
+				})
+			}
+			w.write(batch)
+
+			for i, p := range batch {
+				if err := <-p.done; err != test.wantErrs[i] {
+					t.Errorf("row %d = %v, wanted %v", i, err, test.wantErrs[i])
+				}
+			}
+			if len(d.statements) != len(test.wantStatements) {
+				t.Fatalf("statements = %v, wanted %v", d.statements, test.wantStatements)
+			}
+			for i := range d.statements {
+				if d.statements[i] != test.wantStatements[i] {
+					t.Errorf("statements = %v, wanted %v", d.statements, test.wantStatements)
+				}
+			}
+		})
+	}
+}
+
+// connector opens connections to a fakeDriver.
+type connector struct{ d *fakeDriver }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c connector) Driver() driver.Driver                        { return c.d }
@@ -0,0 +1,170 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stepdigests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn/k8schain"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// TaskRunLabelKey is the label with which Tekton marks the pods
+	// it creates with the name of their TaskRun.
+	TaskRunLabelKey = "tekton.dev/taskRun"
+
+	// ResolutionErrorsAnnotationKey is the annotation in which we record
+	// why the images of a TaskRun pod's steps and sidecars could not be
+	// pinned, as a JSON object keyed by the name of the container.
+	ResolutionErrorsAnnotationKey = "pipeline.mink.knative.dev/image-resolution-errors"
+
+	// stepPrefix and sidecarPrefix are the prefixes Tekton gives the
+	// names of the containers for steps and sidecars, respectively.
+	stepPrefix    = "step-"
+	sidecarPrefix = "sidecar-"
+)
+
+// Resolver resolves the image tags of TaskRun pods to digests.
+type Resolver struct {
+	kubeclient kubernetes.Interface
+	transport  http.RoundTripper
+}
+
+// NewResolver creates a Resolver that authenticates to registries as the
+// pods' service accounts.
+func NewResolver(kubeclient kubernetes.Interface) *Resolver {
+	return &Resolver{
+		kubeclient: kubeclient,
+		// Admission must not hang on slow registries.
+		transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: 5 * time.Second}).DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 5 * time.Second,
+		},
+	}
+}
+
+type resolverKey struct{}
+
+// WithResolver attaches the provided Resolver to the context.
+func WithResolver(ctx context.Context, r *Resolver) context.Context {
+	return context.WithValue(ctx, resolverKey{}, r)
+}
+
+func getResolver(ctx context.Context) *Resolver {
+	r, _ := ctx.Value(resolverKey{}).(*Resolver)
+	return r
+}
+
+// IsPinnable returns whether the named container of a TaskRun pod runs a
+// step or sidecar.
+func IsPinnable(name string) bool {
+	return strings.HasPrefix(name, stepPrefix) || strings.HasPrefix(name, sidecarPrefix)
+}
+
+// Pin rewrites the images of the steps and sidecars of the TaskRun pod
+// being created to the digests their tags currently refer to, so that
+// what runs is exactly what we record in the TaskRun's status.  Images
+// that cannot be resolved are left alone for the kubelet to deal with,
+// and why is recorded on the pod, for us to report on the TaskRun.
+func Pin(ctx context.Context, pod *corev1.Pod) {
+	if !apis.IsInCreate(ctx) {
+		return
+	}
+	if _, ok := pod.Labels[TaskRunLabelKey]; !ok {
+		return
+	}
+	r := getResolver(ctx)
+	if r == nil {
+		return
+	}
+	logger := logging.FromContext(ctx)
+
+	pullSecrets := make([]string, 0, len(pod.Spec.ImagePullSecrets))
+	for _, s := range pod.Spec.ImagePullSecrets {
+		pullSecrets = append(pullSecrets, s.Name)
+	}
+	failures := make(map[string]string)
+	defer func() {
+		if len(failures) == 0 {
+			return
+		}
+		raw, err := json.Marshal(failures)
+		if err != nil {
+			logger.Errorw("Error recording image resolution errors", "error", err)
+			return
+		}
+		if pod.Annotations == nil {
+			pod.Annotations = make(map[string]string, 1)
+		}
+		pod.Annotations[ResolutionErrorsAnnotationKey] = string(raw)
+	}()
+
+	kc, err := k8schain.New(r.kubeclient, k8schain.Options{
+		Namespace:          pod.Namespace,
+		ServiceAccountName: pod.Spec.ServiceAccountName,
+		ImagePullSecrets:   pullSecrets,
+	})
+	if err != nil {
+		logger.Errorw("Error creating keychain", "error", err)
+		for _, c := range pod.Spec.Containers {
+			if IsPinnable(c.Name) {
+				failures[c.Name] = fmt.Sprintf("%s (creating keychain: %v)", c.Image, err)
+			}
+		}
+		return
+	}
+	opts := []remote.Option{
+		remote.WithAuthFromKeychain(kc),
+		remote.WithTransport(r.transport),
+	}
+
+	for i, c := range pod.Spec.Containers {
+		if !IsPinnable(c.Name) {
+			continue
+		}
+		ref, err := name.ParseReference(c.Image)
+		if err != nil {
+			logger.Warnw("Unable to parse image of "+c.Name, "image", c.Image, "error", err)
+			failures[c.Name] = fmt.Sprintf("%s (%v)", c.Image, err)
+			continue
+		}
+		if _, ok := ref.(name.Digest); ok {
+			continue
+		}
+		desc, err := remote.Get(ref, opts...)
+		if err != nil {
+			logger.Warnw("Unable to resolve image of "+c.Name, "image", c.Image, "error", err)
+			failures[c.Name] = fmt.Sprintf("%s (%v)", c.Image, err)
+			continue
+		}
+		pod.Spec.Containers[i].Image = ref.Context().Digest(desc.Digest.String()).String()
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stepdigests
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	clientset "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// ImageDigestsAnnotationKey is the annotation in which we record the
+	// digests that the TaskRun's steps and sidecars ran, as a JSON object
+	// keyed by the name of the container whose value is its pinned image.
+	ImageDigestsAnnotationKey = "pipeline.mink.knative.dev/image-digests"
+
+	// ConditionImagesPinned is the informational condition we add to
+	// TaskRuns once their pods are created.  Its message lists the image
+	// each step and sidecar ran, and it is False when some of their tags
+	// could not be resolved, which then ran as tagged.
+	ConditionImagesPinned apis.ConditionType = "ImagesPinned"
+)
+
+// reconciler implements controller.Reconciler for recording the digests
+// of the images that TaskRuns ran.
+type reconciler struct {
+	pipelineclient clientset.Interface
+
+	// listers index properties about resources
+	taskRunLister listers.TaskRunLister
+	podLister     corev1listers.PodLister
+}
+
+// Check that our reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*reconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	original, err := r.taskRunLister.TaskRuns(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if original.Status.PodName == "" {
+		return nil
+	}
+	pod, err := r.podLister.Pods(namespace).Get(original.Status.PodName)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if !metav1.IsControlledBy(pod, original) {
+		return nil
+	}
+
+	want := make(map[string]string, len(pod.Spec.Containers))
+	for _, c := range pod.Spec.Containers {
+		if !IsPinnable(c.Name) || !isDigest(c.Image) {
+			continue
+		}
+		want[c.Name] = c.Image
+	}
+	var failures map[string]string
+	if raw, ok := pod.Annotations[ResolutionErrorsAnnotationKey]; ok {
+		if err := json.Unmarshal([]byte(raw), &failures); err != nil {
+			logger.Warnw("Ignoring invalid image resolution errors", "error", err)
+		}
+	}
+	if len(want) == 0 && len(failures) == 0 {
+		return nil
+	}
+
+	tr, err := r.recordDigests(ctx, original, want)
+	if err != nil {
+		return err
+	}
+	cond := pinnedCondition(want, failures)
+	if existing := tr.Status.GetCondition(ConditionImagesPinned); existing != nil &&
+		existing.Status == cond.Status && existing.Reason == cond.Reason && existing.Message == cond.Message {
+		return nil
+	}
+	tr = tr.DeepCopy()
+	tr.Status.SetCondition(cond)
+	_, err = r.pipelineclient.TektonV1alpha1().TaskRuns(namespace).UpdateStatus(tr)
+	return err
+}
+
+// recordDigests records the pinned images in the TaskRun's annotation,
+// returning the TaskRun as updated.
+func (r *reconciler) recordDigests(ctx context.Context, tr *v1alpha1.TaskRun, want map[string]string) (*v1alpha1.TaskRun, error) {
+	if len(want) == 0 {
+		return tr, nil
+	}
+	var have map[string]string
+	if raw, ok := tr.Annotations[ImageDigestsAnnotationKey]; ok {
+		if err := json.Unmarshal([]byte(raw), &have); err != nil {
+			logging.FromContext(ctx).Warnw("Overwriting invalid image digests", "error", err)
+		}
+	}
+	if equality.Semantic.DeepEqual(have, want) {
+		return tr, nil
+	}
+
+	raw, err := json.Marshal(want)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				ImageDigestsAnnotationKey: string(raw),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.pipelineclient.TektonV1alpha1().TaskRuns(tr.Namespace).Patch(tr.Name, types.MergePatchType, patch)
+}
+
+// pinnedCondition returns the ConditionImagesPinned that reports the
+// pinned images, and any that could not be resolved.
+func pinnedCondition(pinned, failures map[string]string) *apis.Condition {
+	if len(failures) > 0 {
+		return &apis.Condition{
+			Type:     ConditionImagesPinned,
+			Status:   corev1.ConditionFalse,
+			Severity: apis.ConditionSeverityWarning,
+			Reason:   "ResolutionFailed",
+			Message:  "Unable to resolve the images of " + list(failures),
+		}
+	}
+	return &apis.Condition{
+		Type:     ConditionImagesPinned,
+		Status:   corev1.ConditionTrue,
+		Severity: apis.ConditionSeverityInfo,
+		Reason:   "Pinned",
+		Message:  list(pinned),
+	}
+}
+
+// list formats the provided container names and values in a stable
+// order, e.g. "step-build: gcr.io/foo@sha256:..., step-push: ...".
+func list(m map[string]string) string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+": "+m[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// isDigest returns whether the image is referenced by digest.
+func isDigest(image string) bool {
+	_, err := name.NewDigest(image)
+	return err == nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stepdigests
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPinnedCondition(t *testing.T) {
+	tests := []struct {
+		name        string
+		pinned      map[string]string
+		failures    map[string]string
+		wantStatus  corev1.ConditionStatus
+		wantMessage string
+	}{{
+		name: "pinned",
+		pinned: map[string]string{
+			"step-push":  "gcr.io/foo/push@sha256:abc",
+			"step-build": "gcr.io/foo/build@sha256:def",
+		},
+		wantStatus:  corev1.ConditionTrue,
+		wantMessage: "step-build: gcr.io/foo/build@sha256:def, step-push: gcr.io/foo/push@sha256:abc",
+	}, {
+		name: "unresolved",
+		pinned: map[string]string{
+			"step-build": "gcr.io/foo/build@sha256:def",
+		},
+		failures: map[string]string{
+			"sidecar-db": "postgres:12 (UNAUTHORIZED)",
+		},
+		wantStatus:  corev1.ConditionFalse,
+		wantMessage: "Unable to resolve the images of sidecar-db: postgres:12 (UNAUTHORIZED)",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := pinnedCondition(test.pinned, test.failures)
+			if got.Type != ConditionImagesPinned || got.Status != test.wantStatus || got.Message != test.wantMessage {
+				t.Errorf("pinnedCondition() = %+v, wanted %s %q", got, test.wantStatus, test.wantMessage)
+			}
+		})
+	}
+}
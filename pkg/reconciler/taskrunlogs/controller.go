@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrunlogs
+
+import (
+	"context"
+	"time"
+
+	"github.com/tektoncd/pipeline/pkg/artifacts"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1alpha1/taskrun"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/archive"
+	"github.com/mattmoor/mink/pkg/gc"
+)
+
+const (
+	controllerAgentName = "taskrun-logs-controller"
+
+	// pruneInterval is how often we sweep the archive for expired logs.
+	pruneInterval = time.Hour
+)
+
+// NewController creates a new controller that archives the logs and
+// results of completed TaskRuns.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+	ctx = logging.WithLogger(ctx, logger)
+	taskRunInformer := taskruninformer.Get(ctx)
+
+	c := &reconciler{
+		kubeclient:     kubeclient.Get(ctx),
+		pipelineclient: pipelineclient.Get(ctx),
+		taskRunLister:  taskRunInformer.Lister(),
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+
+	logger.Info("Setting up event handlers")
+	taskRunInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isDone,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("Setting up ConfigMap receivers")
+	cmw.Watch(artifacts.GetBucketConfigName(), func(cm *corev1.ConfigMap) {
+		store, err := archive.NewStoreFromConfigMap(ctx, c.kubeclient, cm)
+		if err == archive.ErrNotConfigured {
+			logger.Info("No archive storage configured, TaskRun logs will not be archived")
+		} else if err != nil {
+			logger.Errorw("Error configuring archive storage", "error", err)
+			return
+		}
+		if old := c.setStore(store); old != nil {
+			if err := old.Close(); err != nil {
+				logger.Errorw("Error closing previous archive storage", "error", err)
+			}
+		}
+		// Pick up any runs that completed while we had no storage.
+		impl.GlobalResync(taskRunInformer.Informer())
+	})
+
+	gcStore := gc.NewStore(logger.Named("config-store"))
+	gcStore.WatchConfigs(cmw)
+
+	go func() {
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			retention := gcStore.Load().TaskRunLogRetention
+			store := c.getStore()
+			if store == nil || retention == 0 {
+				continue
+			}
+			if err := store.Prune(ctx, time.Now().Add(-retention)); err != nil {
+				logger.Errorw("Error pruning archived TaskRun logs", "error", err)
+			}
+		}
+	}()
+
+	return impl
+}
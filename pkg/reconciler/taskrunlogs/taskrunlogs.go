@@ -0,0 +1,186 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrunlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	clientset "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/archive"
+)
+
+const (
+	// ConditionLogsArchived is the informational condition we add to
+	// TaskRuns once their logs have been archived.  Its message holds
+	// the URL of the archived results, which link the log of each step.
+	ConditionLogsArchived apis.ConditionType = "LogsArchived"
+
+	resultsFile = "results.json"
+)
+
+// reconciler implements controller.Reconciler for archiving TaskRun logs.
+type reconciler struct {
+	kubeclient     kubernetes.Interface
+	pipelineclient clientset.Interface
+
+	// listers index properties about resources
+	taskRunLister listers.TaskRunLister
+
+	m     sync.RWMutex
+	store archive.Store
+}
+
+// Check that our reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*reconciler)(nil)
+
+// setStore swaps in the provided store, returning the one it replaces.
+func (r *reconciler) setStore(s archive.Store) archive.Store {
+	r.m.Lock()
+	defer r.m.Unlock()
+	old := r.store
+	r.store = s
+	return old
+}
+
+func (r *reconciler) getStore() archive.Store {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return r.store
+}
+
+// stepRecord is the archived form of a step's termination state.
+type stepRecord struct {
+	Name string `json:"name"`
+	// Log is the URL of the step's archived log.
+	Log      string `json:"log,omitempty"`
+	ExitCode int32  `json:"exitCode"`
+	Reason   string `json:"reason,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// runRecord is the archived form of a TaskRun's results.
+type runRecord struct {
+	Steps   []stepRecord            `json:"steps"`
+	Results []v1beta1.TaskRunResult `json:"results,omitempty"`
+}
+
+// Reconcile implements controller.Reconciler
+func (r *reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	original, err := r.taskRunLister.TaskRuns(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !original.IsDone() || original.Status.GetCondition(ConditionLogsArchived) != nil {
+		return nil
+	}
+	store := r.getStore()
+	if store == nil {
+		return nil
+	}
+
+	prefix := path.Join(original.Namespace, original.Name, string(original.UID))
+	record := runRecord{
+		Results: original.Status.TaskRunResults,
+	}
+	for _, step := range original.Status.Steps {
+		sr := stepRecord{Name: step.Name}
+		if t := step.Terminated; t != nil {
+			sr.ExitCode = t.ExitCode
+			sr.Reason = t.Reason
+			sr.Message = t.Message
+		}
+
+		logs, err := r.fetchLogs(original.Namespace, original.Status.PodName, step.ContainerName)
+		if apierrs.IsNotFound(err) {
+			// The pod is already gone, so archive what we can.
+			logger.Infof("Logs for step %q are no longer available", step.Name)
+		} else if err != nil {
+			return fmt.Errorf("failed to fetch logs for step %q: %w", step.Name, err)
+		} else {
+			key := path.Join(prefix, step.Name+".log")
+			err := store.Write(ctx, key, logs)
+			logs.Close()
+			if err != nil {
+				return fmt.Errorf("failed to archive logs for step %q: %w", step.Name, err)
+			}
+			sr.Log = store.URL(key)
+		}
+		record.Steps = append(record.Steps, sr)
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	resultsKey := path.Join(prefix, resultsFile)
+	if err := store.Write(ctx, resultsKey, bytes.NewReader(b)); err != nil {
+		return fmt.Errorf("failed to archive results: %w", err)
+	}
+
+	tr := original.DeepCopy()
+	tr.Status.SetCondition(&apis.Condition{
+		Type:     ConditionLogsArchived,
+		Status:   corev1.ConditionTrue,
+		Severity: apis.ConditionSeverityInfo,
+		Reason:   "Archived",
+		Message:  store.URL(resultsKey),
+	})
+	_, err = r.pipelineclient.TektonV1alpha1().TaskRuns(tr.Namespace).UpdateStatus(tr)
+	return err
+}
+
+func (r *reconciler) fetchLogs(namespace, pod, container string) (io.ReadCloser, error) {
+	if pod == "" {
+		return nil, apierrs.NewNotFound(corev1.Resource("pods"), pod)
+	}
+	return r.kubeclient.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+	}).Stream()
+}
+
+// isDone filters TaskRuns down to those that have finished executing.
+func isDone(obj interface{}) bool {
+	tr, ok := obj.(*v1alpha1.TaskRun)
+	return ok && tr.IsDone() && tr.Status.GetCondition(ConditionLogsArchived) == nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/cache"
+	contourconfig "knative.dev/net-contour/pkg/reconciler/contour/config"
+	"knative.dev/pkg/configmap"
+	"knative.dev/serving/pkg/apis/networking/v1alpha1"
+	"knative.dev/serving/pkg/network"
+)
+
+const (
+	// ConfigName is the name of the ConfigMap in which the visibility
+	// classes are defined alongside net-contour's own configuration.
+	ConfigName = contourconfig.ContourConfigName
+
+	classesKey    = "classes"
+	visibilityKey = "visibility"
+)
+
+// Class is a named visibility class, which exposes services through
+// its own Contour and fleet of Envoys.
+type Class struct {
+	// Class is the value to pass to the Contour class annotations.
+	Class string `json:"class"`
+
+	// Service is the namespace/name of the Contour Envoy service.
+	Service string `json:"service"`
+}
+
+// Visibility holds the contents of config-contour, extending the
+// visibilities known to net-contour with named visibility classes.
+type Visibility struct {
+	*contourconfig.Contour
+
+	// Classes holds the named visibility classes.
+	Classes map[string]Class
+}
+
+// NewVisibilityFromConfigMap creates a Visibility from the supplied ConfigMap.
+func NewVisibilityFromConfigMap(configMap *corev1.ConfigMap) (*Visibility, error) {
+	contour, err := contourconfig.NewContourFromConfigMap(configMap)
+	if err != nil {
+		return nil, err
+	}
+	v := &Visibility{
+		Contour: contour,
+		Classes: make(map[string]Class),
+	}
+	raw, ok := configMap.Data[classesKey]
+	if !ok {
+		return v, nil
+	}
+	if err := yaml.Unmarshal([]byte(raw), v.Classes); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", classesKey, err)
+	}
+	for name, class := range v.Classes {
+		// Services and Routes select the class with a label.
+		if errs := validation.IsValidLabelValue(name); len(errs) > 0 {
+			return nil, fmt.Errorf("class %q is not a valid label value: %s", name, strings.Join(errs, ", "))
+		}
+		if class.Class == "" {
+			return nil, fmt.Errorf("class %q must specify a Contour class", name)
+		}
+		// See if the Service is a valid namespace/name token.
+		if class.Service == "" {
+			return nil, fmt.Errorf("class %q must specify a service", name)
+		} else if _, _, err := cache.SplitMetaNamespaceKey(class.Service); err != nil {
+			return nil, fmt.Errorf("class %q has an invalid service: %w", name, err)
+		}
+	}
+	return v, nil
+}
+
+// ConfigMapForClass returns a copy of config-contour in which the named
+// visibility class takes the place of the ExternalIP visibility, with which
+// net-contour exposes the services of that class.
+func ConfigMapForClass(configMap *corev1.ConfigMap, name string) (*corev1.ConfigMap, error) {
+	v, err := NewVisibilityFromConfigMap(configMap)
+	if err != nil {
+		return nil, err
+	}
+	class, ok := v.Classes[name]
+	if !ok {
+		return nil, fmt.Errorf("class %q is not defined", name)
+	}
+	entries := make(map[v1alpha1.IngressVisibility]Class, len(v.VisibilityClasses))
+	for vis, cls := range v.VisibilityClasses {
+		entries[vis] = Class{
+			Class:   cls,
+			Service: v.VisibilityKeys[vis].List()[0],
+		}
+	}
+	entries[v1alpha1.IngressVisibilityExternalIP] = class
+	raw, err := yaml.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := configMap.DeepCopy()
+	delete(cm.Data, classesKey)
+	cm.Data[visibilityKey] = string(raw)
+	return cm, nil
+}
+
+// Config holds the configuration of the visibility class reconciler.
+type Config struct {
+	Visibility *Visibility
+	Network    *network.Config
+}
+
+type cfgKey struct{}
+
+// FromContext extracts the Config attached to the provided context.
+func FromContext(ctx context.Context) *Config {
+	return ctx.Value(cfgKey{}).(*Config)
+}
+
+// ToContext attaches the provided Config to the provided context.
+func ToContext(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, cfgKey{}, c)
+}
+
+// Store is a typed wrapper around configmap.UntypedStore to handle our configmaps.
+type Store struct {
+	*configmap.UntypedStore
+}
+
+// NewStore creates a new Store, and optionally calls functions when
+// config-contour or config-network are updated.
+func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *Store {
+	return &Store{
+		UntypedStore: configmap.NewUntypedStore(
+			"visibility",
+			logger,
+			configmap.Constructors{
+				ConfigName:         NewVisibilityFromConfigMap,
+				network.ConfigName: network.NewConfigFromConfigMap,
+			},
+			onAfterStore...,
+		),
+	}
+}
+
+// ToContext attaches the current Config state to the provided context.
+func (s *Store) ToContext(ctx context.Context) context.Context {
+	return ToContext(ctx, s.Load())
+}
+
+// Load fetches the current Config state of the Store.
+func (s *Store) Load() *Config {
+	return &Config{
+		Visibility: s.UntypedLoad(ConfigName).(*Visibility),
+		Network:    s.UntypedLoad(network.ConfigName).(*network.Config),
+	}
+}
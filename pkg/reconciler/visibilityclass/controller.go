@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package visibilityclass
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	contourcontroller "knative.dev/net-contour/pkg/reconciler/contour"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+	servinginformers "knative.dev/serving/pkg/client/informers/externalversions"
+	servingclient "knative.dev/serving/pkg/client/injection/client"
+	ingressinformer "knative.dev/serving/pkg/client/injection/informers/networking/v1alpha1/ingress"
+	"knative.dev/serving/pkg/network"
+
+	"github.com/mattmoor/mink/pkg/reconciler/visibilityclass/config"
+)
+
+const controllerAgentName = "visibility-class-controller"
+
+// NewController creates a new controller that runs net-contour's KIngress
+// controller for each of the visibility classes in config-contour, which
+// exposes the KIngresses selecting that class through its Envoys.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx).Named(controllerAgentName)
+
+	c := &Reconciler{
+		ctx:     ctx,
+		classes: make(map[string]*class),
+	}
+	impl := controller.NewImpl(c, logger, controllerAgentName)
+
+	logger.Info("Setting up ConfigMap receivers")
+	// We reconcile all of the classes whenever the configuration changes.
+	key := types.NamespacedName{Namespace: system.Namespace(), Name: config.ConfigName}
+	cmw.Watch(config.ConfigName, func(cm *corev1.ConfigMap) {
+		c.setConfig(cm, nil)
+		impl.EnqueueKey(key)
+	})
+	cmw.Watch(network.ConfigName, func(cm *corev1.ConfigMap) {
+		c.setConfig(nil, cm)
+		impl.EnqueueKey(key)
+	})
+
+	return impl
+}
+
+// NewContourController creates net-contour's KIngress controller for the
+// KIngresses that don't select a visibility class, which are exposed as
+// usual.
+func NewContourController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	impl, informer := newContourController(ctx, cmw, "!"+LabelKey)
+	go func() {
+		if err := controller.StartInformers(ctx.Done(), informer); err != nil {
+			logging.FromContext(ctx).Errorw("Failed to start informers", "error", err)
+		}
+	}()
+	return impl
+}
+
+// newContourController creates net-contour's KIngress controller, only
+// informed of the KIngresses matching the label selector.
+func newContourController(ctx context.Context, cmw configmap.Watcher, selector string) (*controller.Impl, controller.Informer) {
+	factory := servinginformers.NewSharedInformerFactoryWithOptions(
+		servingclient.Get(ctx),
+		controller.GetResyncPeriod(ctx),
+		servinginformers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = selector
+		}))
+	informer := factory.Networking().V1alpha1().Ingresses()
+	ctx = context.WithValue(ctx, ingressinformer.Key{}, informer)
+
+	return contourcontroller.NewController(ctx, cmw), informer.Informer()
+}
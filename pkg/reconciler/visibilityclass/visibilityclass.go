@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package visibilityclass
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/reconciler/visibilityclass/config"
+)
+
+// LabelKey is the label through which Services and Routes select the
+// named visibility class through which they are exposed.
+const LabelKey = "networking.mink.knative.dev/visibility-class"
+
+// class is the net-contour KIngress controller running on behalf of a
+// visibility class.
+type class struct {
+	configs []*corev1.ConfigMap
+	cancel  context.CancelFunc
+}
+
+// Reconciler implements controller.Reconciler for the visibility classes
+// in config-contour, running net-contour's KIngress controller for each
+// of them, configured to expose the KIngresses selecting the class through
+// its Envoys in place of the ExternalIP visibility.
+type Reconciler struct {
+	// ctx is the context of the controller, which outlives the
+	// reconciliations that start the controllers of the classes.
+	ctx context.Context
+
+	m       sync.Mutex
+	contour *corev1.ConfigMap
+	network *corev1.ConfigMap
+	classes map[string]*class
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// setConfig records the latest config-contour and config-network.
+func (r *Reconciler) setConfig(contour, network *corev1.ConfigMap) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if contour != nil {
+		r.contour = contour
+	}
+	if network != nil {
+		r.network = network
+	}
+}
+
+// Reconcile implements controller.Reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.contour == nil || r.network == nil {
+		// Wait for the rest of the configuration.
+		return nil
+	}
+	visibility, err := config.NewVisibilityFromConfigMap(r.contour)
+	if err != nil {
+		// Keep running the controllers we have until the configuration
+		// is fixed, which re-enqueues us.
+		logger.Errorw("Invalid visibility classes", "error", err)
+		return nil
+	}
+
+	for name, c := range r.classes {
+		if _, ok := visibility.Classes[name]; !ok {
+			logger.Infof("Stopping the controller of visibility class %q", name)
+			c.cancel()
+			delete(r.classes, name)
+		}
+	}
+	for name := range visibility.Classes {
+		contour, err := config.ConfigMapForClass(r.contour, name)
+		if err != nil {
+			return err
+		}
+		configs := []*corev1.ConfigMap{contour, r.network}
+		if c, ok := r.classes[name]; ok {
+			if sameData(c.configs, configs) {
+				continue
+			}
+			c.cancel()
+		}
+		logger.Infof("Starting the controller of visibility class %q", name)
+		r.classes[name] = &class{
+			configs: configs,
+			cancel:  r.start(name, configs),
+		}
+	}
+	return nil
+}
+
+// start runs net-contour's KIngress controller for the KIngresses of the
+// named visibility class, with the provided configuration, until the
+// returned function is called.
+func (r *Reconciler) start(name string, configs []*corev1.ConfigMap) context.CancelFunc {
+	ctx, cancel := context.WithCancel(r.ctx)
+	impl, informer := newContourController(ctx,
+		configmap.NewStaticWatcher(configs...), LabelKey+"="+name)
+	go func() {
+		if err := controller.StartInformers(ctx.Done(), informer); err != nil {
+			logging.FromContext(ctx).Errorw("Failed to start informers", "error", err)
+			return
+		}
+		impl.Run(controller.DefaultThreadsPerController, ctx.Done())
+	}()
+	return cancel
+}
+
+// sameData returns whether the ConfigMaps hold the same data, regardless of
+// their metadata (e.g. resourceVersion) changing with unrelated updates.
+func sameData(a, b []*corev1.ConfigMap) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equality.Semantic.DeepEqual(a[i].Data, b[i].Data) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package visibilityclass
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/net-contour/pkg/reconciler/contour"
+	"knative.dev/pkg/apis"
+	"knative.dev/serving/pkg/apis/networking"
+
+	"github.com/mattmoor/mink/pkg/reconciler/visibilityclass/config"
+)
+
+// SetIngressClass sets the ingress class of a Service or Route that selects
+// a visibility class to net-contour's, which propagates from Services to
+// their Routes, and from Routes to their KIngresses, along with the label
+// that selects the class.
+func SetIngressClass(om *metav1.ObjectMeta) {
+	if om.Labels[LabelKey] != "" {
+		if om.Annotations == nil {
+			om.Annotations = make(map[string]string, 1)
+		}
+		om.Annotations[networking.IngressClassAnnotationKey] = contour.ContourIngressClassName
+	}
+}
+
+// Validate checks that the visibility class selected by a Service or Route
+// is defined.
+func Validate(ctx context.Context, om *metav1.ObjectMeta) *apis.FieldError {
+	name := om.Labels[LabelKey]
+	if name == "" || apis.IsInStatusUpdate(ctx) {
+		return nil
+	}
+	// Only check newly selected classes, so that removing a class doesn't
+	// wedge the resources that were using it.
+	if base, ok := apis.GetBaseline(ctx).(metav1.Object); ok && base.GetLabels()[LabelKey] == name {
+		return nil
+	}
+	if _, ok := config.FromContext(ctx).Visibility.Classes[name]; !ok {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("visibility class %q is not defined in %s", name, config.ConfigName),
+			Paths:   []string{fmt.Sprintf("metadata.labels[%s]", LabelKey)},
+		}
+	}
+	return nil
+}
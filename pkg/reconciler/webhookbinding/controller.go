@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhookbinding
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"knative.dev/pkg/apis/duck"
+	"knative.dev/pkg/client/injection/ducks/duck/v1/podspecable"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
+	"knative.dev/pkg/injection/clients/dynamicclient"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/tracker"
+	"knative.dev/pkg/webhook/psbinding"
+	"knative.dev/serving/pkg/apis/serving"
+
+	"github.com/mattmoor/mink/pkg/apis/bindings/v1alpha1"
+	whbinformer "github.com/mattmoor/mink/pkg/client/injection/informers/bindings/v1alpha1/webhookbinding"
+	"github.com/mattmoor/mink/pkg/outgoingwebhook"
+)
+
+const controllerAgentName = "webhookbinding-controller"
+
+// NewController returns a constructor for the WebhookBinding reconciler,
+// which binds subjects (other than Knative Serving resources) with a
+// sidecar running the given image.
+func NewController(image string) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		logger := logging.FromContext(ctx)
+		whbInformer := whbinformer.Get(ctx)
+		psInformerFactory := podspecable.Get(ctx)
+
+		c := &psbinding.BaseReconciler{
+			GVR: v1alpha1.SchemeGroupVersion.WithResource("webhookbindings"),
+			Get: func(namespace string, name string) (psbinding.Bindable, error) {
+				return whbInformer.Lister().WebhookBindings(namespace).Get(name)
+			},
+			WithContext:   WithSinkImage(image),
+			DynamicClient: dynamicclient.Get(ctx),
+			Recorder: record.NewBroadcaster().NewRecorder(
+				scheme.Scheme, corev1.EventSource{Component: controllerAgentName}),
+		}
+		impl := controller.NewImpl(c, logger, "WebhookBindings")
+
+		logger.Info("Setting up event handlers")
+
+		whbInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+		c.Tracker = tracker.New(impl.EnqueueKey, controller.GetTrackerLease(ctx))
+		c.Factory = &duck.CachedInformerFactory{
+			Delegate: &duck.EnqueueInformerFactory{
+				Delegate:     psInformerFactory,
+				EventHandler: controller.HandleAll(c.Tracker.OnChanged),
+			},
+		}
+
+		return impl
+	}
+}
+
+// WithSinkImage returns a psbinding.BindableContext that has WebhookBindings
+// add a sidecar running the given image to their subjects.  Knative
+// Serving rejects multi-container specs unless enable-multi-container is
+// on, so its resources are bound without the sidecar.
+func WithSinkImage(image string) psbinding.BindableContext {
+	return func(ctx context.Context, b psbinding.Bindable) (context.Context, error) {
+		if gv, err := schema.ParseGroupVersion(b.GetSubject().APIVersion); err == nil && gv.Group == serving.GroupName {
+			return ctx, nil
+		}
+		return outgoingwebhook.WithSinkImage(ctx, image), nil
+	}
+}
+
+// ListAll implements psbinding.GetListAll for WebhookBindings.
+func ListAll(ctx context.Context, handler cache.ResourceEventHandler) psbinding.ListAll {
+	whbInformer := whbinformer.Get(ctx)
+
+	// Whenever a WebhookBinding changes our webhook programming might change.
+	whbInformer.Informer().AddEventHandler(handler)
+
+	return func() ([]psbinding.Bindable, error) {
+		l, err := whbInformer.Lister().List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		bl := make([]psbinding.Bindable, 0, len(l))
+		for _, elt := range l {
+			bl = append(bl, elt)
+		}
+		return bl, nil
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stepbinding
+
+import (
+	"context"
+	"encoding/json"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/webhook"
+)
+
+// admissionController presents the runs it admits to the binding admission
+// controller it wraps through their PodSpecable view.
+type admissionController struct {
+	controller.Reconciler
+	webhook.AdmissionController
+}
+
+var _ webhook.AdmissionController = (*admissionController)(nil)
+
+// Admit implements webhook.AdmissionController
+func (ac *admissionController) Admit(ctx context.Context, req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if !IsRun(schema.GroupKind{Group: req.Kind.Group, Kind: req.Kind.Kind}) {
+		return ac.AdmissionController.Admit(ctx, req)
+	}
+
+	r := &Run{}
+	if err := json.Unmarshal(req.Object.Raw, r); err != nil {
+		return webhook.MakeErrorStatus("unable to decode run: %v", err)
+	}
+	view, err := r.WithPod()
+	if err != nil {
+		return webhook.MakeErrorStatus("unable to view run: %v", err)
+	}
+	raw, err := json.Marshal(view)
+	if err != nil {
+		return webhook.MakeErrorStatus("unable to encode view of run: %v", err)
+	}
+
+	vreq := req.DeepCopy()
+	vreq.Object.Raw = raw
+	resp := ac.AdmissionController.Admit(ctx, vreq)
+	if !resp.Allowed || len(resp.Patch) == 0 {
+		return resp
+	}
+
+	patch, err := patchRun(r, resp.Patch)
+	if err != nil {
+		return webhook.MakeErrorStatus("unable to create patch with binding: %v", err)
+	}
+	resp.Patch = patch
+	return resp
+}
+
+// Wrap decorates the binding admission controller of the provided Impl to
+// bind the TaskRuns and PipelineRuns it admits through their steps.
+func Wrap(impl *controller.Impl) *controller.Impl {
+	if c, ok := impl.Reconciler.(webhook.AdmissionController); ok {
+		impl.Reconciler = &admissionController{
+			Reconciler:          impl.Reconciler,
+			AdmissionController: c,
+		}
+	}
+	return impl
+}
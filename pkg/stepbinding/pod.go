@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stepbinding
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+)
+
+// stepPrefix is the prefix Tekton gives the names of the containers for
+// steps.
+const stepPrefix = "step-"
+
+// BindSteps adds the step template that bindings recorded on the run of a
+// TaskRun pod to each of its steps.  Pods whose step template can't be
+// decoded are left alone here, and rejected by ValidatePod.
+func BindSteps(ctx context.Context, pod *corev1.Pod) {
+	if !apis.IsInCreate(ctx) {
+		return
+	}
+	st, err := podStepTemplate(pod)
+	if err != nil || st == nil {
+		return
+	}
+
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		if !strings.HasPrefix(c.Name, stepPrefix) {
+			continue
+		}
+		c.Env = mergeEnv(c.Env, st.Env)
+		c.EnvFrom = append(c.EnvFrom, st.EnvFrom...)
+		c.VolumeMounts = mergeVolumeMounts(c.VolumeMounts, st.VolumeMounts)
+	}
+}
+
+// ValidatePod rejects the creation of TaskRun pods whose step template
+// can't be decoded, since their steps would run without what their
+// bindings add.
+func ValidatePod(ctx context.Context, pod *corev1.Pod) *apis.FieldError {
+	if !apis.IsInCreate(ctx) {
+		return nil
+	}
+	if _, err := podStepTemplate(pod); err != nil {
+		return &apis.FieldError{
+			Message: "invalid step template",
+			Paths:   []string{"metadata.annotations[" + StepTemplateAnnotation + "]"},
+			Details: err.Error(),
+		}
+	}
+	return nil
+}
+
+// podStepTemplate decodes the step template of the pod, if it has one.
+func podStepTemplate(pod *corev1.Pod) (*stepTemplate, error) {
+	raw, ok := pod.Annotations[StepTemplateAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	st := &stepTemplate{}
+	if err := json.Unmarshal([]byte(raw), st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// mergeEnv adds the bound variables to env, replacing any of the same name.
+func mergeEnv(env, bound []corev1.EnvVar) []corev1.EnvVar {
+	names := make(map[string]struct{}, len(bound))
+	for _, ev := range bound {
+		names[ev.Name] = struct{}{}
+	}
+	out := make([]corev1.EnvVar, 0, len(env)+len(bound))
+	for _, ev := range env {
+		if _, ok := names[ev.Name]; !ok {
+			out = append(out, ev)
+		}
+	}
+	return append(out, bound...)
+}
+
+// mergeVolumeMounts adds the bound mounts to mounts, replacing any at the
+// same path.
+func mergeVolumeMounts(mounts, bound []corev1.VolumeMount) []corev1.VolumeMount {
+	paths := make(map[string]struct{}, len(bound))
+	for _, vm := range bound {
+		paths[vm.MountPath] = struct{}{}
+	}
+	out := make([]corev1.VolumeMount, 0, len(mounts)+len(bound))
+	for _, vm := range mounts {
+		if _, ok := paths[vm.MountPath]; !ok {
+			out = append(out, vm)
+		}
+	}
+	return append(out, bound...)
+}
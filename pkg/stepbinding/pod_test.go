@@ -0,0 +1,106 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stepbinding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+func TestBindSteps(t *testing.T) {
+	template := `{"env":[{"name":"K_SINK","value":"http://sink"}],"volumeMounts":[{"name":"creds","mountPath":"/var/creds"}]}`
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		template   string
+		containers []corev1.Container
+		want       []corev1.Container
+		wantErr    bool
+	}{{
+		name:     "binds steps only",
+		ctx:      apis.WithinCreate(context.Background()),
+		template: template,
+		containers: []corev1.Container{
+			{Name: "step-build"},
+			{Name: "sidecar-proxy"},
+		},
+		want: []corev1.Container{{
+			Name:         "step-build",
+			Env:          []corev1.EnvVar{{Name: "K_SINK", Value: "http://sink"}},
+			VolumeMounts: []corev1.VolumeMount{{Name: "creds", MountPath: "/var/creds"}},
+		}, {
+			Name: "sidecar-proxy",
+		}},
+	}, {
+		name:     "replaces env and mounts",
+		ctx:      apis.WithinCreate(context.Background()),
+		template: template,
+		containers: []corev1.Container{{
+			Name:         "step-build",
+			Env:          []corev1.EnvVar{{Name: "K_SINK", Value: "http://old"}, {Name: "HOME", Value: "/tekton/home"}},
+			VolumeMounts: []corev1.VolumeMount{{Name: "old", MountPath: "/var/creds"}, {Name: "home", MountPath: "/tekton/home"}},
+		}},
+		want: []corev1.Container{{
+			Name:         "step-build",
+			Env:          []corev1.EnvVar{{Name: "HOME", Value: "/tekton/home"}, {Name: "K_SINK", Value: "http://sink"}},
+			VolumeMounts: []corev1.VolumeMount{{Name: "home", MountPath: "/tekton/home"}, {Name: "creds", MountPath: "/var/creds"}},
+		}},
+	}, {
+		name:       "not on update",
+		ctx:        apis.WithinUpdate(context.Background(), nil),
+		template:   template,
+		containers: []corev1.Container{{Name: "step-build"}},
+		want:       []corev1.Container{{Name: "step-build"}},
+	}, {
+		name:       "no template",
+		ctx:        apis.WithinCreate(context.Background()),
+		containers: []corev1.Container{{Name: "step-build"}},
+		want:       []corev1.Container{{Name: "step-build"}},
+	}, {
+		name:       "bad template",
+		ctx:        apis.WithinCreate(context.Background()),
+		template:   "not json",
+		containers: []corev1.Container{{Name: "step-build"}},
+		want:       []corev1.Container{{Name: "step-build"}},
+		wantErr:    true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: test.containers}}
+			if test.template != "" {
+				pod.ObjectMeta = metav1.ObjectMeta{
+					Annotations: map[string]string{StepTemplateAnnotation: test.template},
+				}
+			}
+
+			BindSteps(test.ctx, pod)
+			if !cmp.Equal(pod.Spec.Containers, test.want) {
+				t.Errorf("BindSteps() (-want, +got) = %s", cmp.Diff(test.want, pod.Spec.Containers))
+			}
+			if err := ValidatePod(test.ctx, pod); (err != nil) != test.wantErr {
+				t.Errorf("ValidatePod() = %v, wanted error %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stepbinding
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis/duck"
+	"knative.dev/pkg/client/injection/ducks/duck/v1/podspecable"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
+	"knative.dev/pkg/injection/clients/dynamicclient"
+)
+
+// WithRuns decorates the constructor of a binding controller, whose
+// psbinding.BaseReconciler gets its PodSpecable informers and dynamic
+// client from the context, so that it binds TaskRuns and PipelineRuns
+// through their PodSpecable view.  This is what lets bindings Undo
+// themselves from runs when they are deleted.
+func WithRuns(ctor injection.ControllerConstructor) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		dc := dynamicclient.Get(ctx)
+
+		ctx = context.WithValue(ctx, podspecable.Key{}, &informerFactory{
+			delegate: podspecable.Get(ctx),
+			runs: &duck.CachedInformerFactory{
+				Delegate: &duck.TypedInformerFactory{
+					Client:       dc,
+					Type:         &Run{},
+					ResyncPeriod: controller.GetResyncPeriod(ctx),
+					StopChannel:  ctx.Done(),
+				},
+			},
+		})
+		ctx = context.WithValue(ctx, dynamicclient.Key{}, &dynamicClient{Interface: dc})
+
+		return ctor(ctx, cmw)
+	}
+}
+
+// informerFactory lists the views of runs, and defers to its delegate for
+// everything else.
+type informerFactory struct {
+	delegate duck.InformerFactory
+	runs     duck.InformerFactory
+}
+
+var _ duck.InformerFactory = (*informerFactory)(nil)
+
+// Get implements duck.InformerFactory
+func (f *informerFactory) Get(gvr schema.GroupVersionResource) (cache.SharedIndexInformer, cache.GenericLister, error) {
+	if !isRunResource(gvr) {
+		return f.delegate.Get(gvr)
+	}
+	inf, lister, err := f.runs.Get(gvr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inf, &viewLister{lister: lister}, nil
+}
+
+// viewLister returns the views of the runs listed by its lister.
+type viewLister struct {
+	lister cache.GenericLister
+}
+
+var _ cache.GenericLister = (*viewLister)(nil)
+
+// List implements cache.GenericLister
+func (l *viewLister) List(selector labels.Selector) ([]runtime.Object, error) {
+	objs, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	return views(objs)
+}
+
+// Get implements cache.GenericLister
+func (l *viewLister) Get(name string) (runtime.Object, error) {
+	obj, err := l.lister.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*Run).WithPod()
+}
+
+// ByNamespace implements cache.GenericLister
+func (l *viewLister) ByNamespace(namespace string) cache.GenericNamespaceLister {
+	return &viewNamespaceLister{lister: l.lister.ByNamespace(namespace)}
+}
+
+// viewNamespaceLister is viewLister for a single namespace.
+type viewNamespaceLister struct {
+	lister cache.GenericNamespaceLister
+}
+
+var _ cache.GenericNamespaceLister = (*viewNamespaceLister)(nil)
+
+// List implements cache.GenericNamespaceLister
+func (l *viewNamespaceLister) List(selector labels.Selector) ([]runtime.Object, error) {
+	objs, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	return views(objs)
+}
+
+// Get implements cache.GenericNamespaceLister
+func (l *viewNamespaceLister) Get(name string) (runtime.Object, error) {
+	obj, err := l.lister.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*Run).WithPod()
+}
+
+func views(objs []runtime.Object) ([]runtime.Object, error) {
+	out := make([]runtime.Object, 0, len(objs))
+	for _, obj := range objs {
+		view, err := obj.(*Run).WithPod()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, view)
+	}
+	return out, nil
+}
+
+// dynamicClient translates the JSON patches that bindings make against the
+// views of runs into patches against the runs themselves.
+type dynamicClient struct {
+	dynamic.Interface
+}
+
+// Resource implements dynamic.Interface
+func (c *dynamicClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	ri := c.Interface.Resource(gvr)
+	if !isRunResource(gvr) {
+		return ri
+	}
+	return &runResource{NamespaceableResourceInterface: ri}
+}
+
+type runResource struct {
+	dynamic.NamespaceableResourceInterface
+}
+
+// Namespace implements dynamic.NamespaceableResourceInterface
+func (r *runResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return &runPatcher{ResourceInterface: r.NamespaceableResourceInterface.Namespace(namespace)}
+}
+
+type runPatcher struct {
+	dynamic.ResourceInterface
+}
+
+// Patch implements dynamic.ResourceInterface
+func (p *runPatcher) Patch(name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if pt != types.JSONPatchType || len(subresources) != 0 {
+		return p.ResourceInterface.Patch(name, pt, data, options, subresources...)
+	}
+
+	u, err := p.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	r := &Run{}
+	if err := duck.FromUnstructured(u, r); err != nil {
+		return nil, err
+	}
+	patch, err := patchRun(r, data)
+	if err != nil {
+		return nil, err
+	}
+	return p.ResourceInterface.Patch(name, pt, patch, options)
+}
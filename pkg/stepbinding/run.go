@@ -0,0 +1,226 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package stepbinding lets the psbinding webhooks and reconcilers bind
+// Tekton TaskRuns and PipelineRuns, which aren't PodSpecable, by presenting
+// them as a PodSpecable view whose single container stands in for every
+// step of the run.
+package stepbinding
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/apis/duck"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+const (
+	// StepTemplateAnnotation holds the env and volume mounts that bindings
+	// add to every step of a run.  Tekton copies the annotations of
+	// PipelineRuns onto their TaskRuns, and of TaskRuns onto their pods,
+	// where the pods webhook adds them to the step containers.
+	StepTemplateAnnotation = "bindings.mink.knative.dev/step-template"
+
+	// stepsContainerName is the name of the container that stands in for
+	// every step in the view of a run.
+	stepsContainerName = "steps"
+)
+
+var (
+	runKinds     = sets.NewString("TaskRun", "PipelineRun")
+	runResources = sets.NewString("taskruns", "pipelineruns")
+)
+
+// IsRun returns whether bindings reach subjects of the given kind through
+// their steps.
+func IsRun(gk schema.GroupKind) bool {
+	return gk.Group == pipeline.GroupName && runKinds.Has(gk.Kind)
+}
+
+// isRunResource is IsRun for resources.
+func isRunResource(gvr schema.GroupVersionResource) bool {
+	return gvr.Group == pipeline.GroupName && runResources.Has(gvr.Resource)
+}
+
+// Run is the subset of a TaskRun or PipelineRun that bindings touch.  Both
+// kinds (and both of their versions) share this shape.
+type Run struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RunSpec `json:"spec,omitempty"`
+}
+
+// RunSpec holds the pod template of a run, whose volumes Tekton adds to the
+// pods it creates.
+type RunSpec struct {
+	PodTemplate *pod.Template `json:"podTemplate,omitempty"`
+}
+
+// RunList is a list of Run resources.
+type RunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Run `json:"items"`
+}
+
+// stepTemplate is what bindings add to every step of a run.
+type stepTemplate struct {
+	Env          []corev1.EnvVar        `json:"env,omitempty"`
+	EnvFrom      []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	VolumeMounts []corev1.VolumeMount   `json:"volumeMounts,omitempty"`
+}
+
+var (
+	_ apis.Listable  = (*Run)(nil)
+	_ runtime.Object = (*RunList)(nil)
+)
+
+// GetListType implements apis.Listable
+func (*Run) GetListType() runtime.Object {
+	return &RunList{}
+}
+
+// WithPod returns the PodSpecable view of the run, whose volumes are those
+// of its pod template and whose single container holds its step template.
+func (r *Run) WithPod() (*duckv1.WithPod, error) {
+	step := corev1.Container{Name: stepsContainerName}
+	if raw, ok := r.Annotations[StepTemplateAnnotation]; ok {
+		st := stepTemplate{}
+		if err := json.Unmarshal([]byte(raw), &st); err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", StepTemplateAnnotation, err)
+		}
+		step.Env, step.EnvFrom, step.VolumeMounts = st.Env, st.EnvFrom, st.VolumeMounts
+	}
+
+	wp := &duckv1.WithPod{
+		TypeMeta:   r.TypeMeta,
+		ObjectMeta: *r.ObjectMeta.DeepCopy(),
+	}
+	delete(wp.Annotations, StepTemplateAnnotation)
+	wp.Spec.Template.Spec.Containers = []corev1.Container{step}
+	if r.Spec.PodTemplate != nil {
+		wp.Spec.Template.Spec.Volumes = r.Spec.PodTemplate.DeepCopy().Volumes
+	}
+	return wp, nil
+}
+
+// Apply updates the run with the state of its PodSpecable view.
+func (r *Run) Apply(wp *duckv1.WithPod) error {
+	r.ObjectMeta = *wp.ObjectMeta.DeepCopy()
+
+	st := stepTemplate{}
+	for _, c := range wp.Spec.Template.Spec.Containers {
+		if c.Name == stepsContainerName {
+			st = stepTemplate{Env: c.Env, EnvFrom: c.EnvFrom, VolumeMounts: c.VolumeMounts}
+		}
+	}
+	if len(st.Env) == 0 && len(st.EnvFrom) == 0 && len(st.VolumeMounts) == 0 {
+		delete(r.Annotations, StepTemplateAnnotation)
+	} else {
+		b, err := json.Marshal(st)
+		if err != nil {
+			return err
+		}
+		if r.Annotations == nil {
+			r.Annotations = make(map[string]string, 1)
+		}
+		r.Annotations[StepTemplateAnnotation] = string(b)
+	}
+
+	volumes := wp.Spec.Template.Spec.Volumes
+	switch {
+	case r.Spec.PodTemplate != nil:
+		r.Spec.PodTemplate.Volumes = volumes
+	case len(volumes) > 0:
+		r.Spec.PodTemplate = &pod.Template{Volumes: volumes}
+	}
+	return nil
+}
+
+// patchRun applies a JSON patch against the view of the run, and returns the
+// equivalent patch against the run itself.
+func patchRun(r *Run, patch []byte) ([]byte, error) {
+	view, err := r.WithPod()
+	if err != nil {
+		return nil, err
+	}
+	before, err := json.Marshal(view)
+	if err != nil {
+		return nil, err
+	}
+	p, err := jsonpatch.DecodePatch(patch)
+	if err != nil {
+		return nil, fmt.Errorf("decoding patch: %w", err)
+	}
+	after, err := p.Apply(before)
+	if err != nil {
+		return nil, fmt.Errorf("applying patch to view: %w", err)
+	}
+	bound := &duckv1.WithPod{}
+	if err := json.Unmarshal(after, bound); err != nil {
+		return nil, err
+	}
+
+	rr := r.DeepCopy()
+	if err := rr.Apply(bound); err != nil {
+		return nil, err
+	}
+	return duck.CreateBytePatch(r, rr)
+}
+
+// DeepCopy returns a deep copy of the run.
+func (r *Run) DeepCopy() *Run {
+	if r == nil {
+		return nil
+	}
+	out := &Run{TypeMeta: r.TypeMeta}
+	r.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.PodTemplate = r.Spec.PodTemplate.DeepCopy()
+	return out
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *Run) DeepCopyObject() runtime.Object {
+	return r.DeepCopy()
+}
+
+// DeepCopyObject implements runtime.Object
+func (l *RunList) DeepCopyObject() runtime.Object {
+	if l == nil {
+		return nil
+	}
+	out := &RunList{TypeMeta: l.TypeMeta}
+	l.ListMeta.DeepCopyInto(&out.ListMeta)
+	if l.Items != nil {
+		out.Items = make([]Run, len(l.Items))
+		for i := range l.Items {
+			out.Items[i] = *l.Items[i].DeepCopy()
+		}
+	}
+	return out
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stepbinding
+
+import (
+	"encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestApply(t *testing.T) {
+	volume := corev1.Volume{Name: "creds"}
+	mount := corev1.VolumeMount{Name: "creds", MountPath: "/var/creds"}
+	env := corev1.EnvVar{Name: "K_SINK", Value: "http://sink"}
+
+	tests := []struct {
+		name string
+		run  *Run
+		view *duckv1.WithPod
+		want *Run
+	}{{
+		name: "bind env and volumes",
+		run:  &Run{ObjectMeta: metav1.ObjectMeta{Name: "run"}},
+		view: withPod(metav1.ObjectMeta{Name: "run"}, []corev1.Volume{volume},
+			corev1.Container{Name: stepsContainerName, Env: []corev1.EnvVar{env}, VolumeMounts: []corev1.VolumeMount{mount}}),
+		want: &Run{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "run",
+				Annotations: map[string]string{
+					StepTemplateAnnotation: `{"env":[{"name":"K_SINK","value":"http://sink"}],"volumeMounts":[{"name":"creds","mountPath":"/var/creds"}]}`,
+				},
+			},
+			Spec: RunSpec{PodTemplate: &pod.Template{Volumes: []corev1.Volume{volume}}},
+		},
+	}, {
+		name: "keep the rest of the pod template",
+		run: &Run{
+			ObjectMeta: metav1.ObjectMeta{Name: "run"},
+			Spec: RunSpec{PodTemplate: &pod.Template{
+				NodeSelector: map[string]string{"disk": "ssd"},
+			}},
+		},
+		view: withPod(metav1.ObjectMeta{Name: "run"}, []corev1.Volume{volume},
+			corev1.Container{Name: stepsContainerName}),
+		want: &Run{
+			ObjectMeta: metav1.ObjectMeta{Name: "run"},
+			Spec: RunSpec{PodTemplate: &pod.Template{
+				NodeSelector: map[string]string{"disk": "ssd"},
+				Volumes:      []corev1.Volume{volume},
+			}},
+		},
+	}, {
+		name: "unbind",
+		run: &Run{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "run",
+				Annotations: map[string]string{StepTemplateAnnotation: `{"env":[{"name":"K_SINK","value":"http://sink"}]}`},
+			},
+		},
+		view: withPod(metav1.ObjectMeta{
+			Name:        "run",
+			Annotations: map[string]string{"other": "annotation"},
+		}, nil, corev1.Container{Name: stepsContainerName}),
+		want: &Run{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "run",
+				Annotations: map[string]string{"other": "annotation"},
+			},
+		},
+	}, {
+		name: "other containers are ignored",
+		run:  &Run{ObjectMeta: metav1.ObjectMeta{Name: "run"}},
+		view: withPod(metav1.ObjectMeta{Name: "run"}, nil,
+			corev1.Container{Name: "sidecar", Env: []corev1.EnvVar{env}}),
+		want: &Run{ObjectMeta: metav1.ObjectMeta{Name: "run"}},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.run.DeepCopy()
+			if err := got.Apply(test.view); err != nil {
+				t.Fatalf("Apply() = %v", err)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("Apply() (-want, +got) = %s", cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
+
+func TestWithPodRoundTrip(t *testing.T) {
+	run := &Run{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "run",
+			Annotations: map[string]string{StepTemplateAnnotation: `{"env":[{"name":"K_SINK","value":"http://sink"}]}`},
+		},
+		Spec: RunSpec{PodTemplate: &pod.Template{Volumes: []corev1.Volume{{Name: "creds"}}}},
+	}
+	view, err := run.WithPod()
+	if err != nil {
+		t.Fatalf("WithPod() = %v", err)
+	}
+	if _, ok := view.Annotations[StepTemplateAnnotation]; ok {
+		t.Error("WithPod() kept the step template annotation")
+	}
+	got := &Run{}
+	if err := got.Apply(view); err != nil {
+		t.Fatalf("Apply() = %v", err)
+	}
+	if !cmp.Equal(got, run) {
+		t.Errorf("Apply(WithPod()) (-want, +got) = %s", cmp.Diff(run, got))
+	}
+
+	run.Annotations[StepTemplateAnnotation] = "not json"
+	if _, err := run.WithPod(); err == nil {
+		t.Error("WithPod() = nil, wanted an error for a bad step template")
+	}
+}
+
+func TestPatchRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		run     *Run
+		patch   string
+		want    *Run
+		wantErr bool
+	}{{
+		name:  "add env",
+		run:   &Run{ObjectMeta: metav1.ObjectMeta{Name: "run"}},
+		patch: `[{"op":"add","path":"/spec/template/spec/containers/0/env","value":[{"name":"K_SINK","value":"http://sink"}]}]`,
+		want: &Run{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "run",
+				Annotations: map[string]string{StepTemplateAnnotation: `{"env":[{"name":"K_SINK","value":"http://sink"}]}`},
+			},
+		},
+	}, {
+		name:  "add volume",
+		run:   &Run{ObjectMeta: metav1.ObjectMeta{Name: "run"}},
+		patch: `[{"op":"add","path":"/spec/template/spec/volumes","value":[{"name":"creds","secret":{"secretName":"creds"}}]}]`,
+		want: &Run{
+			ObjectMeta: metav1.ObjectMeta{Name: "run"},
+			Spec: RunSpec{PodTemplate: &pod.Template{Volumes: []corev1.Volume{{
+				Name:         "creds",
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "creds"}},
+			}}}},
+		},
+	}, {
+		name: "remove env",
+		run: &Run{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "run",
+				Annotations: map[string]string{StepTemplateAnnotation: `{"env":[{"name":"K_SINK","value":"http://sink"}]}`},
+			},
+		},
+		patch: `[{"op":"remove","path":"/spec/template/spec/containers/0/env"}]`,
+		want:  &Run{ObjectMeta: metav1.ObjectMeta{Name: "run"}},
+	}, {
+		name:  "annotate",
+		run:   &Run{ObjectMeta: metav1.ObjectMeta{Name: "run"}},
+		patch: `[{"op":"add","path":"/metadata/annotations","value":{"bound":"yes"}}]`,
+		want: &Run{ObjectMeta: metav1.ObjectMeta{
+			Name:        "run",
+			Annotations: map[string]string{"bound": "yes"},
+		}},
+	}, {
+		name:    "bad patch",
+		run:     &Run{ObjectMeta: metav1.ObjectMeta{Name: "run"}},
+		patch:   `{"op":"add"}`,
+		wantErr: true,
+	}, {
+		name:    "patch doesn't apply",
+		run:     &Run{ObjectMeta: metav1.ObjectMeta{Name: "run"}},
+		patch:   `[{"op":"replace","path":"/spec/template/spec/containers/3/env","value":[]}]`,
+		wantErr: true,
+	}, {
+		name: "bad step template",
+		run: &Run{ObjectMeta: metav1.ObjectMeta{
+			Name:        "run",
+			Annotations: map[string]string{StepTemplateAnnotation: "not json"},
+		}},
+		patch:   `[]`,
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patch, err := patchRun(test.run, []byte(test.patch))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("patchRun() = %v, wanted error %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			// The patch should turn the run into the bound run.
+			before, err := json.Marshal(test.run)
+			if err != nil {
+				t.Fatalf("json.Marshal() = %v", err)
+			}
+			p, err := jsonpatch.DecodePatch(patch)
+			if err != nil {
+				t.Fatalf("DecodePatch(%s) = %v", patch, err)
+			}
+			after, err := p.Apply(before)
+			if err != nil {
+				t.Fatalf("Apply(%s) = %v", patch, err)
+			}
+			got := &Run{}
+			if err := json.Unmarshal(after, got); err != nil {
+				t.Fatalf("json.Unmarshal() = %v", err)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("patchRun() (-want, +got) = %s", cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
+
+func withPod(om metav1.ObjectMeta, volumes []corev1.Volume, containers ...corev1.Container) *duckv1.WithPod {
+	wp := &duckv1.WithPod{ObjectMeta: om}
+	wp.Spec.Template.Spec.Volumes = volumes
+	wp.Spec.Template.Spec.Containers = containers
+	return wp
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapters
+
+import (
+	"context"
+
+	contourv1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+
+	"github.com/mattmoor/mink/pkg/reconciler/activatorlocality"
+	"github.com/mattmoor/mink/pkg/reconciler/guard"
+)
+
+// HTTPProxy adapts Contour's HTTPProxy for use with the defaulting
+// webhook, which reroutes the HTTPProxies that net-contour writes.
+type HTTPProxy struct {
+	contourv1.HTTPProxy
+}
+
+var (
+	_ apis.Defaultable = (*HTTPProxy)(nil)
+	_ apis.Validatable = (*HTTPProxy)(nil)
+	_ runtime.Object   = (*HTTPProxy)(nil)
+)
+
+// SetDefaults implements apis.Defaultable
+func (hp *HTTPProxy) SetDefaults(ctx context.Context) {
+	// Guarded services are reached through the guard, so we guard them
+	// first and localize the rest.
+	guard.Guard(ctx, &hp.HTTPProxy)
+	activatorlocality.Localize(ctx, &hp.HTTPProxy)
+}
+
+// Validate implements apis.Validatable
+func (hp *HTTPProxy) Validate(ctx context.Context) *apis.FieldError {
+	return nil
+}
+
+// DeepCopyObject implements runtime.Object
+func (hp *HTTPProxy) DeepCopyObject() runtime.Object {
+	out := &HTTPProxy{}
+	hp.HTTPProxy.DeepCopyInto(&out.HTTPProxy)
+	return out
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapters
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+
+	"github.com/mattmoor/mink/pkg/reconciler/stepdigests"
+	"github.com/mattmoor/mink/pkg/stepbinding"
+	"github.com/mattmoor/mink/pkg/webhook/imagepolicy"
+)
+
+// Pod adapts Kubernetes Pods for use with the defaulting webhook, which
+// pins the images of the pods that Tekton creates for TaskRuns, and adds
+// what bindings of their runs recorded to their steps, and the validation
+// webhook, which checks those images against the image policy and rejects
+// the pods whose bindings can't be added.
+type Pod struct {
+	corev1.Pod
+}
+
+var (
+	_ apis.Defaultable = (*Pod)(nil)
+	_ apis.Validatable = (*Pod)(nil)
+	_ runtime.Object   = (*Pod)(nil)
+)
+
+// SetDefaults implements apis.Defaultable
+func (p *Pod) SetDefaults(ctx context.Context) {
+	stepbinding.BindSteps(ctx, &p.Pod)
+	stepdigests.Pin(ctx, &p.Pod)
+}
+
+// Validate implements apis.Validatable
+func (p *Pod) Validate(ctx context.Context) *apis.FieldError {
+	if _, ok := p.Labels[stepdigests.TaskRunLabelKey]; !ok {
+		return nil
+	}
+	var previous []imagepolicy.Image
+	if base, ok := apis.GetBaseline(ctx).(*Pod); ok {
+		previous = stepImages(&base.Pod)
+	}
+	return stepbinding.ValidatePod(ctx, &p.Pod).Also(
+		imagepolicy.ValidateImages(ctx, &p.ObjectMeta, stepImages(&p.Pod), previous))
+}
+
+// stepImages returns the images of the TaskRun pod's steps and sidecars,
+// which by now are pinned and have had their substitutions made.
+func stepImages(pod *corev1.Pod) []imagepolicy.Image {
+	var images []imagepolicy.Image
+	for i, c := range pod.Spec.Containers {
+		if !stepdigests.IsPinnable(c.Name) {
+			continue
+		}
+		images = append(images, imagepolicy.Image{
+			Path:      fmt.Sprintf("spec.containers[%d].image", i),
+			Reference: c.Image,
+		})
+	}
+	return images
+}
+
+// DeepCopyObject implements runtime.Object
+func (p *Pod) DeepCopyObject() runtime.Object {
+	out := &Pod{}
+	p.Pod.DeepCopyInto(&out.Pod)
+	return out
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/vaikas/postgressource/pkg/apis/sources/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/pkg/apis"
+
+	"github.com/mattmoor/mink/pkg/reconciler/postgrescdc"
+)
+
+// tableName matches the (optionally schema qualified) unquoted table
+// names for which the source creates its triggers.
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+// PostgresSource adapts vaikas/postgressource's PostgresSource, which is
+// not Defaultable, for use with our webhooks, and checks its tables and
+// secret before the adapter trips over them.
+type PostgresSource struct {
+	v1alpha1.PostgresSource
+}
+
+var (
+	_ apis.Defaultable = (*PostgresSource)(nil)
+	_ apis.Validatable = (*PostgresSource)(nil)
+	_ runtime.Object   = (*PostgresSource)(nil)
+)
+
+// SetDefaults implements apis.Defaultable
+func (ps *PostgresSource) SetDefaults(ctx context.Context) {
+	ctx = apis.WithinParent(ctx, ps.ObjectMeta)
+	ps.Spec.Sink.SetDefaults(ctx)
+}
+
+// Validate implements apis.Validatable
+func (ps *PostgresSource) Validate(ctx context.Context) *apis.FieldError {
+	errs := ps.PostgresSource.Validate(ctx)
+
+	if name := ps.Spec.Secret.Name; name != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(name) {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%s: %s", name, msg), "spec.secret.name"))
+		}
+	}
+
+	seen := sets.NewString()
+	for i, table := range ps.Spec.Tables {
+		switch {
+		case table.Name == "":
+			errs = errs.Also(apis.ErrMissingField("name").ViaFieldIndex("spec.tables", i))
+		case !tableName.MatchString(table.Name):
+			errs = errs.Also(apis.ErrInvalidValue(table.Name, "name").ViaFieldIndex("spec.tables", i))
+		case seen.Has(table.Name):
+			errs = errs.Also(&apis.FieldError{
+				Message: fmt.Sprintf("duplicate table %q", table.Name),
+				Paths:   []string{"name"},
+			}).ViaFieldIndex("spec.tables", i)
+		}
+		seen.Insert(table.Name)
+	}
+	// The baseline is the adapter, which postgrescdc doesn't know about.
+	var previous *v1alpha1.PostgresSource
+	if base, ok := apis.GetBaseline(ctx).(*PostgresSource); ok && base != nil {
+		previous = &base.PostgresSource
+	}
+	return errs.Also(postgrescdc.Validate(ctx, &ps.PostgresSource, previous))
+}
+
+// DeepCopyObject implements runtime.Object
+func (ps *PostgresSource) DeepCopyObject() runtime.Object {
+	out := &PostgresSource{}
+	ps.PostgresSource.DeepCopyInto(&out.PostgresSource)
+	return out
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vaikas/postgressource/pkg/apis/sources/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/mattmoor/mink/pkg/reconciler/postgrescdc"
+)
+
+func postgresSource(mode string) *PostgresSource {
+	ps := &PostgresSource{}
+	ps.ObjectMeta = metav1.ObjectMeta{Namespace: "ns", Name: "src"}
+	if mode != "" {
+		ps.Annotations = map[string]string{postgrescdc.ModeAnnotationKey: mode}
+	}
+	ps.Spec.Sink = duckv1.Destination{URI: apis.HTTP("sink.example.com")}
+	ps.Spec.Secret = corev1.LocalObjectReference{Name: "db"}
+	ps.Spec.Tables = []v1alpha1.TableSpec{{Name: "public.users"}}
+	return ps
+}
+
+func TestPostgresSourceValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      *PostgresSource
+		baseline *PostgresSource
+		wantErr  bool
+	}{{
+		name: "triggers",
+		src:  postgresSource(""),
+	}, {
+		name: "logical replication",
+		src:  postgresSource(postgrescdc.ModeLogicalReplication),
+	}, {
+		name:    "unknown mode",
+		src:     postgresSource("polling"),
+		wantErr: true,
+	}, {
+		name:     "unchanged mode",
+		src:      postgresSource(postgrescdc.ModeLogicalReplication),
+		baseline: postgresSource(postgrescdc.ModeLogicalReplication),
+	}, {
+		name:     "explicit default mode",
+		src:      postgresSource(postgrescdc.ModeTriggers),
+		baseline: postgresSource(""),
+	}, {
+		name:     "changed mode",
+		src:      postgresSource(postgrescdc.ModeLogicalReplication),
+		baseline: postgresSource(""),
+		wantErr:  true,
+	}, {
+		name: "duplicate tables",
+		src: func() *PostgresSource {
+			ps := postgresSource("")
+			ps.Spec.Tables = append(ps.Spec.Tables, ps.Spec.Tables[0])
+			return ps
+		}(),
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.baseline != nil {
+				ctx = apis.WithinUpdate(ctx, test.baseline)
+			} else {
+				ctx = apis.WithinCreate(ctx)
+			}
+			if err := test.src.Validate(ctx); (err != nil) != test.wantErr {
+				t.Errorf("Validate() = %v, wanted error %v", err, test.wantErr)
+			}
+		})
+	}
+}
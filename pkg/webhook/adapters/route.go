@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+
+	"github.com/mattmoor/mink/pkg/reconciler/guard"
+	"github.com/mattmoor/mink/pkg/reconciler/rollout"
+	"github.com/mattmoor/mink/pkg/reconciler/visibilityclass"
+)
+
+// Route adapts Knative's Services and Routes for use with the webhooks
+// that customize how they are exposed, which only concern themselves
+// with metadata.
+type Route struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   json.RawMessage `json:"spec,omitempty"`
+	Status json.RawMessage `json:"status,omitempty"`
+}
+
+var (
+	_ apis.Defaultable = (*Route)(nil)
+	_ apis.Validatable = (*Route)(nil)
+	_ runtime.Object   = (*Route)(nil)
+)
+
+// SetDefaults implements apis.Defaultable
+func (r *Route) SetDefaults(ctx context.Context) {
+	visibilityclass.SetIngressClass(&r.ObjectMeta)
+}
+
+// Validate implements apis.Validatable
+func (r *Route) Validate(ctx context.Context) *apis.FieldError {
+	return visibilityclass.Validate(ctx, &r.ObjectMeta).Also(
+		guard.Validate(ctx, &r.ObjectMeta)).Also(
+		rollout.Validate(ctx, &r.ObjectMeta))
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *Route) DeepCopyObject() runtime.Object {
+	out := &Route{
+		TypeMeta: r.TypeMeta,
+	}
+	r.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if r.Spec != nil {
+		out.Spec = append(json.RawMessage(nil), r.Spec...)
+	}
+	if r.Status != nil {
+		out.Status = append(json.RawMessage(nil), r.Status...)
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+
+	"github.com/mattmoor/mink/pkg/webhook/imagepolicy"
+)
+
+// Workload adapts Knative's Services, Configurations and Revisions, and
+// Tekton's Tasks, Pipelines and their runs for use with the webhook that
+// checks the images they reference, which only concerns itself with the
+// images within their specs.
+type Workload struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   json.RawMessage `json:"spec,omitempty"`
+	Status json.RawMessage `json:"status,omitempty"`
+}
+
+var (
+	_ apis.Defaultable = (*Workload)(nil)
+	_ apis.Validatable = (*Workload)(nil)
+	_ runtime.Object   = (*Workload)(nil)
+)
+
+// SetDefaults implements apis.Defaultable
+func (w *Workload) SetDefaults(ctx context.Context) {}
+
+// Validate implements apis.Validatable
+func (w *Workload) Validate(ctx context.Context) *apis.FieldError {
+	var baseline json.RawMessage
+	if base, ok := apis.GetBaseline(ctx).(*Workload); ok {
+		baseline = base.Spec
+	}
+	// Tekton's images are checked in full on the pods of its TaskRuns.
+	onPod := w.GroupVersionKind().Group == pipeline.GroupName
+	return imagepolicy.Validate(ctx, &w.ObjectMeta, w.Spec, baseline, onPod)
+}
+
+// DeepCopyObject implements runtime.Object
+func (w *Workload) DeepCopyObject() runtime.Object {
+	out := &Workload{
+		TypeMeta: w.TypeMeta,
+	}
+	w.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if w.Spec != nil {
+		out.Spec = append(json.RawMessage(nil), w.Spec...)
+	}
+	if w.Status != nil {
+		out.Status = append(json.RawMessage(nil), w.Status...)
+	}
+	return out
+}
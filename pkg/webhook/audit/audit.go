@@ -0,0 +1,217 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apixv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/webhook/audit/config"
+)
+
+const (
+	// EventTypePrefix is the prefix of the CloudEvent types we emit,
+	// which are followed by the decision, for example:
+	//   dev.mink.admission.denied
+	EventTypePrefix = "dev.mink.admission."
+
+	// queueSize bounds the records waiting to be sent, beyond which we
+	// drop sampled records rather than slow down admission.
+	queueSize = 1000
+
+	// denialTimeout bounds how long admission waits for room in the
+	// queue of denials, which we would rather not drop.
+	denialTimeout = 500 * time.Millisecond
+)
+
+// delivery is a record on its way to a sink.
+type delivery struct {
+	sink  string
+	event cloudevents.Event
+}
+
+// Auditor records the decisions of admission webhooks to the sink in
+// config-admission-audit.  A single Auditor is shared by all of the
+// webhooks it wraps, so that they share its config and queue.
+type Auditor struct {
+	once     sync.Once
+	logger   *zap.SugaredLogger
+	store    *config.Store
+	ceclient cloudevents.Client
+	queue    chan delivery
+	// denials are queued apart, so that a burst of sampled decisions
+	// doesn't crowd them out.
+	denials chan delivery
+}
+
+// NewAuditor creates an Auditor, which starts sending records when it
+// first wraps a webhook.
+func NewAuditor() *Auditor {
+	return &Auditor{
+		queue:   make(chan delivery, queueSize),
+		denials: make(chan delivery, queueSize),
+	}
+}
+
+// setup watches the Auditor's config and sends records until the context
+// is cancelled.
+func (a *Auditor) setup(ctx context.Context, cmw configmap.Watcher) {
+	a.once.Do(func() {
+		a.logger = logging.FromContext(ctx).Named("audit")
+		ceclient, err := cloudevents.NewDefaultClient()
+		if err != nil {
+			a.logger.Fatalw("Error creating CloudEvents client", "error", err)
+		}
+		a.ceclient = ceclient
+		a.store = config.NewStore(a.logger.Named("config-store"))
+		a.store.WatchConfigs(cmw)
+		go a.run(ctx)
+	})
+}
+
+// admitted records an admission webhook's response to a request.
+func (a *Auditor) admitted(path string, req *admissionv1beta1.AdmissionRequest, resp *admissionv1beta1.AdmissionResponse) {
+	cfg := a.store.Load()
+	if !cfg.Enabled() || !sampled(cfg, resp.Allowed) {
+		return
+	}
+	a.record(cfg, fromAdmission(cfg, path, req, resp))
+}
+
+// converted records our conversion webhook's response to a request.
+func (a *Auditor) converted(path string, req *apixv1beta1.ConversionRequest, resp *apixv1beta1.ConversionResponse) {
+	cfg := a.store.Load()
+	if !cfg.Enabled() {
+		return
+	}
+	for _, r := range fromConversion(path, req, resp) {
+		if sampled(cfg, r.Allowed) {
+			a.record(cfg, r)
+		}
+	}
+}
+
+// sampled returns whether we record a decision.  Rejections are always
+// recorded.
+func sampled(cfg *config.Config, allowed bool) bool {
+	return !allowed || rand.Float64() < cfg.SampleRate
+}
+
+func (a *Auditor) record(cfg *config.Config, r *Record) {
+	if cfg.Log {
+		a.logger.Infow("Admission decision", "record", r)
+	}
+	if cfg.SinkURI == nil {
+		return
+	}
+	event, err := r.toEvent()
+	if err != nil {
+		a.logger.Errorw("Error creating audit event", "error", err)
+		return
+	}
+	d := delivery{sink: cfg.SinkURI.String(), event: *event}
+	if !r.Allowed {
+		t := time.NewTimer(denialTimeout)
+		defer t.Stop()
+		select {
+		case a.denials <- d:
+		case <-t.C:
+			a.logger.Errorw("Audit queue of denials is full, dropping record", "record", r)
+		}
+		return
+	}
+	select {
+	case a.queue <- d:
+	default:
+		a.logger.Warnw("Audit queue is full, dropping record", "record", r)
+	}
+}
+
+// run sends the queued records until the context is cancelled, sending
+// denials ahead of sampled records.
+func (a *Auditor) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-a.denials:
+			a.send(ctx, d)
+			continue
+		default:
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-a.denials:
+			a.send(ctx, d)
+		case d := <-a.queue:
+			a.send(ctx, d)
+		}
+	}
+}
+
+func (a *Auditor) send(ctx context.Context, d delivery) {
+	ctx = cloudevents.ContextWithTarget(ctx, d.sink)
+	ctx = cloudevents.ContextWithRetriesExponentialBackoff(ctx, 10*time.Millisecond, 5)
+	if result := a.ceclient.Send(ctx, d.event); !cloudevents.IsACK(result) {
+		a.logger.Errorw("Error sending audit event", "id", d.event.ID(), "error", result)
+	}
+}
+
+// toEvent produces the CloudEvent for the record.
+func (r *Record) toEvent() (*cloudevents.Event, error) {
+	decision := "allowed"
+	if !r.Allowed {
+		decision = "denied"
+	}
+
+	event := cloudevents.NewEvent()
+	event.SetID(fmt.Sprintf("%s.%s.%s", r.UID, r.Namespace, r.Name))
+	event.SetType(EventTypePrefix + decision)
+	event.SetSource(r.Webhook)
+	event.SetTime(time.Now())
+	if r.Namespace != "" {
+		event.SetSubject(r.Namespace + "/" + r.Name)
+	} else {
+		event.SetSubject(r.Name)
+	}
+	// Surface the salient parts of the record as attributes, so that
+	// Triggers may filter on them.
+	event.SetExtension("operation", strings.ToLower(r.Operation))
+	event.SetExtension("kind", r.Kind.Kind)
+	if r.Kind.Group != "" {
+		event.SetExtension("group", r.Kind.Group)
+	}
+	if r.User != "" {
+		event.SetExtension("user", r.User)
+	}
+	if err := event.SetData(cloudevents.ApplicationJSON, r); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/configmap"
+)
+
+const (
+	// ConfigName is the name of the ConfigMap that configures the audit
+	// trail of the requests our admission webhooks handle.
+	ConfigName = "config-admission-audit"
+
+	sinkKey         = "sink"
+	sampleRateKey   = "sample-rate"
+	redactKindsKey  = "redact-kinds"
+	redactFieldsKey = "redact-fields"
+
+	// LogSink is the value of sink with which records are written to
+	// the webhook's logs rather than sent as CloudEvents.
+	LogSink = "log"
+)
+
+// Config holds the settings for auditing admission requests.
+type Config struct {
+	// Log is whether records are written to the logs.
+	Log bool
+
+	// SinkURI is the absolute URI (e.g. of a Broker) to which records
+	// are sent as CloudEvents, if any.
+	SinkURI *apis.URL
+
+	// SampleRate is the fraction of allowed requests that are recorded.
+	// Rejections are always recorded.
+	SampleRate float64
+
+	// RedactKinds holds the kinds whose patches are recorded without
+	// any of their values.
+	RedactKinds sets.String
+
+	// RedactFields holds the lowercased names of the fields whose values
+	// are redacted from the patches of all kinds.
+	RedactFields sets.String
+}
+
+// Enabled returns whether requests are audited at all.
+func (c *Config) Enabled() bool {
+	return c.Log || c.SinkURI != nil
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		SampleRate:   1.0,
+		RedactKinds:  sets.NewString("Secret"),
+		RedactFields: sets.NewString("password", "token", "secret", "apikey", "privatekey"),
+	}
+}
+
+// NewConfigFromConfigMap creates a Config from the supplied ConfigMap.
+func NewConfigFromConfigMap(configMap *corev1.ConfigMap) (*Config, error) {
+	c := defaultConfig()
+
+	switch raw := strings.TrimSpace(configMap.Data[sinkKey]); raw {
+	case "":
+	case LogSink:
+		c.Log = true
+	default:
+		u, err := apis.ParseURL(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", sinkKey, err)
+		} else if !u.URL().IsAbs() {
+			return nil, fmt.Errorf("%s must be %q or an absolute URI, was: %q", sinkKey, LogSink, raw)
+		}
+		c.SinkURI = u
+	}
+	if raw, ok := configMap.Data[sampleRateKey]; ok {
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", sampleRateKey, err)
+		} else if f < 0 || f > 1 {
+			return nil, fmt.Errorf("%s must be between 0 and 1, was: %v", sampleRateKey, f)
+		}
+		c.SampleRate = f
+	}
+	if raw, ok := configMap.Data[redactKindsKey]; ok {
+		c.RedactKinds = sets.NewString(splitList(raw)...)
+	}
+	if raw, ok := configMap.Data[redactFieldsKey]; ok {
+		c.RedactFields = sets.NewString(splitList(strings.ToLower(raw))...)
+	}
+	return c, nil
+}
+
+// splitList splits a comma or newline separated list, dropping empty entries.
+func splitList(raw string) []string {
+	var out []string
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+type cfgKey struct{}
+
+// FromContext extracts the Config attached to the provided context.
+func FromContext(ctx context.Context) *Config {
+	return ctx.Value(cfgKey{}).(*Config)
+}
+
+// ToContext attaches the provided Config to the provided context.
+func ToContext(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, cfgKey{}, c)
+}
+
+// Store is a typed wrapper around configmap.UntypedStore to handle our configmaps.
+type Store struct {
+	*configmap.UntypedStore
+}
+
+// NewStore creates a new Store, and optionally calls functions when
+// config-admission-audit is updated.
+func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *Store {
+	return &Store{
+		UntypedStore: configmap.NewUntypedStore(
+			"admission-audit",
+			logger,
+			configmap.Constructors{
+				ConfigName: NewConfigFromConfigMap,
+			},
+			onAfterStore...,
+		),
+	}
+}
+
+// ToContext attaches the current Config state to the provided context.
+func (s *Store) ToContext(ctx context.Context) context.Context {
+	return ToContext(ctx, s.Load())
+}
+
+// Load fetches the current Config state of the Store.
+func (s *Store) Load() *Config {
+	return s.UntypedLoad(ConfigName).(*Config)
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apixv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/mattmoor/mink/pkg/webhook/audit/config"
+)
+
+const (
+	// OperationConvert is the operation we record for the objects that
+	// pass through our conversion webhook.
+	OperationConvert = "CONVERT"
+
+	// redacted replaces the values we do not record.
+	redacted = "<redacted>"
+)
+
+// Record is the audit record of an admission webhook's decision about
+// an object.
+type Record struct {
+	// Webhook is the path of the webhook that made the decision.
+	Webhook string `json:"webhook"`
+	// UID identifies the request to which the webhook responded.
+	UID string `json:"uid"`
+
+	Kind        metav1.GroupVersionKind `json:"kind"`
+	SubResource string                  `json:"subResource,omitempty"`
+	Namespace   string                  `json:"namespace,omitempty"`
+	Name        string                  `json:"name,omitempty"`
+	Operation   string                  `json:"operation"`
+	DryRun      bool                    `json:"dryRun,omitempty"`
+
+	// User and Groups identify who made the request.  Conversions are
+	// not attributed to a user.
+	User   string   `json:"user,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+
+	// Allowed is the webhook's decision, and Reason explains rejections.
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+
+	// Patch is the JSON patch the webhook applied, if any, with the
+	// values of sensitive fields redacted.
+	Patch json.RawMessage `json:"patch,omitempty"`
+}
+
+// objectMeta is the part of the objects in requests we need to identify
+// them.
+type objectMeta struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+}
+
+func metaOf(raw runtime.RawExtension) objectMeta {
+	var om objectMeta
+	if err := json.Unmarshal(raw.Raw, &om); err != nil {
+		// Records are best effort, so malformed objects are simply
+		// recorded without their metadata.
+		return objectMeta{}
+	}
+	return om
+}
+
+// fromAdmission produces the Record of an admission webhook's response.
+func fromAdmission(cfg *config.Config, path string, req *admissionv1beta1.AdmissionRequest, resp *admissionv1beta1.AdmissionResponse) *Record {
+	r := &Record{
+		Webhook:     path,
+		UID:         string(req.UID),
+		Kind:        req.Kind,
+		SubResource: req.SubResource,
+		Namespace:   req.Namespace,
+		Name:        req.Name,
+		Operation:   string(req.Operation),
+		DryRun:      req.DryRun != nil && *req.DryRun,
+		User:        req.UserInfo.Username,
+		Groups:      req.UserInfo.Groups,
+		Allowed:     resp.Allowed,
+	}
+	if r.Name == "" {
+		// Objects created with generateName have no name yet.
+		om := metaOf(req.Object)
+		r.Name = om.Name
+		if r.Name == "" {
+			r.Name = om.GenerateName
+		}
+	}
+	if resp.Result != nil {
+		r.Reason = resp.Result.Message
+	}
+	r.Patch = redact(cfg, req.Kind.Kind, resp.Patch)
+	return r
+}
+
+// fromConversion produces the Records of the objects our conversion
+// webhook converted.
+func fromConversion(path string, req *apixv1beta1.ConversionRequest, resp *apixv1beta1.ConversionResponse) []*Record {
+	allowed := resp.Result.Status == metav1.StatusSuccess
+	records := make([]*Record, 0, len(req.Objects))
+	for _, raw := range req.Objects {
+		om := metaOf(raw)
+		gvk := schema.FromAPIVersionAndKind(om.APIVersion, om.Kind)
+		r := &Record{
+			Webhook: path,
+			UID:     string(req.UID),
+			Kind: metav1.GroupVersionKind{
+				Group:   gvk.Group,
+				Version: gvk.Version,
+				Kind:    gvk.Kind,
+			},
+			Namespace: om.Namespace,
+			Name:      om.Name,
+			Operation: OperationConvert,
+			Allowed:   allowed,
+		}
+		if !allowed {
+			r.Reason = resp.Result.Message
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// redact returns the provided JSON patch with the values of sensitive
+// fields replaced, or the values of all fields for sensitive kinds.
+func redact(cfg *config.Config, kind string, patch []byte) json.RawMessage {
+	if len(patch) == 0 {
+		return nil
+	}
+	var ops []map[string]interface{}
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil
+	}
+	for _, op := range ops {
+		value, ok := op["value"]
+		if !ok {
+			continue
+		}
+		path, _ := op["path"].(string)
+		if cfg.RedactKinds.Has(kind) || redactedPath(cfg, path) {
+			op["value"] = redacted
+		} else {
+			op["value"] = redactValue(cfg, value)
+		}
+	}
+	b, err := json.Marshal(ops)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// redactedPath returns whether the JSON pointer passes through a field
+// whose value we redact.
+func redactedPath(cfg *config.Config, path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+		if cfg.RedactFields.Has(strings.ToLower(segment)) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactValue replaces the values of the fields we redact within the
+// provided JSON value.
+func redactValue(cfg *config.Config, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if cfg.RedactFields.Has(strings.ToLower(k)) {
+				v[k] = redacted
+			} else {
+				v[k] = redactValue(cfg, field)
+			}
+		}
+	case []interface{}:
+		for i, elt := range v {
+			v[i] = redactValue(cfg, elt)
+		}
+	}
+	return value
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apixv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/webhook"
+)
+
+// admissionController records the decisions of the admission controller
+// it wraps.
+type admissionController struct {
+	controller.Reconciler
+	webhook.AdmissionController
+
+	auditor *Auditor
+}
+
+var _ webhook.AdmissionController = (*admissionController)(nil)
+
+// Admit implements webhook.AdmissionController
+func (ac *admissionController) Admit(ctx context.Context, req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	resp := ac.AdmissionController.Admit(ctx, req)
+	ac.auditor.admitted(ac.Path(), req, resp)
+	return resp
+}
+
+// statelessAdmissionController is an admissionController wrapping an
+// admission controller that doesn't depend on informer state.
+type statelessAdmissionController struct {
+	*admissionController
+	webhook.StatelessAdmissionImpl
+}
+
+var _ webhook.StatelessAdmissionController = (*statelessAdmissionController)(nil)
+
+// conversionController records the conversions of the conversion
+// controller it wraps.
+type conversionController struct {
+	controller.Reconciler
+	webhook.ConversionController
+
+	auditor *Auditor
+}
+
+var _ webhook.ConversionController = (*conversionController)(nil)
+
+// Convert implements webhook.ConversionController
+func (cc *conversionController) Convert(ctx context.Context, req *apixv1beta1.ConversionRequest) *apixv1beta1.ConversionResponse {
+	resp := cc.ConversionController.Convert(ctx, req)
+	cc.auditor.converted(cc.Path(), req, resp)
+	return resp
+}
+
+// Wrap decorates the admission or conversion controller of the provided
+// Impl to record its decisions in the audit trail of the shared Auditor.
+func Wrap(ctx context.Context, cmw configmap.Watcher, a *Auditor, impl *controller.Impl) *controller.Impl {
+	a.setup(ctx, cmw)
+	switch c := impl.Reconciler.(type) {
+	case webhook.AdmissionController:
+		ac := &admissionController{
+			Reconciler:          impl.Reconciler,
+			AdmissionController: c,
+			auditor:             a,
+		}
+		if _, ok := c.(webhook.StatelessAdmissionController); ok {
+			impl.Reconciler = &statelessAdmissionController{admissionController: ac}
+		} else {
+			impl.Reconciler = ac
+		}
+	case webhook.ConversionController:
+		impl.Reconciler = &conversionController{
+			Reconciler:           impl.Reconciler,
+			ConversionController: c,
+			auditor:              a,
+		}
+	}
+	return impl
+}
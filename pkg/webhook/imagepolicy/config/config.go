@@ -0,0 +1,171 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/pkg/configmap"
+)
+
+const (
+	// ConfigName is the name of the ConfigMap that configures the image
+	// provenance policy enforced on Serving and Tekton resources.
+	ConfigName = "config-image-policy"
+
+	allowedRegistriesKey = "allowed-registries"
+	requireDigestKey     = "require-digest"
+	signatureKeyKey      = "signature-key"
+	auditNamespacesKey   = "audit-namespaces"
+)
+
+// Policy holds the requirements on the images that resources reference.
+type Policy struct {
+	// AllowedRegistries holds the registries or repository prefixes from
+	// which images may come, or is empty to allow any.
+	AllowedRegistries []string
+
+	// RequireDigest is whether images must be pinned by digest.
+	RequireDigest bool
+
+	// SignatureKey is the name of the Secret in the system namespace
+	// whose public key must have signed images, if any.
+	SignatureKey string
+
+	// AuditNamespaces holds the namespaces in which violations are
+	// logged rather than rejected.
+	AuditNamespaces sets.String
+}
+
+// Enabled returns whether the Policy places any requirement on images.
+func (p *Policy) Enabled() bool {
+	return len(p.AllowedRegistries) > 0 || p.RequireDigest || p.SignatureKey != ""
+}
+
+// Allowed returns whether the provided repository (e.g. gcr.io/foo/bar)
+// comes from one of the allowed registries.
+func (p *Policy) Allowed(repository string) bool {
+	if len(p.AllowedRegistries) == 0 {
+		return true
+	}
+	for _, prefix := range p.AllowedRegistries {
+		if repository == prefix || strings.HasPrefix(repository, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NewPolicyFromConfigMap creates a Policy from the supplied ConfigMap.
+func NewPolicyFromConfigMap(configMap *corev1.ConfigMap) (*Policy, error) {
+	p := &Policy{
+		AuditNamespaces: sets.NewString(),
+	}
+	for _, raw := range splitList(configMap.Data[allowedRegistriesKey]) {
+		// Normalize the entries the way image references are normalized,
+		// so that e.g. docker.io/mattmoor matches index.docker.io/mattmoor.
+		if strings.Contains(raw, "/") {
+			repo, err := name.NewRepository(raw, name.WeakValidation)
+			if err != nil {
+				return nil, fmt.Errorf("invalid entry in %s %q: %w", allowedRegistriesKey, raw, err)
+			}
+			p.AllowedRegistries = append(p.AllowedRegistries, repo.Name())
+		} else {
+			// A bare registry allows all of its repositories.
+			reg, err := name.NewRegistry(raw, name.WeakValidation)
+			if err != nil {
+				return nil, fmt.Errorf("invalid entry in %s %q: %w", allowedRegistriesKey, raw, err)
+			}
+			p.AllowedRegistries = append(p.AllowedRegistries, reg.RegistryStr())
+		}
+	}
+	if raw, ok := configMap.Data[requireDigestKey]; ok {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", requireDigestKey, err)
+		}
+		p.RequireDigest = b
+	}
+	if raw := strings.TrimSpace(configMap.Data[signatureKeyKey]); raw != "" {
+		if errs := validation.IsDNS1123Subdomain(raw); len(errs) > 0 {
+			return nil, fmt.Errorf("%s must name a Secret, was: %q", signatureKeyKey, raw)
+		}
+		p.SignatureKey = raw
+	}
+	p.AuditNamespaces.Insert(splitList(configMap.Data[auditNamespacesKey])...)
+	return p, nil
+}
+
+// splitList splits a comma or newline separated list, dropping empty entries.
+func splitList(raw string) []string {
+	var out []string
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+type cfgKey struct{}
+
+// FromContext extracts the Policy attached to the provided context.
+func FromContext(ctx context.Context) *Policy {
+	return ctx.Value(cfgKey{}).(*Policy)
+}
+
+// ToContext attaches the provided Policy to the provided context.
+func ToContext(ctx context.Context, p *Policy) context.Context {
+	return context.WithValue(ctx, cfgKey{}, p)
+}
+
+// Store is a typed wrapper around configmap.UntypedStore to handle our configmaps.
+type Store struct {
+	*configmap.UntypedStore
+}
+
+// NewStore creates a new Store, and optionally calls functions when
+// config-image-policy is updated.
+func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *Store {
+	return &Store{
+		UntypedStore: configmap.NewUntypedStore(
+			"image-policy",
+			logger,
+			configmap.Constructors{
+				ConfigName: NewPolicyFromConfigMap,
+			},
+			onAfterStore...,
+		),
+	}
+}
+
+// ToContext attaches the current Policy to the provided context.
+func (s *Store) ToContext(ctx context.Context) context.Context {
+	return ToContext(ctx, s.Load())
+}
+
+// Load fetches the current Policy of the Store.
+func (s *Store) Load() *Policy {
+	return s.UntypedLoad(ConfigName).(*Policy)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagepolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
+
+	"github.com/mattmoor/mink/pkg/webhook/imagepolicy/config"
+)
+
+// Validate checks the images referenced within the spec of a resource
+// against the image policy.  Images that are unchanged from the baseline
+// spec were admitted under the policy in effect at the time, and are not
+// checked again.  Violations in audited namespaces are logged instead of
+// failing admission.
+//
+// The images of Tekton resources (as indicated by onPod) only have their
+// registries checked here, and references that Tekton substitutes not at
+// all: their tags are pinned and their substitutions made on the TaskRun's
+// pod, where ValidateImages checks them against the whole policy.
+func Validate(ctx context.Context, om *metav1.ObjectMeta, spec, baseline json.RawMessage, onPod bool) *apis.FieldError {
+	if apis.IsInStatusUpdate(ctx) {
+		return nil
+	}
+	if !config.FromContext(ctx).Enabled() {
+		return nil
+	}
+	images, err := Images(spec)
+	if err != nil {
+		return apis.ErrGeneric(err.Error(), "spec")
+	}
+	// A baseline we cannot parse just means that every image is checked.
+	previous, _ := Images(baseline)
+	return validate(ctx, om, images, previous, onPod)
+}
+
+// ValidateImages checks the provided images, e.g. those of a TaskRun's
+// pod, against the image policy, in the same way as Validate.
+func ValidateImages(ctx context.Context, om *metav1.ObjectMeta, images, previous []Image) *apis.FieldError {
+	if !config.FromContext(ctx).Enabled() {
+		return nil
+	}
+	return validate(ctx, om, images, previous, false)
+}
+
+func validate(ctx context.Context, om *metav1.ObjectMeta, images, previous []Image, onPod bool) *apis.FieldError {
+	policy := config.FromContext(ctx)
+	unchanged := make(map[string]string, len(previous))
+	for _, img := range previous {
+		unchanged[img.Path] = img.Reference
+	}
+
+	var errs *apis.FieldError
+	for _, img := range images {
+		if ref, ok := unchanged[img.Path]; ok && ref == img.Reference {
+			continue
+		}
+		if onPod && img.substituted() {
+			continue
+		}
+		errs = errs.Also(check(ctx, policy, om.Namespace, img, onPod))
+	}
+	if errs != nil && policy.AuditNamespaces.Has(om.Namespace) {
+		logging.FromContext(ctx).Warnw("Admitting images that violate the image policy in audited namespace",
+			"namespace", om.Namespace, "name", om.Name, "violations", errs.Error())
+		return nil
+	}
+	return errs
+}
+
+// check checks a single image against the policy, or only its registry
+// when onPod, since its digest and signature are checked on the pod.
+func check(ctx context.Context, policy *config.Policy, namespace string, img Image, onPod bool) *apis.FieldError {
+	ref, err := name.ParseReference(img.Reference, name.WeakValidation)
+	if err != nil {
+		return apis.ErrInvalidValue(img.Reference, img.Path)
+	}
+	if !policy.Allowed(ref.Context().Name()) {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("image %q is not from an allowed registry", img.Reference),
+			Paths:   []string{img.Path},
+			Details: "allowed: " + strings.Join(policy.AllowedRegistries, ", "),
+		}
+	}
+	if onPod {
+		return nil
+	}
+	// Signatures cover digests, so a tag that was verified could later be
+	// moved to an image that isn't signed.
+	if _, ok := ref.(name.Digest); (policy.RequireDigest || policy.SignatureKey != "") && !ok {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("image %q must be pinned by digest", img.Reference),
+			Paths:   []string{img.Path},
+		}
+	}
+	if policy.SignatureKey == "" {
+		return nil
+	}
+	v := getVerifier(ctx)
+	if v == nil {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("unable to verify image %q", img.Reference),
+			Paths:   []string{img.Path},
+			Details: "no signature verifier is configured",
+		}
+	}
+	key, err := v.PublicKey(policy.SignatureKey)
+	if err != nil {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("unable to verify image %q", img.Reference),
+			Paths:   []string{img.Path},
+			Details: fmt.Sprintf("failed to read the signature key: %v", err),
+		}
+	}
+	digest, err := v.Digest(namespace, ref)
+	if err != nil {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("unable to verify image %q", img.Reference),
+			Paths:   []string{img.Path},
+			Details: fmt.Sprintf("failed to resolve its digest: %v", err),
+		}
+	}
+	if err := v.Verify(namespace, digest, key); err != nil {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("image %q is not signed with the signature key", img.Reference),
+			Paths:   []string{img.Path},
+			Details: err.Error(),
+		}
+	}
+	return nil
+}
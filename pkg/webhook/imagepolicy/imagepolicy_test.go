@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagepolicy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/mattmoor/mink/pkg/webhook/imagepolicy/config"
+)
+
+func TestValidate(t *testing.T) {
+	digest := "gcr.io/foo/bar@sha256:" + strings.Repeat("a", 64)
+	policy := &config.Policy{
+		AllowedRegistries: []string{"gcr.io"},
+		RequireDigest:     true,
+		AuditNamespaces:   sets.NewString("audited"),
+	}
+
+	tests := []struct {
+		name      string
+		policy    *config.Policy
+		namespace string
+		spec      string
+		baseline  string
+		onPod     bool
+		wantErr   bool
+	}{{
+		name:   "no policy",
+		policy: &config.Policy{},
+		spec:   `{"containers": [{"image": "docker.io/ubuntu"}]}`,
+	}, {
+		name:   "pinned",
+		policy: policy,
+		spec:   `{"containers": [{"image": "` + digest + `"}]}`,
+	}, {
+		name:    "tagged",
+		policy:  policy,
+		spec:    `{"containers": [{"image": "gcr.io/foo/bar:latest"}]}`,
+		wantErr: true,
+	}, {
+		name:    "not allowed",
+		policy:  policy,
+		spec:    `{"containers": [{"image": "docker.io/ubuntu@sha256:` + strings.Repeat("a", 64) + `"}]}`,
+		wantErr: true,
+	}, {
+		name:     "unchanged",
+		policy:   policy,
+		spec:     `{"containers": [{"image": "gcr.io/foo/bar:latest"}]}`,
+		baseline: `{"containers": [{"image": "gcr.io/foo/bar:latest"}]}`,
+	}, {
+		name:      "audited",
+		policy:    policy,
+		namespace: "audited",
+		spec:      `{"containers": [{"image": "gcr.io/foo/bar:latest"}]}`,
+	}, {
+		name:    "substituted outside of Tekton",
+		policy:  policy,
+		spec:    `{"containers": [{"image": "$(params.image)"}]}`,
+		wantErr: true,
+	}, {
+		name:   "substituted by Tekton",
+		policy: policy,
+		spec:   `{"steps": [{"image": "$(params.image)"}]}`,
+		onPod:  true,
+	}, {
+		name:   "tagged for Tekton to pin",
+		policy: policy,
+		spec:   `{"steps": [{"image": "gcr.io/foo/bar:latest"}]}`,
+		onPod:  true,
+	}, {
+		name:    "not allowed for Tekton",
+		policy:  policy,
+		spec:    `{"steps": [{"image": "docker.io/ubuntu:latest"}]}`,
+		onPod:   true,
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := config.ToContext(context.Background(), test.policy)
+			om := &metav1.ObjectMeta{Namespace: test.namespace, Name: "foo"}
+			err := Validate(ctx, om, []byte(test.spec), []byte(test.baseline), test.onPod)
+			if (err != nil) != test.wantErr {
+				t.Errorf("Validate() = %v, wanted error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateImages(t *testing.T) {
+	policy := &config.Policy{RequireDigest: true}
+	digest := "gcr.io/foo/bar@sha256:" + strings.Repeat("a", 64)
+
+	tests := []struct {
+		name     string
+		images   []Image
+		previous []Image
+		wantErr  bool
+	}{{
+		name:   "pinned",
+		images: []Image{{Path: "spec.containers[0].image", Reference: digest}},
+	}, {
+		name:    "left tagged",
+		images:  []Image{{Path: "spec.containers[0].image", Reference: "gcr.io/foo/bar:latest"}},
+		wantErr: true,
+	}, {
+		name:     "unchanged",
+		images:   []Image{{Path: "spec.containers[0].image", Reference: "gcr.io/foo/bar:latest"}},
+		previous: []Image{{Path: "spec.containers[0].image", Reference: "gcr.io/foo/bar:latest"}},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := config.ToContext(context.Background(), policy)
+			err := ValidateImages(ctx, &metav1.ObjectMeta{Name: "foo"}, test.images, test.previous)
+			if (err != nil) != test.wantErr {
+				t.Errorf("ValidateImages() = %v, wanted error %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagepolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// containerLists are the fields of Kubernetes, Knative and Tekton specs that
+// hold lists of containers, whose images we check.
+var containerLists = map[string]struct{}{
+	"containers":     {},
+	"initContainers": {},
+	"steps":          {},
+	"sidecars":       {},
+}
+
+// Image is an image referenced by a resource.
+type Image struct {
+	// Path is the field path of the reference, e.g.
+	// spec.template.spec.containers[0].image
+	Path string
+	// Reference is the image reference itself.
+	Reference string
+}
+
+// substituted returns whether Tekton substitutes the reference at runtime,
+// so that we cannot know what it will refer to until the TaskRun's pod.
+func (img Image) substituted() bool {
+	return strings.Contains(img.Reference, "$(")
+}
+
+// Images returns the images referenced by the containers, steps and
+// sidecars within the provided spec, in a stable order.  This includes
+// references that Tekton substitutes at runtime, e.g. $(params.image).
+func Images(spec json.RawMessage) ([]Image, error) {
+	if len(spec) == 0 {
+		return nil, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(spec, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	var images []Image
+	walk("spec", v, false, &images)
+	return images, nil
+}
+
+// walk collects the images within v, which is a container when container is
+// set (or a stepTemplate, which has the same shape).
+func walk(path string, v interface{}, container bool, images *[]Image) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if image, ok := v["image"].(string); ok && container {
+			*images = append(*images, Image{Path: path + ".image", Reference: image})
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := v[k]
+			if _, ok := containerLists[k]; ok {
+				if list, ok := child.([]interface{}); ok {
+					for i, elt := range list {
+						walk(fmt.Sprintf("%s.%s[%d]", path, k, i), elt, true, images)
+					}
+					continue
+				}
+			}
+			walk(path+"."+k, child, k == "stepTemplate", images)
+		}
+	case []interface{}:
+		for i, elt := range v {
+			walk(fmt.Sprintf("%s[%d]", path, i), elt, false, images)
+		}
+	}
+}
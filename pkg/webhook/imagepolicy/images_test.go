@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagepolicy
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestImages(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []Image
+		wantErr bool
+	}{{
+		name: "no spec",
+	}, {
+		name:    "invalid spec",
+		spec:    `{"containers": [`,
+		wantErr: true,
+	}, {
+		name: "revision",
+		spec: `{"containers": [{"image": "gcr.io/foo/bar"}, {"name": "side", "image": "ubuntu"}]}`,
+		want: []Image{
+			{Path: "spec.containers[0].image", Reference: "gcr.io/foo/bar"},
+			{Path: "spec.containers[1].image", Reference: "ubuntu"},
+		},
+	}, {
+		name: "service",
+		spec: `{"template": {"spec": {"initContainers": [{"image": "busybox"}], "containers": [{"image": "gcr.io/foo/bar"}]}}}`,
+		want: []Image{
+			{Path: "spec.template.spec.containers[0].image", Reference: "gcr.io/foo/bar"},
+			{Path: "spec.template.spec.initContainers[0].image", Reference: "busybox"},
+		},
+	}, {
+		name: "task",
+		spec: `{
+			"stepTemplate": {"image": "alpine"},
+			"steps": [{"name": "build", "image": "gcr.io/kaniko-project/executor"}],
+			"sidecars": [{"name": "docker", "image": "docker:dind"}]
+		}`,
+		want: []Image{
+			{Path: "spec.sidecars[0].image", Reference: "docker:dind"},
+			{Path: "spec.stepTemplate.image", Reference: "alpine"},
+			{Path: "spec.steps[0].image", Reference: "gcr.io/kaniko-project/executor"},
+		},
+	}, {
+		name: "substituted",
+		spec: `{"steps": [{"image": "$(params.image)"}, {"image": "gcr.io/foo/$(params.name):latest"}]}`,
+		want: []Image{
+			{Path: "spec.steps[0].image", Reference: "$(params.image)"},
+			{Path: "spec.steps[1].image", Reference: "gcr.io/foo/$(params.name):latest"},
+		},
+	}, {
+		name: "pipeline run with embedded tasks",
+		spec: `{"pipelineSpec": {"tasks": [{"name": "a", "taskSpec": {"steps": [{"image": "ubuntu"}]}}]}}`,
+		want: []Image{
+			{Path: "spec.pipelineSpec.tasks[0].taskSpec.steps[0].image", Reference: "ubuntu"},
+		},
+	}, {
+		name: "images outside of containers",
+		spec: `{"image": "ubuntu", "params": [{"name": "image", "default": "ubuntu"}], "resources": [{"image": "ubuntu"}]}`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Images([]byte(test.spec))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Images() = %v, wanted error %v", err, test.wantErr)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("Images() = %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
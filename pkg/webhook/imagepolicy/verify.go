@@ -0,0 +1,240 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagepolicy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn/k8schain"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/pkg/system"
+)
+
+const (
+	// PublicKeyKey is the key of the Secret named by signature-key that
+	// holds the PEM-encoded ECDSA public key that must have signed images.
+	PublicKeyKey = "cosign.pub"
+
+	// SignatureAnnotationKey is the annotation on the layers of signature
+	// images that holds the base64 encoded signature of the layer.
+	SignatureAnnotationKey = "dev.cosignproject.cosign/signature"
+
+	// maxVerified bounds the number of verified digests we remember.
+	maxVerified = 1000
+)
+
+// payload is the part of the signed payload that we check, which follows
+// the "simple signing" format.
+type payload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// Verifier resolves image references to digests and checks their
+// signatures, which are stored in the image's repository as an image
+// tagged sha256-<hex>.sig whose layers are the signed payloads.
+type Verifier struct {
+	kubeclient   kubernetes.Interface
+	secretLister corev1listers.SecretLister
+	transport    http.RoundTripper
+
+	m sync.Mutex
+	// verified holds the digests whose signatures were verified, keyed
+	// by the key that verified them.
+	verified map[string]struct{}
+}
+
+// NewVerifier creates a Verifier that reads keys through the provided
+// lister, and authenticates to registries as the namespaces' default
+// service accounts.
+func NewVerifier(kubeclient kubernetes.Interface, secretLister corev1listers.SecretLister) *Verifier {
+	return &Verifier{
+		kubeclient:   kubeclient,
+		secretLister: secretLister,
+		// Admission must not hang on slow registries.
+		transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: 5 * time.Second}).DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 5 * time.Second,
+		},
+		verified: make(map[string]struct{}),
+	}
+}
+
+type verifierKey struct{}
+
+// WithVerifier attaches the provided Verifier to the context.
+func WithVerifier(ctx context.Context, v *Verifier) context.Context {
+	return context.WithValue(ctx, verifierKey{}, v)
+}
+
+func getVerifier(ctx context.Context) *Verifier {
+	v, _ := ctx.Value(verifierKey{}).(*Verifier)
+	return v
+}
+
+func (v *Verifier) options(namespace string) ([]remote.Option, error) {
+	kc, err := k8schain.New(v.kubeclient, k8schain.Options{Namespace: namespace})
+	if err != nil {
+		return nil, err
+	}
+	return []remote.Option{
+		remote.WithAuthFromKeychain(kc),
+		remote.WithTransport(v.transport),
+	}, nil
+}
+
+// Digest resolves the provided reference to the digest of the image it
+// refers to, from the perspective of the provided namespace.
+func (v *Verifier) Digest(namespace string, ref name.Reference) (name.Digest, error) {
+	if d, ok := ref.(name.Digest); ok {
+		return d, nil
+	}
+	opts, err := v.options(namespace)
+	if err != nil {
+		return name.Digest{}, err
+	}
+	desc, err := remote.Get(ref, opts...)
+	if err != nil {
+		return name.Digest{}, err
+	}
+	return ref.Context().Digest(desc.Digest.String()), nil
+}
+
+// PublicKey reads the public key from the named Secret in the system
+// namespace.
+func (v *Verifier) PublicKey(secretName string) (*ecdsa.PublicKey, error) {
+	secret, err := v.secretLister.Secrets(system.Namespace()).Get(secretName)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(secret.Data[PublicKeyKey])
+	if block == nil {
+		return nil, fmt.Errorf("secret %s has no PEM-encoded %s", secretName, PublicKeyKey)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("secret %s holds a %T, not an ECDSA public key", secretName, pub)
+	}
+	return key, nil
+}
+
+// Verify checks that the image with the provided digest carries a
+// signature made with the provided key.
+func (v *Verifier) Verify(namespace string, digest name.Digest, key *ecdsa.PublicKey) error {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return err
+	}
+	cacheKey := base64.StdEncoding.EncodeToString(der) + "@" + digest.String()
+	v.m.Lock()
+	_, ok := v.verified[cacheKey]
+	v.m.Unlock()
+	if ok {
+		return nil
+	}
+
+	h, err := v1.NewHash(digest.DigestStr())
+	if err != nil {
+		return err
+	}
+	opts, err := v.options(namespace)
+	if err != nil {
+		return err
+	}
+	sigRef := digest.Context().Tag(fmt.Sprintf("%s-%s.sig", h.Algorithm, h.Hex))
+	img, err := remote.Image(sigRef, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch signatures %s: %w", sigRef, err)
+	}
+	m, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+	for _, desc := range m.Layers {
+		sig, err := base64.StdEncoding.DecodeString(desc.Annotations[SignatureAnnotationKey])
+		if err != nil || len(sig) == 0 {
+			continue
+		}
+		if err := verifyLayer(img, desc.Digest, sig, key, digest.DigestStr()); err != nil {
+			continue
+		}
+		v.m.Lock()
+		if len(v.verified) >= maxVerified {
+			v.verified = make(map[string]struct{})
+		}
+		v.verified[cacheKey] = struct{}{}
+		v.m.Unlock()
+		return nil
+	}
+	return errors.New("no valid signature was found")
+}
+
+// verifyLayer checks that the layer is a payload for the expected digest,
+// signed with the provided key.
+func verifyLayer(img v1.Image, h v1.Hash, sig []byte, key *ecdsa.PublicKey, expected string) error {
+	layer, err := img.LayerByDigest(h)
+	if err != nil {
+		return err
+	}
+	rc, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	raw, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(raw)
+	if !ecdsa.VerifyASN1(key, sum[:], sig) {
+		return errors.New("signature does not match")
+	}
+	var p payload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return err
+	}
+	if p.Critical.Image.DockerManifestDigest != expected {
+		return fmt.Errorf("signature is for %s", p.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The authzstub binary is a stand-in for an external authorization
+// endpoint, which admits the requests that bear the expected bearer token,
+// for exercising the guard's external authorization end to end.
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	token := os.Getenv("TOKEN")
+	if token == "" {
+		log.Fatal("TOKEN must be set")
+	}
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			log.Printf("Denying %s %s%s", r.Method, r.Header.Get("X-Forwarded-Host"), r.URL.Path)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mink"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		log.Printf("Admitting %s %s%s", r.Method, r.Header.Get("X-Forwarded-Host"), r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	})
+	log.Fatal(http.ListenAndServe(":"+port, nil))
+}